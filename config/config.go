@@ -3,12 +3,34 @@ package config
 import (
 	"locate-this/database"
 	"locate-this/database/dbmodel"
+	"locate-this/pkg/events"
+	"locate-this/pkg/geocoder"
+	"locate-this/pkg/geofence/detector"
+	"locate-this/pkg/notify"
+	"locate-this/pkg/models"
+	"locate-this/pkg/routing"
+	"locate-this/pkg/sharerule/publisher"
+	"locate-this/pkg/storage"
+	"locate-this/pkg/tiles"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
 type Constants struct {
+	AttachmentMaxSize int64
+	LivePositionTTL   time.Duration
+	// Days the position history is kept for the users who did not choose
+	HistoryRetentionDays int
+	// Directory of the basemap tiles of the static maps, none when empty
+	MapTilesDir         string
+	MapTilesAttribution string
+	// How long a check-in lasts when its duration is not given
+	CheckinDuration time.Duration
 }
 
 type Config struct {
@@ -17,6 +39,27 @@ type Config struct {
 	LocationEntryRepository      dbmodel.LocationRepository
 	GroupLocationEntryRepository dbmodel.GroupLocationRepository
 	GroupUserEntryRepository     dbmodel.GroupUserRepository
+	SearchRepository             dbmodel.SearchRepository
+	TagRepository                dbmodel.TagRepository
+	AttachmentRepository         dbmodel.AttachmentRepository
+	LiveShareRepository          dbmodel.LiveShareRepository
+	HistoryRepository            dbmodel.HistoryRepository
+	GeofenceRepository           dbmodel.GeofenceRepository
+	PlaceRepository              dbmodel.PlaceRepository
+	TripRepository               dbmodel.TripRepository
+	FolderRepository             dbmodel.FolderRepository
+	ShareRuleRepository          dbmodel.ShareRuleRepository
+	CheckinRepository            dbmodel.CheckinRepository
+	CommentRepository            dbmodel.CommentRepository
+	BlobStore                    storage.BlobStore
+	EventHub                     *events.Hub
+	Notifier                     notify.Notifier
+	GeofenceDetector             *detector.Detector
+	Geocoder                     geocoder.Geocoder
+	TileCache                    *tiles.Cache
+	SharePublisher               *publisher.Publisher
+	Router                       routing.Router
+	Constants                    Constants
 }
 
 func New() (*Config, error) {
@@ -31,12 +74,107 @@ func New() (*Config, error) {
 	// Migration des modèles
 	database.Migrate(databaseSession)
 
+	// Taxonomie des catégories de localisation
+	if categories := os.Getenv("LOCATION_CATEGORIES"); categories != "" {
+		models.LocationCategories = nil
+		for _, category := range strings.Split(categories, ",") {
+			if category = strings.TrimSpace(category); category != "" {
+				models.LocationCategories = append(models.LocationCategories, category)
+			}
+		}
+	}
+
 	// Initialisation des repositories
 	config.GroupEntryRepository = dbmodel.NewGroupRepository(databaseSession)
 	config.UserEntryRepository = dbmodel.NewUserRepository(databaseSession)
 	config.LocationEntryRepository = dbmodel.NewLocationRepository(databaseSession)
 	config.GroupLocationEntryRepository = dbmodel.NewGroupLocationRepository(databaseSession)
 	config.GroupUserEntryRepository = dbmodel.NewGroupUserRepository(databaseSession)
+	config.SearchRepository = dbmodel.NewSearchRepository(databaseSession)
+	config.TagRepository = dbmodel.NewTagRepository(databaseSession)
+	config.AttachmentRepository = dbmodel.NewAttachmentRepository(databaseSession)
+	config.LiveShareRepository = dbmodel.NewLiveShareRepository(databaseSession)
+	config.HistoryRepository = dbmodel.NewHistoryRepository(databaseSession)
+	config.GeofenceRepository = dbmodel.NewGeofenceRepository(databaseSession)
+	config.PlaceRepository = dbmodel.NewPlaceRepository(databaseSession)
+	config.TripRepository = dbmodel.NewTripRepository(databaseSession)
+	config.FolderRepository = dbmodel.NewFolderRepository(databaseSession)
+	config.ShareRuleRepository = dbmodel.NewShareRuleRepository(databaseSession)
+	config.CheckinRepository = dbmodel.NewCheckinRepository(databaseSession)
+	config.CommentRepository = dbmodel.NewCommentRepository(databaseSession)
+
+	// Diffusion des évènements des groupes
+	config.EventHub = events.NewHub()
+	config.Notifier = notify.NewWebhookNotifier(os.Getenv("WEBHOOK_SECRET"))
+	config.GeofenceDetector = detector.NewDetector(config.GeofenceRepository, config.LocationEntryRepository, config.EventHub, config.Notifier)
+
+	// Géocodage des adresses
+	config.Geocoder, err = geocoder.New(config.PlaceRepository)
+	if err != nil {
+		return &config, err
+	}
+
+	// Distances et temps de trajet
+	for _, mode := range routing.Modes {
+		if speed, err := strconv.ParseFloat(os.Getenv("TRAVEL_SPEED_"+strings.ToUpper(string(mode))), 64); err == nil && speed > 0 {
+			routing.Speeds[mode] = speed
+		}
+	}
+	config.Router, err = routing.New()
+	if err != nil {
+		return &config, err
+	}
+
+	// Cache des tuiles vectorielles de la carte
+	tileCacheSize := 10000
+	if size, err := strconv.Atoi(os.Getenv("TILE_CACHE_SIZE")); err == nil && size >= 0 {
+		tileCacheSize = size
+	}
+	config.TileCache = tiles.NewCache(tileCacheSize)
+
+	// Diffusion des partages des règles de partage
+	config.SharePublisher = publisher.NewPublisher(config.LocationEntryRepository, config.EventHub, config.TileCache)
+
+	// Stockage des pièces jointes
+	config.BlobStore, err = storage.New()
+	if err != nil {
+		return &config, err
+	}
+	config.Constants.AttachmentMaxSize = 10 << 20
+	if size, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_SIZE"), 10, 64); err == nil && size > 0 {
+		config.Constants.AttachmentMaxSize = size
+	}
+
+	// Durée de validité des positions partagées en direct
+	config.Constants.LivePositionTTL = 5 * time.Minute
+	if ttl, err := time.ParseDuration(os.Getenv("LIVE_POSITION_TTL")); err == nil && ttl > 0 {
+		config.Constants.LivePositionTTL = ttl
+	}
+
+	// Durée de conservation par défaut de l'historique des positions
+	config.Constants.HistoryRetentionDays = 90
+	if days, err := strconv.Atoi(os.Getenv("HISTORY_RETENTION_DAYS")); err == nil && days >= 0 {
+		config.Constants.HistoryRetentionDays = days
+	}
+
+	// Durée par défaut des check-ins
+	config.Constants.CheckinDuration = 2 * time.Hour
+	if duration, err := time.ParseDuration(os.Getenv("CHECKIN_DURATION")); err == nil && duration > 0 && duration <= models.CheckinMaxDuration*time.Minute {
+		config.Constants.CheckinDuration = duration
+	}
+
+	// Fond de carte des images statiques
+	config.Constants.MapTilesDir = os.Getenv("MAP_TILES_DIR")
+	config.Constants.MapTilesAttribution = os.Getenv("MAP_TILES_ATTRIBUTION")
 
 	return &config, nil
 }
+
+// HistoryRetentionDays returns how many days the position history of a user
+// is kept, 0 when it is not recorded.
+func (config *Config) HistoryRetentionDays(user *dbmodel.UserEntry) int {
+	if user.HistoryRetentionDays != nil {
+		return *user.HistoryRetentionDays
+	}
+	return config.Constants.HistoryRetentionDays
+}
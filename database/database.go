@@ -30,6 +30,24 @@ func Migrate(db *gorm.DB) {
 		&dbmodel.GroupEntry{},
 		&dbmodel.GroupUserEntry{},
 		&dbmodel.GroupLocationEntry{},
+		&dbmodel.TagEntry{},
+		&dbmodel.LocationTagEntry{},
+		&dbmodel.AttachmentEntry{},
+		&dbmodel.LiveShareEntry{},
+		&dbmodel.LocationHistoryEntry{},
+		&dbmodel.GeofenceEntry{},
+		&dbmodel.GeofenceStateEntry{},
+		&dbmodel.PlaceEntry{},
+		&dbmodel.TripEntry{},
+		&dbmodel.TripStopEntry{},
+		&dbmodel.TripGroupEntry{},
+		&dbmodel.FolderEntry{},
+		&dbmodel.FolderLocationEntry{},
+		&dbmodel.FolderGroupEntry{},
+		&dbmodel.ShareRuleEntry{},
+		&dbmodel.CheckinEntry{},
+		&dbmodel.CommentEntry{},
+		&dbmodel.ReactionEntry{},
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
@@ -42,5 +60,17 @@ func Migrate(db *gorm.DB) {
 	if err != nil {
 		log.Fatal("Failed to setup join table for Group and Locations:", err)
 	}
+	err = db.SetupJoinTable(&dbmodel.LocationEntry{}, "Tags", &dbmodel.LocationTagEntry{})
+	if err != nil {
+		log.Fatal("Failed to setup join table for Location and Tags:", err)
+	}
+	err = dbmodel.MigrateLocationGeohashes(db)
+	if err != nil {
+		log.Fatal("Failed to migrate location geohashes:", err)
+	}
+	err = dbmodel.MigrateSearchIndex(db)
+	if err != nil {
+		log.Fatal("Failed to migrate search index:", err)
+	}
 	log.Println("Database migrated successfully")
 }
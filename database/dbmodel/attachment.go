@@ -0,0 +1,57 @@
+package dbmodel
+
+import "gorm.io/gorm"
+
+type AttachmentEntry struct {
+	gorm.Model
+	LocationID   uint          `json:"location_id" gorm:"not null;index"`
+	Location     LocationEntry `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
+	UserID       uint          `json:"user_id" gorm:"not null"`
+	Filename     string        `json:"filename" gorm:"not null"`
+	ContentType  string        `json:"content_type" gorm:"not null"`
+	Size         int64         `json:"size" gorm:"not null"`
+	BlobKey      string        `json:"-" gorm:"not null"`
+	ThumbnailKey string        `json:"-"`
+}
+
+type AttachmentRepository interface {
+	Create(entry *AttachmentEntry) (*AttachmentEntry, error)
+	FindById(id uint) (*AttachmentEntry, error)
+	FindAttachmentsForLocation(id uint) ([]AttachmentEntry, error)
+	Delete(id uint) error
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (attachmentRepository *attachmentRepository) Create(entry *AttachmentEntry) (*AttachmentEntry, error) {
+	if err := attachmentRepository.db.Create(entry).Error; err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (attachmentRepository *attachmentRepository) FindById(id uint) (*AttachmentEntry, error) {
+	var attachment AttachmentEntry
+	if err := attachmentRepository.db.First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (attachmentRepository *attachmentRepository) FindAttachmentsForLocation(id uint) ([]AttachmentEntry, error) {
+	var attachments []AttachmentEntry
+	if err := attachmentRepository.db.Where("location_id = ?", id).Order("id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (attachmentRepository *attachmentRepository) Delete(id uint) error {
+	return attachmentRepository.db.Unscoped().Delete(&AttachmentEntry{}, id).Error
+}
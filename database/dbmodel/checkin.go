@@ -0,0 +1,114 @@
+package dbmodel
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CheckinEntry records that a user is at a location until EndsAt, the expiry
+// chosen when checking in or the moment they checked out or checked in
+// somewhere else. It is seen by the members of GroupID while the location is
+// shared in it, or by the user alone when GroupID is nil.
+type CheckinEntry struct {
+	ID         uint          `gorm:"primarykey"`
+	LocationID uint          `gorm:"not null;index:idx_checkin_entries_location"`
+	Location   LocationEntry `gorm:"foreignKey:LocationID;constraint:OnDelete:CASCADE;"`
+	UserID     uint          `gorm:"not null;index"`
+	User       UserEntry     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	GroupID    *uint         `gorm:"index"`
+	Group      *GroupEntry   `gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE;"`
+	Note       string        `gorm:"not null;default:''"`
+	CreatedAt  time.Time     `gorm:"not null;index:idx_checkin_entries_location"`
+	EndsAt     time.Time     `gorm:"not null;index"`
+}
+
+type CheckinRepository interface {
+	Create(entry *CheckinEntry) (*CheckinEntry, error)
+	CheckOut(locationID, userID uint, at time.Time) (*CheckinEntry, error)
+	FindCheckinsForLocation(locationID, viewerID uint, query CheckinQuery) ([]CheckinEntry, error)
+}
+
+// CheckinQuery selects the check-ins of a location: those active at Now when
+// ActiveOnly is set, otherwise those made between From and To, the latest
+// first and Limit at most when it is positive.
+type CheckinQuery struct {
+	ActiveOnly bool
+	Now        time.Time
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+// visibleCheckins selects the check-ins of a viewer and the ones made in their
+// groups at a location still shared in the group.
+const visibleCheckins = `(checkin_entries.user_id = ? OR (checkin_entries.group_id IN (` + memberGroups + `)
+	AND checkin_entries.location_id IN (SELECT location_entry_id FROM group_location_entries WHERE group_entry_id = checkin_entries.group_id)))`
+
+type checkinRepository struct {
+	db *gorm.DB
+}
+
+func NewCheckinRepository(db *gorm.DB) CheckinRepository {
+	return &checkinRepository{db: db}
+}
+
+// Create saves a check-in and ends the check-ins of the user still active, a
+// user being at a single location at a time.
+func (checkinRepository *checkinRepository) Create(entry *CheckinEntry) (*CheckinEntry, error) {
+	err := checkinRepository.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&CheckinEntry{}).
+			Where("user_id = ? AND ends_at > ?", entry.UserID, entry.CreatedAt).
+			UpdateColumn("ends_at", entry.CreatedAt).Error
+		if err != nil {
+			return err
+		}
+		return tx.Omit("Location", "User", "Group").Create(entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// CheckOut ends the active check-in of a user at a location. It returns
+// gorm.ErrRecordNotFound when there is none.
+func (checkinRepository *checkinRepository) CheckOut(locationID, userID uint, at time.Time) (*CheckinEntry, error) {
+	var checkin CheckinEntry
+	err := checkinRepository.db.Where("location_id = ? AND user_id = ? AND ends_at > ?", locationID, userID, at).
+		Order("created_at DESC").
+		First(&checkin).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := checkinRepository.db.Model(&checkin).UpdateColumn("ends_at", at).Error; err != nil {
+		return nil, err
+	}
+	checkin.EndsAt = at
+	return &checkin, nil
+}
+
+// FindCheckinsForLocation returns the check-ins of a location the viewer can
+// see, with their users.
+func (checkinRepository *checkinRepository) FindCheckinsForLocation(locationID, viewerID uint, query CheckinQuery) ([]CheckinEntry, error) {
+	db := checkinRepository.db.Preload("User").
+		Where("checkin_entries.location_id = ?", locationID).
+		Where(visibleCheckins, viewerID, viewerID, viewerID)
+	if query.ActiveOnly {
+		db = db.Where("checkin_entries.created_at <= ? AND checkin_entries.ends_at > ?", query.Now, query.Now)
+	}
+	if query.From != nil {
+		db = db.Where("checkin_entries.created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("checkin_entries.created_at <= ?", *query.To)
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+	var checkins []CheckinEntry
+	if err := db.Order("checkin_entries.created_at DESC, checkin_entries.id DESC").Find(&checkins).Error; err != nil {
+		return nil, err
+	}
+	return checkins, nil
+}
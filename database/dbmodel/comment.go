@@ -0,0 +1,214 @@
+package dbmodel
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CommentSortFields are the fields accepted by the sort parameter of the
+// comment list.
+var CommentSortFields = []string{"id", "created_at"}
+
+// CommentEntry is a message about a location shared in a group, read by the
+// members of the group while the location is shared in it. A reply has the
+// comment it answers as ParentID. A comment removed while it has replies
+// stays without its body so that the thread keeps its shape.
+type CommentEntry struct {
+	ID         uint          `gorm:"primarykey"`
+	GroupID    uint          `gorm:"not null;index:idx_comment_entries_share"`
+	Group      GroupEntry    `gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE;"`
+	LocationID uint          `gorm:"not null;index:idx_comment_entries_share"`
+	Location   LocationEntry `gorm:"foreignKey:LocationID;constraint:OnDelete:CASCADE;"`
+	ParentID   *uint         `gorm:"index"`
+	Parent     *CommentEntry `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE;"`
+	UserID     uint          `gorm:"not null"`
+	User       UserEntry     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	Body       string        `gorm:"not null"`
+	CreatedAt  time.Time
+	EditedAt   *time.Time
+	RemovedAt  *time.Time
+	// RemovedBy is the author or the group admin who removed the comment
+	RemovedBy *uint
+}
+
+// ReactionEntry is an emoji put by a user on a location shared in a group,
+// or on a comment about it when CommentID is not 0.
+type ReactionEntry struct {
+	ID         uint      `gorm:"primarykey"`
+	GroupID    uint      `gorm:"not null;uniqueIndex:idx_reaction_entries_unique"`
+	LocationID uint      `gorm:"not null;uniqueIndex:idx_reaction_entries_unique"`
+	CommentID  uint      `gorm:"not null;default:0;uniqueIndex:idx_reaction_entries_unique;index"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_reaction_entries_unique"`
+	Emoji      string    `gorm:"not null;uniqueIndex:idx_reaction_entries_unique"`
+	CreatedAt  time.Time `gorm:"not null"`
+}
+
+type CommentRepository interface {
+	Create(entry *CommentEntry) (*CommentEntry, error)
+	FindById(id uint) (*CommentEntry, error)
+	FindComments(groupID, locationID uint, parentID *uint, options QueryOptions) ([]CommentEntry, PageInfo, error)
+	CountReplies(ids []uint) (map[uint]int, error)
+	UpdateBody(id uint, body string, editedAt time.Time) (*CommentEntry, error)
+	Remove(id uint, removedBy uint, removedAt time.Time) error
+	AddReaction(entry *ReactionEntry) error
+	RemoveReaction(entry *ReactionEntry) (bool, error)
+	FindReactions(groupID, locationID uint, commentIDs []uint) ([]ReactionEntry, error)
+}
+
+var commentList = listQuery{table: "comment_entries", ownerColumn: "user_id", sortable: CommentSortFields}
+
+// listedComment hides the removed comments left without replies.
+const listedComment = `(comment_entries.removed_at IS NULL OR EXISTS (SELECT 1 FROM comment_entries AS replies WHERE replies.parent_id = comment_entries.id))`
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+func (commentRepository *commentRepository) Create(entry *CommentEntry) (*CommentEntry, error) {
+	if err := commentRepository.db.Omit("Group", "Location", "Parent", "User").Create(entry).Error; err != nil {
+		return nil, err
+	}
+	return commentRepository.FindById(entry.ID)
+}
+
+func (commentRepository *commentRepository) FindById(id uint) (*CommentEntry, error) {
+	var comment CommentEntry
+	if err := commentRepository.db.Preload("User").First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// FindComments returns a page of the comments about a shared location
+// answering parentID, or starting a thread when parentID is nil, with their
+// authors.
+func (commentRepository *commentRepository) FindComments(groupID, locationID uint, parentID *uint, options QueryOptions) ([]CommentEntry, PageInfo, error) {
+	query := commentRepository.db.Model(&CommentEntry{}).Preload("User").
+		Where("comment_entries.group_id = ? AND comment_entries.location_id = ?", groupID, locationID).
+		Where(listedComment)
+	if parentID != nil {
+		query = query.Where("comment_entries.parent_id = ?", *parentID)
+	} else {
+		query = query.Where("comment_entries.parent_id IS NULL")
+	}
+	return paginate[CommentEntry](query, commentList, options)
+}
+
+// CountReplies returns the number of listed replies to each comment of ids.
+func (commentRepository *commentRepository) CountReplies(ids []uint) (map[uint]int, error) {
+	counts := make(map[uint]int, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		ParentID uint
+		Count    int
+	}
+	err := commentRepository.db.Model(&CommentEntry{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ?", ids).
+		Where(listedComment).
+		Group("parent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.ParentID] = row.Count
+	}
+	return counts, nil
+}
+
+func (commentRepository *commentRepository) UpdateBody(id uint, body string, editedAt time.Time) (*CommentEntry, error) {
+	err := commentRepository.db.Model(&CommentEntry{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"body": body, "edited_at": editedAt}).Error
+	if err != nil {
+		return nil, err
+	}
+	return commentRepository.FindById(id)
+}
+
+// Remove deletes a comment with its reactions. A comment with replies is
+// only emptied of its body and reactions, and a removed parent left without
+// replies is deleted too.
+func (commentRepository *commentRepository) Remove(id uint, removedBy uint, removedAt time.Time) error {
+	return commentRepository.db.Transaction(func(tx *gorm.DB) error {
+		for {
+			var comment CommentEntry
+			if err := tx.First(&comment, id).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("comment_id = ?", id).Delete(&ReactionEntry{}).Error; err != nil {
+				return err
+			}
+			var replies int64
+			if err := tx.Model(&CommentEntry{}).Where("parent_id = ?", id).Count(&replies).Error; err != nil {
+				return err
+			}
+			if replies > 0 {
+				if comment.RemovedAt != nil {
+					return nil
+				}
+				return tx.Model(&CommentEntry{}).Where("id = ?", id).
+					UpdateColumns(map[string]interface{}{"body": "", "removed_at": removedAt, "removed_by": removedBy}).Error
+			}
+			if err := tx.Delete(&CommentEntry{}, id).Error; err != nil {
+				return err
+			}
+
+			if comment.ParentID == nil {
+				return nil
+			}
+			var parent CommentEntry
+			if err := tx.First(&parent, *comment.ParentID).Error; err != nil {
+				return err
+			}
+			if parent.RemovedAt == nil {
+				return nil
+			}
+			id = parent.ID
+		}
+	})
+}
+
+// AddReaction saves a reaction, doing nothing when the user already put the
+// same emoji.
+func (commentRepository *commentRepository) AddReaction(entry *ReactionEntry) error {
+	var count int64
+	err := commentRepository.db.Model(&ReactionEntry{}).
+		Where("group_id = ? AND location_id = ? AND comment_id = ? AND user_id = ? AND emoji = ?",
+			entry.GroupID, entry.LocationID, entry.CommentID, entry.UserID, entry.Emoji).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+	return commentRepository.db.Create(entry).Error
+}
+
+// RemoveReaction deletes a reaction, reporting whether there was one.
+func (commentRepository *commentRepository) RemoveReaction(entry *ReactionEntry) (bool, error) {
+	result := commentRepository.db.
+		Where("group_id = ? AND location_id = ? AND comment_id = ? AND user_id = ? AND emoji = ?",
+			entry.GroupID, entry.LocationID, entry.CommentID, entry.UserID, entry.Emoji).
+		Delete(&ReactionEntry{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindReactions returns the reactions to a shared location, for comment ID
+// 0, and to its comments of commentIDs, the oldest first.
+func (commentRepository *commentRepository) FindReactions(groupID, locationID uint, commentIDs []uint) ([]ReactionEntry, error) {
+	var reactions []ReactionEntry
+	err := commentRepository.db.
+		Where("group_id = ? AND location_id = ? AND comment_id IN ?", groupID, locationID, commentIDs).
+		Order("id").
+		Find(&reactions).Error
+	if err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
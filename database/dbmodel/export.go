@@ -0,0 +1,151 @@
+package dbmodel
+
+import "gorm.io/gorm"
+
+// exportBatchSize is the number of locations loaded at once by the exports.
+const exportBatchSize = 500
+
+// ExportBatchFunc receives the exported locations batch by batch, with the IDs
+// of the ones whose coordinates are visible to the viewer.
+type ExportBatchFunc func(locations []LocationEntry, visible map[uint]bool) error
+
+// ExportLocationsForUser iterates over every location of a user the viewer can
+// see, with their tags. The coordinates are visible to the owner, otherwise
+// for the locations shared in a group of the viewer with visible coordinates.
+func (locationRepository *locationRepository) ExportLocationsForUser(ownerID, viewerID uint, fn ExportBatchFunc) error {
+	query := locationRepository.db.Model(&LocationEntry{}).Where("user_id = ?", ownerID)
+	if ownerID != viewerID {
+		query = query.Where("id IN ("+sharedWithUser+")", viewerID, viewerID)
+	}
+	return locationRepository.exportInBatches(query, fn, func(ids []uint) *gorm.DB {
+		visible := locationRepository.db.Model(&LocationEntry{}).Where("id IN ?", ids)
+		if ownerID != viewerID {
+			visible = visible.Where("id IN ("+sharedWithUser+" AND group_location_entries.is_visible_coordinates)", viewerID, viewerID)
+		}
+		return visible
+	})
+}
+
+// ExportLocationsForGroup iterates over every location shared in a group, with
+// their tags. The coordinates are visible for the locations shared with
+// visible coordinates and for the viewer's own.
+func (locationRepository *locationRepository) ExportLocationsForGroup(groupID, viewerID uint, fn ExportBatchFunc) error {
+	query := locationRepository.db.Model(&LocationEntry{}).
+		Where("id IN (SELECT location_entry_id FROM group_location_entries WHERE group_entry_id = ?)", groupID)
+	return locationRepository.exportInBatches(query, fn, func(ids []uint) *gorm.DB {
+		return locationRepository.db.Model(&LocationEntry{}).
+			Where("id IN ?", ids).
+			Where("user_id = ? OR id IN (SELECT location_entry_id FROM group_location_entries WHERE group_entry_id = ? AND is_visible_coordinates)", viewerID, groupID)
+	})
+}
+
+// exportInBatches loads the locations of query by batches of exportBatchSize
+// and passes each of them to fn with the IDs selected by visibleQuery.
+func (locationRepository *locationRepository) exportInBatches(query *gorm.DB, fn ExportBatchFunc, visibleQuery func(ids []uint) *gorm.DB) error {
+	var locations []LocationEntry
+	return query.Preload("Tags").FindInBatches(&locations, exportBatchSize, func(tx *gorm.DB, batch int) error {
+		ids := make([]uint, 0, len(locations))
+		for _, location := range locations {
+			ids = append(ids, location.ID)
+		}
+		var visibleIDs []uint
+		if err := visibleQuery(ids).Pluck("id", &visibleIDs).Error; err != nil {
+			return err
+		}
+		visible := make(map[uint]bool, len(visibleIDs))
+		for _, id := range visibleIDs {
+			visible[id] = true
+		}
+		return fn(locations, visible)
+	}).Error
+}
+
+// FindLocationPointsForUser returns the ID, name and coordinates of every
+// location of a user.
+func (locationRepository *locationRepository) FindLocationPointsForUser(userID uint) ([]LocationEntry, error) {
+	var locations []LocationEntry
+	err := locationRepository.db.Model(&LocationEntry{}).
+		Select("id", "name", "latitude", "longitude").
+		Where("user_id = ?", userID).
+		Find(&locations).Error
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// FindLocationPointsForGroup returns the ID, name, color and coordinates of
+// the locations shared in a group whose coordinates are visible to the viewer,
+// by ID.
+func (locationRepository *locationRepository) FindLocationPointsForGroup(groupID, viewerID uint) ([]LocationEntry, error) {
+	var locations []LocationEntry
+	err := locationRepository.db.Model(&LocationEntry{}).
+		Select("id", "name", "color", "latitude", "longitude").
+		Where("id IN (SELECT location_entry_id FROM group_location_entries WHERE group_entry_id = ?)", groupID).
+		Where("user_id = ? OR id IN (SELECT location_entry_id FROM group_location_entries WHERE group_entry_id = ? AND is_visible_coordinates)", viewerID, groupID).
+		Order("id").
+		Find(&locations).Error
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// FindSelectedLocationPointsInGroup returns the ID, name and coordinates of
+// the locations of ids shared in a group, by ID, with the IDs of those whose
+// coordinates are visible to the viewer. The others must not be revealed.
+func (locationRepository *locationRepository) FindSelectedLocationPointsInGroup(groupID, viewerID uint, ids []uint) ([]LocationEntry, map[uint]bool, error) {
+	var locations []LocationEntry
+	err := locationRepository.db.Model(&LocationEntry{}).
+		Select("id", "name", "latitude", "longitude").
+		Where("id IN ?", ids).
+		Where("id IN (SELECT location_entry_id FROM group_location_entries WHERE group_entry_id = ?)", groupID).
+		Order("id").
+		Find(&locations).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	visible, err := locationRepository.FindVisibleLocationIDsInGroup(groupID, viewerID, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	return locations, visible, nil
+}
+
+// FindVisibleLocationIDs returns the IDs of ids whose coordinates are visible
+// to the viewer: their own locations and the ones shared in one of their
+// groups with visible coordinates.
+func (locationRepository *locationRepository) FindVisibleLocationIDs(viewerID uint, ids []uint) (map[uint]bool, error) {
+	var visibleIDs []uint
+	err := locationRepository.db.Model(&LocationEntry{}).
+		Where("id IN ?", ids).
+		Where(visibleCoordinates, viewerID, viewerID, viewerID).
+		Pluck("id", &visibleIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	visible := make(map[uint]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		visible[id] = true
+	}
+	return visible, nil
+}
+
+// FindVisibleLocationIDsInGroup returns the IDs of ids whose coordinates are
+// visible to the viewer in a group: their own locations and the ones shared
+// in the group with visible coordinates.
+func (locationRepository *locationRepository) FindVisibleLocationIDsInGroup(groupID, viewerID uint, ids []uint) (map[uint]bool, error) {
+	var visibleIDs []uint
+	err := locationRepository.db.Model(&LocationEntry{}).
+		Where("id IN ?", ids).
+		Where("user_id = ? OR id IN (SELECT location_entry_id FROM group_location_entries WHERE group_entry_id = ? AND is_visible_coordinates)", viewerID, groupID).
+		Pluck("id", &visibleIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	visible := make(map[uint]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		visible[id] = true
+	}
+	return visible, nil
+}
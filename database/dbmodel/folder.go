@@ -0,0 +1,585 @@
+package dbmodel
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrFolderCycle is returned when a folder would be moved into itself or
+	// one of its subfolders.
+	ErrFolderCycle = errors.New("a folder cannot be moved into itself or one of its subfolders")
+	// ErrFolderLocationNotFound is returned when a location is not in the
+	// folder.
+	ErrFolderLocationNotFound = errors.New("location not found in the folder")
+)
+
+// FolderEntry organizes the locations of a user, independently of the groups
+// they are shared in. Folders nest through ParentID, the root folders having
+// none, and are ordered among their siblings by increasing Position.
+type FolderEntry struct {
+	gorm.Model
+	UserID   uint         `json:"user_id" gorm:"not null;index"`
+	User     UserEntry    `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	ParentID *uint        `json:"parent_id" gorm:"index"`
+	Parent   *FolderEntry `json:"-" gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE;"`
+	Name     string       `json:"name" gorm:"not null"`
+	Position int          `json:"position" gorm:"not null;default:0"`
+}
+
+// FolderLocationEntry puts a location in a folder. A location can be in
+// several folders. The locations of a folder are ordered by increasing
+// Position.
+type FolderLocationEntry struct {
+	FolderID   uint          `gorm:"primaryKey"`
+	Folder     FolderEntry   `gorm:"foreignKey:FolderID;constraint:OnDelete:CASCADE;"`
+	LocationID uint          `gorm:"primaryKey;index"`
+	Location   LocationEntry `gorm:"foreignKey:LocationID;constraint:OnDelete:CASCADE;"`
+	Position   int           `gorm:"not null"`
+}
+
+// FolderGroupEntry shares a folder into a group: the locations of the folder
+// and of its subfolders are shared in the group, those added later too.
+type FolderGroupEntry struct {
+	FolderID             uint        `gorm:"primaryKey"`
+	Folder               FolderEntry `gorm:"foreignKey:FolderID;constraint:OnDelete:CASCADE;"`
+	GroupID              uint        `gorm:"primaryKey;index"`
+	Group                GroupEntry  `gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE;"`
+	IsVisibleCoordinates bool        `gorm:"not null;default:true"`
+}
+
+type FolderRepository interface {
+	Create(entry *FolderEntry) (*FolderEntry, error)
+	FindById(id uint) (*FolderEntry, error)
+	FindFoldersForUser(userID uint) ([]FolderEntry, error)
+	CountLocations(userID uint) (map[uint]int, error)
+	Update(entry *FolderEntry, id uint) (*FolderEntry, error)
+	Move(id uint, parentID *uint, position int) ([]LocationShare, error)
+	Delete(id uint) error
+	FindLocations(id uint) ([]LocationEntry, error)
+	AddLocations(id uint, locationIDs []uint, position int) ([]LocationShare, error)
+	MoveLocations(id uint, fromID uint, locationIDs []uint, position int) ([]LocationShare, error)
+	RemoveLocation(id uint, locationID uint) error
+	ReorderLocations(id uint, locationIDs []uint) error
+	FindGroups(userID uint) ([]FolderGroupEntry, error)
+	Share(entry *FolderGroupEntry) ([]LocationShare, error)
+	Unshare(id uint, groupID uint) error
+}
+
+type folderRepository struct {
+	db *gorm.DB
+}
+
+func NewFolderRepository(db *gorm.DB) FolderRepository {
+	return &folderRepository{db: db}
+}
+
+// Create saves a folder after the last folder of its parent.
+func (folderRepository *folderRepository) Create(entry *FolderEntry) (*FolderEntry, error) {
+	err := folderRepository.db.Transaction(func(tx *gorm.DB) error {
+		siblingIDs, err := folderChildIDs(tx, entry.UserID, entry.ParentID)
+		if err != nil {
+			return err
+		}
+		entry.Position = len(siblingIDs)
+		return tx.Omit("User", "Parent").Create(entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (folderRepository *folderRepository) FindById(id uint) (*FolderEntry, error) {
+	var folder FolderEntry
+	if err := folderRepository.db.First(&folder, id).Error; err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// FindFoldersForUser returns every folder of a user, siblings in their order.
+func (folderRepository *folderRepository) FindFoldersForUser(userID uint) ([]FolderEntry, error) {
+	var folders []FolderEntry
+	if err := folderRepository.db.Where("user_id = ?", userID).Order("position, id").Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// CountLocations returns the number of locations in each folder of a user.
+func (folderRepository *folderRepository) CountLocations(userID uint) (map[uint]int, error) {
+	var rows []struct {
+		FolderID uint
+		Count    int
+	}
+	err := folderRepository.db.Model(&FolderLocationEntry{}).
+		Select("folder_location_entries.folder_id, COUNT(*) AS count").
+		Joins("JOIN folder_entries ON folder_entries.id = folder_location_entries.folder_id").
+		Joins("JOIN location_entries ON location_entries.id = folder_location_entries.location_id").
+		Where("folder_entries.user_id = ? AND location_entries.deleted_at IS NULL", userID).
+		Group("folder_location_entries.folder_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.FolderID] = row.Count
+	}
+	return counts, nil
+}
+
+func (folderRepository *folderRepository) Update(entry *FolderEntry, id uint) (*FolderEntry, error) {
+	err := folderRepository.db.Model(&FolderEntry{}).Where("id = ?", id).
+		Select("name").
+		Updates(entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return folderRepository.FindById(id)
+}
+
+// Move puts a folder in parentID, or at the root when it is nil, at a
+// position among its new siblings counted from 0. A position beyond the last
+// sibling puts it last. Its locations are shared in the groups its new
+// parents are shared in.
+func (folderRepository *folderRepository) Move(id uint, parentID *uint, position int) ([]LocationShare, error) {
+	var shares []LocationShare
+	err := folderRepository.db.Transaction(func(tx *gorm.DB) error {
+		var folder FolderEntry
+		if err := tx.First(&folder, id).Error; err != nil {
+			return err
+		}
+		if parentID != nil {
+			ancestorIDs, err := folderAncestorIDs(tx, *parentID)
+			if err != nil {
+				return err
+			}
+			for _, ancestorID := range ancestorIDs {
+				if ancestorID == id {
+					return ErrFolderCycle
+				}
+			}
+		}
+
+		previousIDs, err := folderChildIDs(tx, folder.UserID, folder.ParentID)
+		if err != nil {
+			return err
+		}
+		if err := setFolderPositions(tx, removeID(previousIDs, id)); err != nil {
+			return err
+		}
+		if err := tx.Model(&FolderEntry{}).Where("id = ?", id).UpdateColumn("parent_id", parentID).Error; err != nil {
+			return err
+		}
+		siblingIDs, err := folderChildIDs(tx, folder.UserID, parentID)
+		if err != nil {
+			return err
+		}
+		if err := setFolderPositions(tx, insertID(removeID(siblingIDs, id), id, position)); err != nil {
+			return err
+		}
+
+		shares, err = shareFolderLocations(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// Delete removes a folder with its subfolders. Their locations are kept, and
+// stay shared in the groups the folders were shared in.
+func (folderRepository *folderRepository) Delete(id uint) error {
+	return folderRepository.db.Transaction(func(tx *gorm.DB) error {
+		var folder FolderEntry
+		if err := tx.First(&folder, id).Error; err != nil {
+			return err
+		}
+		folderIDs, err := folderSubtreeIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("folder_id IN ?", folderIDs).Delete(&FolderLocationEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("folder_id IN ?", folderIDs).Delete(&FolderGroupEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&FolderEntry{}, folderIDs).Error; err != nil {
+			return err
+		}
+		siblingIDs, err := folderChildIDs(tx, folder.UserID, folder.ParentID)
+		if err != nil {
+			return err
+		}
+		return setFolderPositions(tx, siblingIDs)
+	})
+}
+
+// FindLocations returns the locations of a folder in their order.
+func (folderRepository *folderRepository) FindLocations(id uint) ([]LocationEntry, error) {
+	var locations []LocationEntry
+	err := folderRepository.db.Model(&LocationEntry{}).
+		Joins("JOIN folder_location_entries ON folder_location_entries.location_id = location_entries.id").
+		Where("folder_location_entries.folder_id = ?", id).
+		Order("folder_location_entries.position, location_entries.id").
+		Find(&locations).Error
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// AddLocations puts locations in a folder at a position counted from 0, the
+// following locations moving further, or after the last location when the
+// position is beyond it. The locations already in the folder stay where they
+// are. The added locations are shared in the groups the folder or one of its
+// parents is shared in.
+func (folderRepository *folderRepository) AddLocations(id uint, locationIDs []uint, position int) ([]LocationShare, error) {
+	var shares []LocationShare
+	err := folderRepository.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if err = addFolderLocations(tx, id, locationIDs, position); err != nil {
+			return err
+		}
+		shares, err = shareFolderLocations(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// MoveLocations takes locations out of the folder fromID, which must hold all
+// of them, and adds them to a folder like AddLocations.
+func (folderRepository *folderRepository) MoveLocations(id uint, fromID uint, locationIDs []uint, position int) ([]LocationShare, error) {
+	var shares []LocationShare
+	err := folderRepository.db.Transaction(func(tx *gorm.DB) error {
+		current, err := folderLocationIDs(tx, fromID)
+		if err != nil {
+			return err
+		}
+		remaining := make(map[uint]bool, len(current))
+		for _, locationID := range current {
+			remaining[locationID] = true
+		}
+		for _, locationID := range locationIDs {
+			if !remaining[locationID] {
+				return ErrFolderLocationNotFound
+			}
+			delete(remaining, locationID)
+		}
+		if err := tx.Where("folder_id = ? AND location_id IN ?", fromID, locationIDs).Delete(&FolderLocationEntry{}).Error; err != nil {
+			return err
+		}
+		if current, err = folderLocationIDs(tx, fromID); err != nil {
+			return err
+		}
+		if err := setFolderLocationPositions(tx, fromID, current); err != nil {
+			return err
+		}
+
+		if err := addFolderLocations(tx, id, locationIDs, position); err != nil {
+			return err
+		}
+		shares, err = shareFolderLocations(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// RemoveLocation takes a location out of a folder, the following locations
+// moving one position back. The location stays shared in the groups it was
+// shared in.
+func (folderRepository *folderRepository) RemoveLocation(id uint, locationID uint) error {
+	return folderRepository.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("folder_id = ? AND location_id = ?", id, locationID).Delete(&FolderLocationEntry{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrFolderLocationNotFound
+		}
+		locationIDs, err := folderLocationIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		return setFolderLocationPositions(tx, id, locationIDs)
+	})
+}
+
+// ReorderLocations orders the locations of a folder as locationIDs, which
+// must hold every location of the folder once.
+func (folderRepository *folderRepository) ReorderLocations(id uint, locationIDs []uint) error {
+	return folderRepository.db.Transaction(func(tx *gorm.DB) error {
+		current, err := folderLocationIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		if len(current) != len(locationIDs) {
+			return ErrFolderLocationNotFound
+		}
+		remaining := make(map[uint]bool, len(current))
+		for _, locationID := range current {
+			remaining[locationID] = true
+		}
+		for _, locationID := range locationIDs {
+			if !remaining[locationID] {
+				return ErrFolderLocationNotFound
+			}
+			delete(remaining, locationID)
+		}
+		return setFolderLocationPositions(tx, id, locationIDs)
+	})
+}
+
+// FindGroups returns the groups the folders of a user are shared in.
+func (folderRepository *folderRepository) FindGroups(userID uint) ([]FolderGroupEntry, error) {
+	var groups []FolderGroupEntry
+	err := folderRepository.db.
+		Where("folder_id IN (SELECT id FROM folder_entries WHERE user_id = ? AND deleted_at IS NULL)", userID).
+		Order("folder_id, group_id").
+		Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// Share shares a folder into a group, or changes whether the coordinates are
+// visible there, and shares its locations and those of its subfolders in the
+// group.
+func (folderRepository *folderRepository) Share(entry *FolderGroupEntry) ([]LocationShare, error) {
+	var shares []LocationShare
+	err := folderRepository.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("folder_id = ? AND group_id = ?", entry.FolderID, entry.GroupID).Delete(&FolderGroupEntry{}).Error
+		if err != nil {
+			return err
+		}
+		// created from a map, otherwise the column default replaces IsVisibleCoordinates when false
+		err = tx.Model(&FolderGroupEntry{}).Create(map[string]interface{}{
+			"folder_id":              entry.FolderID,
+			"group_id":               entry.GroupID,
+			"is_visible_coordinates": entry.IsVisibleCoordinates,
+		}).Error
+		if err != nil {
+			return err
+		}
+		shares, err = shareFolderLocations(tx, entry.FolderID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// Unshare stops sharing the locations added to a folder in a group. The
+// locations already shared there stay shared.
+func (folderRepository *folderRepository) Unshare(id uint, groupID uint) error {
+	return folderRepository.db.Where("folder_id = ? AND group_id = ?", id, groupID).Delete(&FolderGroupEntry{}).Error
+}
+
+// shareFolderLocations shares the locations of a folder and of its
+// subfolders in the groups the folder or one of its parents is shared in,
+// when the owner still belongs to them. It returns the locations newly shared.
+func shareFolderLocations(tx *gorm.DB, id uint) ([]LocationShare, error) {
+	var folder FolderEntry
+	if err := tx.First(&folder, id).Error; err != nil {
+		return nil, err
+	}
+	ancestorIDs, err := folderAncestorIDs(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	var groups []FolderGroupEntry
+	err = tx.Where("folder_id IN ?", ancestorIDs).
+		Where("group_id IN ("+memberGroups+")", folder.UserID, folder.UserID).
+		Order("group_id").
+		Find(&groups).Error
+	if err != nil || len(groups) == 0 {
+		return nil, err
+	}
+
+	folderIDs, err := folderSubtreeIDs(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	var locationIDs []uint
+	err = tx.Model(&FolderLocationEntry{}).
+		Distinct("folder_location_entries.location_id").
+		Joins("JOIN location_entries ON location_entries.id = folder_location_entries.location_id").
+		Where("folder_location_entries.folder_id IN ? AND location_entries.deleted_at IS NULL", folderIDs).
+		Order("folder_location_entries.location_id").
+		Pluck("folder_location_entries.location_id", &locationIDs).Error
+	if err != nil || len(locationIDs) == 0 {
+		return nil, err
+	}
+
+	var shares []LocationShare
+	shared := make(map[LocationShare]bool)
+	for _, group := range groups {
+		var existing []uint
+		err := tx.Model(&GroupLocationEntry{}).
+			Where("group_entry_id = ? AND location_entry_id IN ?", group.GroupID, locationIDs).
+			Pluck("location_entry_id", &existing).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, locationID := range existing {
+			shared[LocationShare{GroupID: group.GroupID, LocationID: locationID}] = true
+		}
+		for _, locationID := range locationIDs {
+			share := LocationShare{GroupID: group.GroupID, LocationID: locationID}
+			if shared[share] {
+				continue
+			}
+			err := tx.Model(&GroupLocationEntry{}).Create(map[string]interface{}{
+				"group_entry_id":         group.GroupID,
+				"location_entry_id":      locationID,
+				"is_visible_coordinates": group.IsVisibleCoordinates,
+			}).Error
+			if err != nil {
+				return nil, err
+			}
+			shared[share] = true
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+// folderAncestorIDs returns the ID of a folder followed by those of its
+// parents up to the root.
+func folderAncestorIDs(tx *gorm.DB, id uint) ([]uint, error) {
+	ids := []uint{id}
+	for {
+		var folder FolderEntry
+		if err := tx.Select("id", "parent_id").First(&folder, ids[len(ids)-1]).Error; err != nil {
+			return nil, err
+		}
+		if folder.ParentID == nil {
+			return ids, nil
+		}
+		ids = append(ids, *folder.ParentID)
+	}
+}
+
+// folderSubtreeIDs returns the ID of a folder and those of its subfolders.
+func folderSubtreeIDs(tx *gorm.DB, id uint) ([]uint, error) {
+	ids := []uint{id}
+	for level := []uint{id}; len(level) > 0; {
+		var children []uint
+		if err := tx.Model(&FolderEntry{}).Where("parent_id IN ?", level).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, children...)
+		level = children
+	}
+	return ids, nil
+}
+
+func folderChildIDs(tx *gorm.DB, userID uint, parentID *uint) ([]uint, error) {
+	query := tx.Model(&FolderEntry{}).Where("user_id = ?", userID)
+	if parentID != nil {
+		query = query.Where("parent_id = ?", *parentID)
+	} else {
+		query = query.Where("parent_id IS NULL")
+	}
+	var ids []uint
+	if err := query.Order("position, id").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// setFolderPositions numbers the folders from 0 in the order of folderIDs.
+func setFolderPositions(tx *gorm.DB, folderIDs []uint) error {
+	for position, folderID := range folderIDs {
+		if err := tx.Model(&FolderEntry{}).Where("id = ?", folderID).UpdateColumn("position", position).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func folderLocationIDs(tx *gorm.DB, id uint) ([]uint, error) {
+	var locationIDs []uint
+	err := tx.Model(&FolderLocationEntry{}).Where("folder_id = ?", id).Order("position, location_id").Pluck("location_id", &locationIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return locationIDs, nil
+}
+
+func addFolderLocations(tx *gorm.DB, id uint, locationIDs []uint, position int) error {
+	current, err := folderLocationIDs(tx, id)
+	if err != nil {
+		return err
+	}
+	present := make(map[uint]bool, len(current))
+	for _, locationID := range current {
+		present[locationID] = true
+	}
+	if position < 0 || position > len(current) {
+		position = len(current)
+	}
+	ordered := append([]uint{}, current[:position]...)
+	for _, locationID := range locationIDs {
+		if present[locationID] {
+			continue
+		}
+		present[locationID] = true
+		entry := &FolderLocationEntry{FolderID: id, LocationID: locationID}
+		if err := tx.Omit("Folder", "Location").Create(entry).Error; err != nil {
+			return err
+		}
+		ordered = append(ordered, locationID)
+	}
+	ordered = append(ordered, current[position:]...)
+	return setFolderLocationPositions(tx, id, ordered)
+}
+
+// setFolderLocationPositions numbers the locations of a folder from 0 in the
+// order of locationIDs.
+func setFolderLocationPositions(tx *gorm.DB, id uint, locationIDs []uint) error {
+	for position, locationID := range locationIDs {
+		err := tx.Model(&FolderLocationEntry{}).
+			Where("folder_id = ? AND location_id = ?", id, locationID).
+			UpdateColumn("position", position).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeID(ids []uint, id uint) []uint {
+	kept := make([]uint, 0, len(ids))
+	for _, value := range ids {
+		if value != id {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// insertID inserts id at a position of ids, or last when the position is
+// beyond the end.
+func insertID(ids []uint, id uint, position int) []uint {
+	if position < 0 || position > len(ids) {
+		position = len(ids)
+	}
+	inserted := make([]uint, 0, len(ids)+1)
+	inserted = append(inserted, ids[:position]...)
+	inserted = append(inserted, id)
+	return append(inserted, ids[position:]...)
+}
@@ -0,0 +1,167 @@
+package dbmodel
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// newFolderTree returns the folders of a user, by name:
+//
+//	root
+//	├── a
+//	│   └── b
+//	│       └── c
+//	├── d
+//	└── e
+//	other
+func newFolderTree(t *testing.T) (*gorm.DB, FolderRepository, map[string]uint) {
+	db, user, _ := newTestDB(t)
+	if err := db.AutoMigrate(&FolderEntry{}, &FolderLocationEntry{}, &FolderGroupEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	folders := NewFolderRepository(db)
+	ids := make(map[string]uint)
+	for _, folder := range []struct{ name, parent string }{
+		{"root", ""}, {"a", "root"}, {"b", "a"}, {"c", "b"}, {"d", "root"}, {"e", "root"}, {"other", ""},
+	} {
+		entry := &FolderEntry{UserID: user.ID, Name: folder.name}
+		if folder.parent != "" {
+			parentID := ids[folder.parent]
+			entry.ParentID = &parentID
+		}
+		if _, err := folders.Create(entry); err != nil {
+			t.Fatal(err)
+		}
+		ids[folder.name] = entry.ID
+	}
+	return db, folders, ids
+}
+
+// folderPath returns the names from the root to a folder, stopping on a
+// cycle.
+func folderPath(t *testing.T, folders FolderRepository, id uint) []string {
+	var names []string
+	seen := make(map[uint]bool)
+	for {
+		if seen[id] {
+			t.Fatalf("cycle through %v", names)
+		}
+		seen[id] = true
+		folder, err := folders.FindById(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append([]string{folder.Name}, names...)
+		if folder.ParentID == nil {
+			return names
+		}
+		id = *folder.ParentID
+	}
+}
+
+func TestFolderMove(t *testing.T) {
+	tests := []struct {
+		folder string
+		parent string
+		cycle  bool
+		want   []string
+	}{
+		{"a", "a", true, []string{"root", "a"}},
+		{"a", "b", true, []string{"root", "a"}},
+		{"a", "c", true, []string{"root", "a"}},
+		{"b", "c", true, []string{"root", "a", "b"}},
+		{"root", "d", true, []string{"root"}},
+		{"root", "c", true, []string{"root"}},
+		{"c", "c", true, []string{"root", "a", "b", "c"}},
+		// up, across and out of the tree
+		{"c", "a", false, []string{"root", "a", "c"}},
+		{"c", "root", false, []string{"root", "c"}},
+		{"b", "d", false, []string{"root", "d", "b"}},
+		{"d", "c", false, []string{"root", "a", "b", "c", "d"}},
+		{"a", "other", false, []string{"other", "a"}},
+		{"a", "", false, []string{"a"}},
+		{"root", "other", false, []string{"other", "root"}},
+		{"other", "c", false, []string{"root", "a", "b", "c", "other"}},
+		{"c", "b", false, []string{"root", "a", "b", "c"}},
+	}
+	for _, test := range tests {
+		_, folders, ids := newFolderTree(t)
+		var parentID *uint
+		if test.parent != "" {
+			id := ids[test.parent]
+			parentID = &id
+		}
+		_, err := folders.Move(ids[test.folder], parentID, 0)
+		if test.cycle != errors.Is(err, ErrFolderCycle) || (!test.cycle && err != nil) {
+			t.Errorf("Move(%s into %q) = %v, want a cycle %v", test.folder, test.parent, err, test.cycle)
+		}
+		if got := folderPath(t, folders, ids[test.folder]); !slices.Equal(got, test.want) {
+			t.Errorf("Move(%s into %q) leaves it in %v, want %v", test.folder, test.parent, got, test.want)
+		}
+		// the subfolders follow
+		if got := folderPath(t, folders, ids["c"]); test.folder != "c" && !slices.Equal(got[len(got)-2:], []string{"b", "c"}) {
+			t.Errorf("Move(%s into %q) leaves c in %v", test.folder, test.parent, got)
+		}
+	}
+}
+
+func TestFolderMovePositions(t *testing.T) {
+	tests := []struct {
+		folder   string
+		parent   string
+		position int
+		want     []string
+		previous []string
+	}{
+		{"d", "root", 0, []string{"d", "a", "e"}, nil},
+		{"a", "root", 2, []string{"d", "e", "a"}, nil},
+		{"a", "root", 10, []string{"d", "e", "a"}, nil},
+		{"e", "b", 0, []string{"e", "c"}, []string{"a", "d"}},
+		{"e", "b", 1, []string{"c", "e"}, []string{"a", "d"}},
+		{"a", "", 0, []string{"a", "root", "other"}, []string{"d", "e"}},
+		{"c", "", 5, []string{"root", "other", "c"}, []string{}},
+	}
+	for _, test := range tests {
+		db, folders, ids := newFolderTree(t)
+		folder, err := folders.FindById(ids[test.folder])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parentID *uint
+		if test.parent != "" {
+			id := ids[test.parent]
+			parentID = &id
+		}
+		if _, err := folders.Move(folder.ID, parentID, test.position); err != nil {
+			t.Fatal(err)
+		}
+		children := func(parentID *uint) []string {
+			ids, err := folderChildIDs(db, folder.UserID, parentID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, len(ids))
+			for i, id := range ids {
+				child, err := folders.FindById(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if names[i] = child.Name; child.Position != i {
+					t.Errorf("%s at position %d, want %d", child.Name, child.Position, i)
+				}
+			}
+			return names
+		}
+		if got := children(parentID); !slices.Equal(got, test.want) {
+			t.Errorf("Move(%s into %q at %d) orders %v, want %v", test.folder, test.parent, test.position, got, test.want)
+		}
+		if test.previous != nil {
+			if got := children(folder.ParentID); !slices.Equal(got, test.previous) {
+				t.Errorf("Move(%s into %q at %d) leaves %v, want %v", test.folder, test.parent, test.position, got, test.previous)
+			}
+		}
+	}
+}
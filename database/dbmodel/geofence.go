@@ -0,0 +1,134 @@
+package dbmodel
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	GeofenceShapeCircle  = "circle"
+	GeofenceShapePolygon = "polygon"
+)
+
+// GeofenceEntry is an area of a group whose members are watched while they
+// share their position live. A circle is centered on a location when
+// LocationID is set, so it follows the location when it moves.
+type GeofenceEntry struct {
+	gorm.Model
+	GroupID         uint           `json:"group_id" gorm:"not null;index"`
+	Group           GroupEntry     `json:"-" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE;"`
+	UserID          uint           `json:"user_id" gorm:"not null"`
+	Name            string         `json:"name" gorm:"not null"`
+	Shape           string         `json:"shape" gorm:"not null"`
+	LocationID      *uint          `json:"location_id" gorm:"index"`
+	Location        *LocationEntry `json:"-" gorm:"foreignKey:LocationID;constraint:OnDelete:CASCADE;"`
+	Latitude        *float64       `json:"latitude"`
+	Longitude       *float64       `json:"longitude"`
+	Radius          float64        `json:"radius"`
+	Polygon         [][2]float64   `json:"polygon" gorm:"serializer:json"`
+	Hysteresis      float64        `json:"hysteresis" gorm:"not null"`
+	DebounceSeconds int            `json:"debounce_seconds" gorm:"not null"`
+	WebhookURL      string         `json:"webhook_url" gorm:"not null;default:''"`
+}
+
+// GeofenceStateEntry is the side of a geofence a member is on. A change is
+// only confirmed once it has lasted the debounce delay of the geofence.
+type GeofenceStateEntry struct {
+	GeofenceID    uint          `gorm:"primaryKey;constraint:OnDelete:CASCADE"`
+	Geofence      GeofenceEntry `gorm:"foreignKey:GeofenceID;constraint:OnDelete:CASCADE;"`
+	UserID        uint          `gorm:"primaryKey"`
+	Inside        bool          `gorm:"not null"`
+	ChangedAt     time.Time     `gorm:"not null"`
+	PendingInside *bool
+	PendingSince  *time.Time
+}
+
+type GeofenceRepository interface {
+	Create(entry *GeofenceEntry) (*GeofenceEntry, error)
+	FindById(id uint) (*GeofenceEntry, error)
+	FindGeofencesForGroup(groupID uint) ([]GeofenceEntry, error)
+	Update(entry *GeofenceEntry, id uint) (*GeofenceEntry, error)
+	Delete(id uint) error
+	FindState(geofenceID, userID uint) (*GeofenceStateEntry, error)
+	FindStatesForGeofence(geofenceID uint) ([]GeofenceStateEntry, error)
+	SaveState(state *GeofenceStateEntry) error
+}
+
+type geofenceRepository struct {
+	db *gorm.DB
+}
+
+func NewGeofenceRepository(db *gorm.DB) GeofenceRepository {
+	return &geofenceRepository{db: db}
+}
+
+func (geofenceRepository *geofenceRepository) Create(entry *GeofenceEntry) (*GeofenceEntry, error) {
+	if err := geofenceRepository.db.Create(entry).Error; err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (geofenceRepository *geofenceRepository) FindById(id uint) (*GeofenceEntry, error) {
+	var geofence GeofenceEntry
+	if err := geofenceRepository.db.First(&geofence, id).Error; err != nil {
+		return nil, err
+	}
+	return &geofence, nil
+}
+
+func (geofenceRepository *geofenceRepository) FindGeofencesForGroup(groupID uint) ([]GeofenceEntry, error) {
+	var geofences []GeofenceEntry
+	if err := geofenceRepository.db.Where("group_id = ?", groupID).Order("id").Find(&geofences).Error; err != nil {
+		return nil, err
+	}
+	return geofences, nil
+}
+
+// Update replaces the geofence and forgets the states computed with its
+// previous shape.
+func (geofenceRepository *geofenceRepository) Update(entry *GeofenceEntry, id uint) (*GeofenceEntry, error) {
+	err := geofenceRepository.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&GeofenceEntry{}).Where("id = ?", id).
+			Select("name", "shape", "location_id", "latitude", "longitude", "radius", "polygon", "hysteresis", "debounce_seconds", "webhook_url").
+			Updates(entry).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("geofence_id = ?", id).Delete(&GeofenceStateEntry{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return geofenceRepository.FindById(id)
+}
+
+func (geofenceRepository *geofenceRepository) Delete(id uint) error {
+	return geofenceRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("geofence_id = ?", id).Delete(&GeofenceStateEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&GeofenceEntry{}, id).Error
+	})
+}
+
+func (geofenceRepository *geofenceRepository) FindState(geofenceID, userID uint) (*GeofenceStateEntry, error) {
+	var state GeofenceStateEntry
+	if err := geofenceRepository.db.Where("geofence_id = ? AND user_id = ?", geofenceID, userID).First(&state).Error; err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (geofenceRepository *geofenceRepository) FindStatesForGeofence(geofenceID uint) ([]GeofenceStateEntry, error) {
+	var states []GeofenceStateEntry
+	if err := geofenceRepository.db.Where("geofence_id = ?", geofenceID).Order("user_id").Find(&states).Error; err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+func (geofenceRepository *geofenceRepository) SaveState(state *GeofenceStateEntry) error {
+	return geofenceRepository.db.Omit("Geofence").Save(state).Error
+}
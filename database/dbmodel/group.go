@@ -5,21 +5,29 @@ import "gorm.io/gorm"
 type GroupEntry struct {
 	gorm.Model
 	Name      string           `json:"name" gorm:"not null"`
-	Admin     UserEntry        `json:"admin_id" gorm:"not null;foreignKey:ID;constraint:OnDelete:CASCADE;"`
+	AdminID   uint             `json:"admin_id" gorm:"index"`
+	Admin     UserEntry        `json:"-" gorm:"foreignKey:AdminID;constraint:OnDelete:CASCADE;"`
 	Users     []*UserEntry     `gorm:"many2many:group_user_entries;constraint:OnDelete:CASCADE;" json:"users"`
 	Locations []*LocationEntry `gorm:"many2many:group_location_entries;constraint:OnDelete:CASCADE;" json:"locations"`
 }
 
 type GroupRepository interface {
 	Create(entry *GroupEntry) (*GroupEntry, error)
-	FindAll() ([]GroupEntry, error)
+	FindAll(options QueryOptions) ([]GroupEntry, PageInfo, error)
 	FindById(id uint) (*GroupEntry, error)
-	FindLocationsForGroup(id uint) ([]LocationEntry, error)
-	FindUsersForGroup(id uint) ([]UserEntry, error)
+	FindLocationsForGroup(id uint, viewerID uint, options QueryOptions) ([]LocationEntry, PageInfo, error)
+	FindUsersForGroup(id uint, options QueryOptions) ([]UserEntry, PageInfo, error)
+	IsMember(id uint, userID uint) (bool, error)
 	Update(entry *GroupEntry, id uint) (*GroupEntry, error)
 	Delete(id uint) error
 }
 
+var groupList = listQuery{table: "group_entries", nameColumn: "name", ownerColumn: "admin_id", sortable: GroupSortFields}
+
+// memberGroups selects the IDs of the groups a user administrates or belongs to.
+const memberGroups = `SELECT id FROM group_entries WHERE deleted_at IS NULL
+	AND (admin_id = ? OR id IN (SELECT group_entry_id FROM group_user_entries WHERE user_entry_id = ?))`
+
 type groupRepository struct {
 	db *gorm.DB
 }
@@ -29,18 +37,20 @@ func NewGroupRepository(db *gorm.DB) GroupRepository {
 }
 
 func (groupRepository *groupRepository) Create(entry *GroupEntry) (*GroupEntry, error) {
-	if err := groupRepository.db.Create(entry).Error; err != nil {
+	err := groupRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return indexDocument(tx, SearchKindGroup, entry.ID, entry.Name, "", "")
+	})
+	if err != nil {
 		return nil, err
 	}
 	return entry, nil
 }
 
-func (groupRepository *groupRepository) FindAll() ([]GroupEntry, error) {
-	var groups []GroupEntry
-	if err := groupRepository.db.Find(&groups).Error; err != nil {
-		return nil, err
-	}
-	return groups, nil
+func (groupRepository *groupRepository) FindAll(options QueryOptions) ([]GroupEntry, PageInfo, error) {
+	return paginate[GroupEntry](groupRepository.db.Model(&GroupEntry{}), groupList, options)
 }
 
 func (groupRepository *groupRepository) FindById(id uint) (*GroupEntry, error) {
@@ -51,40 +61,66 @@ func (groupRepository *groupRepository) FindById(id uint) (*GroupEntry, error) {
 	return &group, nil
 }
 
-func (groupRepository *groupRepository) FindLocationsForGroup(id uint) ([]LocationEntry, error) {
-	var group GroupEntry
-	if err := groupRepository.db.Preload("Locations").First(&group, id).Error; err != nil {
-		return nil, err
+// FindLocationsForGroup returns a page of the locations shared in a group. The
+// filters on the address, altitude and accuracy only match the locations
+// whose coordinates are visible to the viewer.
+func (groupRepository *groupRepository) FindLocationsForGroup(id uint, viewerID uint, options QueryOptions) ([]LocationEntry, PageInfo, error) {
+	if err := groupRepository.db.First(&GroupEntry{}, id).Error; err != nil {
+		return nil, PageInfo{}, err
 	}
-	locations := make([]LocationEntry, len(group.Locations))
-	for i, l := range group.Locations {
-		locations[i] = *l
+	query := groupRepository.db.Model(&LocationEntry{}).
+		Joins("JOIN group_location_entries ON group_location_entries.location_entry_id = location_entries.id").
+		Where("group_location_entries.group_entry_id = ?", id)
+	if options.filtersCoordinateDetails() {
+		query = query.Where("location_entries.user_id = ? OR group_location_entries.is_visible_coordinates", viewerID)
 	}
-	return locations, nil
+	return paginate[LocationEntry](query, locationList, options)
 }
 
-func (groupRepository *groupRepository) FindUsersForGroup(id uint) ([]UserEntry, error) {
-	var group GroupEntry
-	if err := groupRepository.db.Preload("Users").First(&group, id).Error; err != nil {
-		return nil, err
+func (groupRepository *groupRepository) FindUsersForGroup(id uint, options QueryOptions) ([]UserEntry, PageInfo, error) {
+	if err := groupRepository.db.First(&GroupEntry{}, id).Error; err != nil {
+		return nil, PageInfo{}, err
 	}
-	users := make([]UserEntry, len(group.Users))
-	for i, u := range group.Users {
-		users[i] = *u
+	query := groupRepository.db.Model(&UserEntry{}).
+		Joins("JOIN group_user_entries ON group_user_entries.user_entry_id = user_entries.id").
+		Where("group_user_entries.group_entry_id = ?", id)
+	return paginate[UserEntry](query, userList, options)
+}
+
+// IsMember tells whether the user administrates or belongs to the group.
+func (groupRepository *groupRepository) IsMember(id uint, userID uint) (bool, error) {
+	var count int64
+	err := groupRepository.db.Model(&GroupEntry{}).
+		Where("id = ? AND (admin_id = ? OR id IN (SELECT group_entry_id FROM group_user_entries WHERE user_entry_id = ?))", id, userID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
 	}
-	return users, nil
+	return count > 0, nil
 }
 
 func (groupRepository *groupRepository) Update(entry *GroupEntry, id uint) (*GroupEntry, error) {
-	if err := groupRepository.db.Model(&GroupEntry{}).Where("id = ?", id).Updates(entry).Error; err != nil {
+	err := groupRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&GroupEntry{}).Where("id = ?", id).Updates(entry).Error; err != nil {
+			return err
+		}
+		var updated GroupEntry
+		if err := tx.First(&updated, id).Error; err != nil {
+			return err
+		}
+		return indexDocument(tx, SearchKindGroup, updated.ID, updated.Name, "", "")
+	})
+	if err != nil {
 		return nil, err
 	}
 	return entry, nil
 }
 
 func (groupRepository *groupRepository) Delete(id uint) error {
-	if err := groupRepository.db.Delete(&GroupEntry{}, id).Error; err != nil {
-		return err
-	}
-	return nil
+	return groupRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&GroupEntry{}, id).Error; err != nil {
+			return err
+		}
+		return removeDocument(tx, SearchKindGroup, id)
+	})
 }
@@ -6,6 +6,16 @@ type GroupLocationEntry struct {
 	GroupEntryID         uint `gorm:"not null;primaryKey;constraint:OnDelete:CASCADE"`
 	LocationEntryID      uint `gorm:"not null;primaryKey;constraint:OnDelete:CASCADE"`
 	IsVisibleCoordinates bool `gorm:"not null;default:true"`
+	// ShareRuleID is the rule that shared the location, nil when it was
+	// shared by hand or with a folder
+	ShareRuleID *uint `gorm:"index"`
+}
+
+// LocationShare is a location shared in a group, or taken out of it, by a
+// shared folder or a share rule.
+type LocationShare struct {
+	GroupID    uint
+	LocationID uint
 }
 
 type GroupLocationRepository interface {
@@ -13,6 +23,7 @@ type GroupLocationRepository interface {
 	FindAll() ([]GroupLocationEntry, error)
 	Update(entry *GroupLocationEntry) (*GroupLocationEntry, error)
 	Delete(groupID, locationID uint) error
+	IsShared(groupID, locationID uint) (bool, error)
 }
 type groupLocationRepository struct {
 	db *gorm.DB
@@ -22,7 +33,13 @@ func NewGroupLocationRepository(db *gorm.DB) GroupLocationRepository {
 	return &groupLocationRepository{db: db}
 }
 func (groupLocationRepository *groupLocationRepository) Create(entry *GroupLocationEntry) (*GroupLocationEntry, error) {
-	if err := groupLocationRepository.db.Create(entry).Error; err != nil {
+	// created from a map, otherwise the column default replaces IsVisibleCoordinates when false
+	err := groupLocationRepository.db.Model(&GroupLocationEntry{}).Create(map[string]interface{}{
+		"group_entry_id":         entry.GroupEntryID,
+		"location_entry_id":      entry.LocationEntryID,
+		"is_visible_coordinates": entry.IsVisibleCoordinates,
+	}).Error
+	if err != nil {
 		return nil, err
 	}
 	return entry, nil
@@ -44,5 +61,19 @@ func (groupLocationRepository *groupLocationRepository) Update(entry *GroupLocat
 }
 
 func (groupLocationRepository *groupLocationRepository) Delete(groupID, locationID uint) error {
-	return groupLocationRepository.db.Where("group_id = ? AND location_id = ?", groupID, locationID).Delete(&GroupLocationEntry{}).Error
+	return groupLocationRepository.db.Where("group_entry_id = ? AND location_entry_id = ?", groupID, locationID).Delete(&GroupLocationEntry{}).Error
+}
+
+// IsShared reports whether a location that was not deleted is shared in the
+// group.
+func (groupLocationRepository *groupLocationRepository) IsShared(groupID, locationID uint) (bool, error) {
+	var count int64
+	err := groupLocationRepository.db.Model(&GroupLocationEntry{}).
+		Where("group_entry_id = ? AND location_entry_id = ?", groupID, locationID).
+		Where("location_entry_id IN (SELECT id FROM location_entries WHERE deleted_at IS NULL)").
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
@@ -37,5 +37,5 @@ func (groupUserRepository *groupUserRepository) FindAll() ([]GroupUserEntry, err
 }
 
 func (groupUserRepository *groupUserRepository) Delete(userID, groupID uint) error {
-	return groupUserRepository.db.Where("user_id = ? AND group_id = ?", userID, groupID).Delete(&GroupUserEntry{}).Error
+	return groupUserRepository.db.Where("user_entry_id = ? AND group_entry_id = ?", userID, groupID).Delete(&GroupUserEntry{}).Error
 }
@@ -0,0 +1,100 @@
+package dbmodel
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LocationHistoryEntry is a past position of a location, or of a user
+// sharing live with a group when GroupID is set.
+type LocationHistoryEntry struct {
+	ID         uint           `gorm:"primarykey" json:"location_history_id"`
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	User       UserEntry      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	LocationID *uint          `json:"location_id" gorm:"index:idx_location_history_entries_location"`
+	Location   *LocationEntry `json:"-" gorm:"foreignKey:LocationID;constraint:OnDelete:CASCADE;"`
+	GroupID    *uint          `json:"group_id" gorm:"index:idx_location_history_entries_live"`
+	Group      *GroupEntry    `json:"-" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE;"`
+	Latitude   float64        `json:"latitude"`
+	Longitude  float64        `json:"longitude"`
+	Accuracy   *float64       `json:"accuracy"`
+	RecordedAt time.Time      `json:"recorded_at" gorm:"not null;index:idx_location_history_entries_location;index:idx_location_history_entries_live"`
+}
+
+type HistoryRepository interface {
+	AppendLocationMove(previous, current *LocationEntry) error
+	Append(entry *LocationHistoryEntry) error
+	FindHistoryForLocation(id uint, from, to *time.Time) ([]LocationHistoryEntry, error)
+	FindHistoryForLiveShare(userID, groupID uint, from, to *time.Time) ([]LocationHistoryEntry, error)
+	DeleteBefore(userID uint, before time.Time) error
+}
+
+type historyRepository struct {
+	db *gorm.DB
+}
+
+func NewHistoryRepository(db *gorm.DB) HistoryRepository {
+	return &historyRepository{db: db}
+}
+
+// AppendLocationMove records the position of a location that moved. The
+// previous position is recorded first when the location has no history yet,
+// so that the track starts where the location was.
+func (historyRepository *historyRepository) AppendLocationMove(previous, current *LocationEntry) error {
+	return historyRepository.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&LocationHistoryEntry{}).Where("location_id = ?", current.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := tx.Create(newLocationHistoryEntry(previous)).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(newLocationHistoryEntry(current)).Error
+	})
+}
+
+func newLocationHistoryEntry(location *LocationEntry) *LocationHistoryEntry {
+	id := location.ID
+	return &LocationHistoryEntry{
+		UserID:     location.UserID,
+		LocationID: &id,
+		Latitude:   location.Latitude,
+		Longitude:  location.Longitude,
+		Accuracy:   location.Accuracy,
+		RecordedAt: location.UpdatedAt,
+	}
+}
+
+func (historyRepository *historyRepository) Append(entry *LocationHistoryEntry) error {
+	return historyRepository.db.Create(entry).Error
+}
+
+func (historyRepository *historyRepository) FindHistoryForLocation(id uint, from, to *time.Time) ([]LocationHistoryEntry, error) {
+	return historyRepository.find(historyRepository.db.Where("location_id = ?", id), from, to)
+}
+
+func (historyRepository *historyRepository) FindHistoryForLiveShare(userID, groupID uint, from, to *time.Time) ([]LocationHistoryEntry, error) {
+	return historyRepository.find(historyRepository.db.Where("user_id = ? AND group_id = ?", userID, groupID), from, to)
+}
+
+func (historyRepository *historyRepository) find(query *gorm.DB, from, to *time.Time) ([]LocationHistoryEntry, error) {
+	if from != nil {
+		query = query.Where("recorded_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("recorded_at <= ?", *to)
+	}
+	var history []LocationHistoryEntry
+	if err := query.Order("recorded_at, id").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// DeleteBefore applies the retention policy of a user.
+func (historyRepository *historyRepository) DeleteBefore(userID uint, before time.Time) error {
+	return historyRepository.db.Where("user_id = ? AND recorded_at < ?", userID, before).Delete(&LocationHistoryEntry{}).Error
+}
@@ -0,0 +1,124 @@
+package dbmodel
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LiveShareEntry is a user sharing their position live with a group, with the
+// latest position published. There is at most one share per user and group.
+type LiveShareEntry struct {
+	ID         uint       `gorm:"primarykey" json:"live_share_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UserID     uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_live_share_entries_user_group"`
+	User       UserEntry  `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	GroupID    uint       `json:"group_id" gorm:"not null;uniqueIndex:idx_live_share_entries_user_group;index"`
+	Group      GroupEntry `json:"-" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE;"`
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"index"`
+	Latitude   *float64   `json:"latitude"`
+	Longitude  *float64   `json:"longitude"`
+	Accuracy   *float64   `json:"accuracy"`
+	Heading    *float64   `json:"heading"`
+	Speed      *float64   `json:"speed"`
+	RecordedAt *time.Time `json:"recorded_at"`
+}
+
+type LiveShareRepository interface {
+	Start(entry *LiveShareEntry) (*LiveShareEntry, error)
+	FindActiveShare(userID, groupID uint) (*LiveShareEntry, error)
+	FindSharesForUser(userID uint) ([]LiveShareEntry, error)
+	FindPositionsForGroup(groupID uint, since time.Time) ([]LiveShareEntry, error)
+	Publish(entry *LiveShareEntry) (*LiveShareEntry, error)
+	Stop(userID, groupID uint) error
+	DeleteExpired() error
+}
+
+type liveShareRepository struct {
+	db *gorm.DB
+}
+
+func NewLiveShareRepository(db *gorm.DB) LiveShareRepository {
+	return &liveShareRepository{db: db}
+}
+
+// activeLiveShares keeps the shares that have not ended and whose user still
+// belongs to the group.
+func activeLiveShares(db *gorm.DB) *gorm.DB {
+	return db.
+		Where("live_share_entries.expires_at IS NULL OR live_share_entries.expires_at > ?", time.Now()).
+		Where(`live_share_entries.group_id IN (
+			SELECT id FROM group_entries WHERE deleted_at IS NULL AND (admin_id = live_share_entries.user_id OR id IN (
+				SELECT group_entry_id FROM group_user_entries WHERE user_entry_id = live_share_entries.user_id)))`)
+}
+
+// Start creates the share or restarts it with a new end, the last position is
+// forgotten.
+func (liveShareRepository *liveShareRepository) Start(entry *LiveShareEntry) (*LiveShareEntry, error) {
+	err := liveShareRepository.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "group_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"created_at", "expires_at", "latitude", "longitude", "accuracy", "heading", "speed", "recorded_at"}),
+	}).Create(entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return liveShareRepository.FindActiveShare(entry.UserID, entry.GroupID)
+}
+
+func (liveShareRepository *liveShareRepository) FindActiveShare(userID, groupID uint) (*LiveShareEntry, error) {
+	var share LiveShareEntry
+	err := activeLiveShares(liveShareRepository.db).Preload("User").
+		Where("live_share_entries.user_id = ? AND live_share_entries.group_id = ?", userID, groupID).
+		First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (liveShareRepository *liveShareRepository) FindSharesForUser(userID uint) ([]LiveShareEntry, error) {
+	var shares []LiveShareEntry
+	err := activeLiveShares(liveShareRepository.db).Preload("User").
+		Where("live_share_entries.user_id = ?", userID).
+		Order("live_share_entries.group_id").
+		Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// FindPositionsForGroup returns the latest position of each member sharing
+// with the group, ignoring the positions recorded before since.
+func (liveShareRepository *liveShareRepository) FindPositionsForGroup(groupID uint, since time.Time) ([]LiveShareEntry, error) {
+	var shares []LiveShareEntry
+	err := activeLiveShares(liveShareRepository.db).Preload("User").
+		Where("live_share_entries.group_id = ? AND live_share_entries.recorded_at >= ?", groupID, since).
+		Order("live_share_entries.recorded_at DESC").
+		Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// Publish records the position of an active share.
+func (liveShareRepository *liveShareRepository) Publish(entry *LiveShareEntry) (*LiveShareEntry, error) {
+	err := liveShareRepository.db.Model(&LiveShareEntry{}).Where("id = ?", entry.ID).
+		Select("latitude", "longitude", "accuracy", "heading", "speed", "recorded_at").
+		Updates(entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (liveShareRepository *liveShareRepository) Stop(userID, groupID uint) error {
+	return liveShareRepository.db.Where("user_id = ? AND group_id = ?", userID, groupID).Delete(&LiveShareEntry{}).Error
+}
+
+// DeleteExpired removes the shares that have ended.
+func (liveShareRepository *liveShareRepository) DeleteExpired() error {
+	return liveShareRepository.db.Where("expires_at <= ?", time.Now()).Delete(&LiveShareEntry{}).Error
+}
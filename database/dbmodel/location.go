@@ -1,26 +1,66 @@
 package dbmodel
 
-import "gorm.io/gorm"
+import (
+	"locate-this/pkg/geo"
+	"strings"
+
+	"gorm.io/gorm"
+)
 
 type LocationEntry struct {
 	gorm.Model
-	UserID    uint          `json:"user_id"`
-	User      UserEntry     `gorm:"not null;constraint:OnDelete:CASCADE;"`
-	Latitude  float64       `json:"latitude" gorm:"not null"`
-	Longitude float64       `json:"longitude" gorm:"not null"`
-	Name      string        `json:"name" gorm:"not null"`
-	Groups    []*GroupEntry `gorm:"many2many:group_location_entries;constraint:OnDelete:CASCADE;" json:"groups"`
+	UserID      uint          `json:"user_id"`
+	User        UserEntry     `gorm:"not null;constraint:OnDelete:CASCADE;"`
+	Latitude    float64       `json:"latitude" gorm:"not null"`
+	Longitude   float64       `json:"longitude" gorm:"not null"`
+	Name        string        `json:"name" gorm:"not null"`
+	Description string        `json:"description" gorm:"not null;default:''"`
+	Address     string        `json:"address" gorm:"not null;default:''"`
+	Category    string        `json:"category" gorm:"not null;default:'';index"`
+	Color       string        `json:"color" gorm:"not null;default:''"`
+	Icon        string        `json:"icon" gorm:"not null;default:''"`
+	Altitude    *float64      `json:"altitude"`
+	Accuracy    *float64      `json:"accuracy"`
+	Groups      []*GroupEntry `gorm:"many2many:group_location_entries;constraint:OnDelete:CASCADE;" json:"groups"`
+	Tags        []*TagEntry   `gorm:"many2many:location_tag_entries;constraint:OnDelete:CASCADE;" json:"tags"`
+	// Geohash of the coordinates, the spatial index of the locations
+	Geohash string `json:"-" gorm:"not null;default:'';index"`
 }
 
 type LocationRepository interface {
-	Create(entry *LocationEntry) (*LocationEntry, error)
-	FindAll() ([]LocationEntry, error)
+	Create(entry *LocationEntry) (*LocationEntry, ShareChanges, error)
+	CreateAll(entries []*LocationEntry, tags [][]string) (ShareChanges, error)
+	FindAll(options QueryOptions) ([]LocationEntry, PageInfo, error)
 	FindById(id uint) (*LocationEntry, error)
-	FindGroupsForLocation(id uint) ([]GroupEntry, error)
-	Update(entry *LocationEntry, id uint) (*LocationEntry, error)
-	Delete(id uint) error
+	FindByIds(ids []uint) ([]LocationEntry, error)
+	FindGroupsForLocation(id uint, options QueryOptions) ([]GroupEntry, PageInfo, error)
+	FindGroupIDsForLocation(id uint) ([]uint, error)
+	IsVisibleTo(id uint, userID uint) (bool, error)
+	AreCoordinatesVisibleTo(id uint, userID uint) (bool, error)
+	ExportLocationsForUser(ownerID, viewerID uint, fn ExportBatchFunc) error
+	ExportLocationsForGroup(groupID, viewerID uint, fn ExportBatchFunc) error
+	FindLocationPointsForUser(userID uint) ([]LocationEntry, error)
+	FindLocationPointsForGroup(groupID, viewerID uint) ([]LocationEntry, error)
+	FindSelectedLocationPointsInGroup(groupID, viewerID uint, ids []uint) ([]LocationEntry, map[uint]bool, error)
+	FindVisibleLocationIDs(viewerID uint, ids []uint) (map[uint]bool, error)
+	FindVisibleLocationIDsInGroup(groupID, viewerID uint, ids []uint) (map[uint]bool, error)
+	FindWithinBounds(bounds geo.Bounds, viewerID uint, limit int) ([]LocationEntry, error)
+	FindPointsWithinBounds(bounds geo.Bounds, viewerID uint) ([]LocationEntry, error)
+	FindNearest(point geo.Point, viewerID uint, limit int, maxDistance float64) ([]NearbyLocation, error)
+	Update(entry *LocationEntry, id uint) (*LocationEntry, ShareChanges, error)
+	Delete(id uint) ([]AttachmentEntry, ShareChanges, error)
 }
 
+// sharedWithUser selects the IDs of the locations shared in the groups a user
+// administrates or belongs to, like memberGroups it takes the user ID twice.
+const sharedWithUser = `SELECT group_location_entries.location_entry_id FROM group_location_entries
+	WHERE group_location_entries.group_entry_id IN (` + memberGroups + `)`
+
+// locationEditableColumns are the columns replaced by an update of a location.
+var locationEditableColumns = []string{"name", "latitude", "longitude", "description", "address", "category", "color", "icon", "altitude", "accuracy"}
+
+var locationList = listQuery{table: "location_entries", nameColumn: "name", ownerColumn: "user_id", categoryColumn: "category", taggable: true, detailed: true, sortable: LocationSortFields}
+
 type locationRepository struct {
 	db *gorm.DB
 }
@@ -29,19 +69,71 @@ func NewLocationRepository(db *gorm.DB) LocationRepository {
 	return &locationRepository{db: db}
 }
 
-func (locationRepository *locationRepository) Create(entry *LocationEntry) (*LocationEntry, error) {
-	if err := locationRepository.db.Create(entry).Error; err != nil {
-		return nil, err
+// Create creates the location and shares it in the groups of the share rules
+// of its owner it matches.
+func (locationRepository *locationRepository) Create(entry *LocationEntry) (*LocationEntry, ShareChanges, error) {
+	var changes ShareChanges
+	entry.Geohash = locationGeohash(entry.Latitude, entry.Longitude)
+	err := locationRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		if err := indexLocation(tx, entry); err != nil {
+			return err
+		}
+		var err error
+		changes, err = syncShareRules(tx, entry.UserID, []uint{entry.ID})
+		return err
+	})
+	if err != nil {
+		return nil, ShareChanges{}, err
 	}
-	return entry, nil
+	return entry, changes, nil
 }
 
-func (locationRepository *locationRepository) FindAll() ([]LocationEntry, error) {
-	var locations []LocationEntry
-	if err := locationRepository.db.Find(&locations).Error; err != nil {
-		return nil, err
+// CreateAll creates the locations with their tags in a single transaction, so
+// that either all of them are created or none, and shares them as the share
+// rules of their owners say.
+func (locationRepository *locationRepository) CreateAll(entries []*LocationEntry, tags [][]string) (ShareChanges, error) {
+	var changes ShareChanges
+	err := locationRepository.db.Transaction(func(tx *gorm.DB) error {
+		// the IDs of the locations of each owner, in the order of the owners
+		var userIDs []uint
+		ids := make(map[uint][]uint)
+		for i, entry := range entries {
+			entry.Geohash = locationGeohash(entry.Latitude, entry.Longitude)
+			if err := tx.Create(entry).Error; err != nil {
+				return err
+			}
+			if len(tags[i]) > 0 {
+				if _, err := addTags(tx, entry, tags[i]); err != nil {
+					return err
+				}
+			} else if err := indexLocation(tx, entry); err != nil {
+				return err
+			}
+			if ids[entry.UserID] == nil {
+				userIDs = append(userIDs, entry.UserID)
+			}
+			ids[entry.UserID] = append(ids[entry.UserID], entry.ID)
+		}
+		for _, userID := range userIDs {
+			userChanges, err := syncShareRules(tx, userID, ids[userID])
+			if err != nil {
+				return err
+			}
+			changes.add(userChanges)
+		}
+		return nil
+	})
+	if err != nil {
+		return ShareChanges{}, err
 	}
-	return locations, nil
+	return changes, nil
+}
+
+func (locationRepository *locationRepository) FindAll(options QueryOptions) ([]LocationEntry, PageInfo, error) {
+	return paginate[LocationEntry](locationRepository.db.Model(&LocationEntry{}), locationList, options)
 }
 
 func (locationRepository *locationRepository) FindById(id uint) (*LocationEntry, error) {
@@ -52,28 +144,148 @@ func (locationRepository *locationRepository) FindById(id uint) (*LocationEntry,
 	return &location, nil
 }
 
-func (locationRepository *locationRepository) FindGroupsForLocation(id uint) ([]GroupEntry, error) {
-	var location LocationEntry
-	if err := locationRepository.db.Preload("Groups").First(&location, id).Error; err != nil {
+// FindByIds returns the locations with the given IDs, by ID.
+func (locationRepository *locationRepository) FindByIds(ids []uint) ([]LocationEntry, error) {
+	var locations []LocationEntry
+	if len(ids) == 0 {
+		return locations, nil
+	}
+	if err := locationRepository.db.Where("id IN ?", ids).Order("id").Find(&locations).Error; err != nil {
 		return nil, err
 	}
-	groups := make([]GroupEntry, len(location.Groups))
-	for i, g := range location.Groups {
-		groups[i] = *g
+	return locations, nil
+}
+
+func (locationRepository *locationRepository) FindGroupsForLocation(id uint, options QueryOptions) ([]GroupEntry, PageInfo, error) {
+	if err := locationRepository.db.First(&LocationEntry{}, id).Error; err != nil {
+		return nil, PageInfo{}, err
 	}
-	return groups, nil
+	query := locationRepository.db.Model(&GroupEntry{}).
+		Joins("JOIN group_location_entries ON group_location_entries.group_entry_id = group_entries.id").
+		Where("group_location_entries.location_entry_id = ?", id)
+	return paginate[GroupEntry](query, groupList, options)
 }
 
-func (locationRepository *locationRepository) Update(entry *LocationEntry, id uint) (*LocationEntry, error) {
-	if err := locationRepository.db.Model(&LocationEntry{}).Where("id = ?", id).Updates(entry).Error; err != nil {
+func (locationRepository *locationRepository) FindGroupIDsForLocation(id uint) ([]uint, error) {
+	var groupIDs []uint
+	err := locationRepository.db.Model(&GroupLocationEntry{}).
+		Where("location_entry_id = ?", id).
+		Pluck("group_entry_id", &groupIDs).Error
+	if err != nil {
 		return nil, err
 	}
-	return entry, nil
+	return groupIDs, nil
+}
+
+// IsVisibleTo reports whether the user owns the location or belongs to a
+// group the location is shared in.
+func (locationRepository *locationRepository) IsVisibleTo(id uint, userID uint) (bool, error) {
+	var count int64
+	err := locationRepository.db.Model(&LocationEntry{}).
+		Where("id = ? AND (user_id = ? OR id IN ("+sharedWithUser+"))", id, userID, userID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AreCoordinatesVisibleTo reports whether the user owns the location or
+// belongs to a group the location is shared in with its coordinates visible.
+func (locationRepository *locationRepository) AreCoordinatesVisibleTo(id uint, userID uint) (bool, error) {
+	var count int64
+	err := locationRepository.db.Model(&LocationEntry{}).
+		Where("id = ? AND (user_id = ? OR id IN ("+sharedWithUser+" AND group_location_entries.is_visible_coordinates))", id, userID, userID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Update replaces the editable fields of the location and updates its shares
+// made by the share rules of its owner.
+func (locationRepository *locationRepository) Update(entry *LocationEntry, id uint) (*LocationEntry, ShareChanges, error) {
+	var changes ShareChanges
+	err := locationRepository.db.Transaction(func(tx *gorm.DB) error {
+		// selected, so that emptied fields and removed altitude or accuracy are written
+		if err := tx.Model(&LocationEntry{}).Where("id = ?", id).Select(locationEditableColumns).Updates(entry).Error; err != nil {
+			return err
+		}
+		var updated LocationEntry
+		if err := tx.First(&updated, id).Error; err != nil {
+			return err
+		}
+		if geohash := locationGeohash(updated.Latitude, updated.Longitude); geohash != updated.Geohash {
+			if err := tx.Model(&updated).UpdateColumn("geohash", geohash).Error; err != nil {
+				return err
+			}
+		}
+		if err := indexLocation(tx, &updated); err != nil {
+			return err
+		}
+		var err error
+		changes, err = syncShareRules(tx, updated.UserID, []uint{id})
+		return err
+	})
+	if err != nil {
+		return nil, ShareChanges{}, err
+	}
+	return entry, changes, nil
+}
+
+// Delete removes the location and returns its attachments, deleted with it,
+// whose blobs are left to the caller to remove from the store, and the shares
+// the rules had made of it.
+func (locationRepository *locationRepository) Delete(id uint) ([]AttachmentEntry, ShareChanges, error) {
+	var attachments []AttachmentEntry
+	var changes ShareChanges
+	err := locationRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&LocationEntry{}, id).Error; err != nil {
+			return err
+		}
+		// the location is soft deleted, so the foreign key never cascades
+		if err := tx.Where("location_id = ?", id).Find(&attachments).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("location_id = ?", id).Delete(&AttachmentEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("location_id = ?", id).Delete(&TripStopEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("location_id = ?", id).Delete(&FolderLocationEntry{}).Error; err != nil {
+			return err
+		}
+		ruleShares := tx.Model(&GroupLocationEntry{}).Where("location_entry_id = ? AND share_rule_id IS NOT NULL", id)
+		var groupIDs []uint
+		if err := ruleShares.Order("group_entry_id").Pluck("group_entry_id", &groupIDs).Error; err != nil {
+			return err
+		}
+		for _, groupID := range groupIDs {
+			changes.Unshared = append(changes.Unshared, LocationShare{GroupID: groupID, LocationID: id})
+		}
+		if err := tx.Where("location_entry_id = ? AND share_rule_id IS NOT NULL", id).Delete(&GroupLocationEntry{}).Error; err != nil {
+			return err
+		}
+		return removeDocument(tx, SearchKindLocation, id)
+	})
+	if err != nil {
+		return nil, ShareChanges{}, err
+	}
+	return attachments, changes, nil
 }
 
-func (locationRepository *locationRepository) Delete(id uint) error {
-	if err := locationRepository.db.Delete(&LocationEntry{}, id).Error; err != nil {
+// indexLocation refreshes the search document of a location.
+func indexLocation(db *gorm.DB, entry *LocationEntry) error {
+	var tags []string
+	err := db.Model(&TagEntry{}).
+		Joins("JOIN location_tag_entries ON location_tag_entries.tag_entry_id = tag_entries.id").
+		Where("location_tag_entries.location_entry_id = ?", entry.ID).
+		Pluck("tag_entries.name", &tags).Error
+	if err != nil {
 		return err
 	}
-	return nil
+	body := strings.Join(append([]string{entry.Description, entry.Category}, tags...), " ")
+	return indexDocument(db, SearchKindLocation, entry.ID, entry.Name, body, entry.Address)
 }
@@ -0,0 +1,79 @@
+package dbmodel
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// PlaceEntry is a populated place of the offline geocoding dataset, imported
+// from a GeoNames dump.
+type PlaceEntry struct {
+	// GeoNames ID
+	ID   uint   `gorm:"primarykey"`
+	Name string `gorm:"not null"`
+	// Lowercase names, for the search
+	SearchName  string  `gorm:"not null;index"`
+	ASCIIName   string  `gorm:"not null;index"`
+	CountryCode string  `gorm:"not null;default:''"`
+	Latitude    float64 `gorm:"not null;index:idx_place_entries_position"`
+	Longitude   float64 `gorm:"not null;index:idx_place_entries_position"`
+	Population  int64   `gorm:"not null;default:0"`
+}
+
+type PlaceRepository interface {
+	DeleteAll() error
+	CreateBatch(places []PlaceEntry) error
+	FindByName(name string, countryCode string, prefix bool, limit int) ([]PlaceEntry, error)
+	FindWithin(minLatitude, maxLatitude, minLongitude, maxLongitude float64, limit int) ([]PlaceEntry, error)
+}
+
+type placeRepository struct {
+	db *gorm.DB
+}
+
+func NewPlaceRepository(db *gorm.DB) PlaceRepository {
+	return &placeRepository{db: db}
+}
+
+func (placeRepository *placeRepository) DeleteAll() error {
+	return placeRepository.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&PlaceEntry{}).Error
+}
+
+func (placeRepository *placeRepository) CreateBatch(places []PlaceEntry) error {
+	return placeRepository.db.CreateInBatches(places, 500).Error
+}
+
+// FindByName returns the places named name, or whose name starts with it when
+// prefix is set, the most populated first. name must be in lowercase.
+func (placeRepository *placeRepository) FindByName(name string, countryCode string, prefix bool, limit int) ([]PlaceEntry, error) {
+	query := placeRepository.db.Model(&PlaceEntry{})
+	if prefix {
+		// a range instead of LIKE, so that the indexes are used
+		upper := name + "\U0010FFFF"
+		query = query.Where("(search_name >= ? AND search_name < ?) OR (ascii_name >= ? AND ascii_name < ?)", name, upper, name, upper)
+	} else {
+		query = query.Where("search_name = ? OR ascii_name = ?", name, name)
+	}
+	if countryCode != "" {
+		query = query.Where("country_code = ?", strings.ToUpper(countryCode))
+	}
+	var places []PlaceEntry
+	if err := query.Order("population DESC").Order("id").Limit(limit).Find(&places).Error; err != nil {
+		return nil, err
+	}
+	return places, nil
+}
+
+func (placeRepository *placeRepository) FindWithin(minLatitude, maxLatitude, minLongitude, maxLongitude float64, limit int) ([]PlaceEntry, error) {
+	var places []PlaceEntry
+	err := placeRepository.db.
+		Where("latitude BETWEEN ? AND ?", minLatitude, maxLatitude).
+		Where("longitude BETWEEN ? AND ?", minLongitude, maxLongitude).
+		Limit(limit).
+		Find(&places).Error
+	if err != nil {
+		return nil, err
+	}
+	return places, nil
+}
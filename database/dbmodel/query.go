@@ -0,0 +1,331 @@
+package dbmodel
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// Fields accepted by the sort parameter of each list endpoint.
+var (
+	UserSortFields     = []string{"id", "username", "email", "created_at", "updated_at"}
+	LocationSortFields = []string{"id", "name", "latitude", "longitude", "created_at", "updated_at"}
+	GroupSortFields    = []string{"id", "name", "created_at", "updated_at"}
+)
+
+var (
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrCursorMismatch = errors.New("cursor was made for another sort order")
+)
+
+// QueryOptions is shared by every repository method returning a list.
+// After is the opaque cursor returned as PageInfo.NextCursor by the previous page.
+type QueryOptions struct {
+	Limit         int
+	After         string
+	Sort          string
+	Descending    bool
+	NameContains  string
+	CreatedBefore *time.Time
+	CreatedAfter  *time.Time
+	OwnerID       uint
+	Category      string
+	Tags          []string
+	MatchAllTags  bool
+	// TagViewerID is the user whose visible tags Tags matches: the tags of
+	// their locations and of the locations shared with them
+	TagViewerID uint
+	// Location details
+	DescriptionContains string
+	AddressContains     string
+	Color               string
+	Icon                string
+	MinAltitude         *float64
+	MaxAltitude         *float64
+	MaxAccuracy         *float64
+}
+
+// filtersCoordinateDetails reports whether the options filter on the address,
+// altitude or accuracy, which would reveal hidden coordinates.
+func (options QueryOptions) filtersCoordinateDetails() bool {
+	return options.AddressContains != "" || options.MinAltitude != nil || options.MaxAltitude != nil || options.MaxAccuracy != nil
+}
+
+// PageInfo describes the position of a page in the full result set.
+// Total is only computed on the first page, where counting is cheap.
+type PageInfo struct {
+	NextCursor string
+	Total      *int64
+}
+
+// cursor records the sort order it was made for, so that it is not replayed
+// on a page sorted differently.
+type cursor struct {
+	Value      interface{} `json:"v"`
+	ID         uint        `json:"id"`
+	Sort       string      `json:"s"`
+	Descending bool        `json:"d,omitempty"`
+}
+
+// listQuery describes the table a list is read from and which of its
+// columns the shared options map to.
+type listQuery struct {
+	table          string
+	nameColumn     string
+	ownerColumn    string
+	categoryColumn string
+	taggable       bool
+	// detailed lists have the description, address, color, icon, altitude
+	// and accuracy columns of the locations
+	detailed bool
+	sortable []string
+}
+
+func (list listQuery) column(name string) string {
+	return list.table + "." + name
+}
+
+func (list listQuery) sortField(options QueryOptions) string {
+	for _, field := range list.sortable {
+		if field == options.Sort {
+			return field
+		}
+	}
+	return "id"
+}
+
+func (list listQuery) filter(query *gorm.DB, options QueryOptions) *gorm.DB {
+	if options.NameContains != "" && list.nameColumn != "" {
+		query = containsFilter(query, list.column(list.nameColumn), options.NameContains)
+	}
+	if options.CreatedBefore != nil {
+		query = query.Where(list.column("created_at")+" < ?", *options.CreatedBefore)
+	}
+	if options.CreatedAfter != nil {
+		query = query.Where(list.column("created_at")+" > ?", *options.CreatedAfter)
+	}
+	if options.OwnerID != 0 && list.ownerColumn != "" {
+		query = query.Where(list.column(list.ownerColumn)+" = ?", options.OwnerID)
+	}
+	if options.Category != "" && list.categoryColumn != "" {
+		query = query.Where(list.column(list.categoryColumn)+" = ?", options.Category)
+	}
+	if len(options.Tags) > 0 && list.taggable {
+		tagged := query.Session(&gorm.Session{NewDB: true}).
+			Table("location_tag_entries").
+			Select("location_tag_entries.location_entry_id").
+			Joins("JOIN tag_entries ON tag_entries.id = location_tag_entries.tag_entry_id").
+			Joins("JOIN location_entries tagged_locations ON tagged_locations.id = location_tag_entries.location_entry_id").
+			Where("tag_entries.name IN ?", options.Tags).
+			Where("tagged_locations.user_id = ? OR tagged_locations.id IN ("+sharedWithUser+")", options.TagViewerID, options.TagViewerID, options.TagViewerID)
+		if options.MatchAllTags {
+			tagged = tagged.Group("location_tag_entries.location_entry_id").
+				Having("COUNT(DISTINCT tag_entries.name) = ?", len(options.Tags))
+		}
+		query = query.Where(list.column("id")+" IN (?)", tagged)
+	}
+	if list.detailed {
+		if options.DescriptionContains != "" {
+			query = containsFilter(query, list.column("description"), options.DescriptionContains)
+		}
+		if options.AddressContains != "" {
+			query = containsFilter(query, list.column("address"), options.AddressContains)
+		}
+		if options.Color != "" {
+			query = query.Where("LOWER("+list.column("color")+") = ?", strings.ToLower(options.Color))
+		}
+		if options.Icon != "" {
+			query = query.Where(list.column("icon")+" = ?", options.Icon)
+		}
+		if options.MinAltitude != nil {
+			query = query.Where(list.column("altitude")+" >= ?", *options.MinAltitude)
+		}
+		if options.MaxAltitude != nil {
+			query = query.Where(list.column("altitude")+" <= ?", *options.MaxAltitude)
+		}
+		if options.MaxAccuracy != nil {
+			query = query.Where(list.column("accuracy")+" <= ?", *options.MaxAccuracy)
+		}
+	}
+	return query
+}
+
+// containsFilter keeps the rows whose column contains value, ignoring case.
+func containsFilter(query *gorm.DB, column, value string) *gorm.DB {
+	return query.Where("LOWER("+column+") LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(value))+"%")
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+// paginate applies the filters, sort order and keyset cursor of options to
+// query and loads one page of entries.
+func paginate[T any](query *gorm.DB, list listQuery, options QueryOptions) ([]T, PageInfo, error) {
+	var page PageInfo
+	query = list.filter(query, options)
+
+	if options.After == "" {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Model(new(T)).Count(&total).Error; err != nil {
+			return nil, page, err
+		}
+		page.Total = &total
+	}
+
+	field := list.sortField(options)
+	comparison, direction := ">", "ASC"
+	if options.Descending {
+		comparison, direction = "<", "DESC"
+	}
+
+	if options.After != "" {
+		after, err := decodeCursor(options.After)
+		if err != nil {
+			return nil, page, err
+		}
+		if after.Sort != field || after.Descending != options.Descending {
+			return nil, page, ErrCursorMismatch
+		}
+		if field == "id" {
+			query = query.Where(list.column("id")+" "+comparison+" ?", after.ID)
+		} else {
+			value, err := cursorValue[T](query, field, after.Value)
+			if err != nil {
+				return nil, page, err
+			}
+			query = query.Where(
+				"("+list.column(field)+" "+comparison+" ?) OR ("+list.column(field)+" = ? AND "+list.column("id")+" "+comparison+" ?)",
+				value, value, after.ID,
+			)
+		}
+	}
+	if field != "id" {
+		query = query.Order(list.column(field) + " " + direction)
+	}
+	query = query.Order(list.column("id") + " " + direction)
+
+	limit := options.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	} else if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	var entries []T
+	if err := query.Limit(limit + 1).Find(&entries).Error; err != nil {
+		return nil, page, err
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next, err := encodeCursor(query, &entries[limit-1], field, options.Descending)
+		if err != nil {
+			return nil, page, err
+		}
+		page.NextCursor = next
+	}
+	return entries, page, nil
+}
+
+func encodeCursor[T any](db *gorm.DB, entry *T, field string, descending bool) (string, error) {
+	fields, err := schemaFields[T](db, "id", field)
+	if err != nil {
+		return "", err
+	}
+	ctx := db.Statement.Context
+	value := reflect.ValueOf(entry).Elem()
+	id, _ := fields[0].ValueOf(ctx, value)
+	sortValue, _ := fields[1].ValueOf(ctx, value)
+
+	c := cursor{Value: sortValue, ID: id.(uint), Sort: field, Descending: descending}
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(encoded string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// CheckCursor rejects the after cursor of options when it is invalid or was
+// made for another sort field or direction.
+func CheckCursor(options QueryOptions) error {
+	after, err := decodeCursor(options.After)
+	if err != nil {
+		return err
+	}
+	sort := options.Sort
+	if sort == "" {
+		sort = "id"
+	}
+	if after.Sort != sort || after.Descending != options.Descending {
+		return ErrCursorMismatch
+	}
+	return nil
+}
+
+// cursorValue converts the JSON decoded sort value back to the Go type of
+// the column so it is bound exactly like the stored values.
+func cursorValue[T any](db *gorm.DB, field string, value interface{}) (interface{}, error) {
+	fields, err := schemaFields[T](db, field)
+	if err != nil {
+		return nil, err
+	}
+	switch fields[0].FieldType {
+	case reflect.TypeOf(time.Time{}):
+		text, ok := value.(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return parsed, nil
+	case reflect.TypeOf(""):
+		if _, ok := value.(string); !ok {
+			return nil, ErrInvalidCursor
+		}
+	default:
+		if _, ok := value.(float64); !ok {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return value, nil
+}
+
+func schemaFields[T any](db *gorm.DB, names ...string) ([]*schema.Field, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	fields := make([]*schema.Field, len(names))
+	for i, name := range names {
+		fields[i] = stmt.Schema.LookUpField(name)
+		if fields[i] == nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return fields, nil
+}
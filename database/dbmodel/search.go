@@ -0,0 +1,177 @@
+package dbmodel
+
+import (
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SearchKindLocation = "location"
+	SearchKindGroup    = "group"
+	SearchKindUser     = "user"
+)
+
+type SearchResult struct {
+	Kind  string
+	RefID uint
+	Name  string
+	Rank  float64
+}
+
+type SearchRepository interface {
+	Search(userID uint, query string, limit int) ([]SearchResult, error)
+}
+
+type searchRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// searchGroups is memberGroups with the user bound by name.
+const searchGroups = `SELECT id FROM group_entries WHERE deleted_at IS NULL
+	AND (admin_id = @user OR id IN (SELECT group_entry_id FROM group_user_entries WHERE user_entry_id = @user))`
+
+// Rows a user is allowed to find: their own locations and the ones shared in
+// their groups, their groups, and the members and admins of those groups.
+const visibleSearchDocuments = `(
+	(kind = 'location' AND ref_id IN (
+		SELECT id FROM location_entries WHERE deleted_at IS NULL AND (user_id = @user OR id IN (
+			SELECT location_entry_id FROM group_location_entries WHERE group_entry_id IN (` + searchGroups + `)))))
+	OR (kind = 'group' AND ref_id IN (` + searchGroups + `))
+	OR (kind = 'user' AND (ref_id = @user OR ref_id IN (
+		SELECT user_entry_id FROM group_user_entries WHERE group_entry_id IN (` + searchGroups + `)
+		UNION SELECT admin_id FROM group_entries WHERE id IN (` + searchGroups + `))))
+)`
+
+// A location whose coordinates are hidden from the user must not be found by
+// its address, which would tell where it is: it has to match without it.
+const visibleSearchAddresses = `(
+	kind != 'location'
+	OR ref_id IN (SELECT id FROM location_entries WHERE user_id = @user OR id IN (
+		SELECT location_entry_id FROM group_location_entries
+		WHERE is_visible_coordinates AND group_entry_id IN (` + searchGroups + `)))
+	OR rowid IN (SELECT rowid FROM search_documents WHERE search_documents MATCH @withoutAddress)
+)`
+
+func (searchRepository *searchRepository) Search(userID uint, query string, limit int) ([]SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	for i, term := range terms {
+		terms[i] = `"` + term + `"*`
+	}
+	match := strings.Join(terms, " ")
+	// bm25 returns lower values for better matches, names weigh more than bodies
+	statement := `SELECT kind, CAST(ref_id AS INTEGER) AS ref_id, name, -bm25(search_documents, 0, 0, 10.0, 1.0, 1.0) AS rank
+		FROM search_documents
+		WHERE search_documents MATCH @match AND ` + visibleSearchDocuments + ` AND ` + visibleSearchAddresses + `
+		ORDER BY rank DESC LIMIT @limit`
+
+	var results []SearchResult
+	err := searchRepository.db.Raw(statement,
+		sql.Named("match", match),
+		sql.Named("withoutAddress", "{name body} : ("+match+")"),
+		sql.Named("user", userID),
+		sql.Named("limit", limit),
+	).Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// searchTerms splits a user query into words, dropping every character the
+// full-text query syntax could interpret.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// MigrateSearchIndex creates the full-text index and fills it from the
+// existing rows the first time it is created. An index without the address
+// column, which held the addresses in the bodies, is created again.
+func MigrateSearchIndex(db *gorm.DB) error {
+	if db.Migrator().HasTable("search_documents") {
+		var columns int64
+		err := db.Raw("SELECT COUNT(*) FROM pragma_table_info('search_documents') WHERE name = 'address'").Scan(&columns).Error
+		if err != nil || columns > 0 {
+			return err
+		}
+		if err := db.Exec("DROP TABLE search_documents").Error; err != nil {
+			return err
+		}
+	}
+
+	err := db.Exec(`CREATE VIRTUAL TABLE search_documents USING fts5(
+		kind UNINDEXED, ref_id UNINDEXED, name, body, address,
+		tokenize = 'unicode61 remove_diacritics 2')`).Error
+	if err != nil {
+		return err
+	}
+	return RebuildSearchIndex(db)
+}
+
+// RebuildSearchIndex indexes again every location, group and user.
+func RebuildSearchIndex(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM search_documents").Error; err != nil {
+			return err
+		}
+
+		var locations []LocationEntry
+		err := tx.FindInBatches(&locations, 500, func(batch *gorm.DB, _ int) error {
+			for i := range locations {
+				if err := indexLocation(batch, &locations[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		var groups []GroupEntry
+		err = tx.FindInBatches(&groups, 500, func(batch *gorm.DB, _ int) error {
+			for i := range groups {
+				if err := indexDocument(batch, SearchKindGroup, groups[i].ID, groups[i].Name, "", ""); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		var users []UserEntry
+		return tx.FindInBatches(&users, 500, func(batch *gorm.DB, _ int) error {
+			for i := range users {
+				if err := indexDocument(batch, SearchKindUser, users[i].ID, users[i].Username, "", ""); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	})
+}
+
+func indexDocument(db *gorm.DB, kind string, refID uint, name, body, address string) error {
+	if err := removeDocument(db, kind, refID); err != nil {
+		return err
+	}
+	return db.Exec("INSERT INTO search_documents (kind, ref_id, name, body, address) VALUES (?, ?, ?, ?, ?)", kind, refID, name, body, address).Error
+}
+
+func removeDocument(db *gorm.DB, kind string, refID uint) error {
+	return db.Exec("DELETE FROM search_documents WHERE kind = ? AND ref_id = ?", kind, refID).Error
+}
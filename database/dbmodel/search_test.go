@@ -0,0 +1,166 @@
+package dbmodel
+
+import (
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// searchNames returns the kind and name of the results of a search.
+func searchNames(t *testing.T, db *gorm.DB, userID uint, query string) []string {
+	results, err := NewSearchRepository(db).Search(userID, query, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(results))
+	for i, result := range results {
+		names[i] = result.Kind + ":" + result.Name
+	}
+	return names
+}
+
+func TestSearch(t *testing.T) {
+	db, user, other := newTestDB(t)
+	locations := NewLocationRepository(db)
+	entries := []*LocationEntry{
+		{UserID: user.ID, Name: "Café de Flore", Address: "172 boulevard Saint-Germain, Paris", Latitude: 48.854, Longitude: 2.333},
+		{UserID: user.ID, Name: "Les Deux Magots", Description: "Le café voisin du Flore", Latitude: 48.854, Longitude: 2.333},
+		{UserID: user.ID, Name: "Crêperie Élysée", Category: "restaurant", Latitude: 48.871, Longitude: 2.307},
+		{UserID: other.ID, Name: "Café Comptoir", Latitude: 45.75, Longitude: 4.85},
+	}
+	for _, entry := range entries {
+		if _, _, err := locations.Create(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := NewTagRepository(db).AddTagsToLocation(entries[2], []string{"galettes"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// names weigh more than descriptions
+		{"café", []string{"location:Café de Flore", "location:Les Deux Magots"}},
+		{"cafe", []string{"location:Café de Flore", "location:Les Deux Magots"}},
+		{"CAFÉ", []string{"location:Café de Flore", "location:Les Deux Magots"}},
+		{"caf", []string{"location:Café de Flore", "location:Les Deux Magots"}},
+		{"crep elys", []string{"location:Crêperie Élysée"}},
+		{"élysée", []string{"location:Crêperie Élysée"}},
+		{"flore cafe", []string{"location:Café de Flore", "location:Les Deux Magots"}},
+		{"germain", []string{"location:Café de Flore"}},
+		{"restaurant", []string{"location:Crêperie Élysée"}},
+		{"galette", []string{"location:Crêperie Élysée"}},
+		{"alic", []string{"user:alice"}},
+		{"café crêpe", []string{}},
+		{"afe", []string{}},
+		// the syntax of the full-text queries is not interpreted
+		{`"café* (`, []string{"location:Café de Flore", "location:Les Deux Magots"}},
+		{"*-:()", []string{}},
+	}
+	for _, test := range tests {
+		if got := searchNames(t, db, user.ID, test.query); !slices.Equal(got, test.want) {
+			t.Errorf("Search(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestSearchVisibility(t *testing.T) {
+	db, user, other := newTestDB(t)
+	locations, groupLocations := NewLocationRepository(db), NewGroupLocationRepository(db)
+	group, err := NewGroupRepository(db).Create(&GroupEntry{Name: "Amis de Lyon", AdminID: other.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewGroupUserRepository(db).Create(&GroupUserEntry{UserEntryID: user.ID, GroupEntryID: group.ID}); err != nil {
+		t.Fatal(err)
+	}
+	create := func(name, address string) *LocationEntry {
+		location, _, err := locations.Create(&LocationEntry{UserID: other.ID, Name: name, Address: address, Latitude: 45.76, Longitude: 4.83})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return location
+	}
+	hidden := create("Maison de Bob", "12 rue Mercière, Lyon")
+	visible := create("Bureau de Bob", "3 rue de la République, Lyon")
+	create("Cabane de Bob", "Chemin des Crêtes, Lyon")
+	for _, location := range []*LocationEntry{hidden, visible} {
+		_, err := groupLocations.Create(&GroupLocationEntry{GroupEntryID: group.ID, LocationEntryID: location.ID, IsVisibleCoordinates: location == visible})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		userID uint
+		query  string
+		want   []string
+	}{
+		{user.ID, "bob", []string{"location:Bureau de Bob", "location:Maison de Bob", "user:bob"}},
+		{user.ID, "amis", []string{"group:Amis de Lyon"}},
+		{user.ID, "republique", []string{"location:Bureau de Bob"}},
+		// the address of a location whose coordinates are hidden is not searched
+		{user.ID, "merciere", []string{}},
+		{user.ID, "maison merciere", []string{}},
+		{user.ID, "maison", []string{"location:Maison de Bob"}},
+		{user.ID, "cabane", []string{}},
+		{user.ID, "cretes", []string{}},
+		// the owner finds every location by its address
+		{other.ID, "merciere", []string{"location:Maison de Bob"}},
+		{other.ID, "cretes", []string{"location:Cabane de Bob"}},
+	}
+	for _, test := range tests {
+		got := searchNames(t, db, test.userID, test.query)
+		slices.Sort(got)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("Search(%d, %q) = %v, want %v", test.userID, test.query, got, test.want)
+		}
+	}
+}
+
+func TestMigrateSearchIndexWithoutAddresses(t *testing.T) {
+	db, user, other := newTestDB(t)
+	location, _, err := NewLocationRepository(db).Create(&LocationEntry{UserID: other.ID, Name: "Maison de Bob", Address: "12 rue Mercière, Lyon"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := NewGroupRepository(db).Create(&GroupEntry{Name: "Amis", AdminID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewGroupLocationRepository(db).Create(&GroupLocationEntry{GroupEntryID: group.ID, LocationEntryID: location.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the index of the earlier versions, with the address in the body
+	err = db.Exec("DROP TABLE search_documents").Error
+	if err == nil {
+		err = db.Exec(`CREATE VIRTUAL TABLE search_documents USING fts5(kind UNINDEXED, ref_id UNINDEXED, name, body, tokenize = 'unicode61 remove_diacritics 2')`).Error
+	}
+	if err == nil {
+		err = db.Exec("INSERT INTO search_documents (kind, ref_id, name, body) VALUES ('location', ?, 'Maison de Bob', '12 rue Mercière, Lyon')", location.ID).Error
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateSearchIndex(db); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchNames(t, db, user.ID, "merciere"); len(got) != 0 {
+		t.Errorf("Search of a hidden address after the migration = %v", got)
+	}
+	if got := searchNames(t, db, user.ID, "maison"); !slices.Equal(got, []string{"location:Maison de Bob"}) {
+		t.Errorf("Search after the migration = %v", got)
+	}
+	// the migrated index is kept
+	if err := MigrateSearchIndex(db); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchNames(t, db, other.ID, "merciere"); !slices.Equal(got, []string{"location:Maison de Bob"}) {
+		t.Errorf("Search of the owner = %v", got)
+	}
+}
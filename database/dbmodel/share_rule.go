@@ -0,0 +1,335 @@
+package dbmodel
+
+import (
+	"locate-this/pkg/geo"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// ShareRuleEntry shares the locations of a user matching its conditions in a
+// group: those with a tag, of a category, inside an area, or all of them
+// together. The shares it makes follow the locations as they are created,
+// updated and deleted.
+type ShareRuleEntry struct {
+	gorm.Model
+	UserID   uint       `json:"user_id" gorm:"not null;index"`
+	User     UserEntry  `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	GroupID  uint       `json:"group_id" gorm:"not null;index"`
+	Group    GroupEntry `json:"-" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE;"`
+	Tag      string     `json:"tag" gorm:"not null;default:''"`
+	Category string     `json:"category" gorm:"not null;default:''"`
+	// Area of the locations, all of them when South is nil
+	South                *float64 `json:"south"`
+	West                 *float64 `json:"west"`
+	North                *float64 `json:"north"`
+	East                 *float64 `json:"east"`
+	IsVisibleCoordinates bool     `json:"is_visible_coordinates" gorm:"not null"`
+}
+
+// Bounds returns the area of the rule, if it has one.
+func (entry *ShareRuleEntry) Bounds() (geo.Bounds, bool) {
+	if entry.South == nil || entry.West == nil || entry.North == nil || entry.East == nil {
+		return geo.Bounds{}, false
+	}
+	return geo.Bounds{South: *entry.South, West: *entry.West, North: *entry.North, East: *entry.East}, true
+}
+
+// ShareChanges are the shares made, updated and removed by the share rules.
+type ShareChanges struct {
+	Shared   []LocationShare
+	Updated  []LocationShare
+	Unshared []LocationShare
+}
+
+func (changes *ShareChanges) add(other ShareChanges) {
+	changes.Shared = append(changes.Shared, other.Shared...)
+	changes.Updated = append(changes.Updated, other.Updated...)
+	changes.Unshared = append(changes.Unshared, other.Unshared...)
+}
+
+type ShareRuleRepository interface {
+	Create(entry *ShareRuleEntry) (*ShareRuleEntry, ShareChanges, error)
+	FindById(id uint) (*ShareRuleEntry, error)
+	FindRulesForUser(userID uint) ([]ShareRuleEntry, error)
+	CountShares(userID uint) (map[uint]int, error)
+	Update(entry *ShareRuleEntry, id uint) (*ShareRuleEntry, ShareChanges, error)
+	Delete(id uint) (ShareChanges, error)
+	Preview(entry *ShareRuleEntry) ([]LocationEntry, map[uint]bool, error)
+}
+
+type shareRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewShareRuleRepository(db *gorm.DB) ShareRuleRepository {
+	return &shareRuleRepository{db: db}
+}
+
+// Create saves a rule and shares the locations it matches.
+func (shareRuleRepository *shareRuleRepository) Create(entry *ShareRuleEntry) (*ShareRuleEntry, ShareChanges, error) {
+	var changes ShareChanges
+	err := shareRuleRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User", "Group").Create(entry).Error; err != nil {
+			return err
+		}
+		var err error
+		changes, err = syncShareRules(tx, entry.UserID, nil)
+		return err
+	})
+	if err != nil {
+		return nil, changes, err
+	}
+	return entry, changes, nil
+}
+
+func (shareRuleRepository *shareRuleRepository) FindById(id uint) (*ShareRuleEntry, error) {
+	var rule ShareRuleEntry
+	if err := shareRuleRepository.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (shareRuleRepository *shareRuleRepository) FindRulesForUser(userID uint) ([]ShareRuleEntry, error) {
+	var rules []ShareRuleEntry
+	if err := shareRuleRepository.db.Where("user_id = ?", userID).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// CountShares returns the number of locations each rule of a user shares.
+func (shareRuleRepository *shareRuleRepository) CountShares(userID uint) (map[uint]int, error) {
+	var rows []struct {
+		ShareRuleID uint
+		Count       int
+	}
+	err := shareRuleRepository.db.Model(&GroupLocationEntry{}).
+		Select("share_rule_id, COUNT(*) AS count").
+		Where("share_rule_id IN (SELECT id FROM share_rule_entries WHERE user_id = ? AND deleted_at IS NULL)", userID).
+		Group("share_rule_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.ShareRuleID] = row.Count
+	}
+	return counts, nil
+}
+
+// Update changes the group and the conditions of a rule, sharing the
+// locations it now matches and unsharing those it no longer does.
+func (shareRuleRepository *shareRuleRepository) Update(entry *ShareRuleEntry, id uint) (*ShareRuleEntry, ShareChanges, error) {
+	var changes ShareChanges
+	err := shareRuleRepository.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&ShareRuleEntry{}).Where("id = ?", id).
+			Select("group_id", "tag", "category", "south", "west", "north", "east", "is_visible_coordinates").
+			Updates(entry).Error
+		if err != nil {
+			return err
+		}
+		var rule ShareRuleEntry
+		if err := tx.First(&rule, id).Error; err != nil {
+			return err
+		}
+		changes, err = syncShareRules(tx, rule.UserID, nil)
+		return err
+	})
+	if err != nil {
+		return nil, changes, err
+	}
+	rule, err := shareRuleRepository.FindById(id)
+	return rule, changes, err
+}
+
+// Delete removes a rule and unshares the locations it shared, unless another
+// rule of the group still matches them.
+func (shareRuleRepository *shareRuleRepository) Delete(id uint) (ShareChanges, error) {
+	var changes ShareChanges
+	err := shareRuleRepository.db.Transaction(func(tx *gorm.DB) error {
+		var rule ShareRuleEntry
+		if err := tx.First(&rule, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&ShareRuleEntry{}, id).Error; err != nil {
+			return err
+		}
+		var err error
+		changes, err = syncShareRules(tx, rule.UserID, nil)
+		return err
+	})
+	return changes, err
+}
+
+// Preview returns the locations a rule matches, in ID order, and which of
+// them are already shared in its group.
+func (shareRuleRepository *shareRuleRepository) Preview(entry *ShareRuleEntry) ([]LocationEntry, map[uint]bool, error) {
+	var locations []LocationEntry
+	if err := shareRuleLocations(shareRuleRepository.db, entry, nil).Order("location_entries.id").Find(&locations).Error; err != nil {
+		return nil, nil, err
+	}
+	ids := make([]uint, 0, len(locations))
+	for _, location := range locations {
+		ids = append(ids, location.ID)
+	}
+	var sharedIDs []uint
+	err := shareRuleRepository.db.Model(&GroupLocationEntry{}).
+		Where("group_entry_id = ? AND location_entry_id IN ?", entry.GroupID, ids).
+		Pluck("location_entry_id", &sharedIDs).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	shared := make(map[uint]bool, len(sharedIDs))
+	for _, id := range sharedIDs {
+		shared[id] = true
+	}
+	return locations, shared, nil
+}
+
+// shareRuleLocations selects the locations of the owner of a rule matching
+// its conditions, among locationIDs unless it is nil.
+func shareRuleLocations(db *gorm.DB, rule *ShareRuleEntry, locationIDs []uint) *gorm.DB {
+	query := db.Model(&LocationEntry{}).Where("location_entries.user_id = ?", rule.UserID)
+	if locationIDs != nil {
+		query = query.Where("location_entries.id IN ?", locationIDs)
+	}
+	if rule.Category != "" {
+		query = query.Where("location_entries.category = ?", rule.Category)
+	}
+	if rule.Tag != "" {
+		query = query.Where(`location_entries.id IN (SELECT location_tag_entries.location_entry_id FROM location_tag_entries
+			JOIN tag_entries ON tag_entries.id = location_tag_entries.tag_entry_id
+			WHERE tag_entries.user_id = ? AND tag_entries.name = ?)`, rule.UserID, rule.Tag)
+	}
+	if bounds, ok := rule.Bounds(); ok {
+		query = withinBounds(query, bounds)
+	}
+	return query
+}
+
+// syncShareRules makes the shares of the rules of a user match their
+// locations, among locationIDs unless it is nil. A location matched by a rule
+// of a group the user belongs to is shared there, by the first such rule; a
+// share made by a rule that no longer matches the location, or whose group
+// the user left, is removed. The shares made by hand or with a folder are
+// left as they are.
+func syncShareRules(tx *gorm.DB, userID uint, locationIDs []uint) (ShareChanges, error) {
+	var changes ShareChanges
+	var rules []ShareRuleEntry
+	if err := tx.Where("user_id = ?", userID).Order("id").Find(&rules).Error; err != nil {
+		return changes, err
+	}
+	var memberIDs []uint
+	if err := tx.Raw(memberGroups, userID, userID).Scan(&memberIDs).Error; err != nil {
+		return changes, err
+	}
+	member := make(map[uint]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		member[id] = true
+	}
+
+	// the shares of the user's locations made by rules, in any group
+	ruleShares := tx.Model(&GroupLocationEntry{}).
+		Where("share_rule_id IS NOT NULL").
+		Where("location_entry_id IN (SELECT id FROM location_entries WHERE user_id = ?)", userID)
+	if locationIDs != nil {
+		ruleShares = ruleShares.Where("location_entry_id IN ?", locationIDs)
+	}
+	var groupIDs []uint
+	if err := ruleShares.Distinct().Pluck("group_entry_id", &groupIDs).Error; err != nil {
+		return changes, err
+	}
+	for _, rule := range rules {
+		groupIDs = append(groupIDs, rule.GroupID)
+	}
+	sort.Slice(groupIDs, func(i, j int) bool { return groupIDs[i] < groupIDs[j] })
+
+	for i, groupID := range groupIDs {
+		if i > 0 && groupIDs[i-1] == groupID {
+			continue
+		}
+		matched := make(map[uint]*ShareRuleEntry)
+		if member[groupID] {
+			for j := range rules {
+				if rules[j].GroupID != groupID {
+					continue
+				}
+				var ids []uint
+				if err := shareRuleLocations(tx, &rules[j], locationIDs).Pluck("location_entries.id", &ids).Error; err != nil {
+					return changes, err
+				}
+				for _, id := range ids {
+					if matched[id] == nil {
+						matched[id] = &rules[j]
+					}
+				}
+			}
+		}
+
+		existing := tx.Model(&GroupLocationEntry{}).
+			Where("group_entry_id = ?", groupID).
+			Where("location_entry_id IN (SELECT id FROM location_entries WHERE user_id = ?)", userID)
+		if locationIDs != nil {
+			existing = existing.Where("location_entry_id IN ?", locationIDs)
+		}
+		var shares []GroupLocationEntry
+		if err := existing.Find(&shares).Error; err != nil {
+			return changes, err
+		}
+		current := make(map[uint]*GroupLocationEntry, len(shares))
+		for j := range shares {
+			current[shares[j].LocationEntryID] = &shares[j]
+		}
+
+		ids := make([]uint, 0, len(matched))
+		for id := range matched {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			rule := matched[id]
+			share := LocationShare{GroupID: groupID, LocationID: id}
+			entry, ok := current[id]
+			if !ok {
+				err := tx.Model(&GroupLocationEntry{}).Create(map[string]interface{}{
+					"group_entry_id":         groupID,
+					"location_entry_id":      id,
+					"is_visible_coordinates": rule.IsVisibleCoordinates,
+					"share_rule_id":          rule.ID,
+				}).Error
+				if err != nil {
+					return changes, err
+				}
+				changes.Shared = append(changes.Shared, share)
+				continue
+			}
+			if entry.ShareRuleID == nil || (*entry.ShareRuleID == rule.ID && entry.IsVisibleCoordinates == rule.IsVisibleCoordinates) {
+				continue
+			}
+			err := tx.Model(&GroupLocationEntry{}).
+				Where("group_entry_id = ? AND location_entry_id = ?", groupID, id).
+				UpdateColumns(map[string]interface{}{"share_rule_id": rule.ID, "is_visible_coordinates": rule.IsVisibleCoordinates}).Error
+			if err != nil {
+				return changes, err
+			}
+			if entry.IsVisibleCoordinates != rule.IsVisibleCoordinates {
+				changes.Updated = append(changes.Updated, share)
+			}
+		}
+
+		for _, entry := range shares {
+			if entry.ShareRuleID == nil || matched[entry.LocationEntryID] != nil {
+				continue
+			}
+			err := tx.Where("group_entry_id = ? AND location_entry_id = ?", groupID, entry.LocationEntryID).Delete(&GroupLocationEntry{}).Error
+			if err != nil {
+				return changes, err
+			}
+			changes.Unshared = append(changes.Unshared, LocationShare{GroupID: groupID, LocationID: entry.LocationEntryID})
+		}
+	}
+	return changes, nil
+}
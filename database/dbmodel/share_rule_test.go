@@ -0,0 +1,185 @@
+package dbmodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocationWritesApplyShareRules(t *testing.T) {
+	db, user, _ := newTestDB(t)
+	locations, tags := NewLocationRepository(db), NewTagRepository(db)
+	group, err := NewGroupRepository(db).Create(&GroupEntry{Name: "food", AdminID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule, _, err := NewShareRuleRepository(db).Create(&ShareRuleEntry{UserID: user.ID, GroupID: group.ID, Tag: "restaurant", IsVisibleCoordinates: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := func(location *LocationEntry) []LocationShare {
+		return []LocationShare{{GroupID: group.ID, LocationID: location.ID}}
+	}
+	// shared reports whether the rule shares the location in the group
+	shared := func(location *LocationEntry) bool {
+		var count int64
+		err := db.Model(&GroupLocationEntry{}).
+			Where("group_entry_id = ? AND location_entry_id = ? AND share_rule_id = ?", group.ID, location.ID, rule.ID).
+			Count(&count).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count > 0
+	}
+
+	bouchon, changes, err := locations.Create(&LocationEntry{UserID: user.ID, Name: "Bouchon", Latitude: 45.762207, Longitude: 4.822104})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes, ShareChanges{}) || shared(bouchon) {
+		t.Errorf("Create of an untagged location: changes = %+v", changes)
+	}
+
+	_, changes, err = tags.AddTagsToLocation(bouchon, []string{"restaurant", "lyon"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes.Shared, share(bouchon)) || !shared(bouchon) {
+		t.Errorf("AddTagsToLocation: changes = %+v, want the location shared", changes)
+	}
+
+	// an update that does not change the match keeps the share
+	bouchon.Name = "Bouchon Les Lyonnais"
+	if _, changes, err = locations.Update(bouchon, bouchon.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes, ShareChanges{}) || !shared(bouchon) {
+		t.Errorf("Update: changes = %+v, want none", changes)
+	}
+
+	if changes, err = tags.RemoveTagFromLocation(bouchon, "restaurant"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes.Unshared, share(bouchon)) || shared(bouchon) {
+		t.Errorf("RemoveTagFromLocation: changes = %+v, want the location unshared", changes)
+	}
+
+	entries := []*LocationEntry{
+		{UserID: user.ID, Name: "Brasserie Georges", Latitude: 45.748, Longitude: 4.827},
+		{UserID: user.ID, Name: "Parc de la Tête d'Or", Latitude: 45.777, Longitude: 4.855},
+	}
+	changes, err = locations.CreateAll(entries, [][]string{{"restaurant"}, {"park"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changes.Shared, share(entries[0])) || !shared(entries[0]) || shared(entries[1]) {
+		t.Errorf("CreateAll: changes = %+v, want the restaurant shared", changes)
+	}
+}
+
+func TestLocationWritesRollBackWhenShareRulesFail(t *testing.T) {
+	db, user, _ := newTestDB(t)
+	locations, tags := NewLocationRepository(db), NewTagRepository(db)
+	location, _, err := locations.Create(&LocationEntry{UserID: user.ID, Name: "Bouchon", Latitude: 45.762207, Longitude: 4.822104})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrator().DropTable(&ShareRuleEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	count := func() (count int64) {
+		if err := db.Model(&LocationEntry{}).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	if _, _, err := locations.Create(&LocationEntry{UserID: user.ID, Name: "Brasserie", Latitude: 45.748, Longitude: 4.827}); err == nil {
+		t.Error("Create did not fail")
+	}
+	if _, err := locations.CreateAll([]*LocationEntry{{UserID: user.ID, Name: "Parc", Latitude: 45.777, Longitude: 4.855}}, [][]string{nil}); err == nil {
+		t.Error("CreateAll did not fail")
+	}
+	if count() != 1 {
+		t.Errorf("%d locations, want the first one only", count())
+	}
+
+	location.Name = "Bouchon Les Lyonnais"
+	if _, _, err := locations.Update(location, location.ID); err == nil {
+		t.Error("Update did not fail")
+	}
+	if _, _, err := tags.AddTagsToLocation(location, []string{"restaurant"}); err == nil {
+		t.Error("AddTagsToLocation did not fail")
+	}
+	stored, err := locations.FindById(location.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "Bouchon" {
+		t.Errorf("name = %q, the update was not rolled back", stored.Name)
+	}
+	if found, err := tags.FindTagsForLocation(location.ID); err != nil || len(found) != 0 {
+		t.Errorf("tags = %v, %v, the tagging was not rolled back", found, err)
+	}
+}
+
+func TestLocationDeleteReturnsTheRuleShares(t *testing.T) {
+	db, user, _ := newTestDB(t)
+	if err := db.AutoMigrate(&AttachmentEntry{}, &TripEntry{}, &TripStopEntry{}, &FolderEntry{}, &FolderLocationEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	locations, groups := NewLocationRepository(db), NewGroupRepository(db)
+	var groupIDs []uint
+	for _, name := range []string{"food", "friends", "family"} {
+		group, err := groups.Create(&GroupEntry{Name: name, AdminID: user.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		groupIDs = append(groupIDs, group.ID)
+	}
+	// rules share in the first two groups, the third one by hand
+	rules := NewShareRuleRepository(db)
+	for _, groupID := range groupIDs[:2] {
+		if _, _, err := rules.Create(&ShareRuleEntry{UserID: user.ID, GroupID: groupID, Category: "restaurant", IsVisibleCoordinates: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bouchon, changes, err := locations.Create(&LocationEntry{UserID: user.ID, Name: "Bouchon", Category: "restaurant", Latitude: 45.762207, Longitude: 4.822104})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Shared) != 2 {
+		t.Fatalf("Create: changes = %+v, want the location shared twice", changes)
+	}
+	if _, err := NewGroupLocationRepository(db).Create(&GroupLocationEntry{GroupEntryID: groupIDs[2], LocationEntryID: bouchon.ID}); err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := locations.Create(&LocationEntry{UserID: user.ID, Name: "Brasserie", Category: "restaurant", Latitude: 45.748, Longitude: 4.827})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, changes, err = locations.Delete(bouchon.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ShareChanges{Unshared: []LocationShare{{GroupID: groupIDs[0], LocationID: bouchon.ID}, {GroupID: groupIDs[1], LocationID: bouchon.ID}}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Delete: changes = %+v, want %+v", changes, want)
+	}
+	var shares []GroupLocationEntry
+	if err := db.Order("location_entry_id, group_entry_id").Find(&shares).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 3 || shares[0].GroupEntryID != groupIDs[2] || shares[1].LocationEntryID != other.ID || shares[2].LocationEntryID != other.ID {
+		t.Errorf("shares left = %+v, want the one made by hand and those of the other location", shares)
+	}
+
+	// a location no rule shared unshares nothing
+	parc, _, err := locations.Create(&LocationEntry{UserID: user.ID, Name: "Parc", Latitude: 45.777, Longitude: 4.855})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, changes, err = locations.Delete(parc.ID); err != nil || !reflect.DeepEqual(changes, ShareChanges{}) {
+		t.Errorf("Delete of an unshared location = %+v, %v", changes, err)
+	}
+}
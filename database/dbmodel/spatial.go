@@ -0,0 +1,156 @@
+package dbmodel
+
+import (
+	"locate-this/pkg/coords"
+	"locate-this/pkg/geo"
+	"math"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// locationGeohashPrecision is the length of the geohash stored with each
+// location, a few centimeters, so that a cell of any size is a prefix.
+const locationGeohashPrecision = 12
+
+// maxBoundsCells is the number of geohash cells a box query is split into,
+// a compromise between the number of index ranges and the rows they cover
+// outside of the box.
+const maxBoundsCells = 16
+
+// nearestInitialRadius is the radius in meters of the first box searched for
+// the nearest locations, quadrupled until enough are found.
+const nearestInitialRadius = 1000
+
+// visibleCoordinates selects the locations owned by a user or shared in one
+// of their groups with the coordinates visible. Spatial queries must not find
+// the others, their position being hidden. It takes the user ID three times.
+const visibleCoordinates = "(location_entries.user_id = ? OR location_entries.id IN (" + sharedWithUser + " AND group_location_entries.is_visible_coordinates))"
+
+// NearbyLocation is a location found by FindNearest, with its distance in
+// meters to the searched point.
+type NearbyLocation struct {
+	LocationEntry
+	Distance float64
+}
+
+func locationGeohash(latitude, longitude float64) string {
+	return coords.EncodeGeohash(latitude, longitude, locationGeohashPrecision)
+}
+
+// withinBounds restricts a query to the locations in the box, with ranges
+// of the geohash index followed by the exact test of the coordinates. The
+// ranges are in a subquery, otherwise SQLite prefers the index of deleted_at.
+func withinBounds(query *gorm.DB, bounds geo.Bounds) *gorm.DB {
+	var ranges, boxes []string
+	var rangeArguments, boxArguments []any
+	for _, part := range bounds.Split() {
+		for _, cells := range coords.GeohashRanges(part.South, part.West, part.North, part.East, maxBoundsCells) {
+			// "~" sorts after every character of the geohash alphabet
+			ranges = append(ranges, "(geohash >= ? AND geohash < ?)")
+			rangeArguments = append(rangeArguments, cells[0], cells[1]+"~")
+		}
+		boxes = append(boxes, "(location_entries.latitude BETWEEN ? AND ? AND location_entries.longitude BETWEEN ? AND ?)")
+		boxArguments = append(boxArguments, part.South, part.North, part.West, part.East)
+	}
+	return query.
+		Where("location_entries.id IN (SELECT id FROM location_entries WHERE "+strings.Join(ranges, " OR ")+")", rangeArguments...).
+		Where(strings.Join(boxes, " OR "), boxArguments...)
+}
+
+// FindWithinBounds returns up to limit locations of the box whose coordinates
+// are visible to the viewer, by ID.
+func (locationRepository *locationRepository) FindWithinBounds(bounds geo.Bounds, viewerID uint, limit int) ([]LocationEntry, error) {
+	query := withinBounds(locationRepository.db.Model(&LocationEntry{}), bounds).
+		Where(visibleCoordinates, viewerID, viewerID, viewerID).
+		Order("location_entries.id")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var locations []LocationEntry
+	if err := query.Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// FindPointsWithinBounds returns the ID and coordinates of every location of
+// the box whose coordinates are visible to the viewer, by ID, to be
+// aggregated without loading the locations.
+func (locationRepository *locationRepository) FindPointsWithinBounds(bounds geo.Bounds, viewerID uint) ([]LocationEntry, error) {
+	var locations []LocationEntry
+	err := withinBounds(locationRepository.db.Model(&LocationEntry{}), bounds).
+		Select("location_entries.id", "location_entries.latitude", "location_entries.longitude").
+		Where(visibleCoordinates, viewerID, viewerID, viewerID).
+		Order("location_entries.id").
+		Find(&locations).Error
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// FindNearest returns the limit locations closest to a point whose
+// coordinates are visible to the viewer, the closest first. maxDistance, in
+// meters, stops the search when positive. Boxes of growing size are searched
+// around the point until they hold enough locations, which are then exactly
+// the closest ones.
+func (locationRepository *locationRepository) FindNearest(point geo.Point, viewerID uint, limit int, maxDistance float64) ([]NearbyLocation, error) {
+	radius := float64(nearestInitialRadius)
+	for {
+		last := false
+		if maxDistance > 0 && radius >= maxDistance {
+			radius, last = maxDistance, true
+		}
+		bounds := geo.BoundsAround(point, radius)
+		if bounds == geo.WorldBounds || radius >= math.Pi*geo.EarthRadius {
+			// the box holds every location, up to the antipode which is
+			// farther than the radius
+			last = true
+			radius = math.Inf(1)
+			if maxDistance > 0 {
+				radius = maxDistance
+			}
+		}
+
+		locations, err := locationRepository.FindWithinBounds(bounds, viewerID, 0)
+		if err != nil {
+			return nil, err
+		}
+		nearby := make([]NearbyLocation, 0, len(locations))
+		for _, location := range locations {
+			distance := geo.Distance(point, geo.Point{Latitude: location.Latitude, Longitude: location.Longitude})
+			// the corners of the box are farther than the radius, locations
+			// outside of the box may be closer than those
+			if distance <= radius {
+				nearby = append(nearby, NearbyLocation{LocationEntry: location, Distance: distance})
+			}
+		}
+		if len(nearby) >= limit || last {
+			sort.SliceStable(nearby, func(i, j int) bool { return nearby[i].Distance < nearby[j].Distance })
+			if len(nearby) > limit {
+				nearby = nearby[:limit]
+			}
+			return nearby, nil
+		}
+		radius *= 4
+	}
+}
+
+// MigrateLocationGeohashes computes the geohash of the locations created
+// before it was stored.
+func MigrateLocationGeohashes(db *gorm.DB) error {
+	var locations []LocationEntry
+	return db.Model(&LocationEntry{}).Select("id", "latitude", "longitude").Where("geohash = ''").
+		FindInBatches(&locations, 500, func(tx *gorm.DB, batch int) error {
+			for _, location := range locations {
+				err := db.Model(&LocationEntry{}).Where("id = ?", location.ID).
+					UpdateColumn("geohash", locationGeohash(location.Latitude, location.Longitude)).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
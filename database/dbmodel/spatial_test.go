@@ -0,0 +1,309 @@
+package dbmodel
+
+import (
+	"fmt"
+	"locate-this/pkg/geo"
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB returns a new database with the tables of the locations, their
+// sharing and the share rules, and a user and another to own them.
+func newTestDB(tb testing.TB) (*gorm.DB, *UserEntry, *UserEntry) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(tb.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	err = db.AutoMigrate(&UserEntry{}, &LocationEntry{}, &GroupEntry{}, &GroupUserEntry{}, &GroupLocationEntry{}, &TagEntry{}, &LocationTagEntry{}, &ShareRuleEntry{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := MigrateSearchIndex(db); err != nil {
+		tb.Fatal(err)
+	}
+	users := NewUserRepository(db)
+	user, err := users.Create(&UserEntry{Email: "a@example.com", Password: "-", Username: "alice"})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	other, err := users.Create(&UserEntry{Email: "b@example.com", Password: "-", Username: "bob"})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return db, user, other
+}
+
+// seedLocations inserts the points as locations of the user, in batches and
+// without indexing them for search.
+func seedLocations(tb testing.TB, db *gorm.DB, userID uint, points []geo.Point) []LocationEntry {
+	locations := make([]LocationEntry, len(points))
+	for i, point := range points {
+		locations[i] = LocationEntry{
+			UserID: userID, Name: fmt.Sprintf("location %d", i),
+			Latitude: point.Latitude, Longitude: point.Longitude,
+			Geohash: locationGeohash(point.Latitude, point.Longitude),
+		}
+	}
+	if err := db.CreateInBatches(locations, 500).Error; err != nil {
+		tb.Fatal(err)
+	}
+	return locations
+}
+
+// randomPoints returns points spread over the globe, with clusters around the
+// poles and along the antimeridian where the geohash cells are the least
+// like the boxes.
+func randomPoints(random *rand.Rand, n int) []geo.Point {
+	points := make([]geo.Point, n)
+	for i := range points {
+		switch i % 4 {
+		case 0:
+			points[i] = geo.Point{Latitude: 89 + random.Float64(), Longitude: random.Float64()*360 - 180}
+		case 1:
+			points[i] = geo.Point{Latitude: -90 + random.Float64(), Longitude: random.Float64()*360 - 180}
+		case 2:
+			points[i] = geo.Point{Latitude: random.Float64()*20 - 10, Longitude: math.Mod(180+random.Float64()*4-2+540, 360) - 180}
+		default:
+			// uniform on the sphere
+			points[i] = geo.Point{Latitude: math.Asin(random.Float64()*2-1) * 180 / math.Pi, Longitude: random.Float64()*360 - 180}
+		}
+	}
+	return points
+}
+
+func locationIDs(locations []LocationEntry) []uint {
+	ids := make([]uint, len(locations))
+	for i, location := range locations {
+		ids[i] = location.ID
+	}
+	return ids
+}
+
+func TestFindWithinBounds(t *testing.T) {
+	db, user, other := newTestDB(t)
+	repository := NewLocationRepository(db)
+	locations := seedLocations(t, db, user.ID, randomPoints(rand.New(rand.NewPCG(1, 2)), 4000))
+	// the locations of another user are not visible
+	seedLocations(t, db, other.ID, []geo.Point{{Latitude: 0, Longitude: 180}, {Latitude: 90, Longitude: 0}, {Latitude: 45.75, Longitude: 4.85}})
+
+	tests := []struct {
+		name   string
+		bounds geo.Bounds
+	}{
+		{"across the antimeridian", geo.Bounds{South: -5, West: 179, North: 5, East: -179}},
+		{"narrow across the antimeridian", geo.Bounds{South: -10, West: 179.99, North: 10, East: -179.99}},
+		{"wide across the antimeridian", geo.Bounds{South: -10, West: 10, North: 10, East: 0}},
+		{"east of the antimeridian", geo.Bounds{South: -10, West: -180, North: 10, East: -179.5}},
+		{"west of the antimeridian", geo.Bounds{South: -10, West: 179.5, North: 10, East: 180}},
+		{"around the north pole", geo.Bounds{South: 89.5, West: -180, North: 90, East: 180}},
+		{"at the north pole", geo.Bounds{South: 89.99, West: 100, North: 90, East: -100}},
+		{"around the south pole", geo.Bounds{South: -90, West: -180, North: -89.5, East: 180}},
+		{"near the south pole", geo.Bounds{South: -89.9, West: -30, North: -89.2, East: 60}},
+		{"around a circle near the pole", geo.BoundsAround(geo.Point{Latitude: 89.7, Longitude: 120}, 50000)},
+		{"around a circle on the antimeridian", geo.BoundsAround(geo.Point{Latitude: 3, Longitude: -179.9}, 100000)},
+		{"europe", geo.Bounds{South: 35, West: -10, North: 70, East: 40}},
+		{"world", geo.WorldBounds},
+		{"empty", geo.Bounds{South: 30, West: 30, North: 30.0001, East: 30.0001}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := make([]uint, 0)
+			for _, location := range locations {
+				if test.bounds.Contains(geo.Point{Latitude: location.Latitude, Longitude: location.Longitude}) {
+					want = append(want, location.ID)
+				}
+			}
+			found, err := repository.FindWithinBounds(test.bounds, user.ID, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := locationIDs(found); !slices.Equal(got, want) {
+				t.Errorf("found %d locations, want %d: %v", len(got), len(want), got)
+			}
+
+			points, err := repository.FindPointsWithinBounds(test.bounds, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := locationIDs(points); !slices.Equal(got, want) {
+				t.Errorf("found the points of %d locations, want %d", len(got), len(want))
+			}
+		})
+	}
+
+	if found, err := repository.FindWithinBounds(geo.WorldBounds, user.ID, 10); err != nil || len(found) != 10 || found[0].ID != locations[0].ID {
+		t.Errorf("FindWithinBounds with a limit of 10 = %d locations, %v", len(found), err)
+	}
+}
+
+func TestFindNearestExpandsTheRadius(t *testing.T) {
+	db, user, other := newTestDB(t)
+	repository := NewLocationRepository(db)
+	center := geo.Point{Latitude: 45.75, Longitude: 4.85}
+	east := func(meters float64) geo.Point {
+		return geo.Point{Latitude: center.Latitude, Longitude: center.Longitude + meters/(geo.EarthRadius*math.Pi/180*math.Cos(center.Latitude*math.Pi/180))}
+	}
+	// the first box, of 1 km, holds one location, the others are found by
+	// the boxes of 4 and 64 km and the last one once the box covers the globe
+	locations := seedLocations(t, db, user.ID, []geo.Point{
+		{Latitude: -45.75, Longitude: -175.15},
+		east(50000),
+		east(500),
+		east(3000),
+		// in the corner of the first box, but farther than its radius
+		{Latitude: center.Latitude + 0.008, Longitude: east(900).Longitude},
+	})
+	seedLocations(t, db, other.ID, []geo.Point{center})
+
+	tests := []struct {
+		limit       int
+		maxDistance float64
+		want        []uint
+	}{
+		{1, 0, []uint{locations[2].ID}},
+		{2, 0, []uint{locations[2].ID, locations[4].ID}},
+		{3, 0, []uint{locations[2].ID, locations[4].ID, locations[3].ID}},
+		{4, 0, []uint{locations[2].ID, locations[4].ID, locations[3].ID, locations[1].ID}},
+		// the antipode is only found once the box covers the globe
+		{10, 0, []uint{locations[2].ID, locations[4].ID, locations[3].ID, locations[1].ID, locations[0].ID}},
+		{10, 10000, []uint{locations[2].ID, locations[4].ID, locations[3].ID}},
+		{10, 400, []uint{}},
+	}
+	for _, test := range tests {
+		nearby, err := repository.FindNearest(center, user.ID, test.limit, test.maxDistance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]uint, len(nearby))
+		for i, location := range nearby {
+			got[i] = location.ID
+			if i > 0 && location.Distance < nearby[i-1].Distance {
+				t.Errorf("limit %d: location %d is closer than the previous one", test.limit, location.ID)
+			}
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("FindNearest(limit %d, max %v) = %v, want %v", test.limit, test.maxDistance, got, test.want)
+		}
+	}
+
+	nearby, err := repository.FindNearest(center, user.ID, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if distance := nearby[0].Distance; math.Abs(distance-500) > 0.5 {
+		t.Errorf("distance = %v, want 500", distance)
+	}
+}
+
+// TestFindNearest compares the search with the distances to every location,
+// around the poles and the antimeridian.
+func TestFindNearest(t *testing.T) {
+	db, user, _ := newTestDB(t)
+	repository := NewLocationRepository(db)
+	random := rand.New(rand.NewPCG(3, 4))
+	locations := seedLocations(t, db, user.ID, randomPoints(random, 2000))
+
+	for _, point := range []geo.Point{
+		{Latitude: 0, Longitude: 180},
+		{Latitude: 2, Longitude: -179.99},
+		{Latitude: 90, Longitude: 0},
+		{Latitude: 89.9, Longitude: -45},
+		{Latitude: -89.95, Longitude: 170},
+		{Latitude: -90, Longitude: 0},
+		{Latitude: 45.75, Longitude: 4.85},
+		{Latitude: -30, Longitude: 100},
+	} {
+		type candidate struct {
+			id       uint
+			distance float64
+		}
+		candidates := make([]candidate, len(locations))
+		for i, location := range locations {
+			candidates[i] = candidate{location.ID, geo.Distance(point, geo.Point{Latitude: location.Latitude, Longitude: location.Longitude})}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+
+		for _, limit := range []int{1, 5, 30} {
+			nearby, err := repository.FindNearest(point, user.ID, limit, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(nearby) != limit {
+				t.Fatalf("FindNearest(%v, %d) found %d locations", point, limit, len(nearby))
+			}
+			for i, location := range nearby {
+				if math.Abs(location.Distance-candidates[i].distance) > 1e-6 {
+					t.Errorf("FindNearest(%v, %d): location %d is %v m away, want %v m (location %d)", point, limit, i, location.Distance, candidates[i].distance, candidates[i].id)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkFindWithinBounds compares the geohash ranges with a scan of the
+// coordinates, on boxes of a few sizes among 50000 locations.
+func BenchmarkFindWithinBounds(b *testing.B) {
+	db, user, _ := newTestDB(b)
+	random := rand.New(rand.NewPCG(5, 6))
+	points := make([]geo.Point, 50000)
+	for i := range points {
+		if i%2 == 0 {
+			// half of them around Lyon, as users keep their places close
+			points[i] = geo.Point{Latitude: 45 + random.Float64()*2, Longitude: 4 + random.Float64()*2}
+		} else {
+			points[i] = geo.Point{Latitude: math.Asin(random.Float64()*2-1) * 180 / math.Pi, Longitude: random.Float64()*360 - 180}
+		}
+	}
+	seedLocations(b, db, user.ID, points)
+	if err := db.Exec("ANALYZE").Error; err != nil {
+		b.Fatal(err)
+	}
+
+	for _, size := range []struct {
+		name   string
+		bounds geo.Bounds
+	}{
+		{"street", geo.BoundsAround(geo.Point{Latitude: 45.75, Longitude: 4.85}, 200)},
+		{"city", geo.BoundsAround(geo.Point{Latitude: 45.75, Longitude: 4.85}, 5000)},
+		{"region", geo.BoundsAround(geo.Point{Latitude: 45.75, Longitude: 4.85}, 100000)},
+		{"antimeridian", geo.BoundsAround(geo.Point{Latitude: 0, Longitude: 180}, 500000)},
+	} {
+		// the box alone, as the locations were found before the geohash
+		scan := func(query *gorm.DB, bounds geo.Bounds) *gorm.DB {
+			var boxes []string
+			var arguments []any
+			for _, part := range bounds.Split() {
+				boxes = append(boxes, "(location_entries.latitude BETWEEN ? AND ? AND location_entries.longitude BETWEEN ? AND ?)")
+				arguments = append(arguments, part.South, part.North, part.West, part.East)
+			}
+			return query.Where(strings.Join(boxes, " OR "), arguments...)
+		}
+		for _, method := range []struct {
+			name   string
+			within func(query *gorm.DB, bounds geo.Bounds) *gorm.DB
+		}{{"scan", scan}, {"geohash", withinBounds}} {
+			b.Run(size.name+"/"+method.name, func(b *testing.B) {
+				var locations []LocationEntry
+				for b.Loop() {
+					err := method.within(db.Model(&LocationEntry{}), size.bounds).
+						Where(visibleCoordinates, user.ID, user.ID, user.ID).
+						Order("location_entries.id").
+						Find(&locations).Error
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(locations)), "locations")
+			})
+		}
+	}
+}
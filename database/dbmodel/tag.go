@@ -0,0 +1,172 @@
+package dbmodel
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagEntry struct {
+	ID        uint             `gorm:"primarykey" json:"tag_id"`
+	CreatedAt time.Time        `json:"created_at"`
+	UserID    uint             `json:"user_id" gorm:"not null;uniqueIndex:idx_tag_entries_user_name"`
+	Name      string           `json:"name" gorm:"not null;uniqueIndex:idx_tag_entries_user_name"`
+	Locations []*LocationEntry `gorm:"many2many:location_tag_entries;constraint:OnDelete:CASCADE;" json:"locations"`
+}
+
+type LocationTagEntry struct {
+	LocationEntryID uint `gorm:"not null;primaryKey;constraint:OnDelete:CASCADE"`
+	TagEntryID      uint `gorm:"not null;primaryKey;constraint:OnDelete:CASCADE"`
+}
+
+type TagRepository interface {
+	AddTagsToLocation(location *LocationEntry, names []string) ([]TagEntry, ShareChanges, error)
+	RemoveTagFromLocation(location *LocationEntry, name string) (ShareChanges, error)
+	FindTagsForLocation(id uint) ([]TagEntry, error)
+	FindVisibleTagsForLocations(userID uint, locationIDs []uint) (map[uint][]string, error)
+	FindByPrefix(userID uint, prefix string, limit int) ([]TagEntry, error)
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+// AddTagsToLocation creates the missing tags of the location owner and links
+// them to the location, which the share rules of these tags then share.
+func (tagRepository *tagRepository) AddTagsToLocation(location *LocationEntry, names []string) ([]TagEntry, ShareChanges, error) {
+	var tags []TagEntry
+	var changes ShareChanges
+	err := tagRepository.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if tags, err = addTags(tx, location, names); err != nil {
+			return err
+		}
+		changes, err = syncShareRules(tx, location.UserID, []uint{location.ID})
+		return err
+	})
+	if err != nil {
+		return nil, ShareChanges{}, err
+	}
+	return tags, changes, nil
+}
+
+// addTags links the tags to the location within a transaction, creating the
+// missing ones, and reindexes the location.
+func addTags(tx *gorm.DB, location *LocationEntry, names []string) ([]TagEntry, error) {
+	tags := make([]TagEntry, 0, len(names))
+	for _, name := range names {
+		tag := TagEntry{UserID: location.UserID, Name: name}
+		if err := tx.Where(&tag).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		link := LocationTagEntry{LocationEntryID: location.ID, TagEntryID: tag.ID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&link).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, reindexLocation(tx, location.ID)
+}
+
+// RemoveTagFromLocation unlinks a tag and deletes it once no location uses
+// it, taking the location out of the groups of the share rules of the tag.
+func (tagRepository *tagRepository) RemoveTagFromLocation(location *LocationEntry, name string) (ShareChanges, error) {
+	var changes ShareChanges
+	err := tagRepository.db.Transaction(func(tx *gorm.DB) error {
+		var tag TagEntry
+		if err := tx.Where("user_id = ? AND name = ?", location.UserID, name).First(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("location_entry_id = ? AND tag_entry_id = ?", location.ID, tag.ID).Delete(&LocationTagEntry{}).Error; err != nil {
+			return err
+		}
+		var remaining int64
+		if err := tx.Model(&LocationTagEntry{}).Where("tag_entry_id = ?", tag.ID).Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining == 0 {
+			if err := tx.Delete(&tag).Error; err != nil {
+				return err
+			}
+		}
+		if err := reindexLocation(tx, location.ID); err != nil {
+			return err
+		}
+		var err error
+		changes, err = syncShareRules(tx, location.UserID, []uint{location.ID})
+		return err
+	})
+	if err != nil {
+		return ShareChanges{}, err
+	}
+	return changes, nil
+}
+
+func (tagRepository *tagRepository) FindTagsForLocation(id uint) ([]TagEntry, error) {
+	var tags []TagEntry
+	err := tagRepository.db.
+		Joins("JOIN location_tag_entries ON location_tag_entries.tag_entry_id = tag_entries.id").
+		Where("location_tag_entries.location_entry_id = ?", id).
+		Order("tag_entries.name").
+		Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// FindVisibleTagsForLocations returns the tag names of the given locations,
+// keyed by location ID, limited to the locations the user owns or that are
+// shared in one of the user's groups.
+func (tagRepository *tagRepository) FindVisibleTagsForLocations(userID uint, locationIDs []uint) (map[uint][]string, error) {
+	tags := make(map[uint][]string)
+	if len(locationIDs) == 0 {
+		return tags, nil
+	}
+
+	var rows []struct {
+		LocationEntryID uint
+		Name            string
+	}
+	err := tagRepository.db.Table("location_tag_entries").
+		Select("location_tag_entries.location_entry_id, tag_entries.name").
+		Joins("JOIN tag_entries ON tag_entries.id = location_tag_entries.tag_entry_id").
+		Joins("JOIN location_entries ON location_entries.id = location_tag_entries.location_entry_id").
+		Where("location_tag_entries.location_entry_id IN ?", locationIDs).
+		Where("location_entries.user_id = ? OR location_entries.id IN ("+sharedWithUser+")", userID, userID, userID).
+		Order("tag_entries.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tags[row.LocationEntryID] = append(tags[row.LocationEntryID], row.Name)
+	}
+	return tags, nil
+}
+
+func (tagRepository *tagRepository) FindByPrefix(userID uint, prefix string, limit int) ([]TagEntry, error) {
+	var tags []TagEntry
+	err := tagRepository.db.
+		Where("user_id = ? AND name LIKE ? ESCAPE '\\'", userID, escapeLike(prefix)+"%").
+		Order("name").
+		Limit(limit).
+		Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func reindexLocation(db *gorm.DB, id uint) error {
+	var location LocationEntry
+	if err := db.First(&location, id).Error; err != nil {
+		return err
+	}
+	return indexLocation(db, &location)
+}
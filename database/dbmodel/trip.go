@@ -0,0 +1,328 @@
+package dbmodel
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrTripStopNotFound is returned when a stop does not belong to the trip.
+var ErrTripStopNotFound = errors.New("stop not found in the trip")
+
+// TripEntry is an ordered itinerary of locations, such as the site visits of
+// a day. It belongs to its creator, who can share it into groups.
+type TripEntry struct {
+	gorm.Model
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	User        UserEntry `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description" gorm:"not null;default:''"`
+}
+
+// TripStopEntry is a stop of a trip at a location. A location can be visited
+// several times in a trip. The stops are visited by increasing Position.
+type TripStopEntry struct {
+	ID         uint          `gorm:"primaryKey"`
+	TripID     uint          `gorm:"not null;index"`
+	Trip       TripEntry     `gorm:"foreignKey:TripID;constraint:OnDelete:CASCADE;"`
+	LocationID uint          `gorm:"not null;index"`
+	Location   LocationEntry `gorm:"foreignKey:LocationID;constraint:OnDelete:CASCADE;"`
+	Position   int           `gorm:"not null"`
+	Note       string        `gorm:"not null;default:''"`
+}
+
+// TripGroupEntry shares a trip into a group, whose members can then see it.
+type TripGroupEntry struct {
+	TripID  uint       `gorm:"primaryKey"`
+	Trip    TripEntry  `gorm:"foreignKey:TripID;constraint:OnDelete:CASCADE;"`
+	GroupID uint       `gorm:"primaryKey;index"`
+	Group   GroupEntry `gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE;"`
+}
+
+// TripStop is a stop of a trip as seen by a viewer. The name and coordinates
+// of its location are only set when the viewer can see them.
+type TripStop struct {
+	TripStopEntry
+	Name               string
+	Latitude           float64
+	Longitude          float64
+	Visible            bool
+	CoordinatesVisible bool
+}
+
+type TripRepository interface {
+	Create(entry *TripEntry, stops []TripStopEntry) (*TripEntry, error)
+	FindById(id uint) (*TripEntry, error)
+	FindTripsForUser(userID uint, groupID *uint) ([]TripEntry, error)
+	IsVisibleTo(id uint, userID uint) (bool, error)
+	Update(entry *TripEntry, id uint) (*TripEntry, error)
+	Delete(id uint) error
+	FindStops(id uint, viewerID uint) ([]TripStop, error)
+	InsertStop(id uint, stop *TripStopEntry, position int) error
+	RemoveStop(id uint, stopID uint) error
+	ReorderStops(id uint, stopIDs []uint) error
+	FindGroupIDsForTrip(id uint) ([]uint, error)
+	Share(id uint, groupID uint) error
+	Unshare(id uint, groupID uint) error
+}
+
+// sharedTripsWithUser selects the IDs of the trips shared in the groups a
+// user administrates or belongs to.
+const sharedTripsWithUser = `SELECT trip_id FROM trip_group_entries WHERE group_id IN (` + memberGroups + `)`
+
+type tripRepository struct {
+	db *gorm.DB
+}
+
+func NewTripRepository(db *gorm.DB) TripRepository {
+	return &tripRepository{db: db}
+}
+
+// Create saves the trip with its stops, visited in the given order.
+func (tripRepository *tripRepository) Create(entry *TripEntry, stops []TripStopEntry) (*TripEntry, error) {
+	err := tripRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		for i := range stops {
+			stops[i].TripID = entry.ID
+			stops[i].Position = i
+		}
+		if len(stops) == 0 {
+			return nil
+		}
+		return tx.Omit("Trip", "Location").Create(&stops).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (tripRepository *tripRepository) FindById(id uint) (*TripEntry, error) {
+	var trip TripEntry
+	if err := tripRepository.db.First(&trip, id).Error; err != nil {
+		return nil, err
+	}
+	return &trip, nil
+}
+
+// FindTripsForUser returns the trips of a user and the ones shared in their
+// groups, by ID, only those shared in groupID when it is set.
+func (tripRepository *tripRepository) FindTripsForUser(userID uint, groupID *uint) ([]TripEntry, error) {
+	query := tripRepository.db.Model(&TripEntry{})
+	if groupID != nil {
+		query = query.Where("id IN (SELECT trip_id FROM trip_group_entries WHERE group_id = ?)", *groupID)
+	} else {
+		query = query.Where("user_id = ? OR id IN ("+sharedTripsWithUser+")", userID, userID, userID)
+	}
+	var trips []TripEntry
+	if err := query.Order("id").Find(&trips).Error; err != nil {
+		return nil, err
+	}
+	return trips, nil
+}
+
+// IsVisibleTo reports whether the user created the trip or belongs to a group
+// it is shared in.
+func (tripRepository *tripRepository) IsVisibleTo(id uint, userID uint) (bool, error) {
+	var count int64
+	err := tripRepository.db.Model(&TripEntry{}).
+		Where("id = ? AND (user_id = ? OR id IN ("+sharedTripsWithUser+"))", id, userID, userID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (tripRepository *tripRepository) Update(entry *TripEntry, id uint) (*TripEntry, error) {
+	err := tripRepository.db.Model(&TripEntry{}).Where("id = ?", id).
+		Select("name", "description").
+		Updates(entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return tripRepository.FindById(id)
+}
+
+func (tripRepository *tripRepository) Delete(id uint) error {
+	return tripRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("trip_id = ?", id).Delete(&TripStopEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("trip_id = ?", id).Delete(&TripGroupEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&TripEntry{}, id).Error
+	})
+}
+
+// FindStops returns the stops of a trip in their order. The location of a
+// stop is visible to its owner and to the members of a group it is shared in,
+// its coordinates only when they are not hidden in one of those groups.
+func (tripRepository *tripRepository) FindStops(id uint, viewerID uint) ([]TripStop, error) {
+	var entries []TripStopEntry
+	if err := tripRepository.db.Where("trip_id = ?", id).Order("position, id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return []TripStop{}, nil
+	}
+
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.LocationID)
+	}
+	var locations []LocationEntry
+	err := tripRepository.db.Model(&LocationEntry{}).
+		Select("id", "name", "latitude", "longitude").
+		Where("id IN ?", ids).
+		Where("user_id = ? OR id IN ("+sharedWithUser+")", viewerID, viewerID, viewerID).
+		Find(&locations).Error
+	if err != nil {
+		return nil, err
+	}
+	var visibleIDs []uint
+	err = tripRepository.db.Model(&LocationEntry{}).
+		Where("id IN ?", ids).
+		Where(visibleCoordinates, viewerID, viewerID, viewerID).
+		Pluck("id", &visibleIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]*LocationEntry, len(locations))
+	for i := range locations {
+		byID[locations[i].ID] = &locations[i]
+	}
+	coordinatesVisible := make(map[uint]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		coordinatesVisible[id] = true
+	}
+	stops := make([]TripStop, 0, len(entries))
+	for _, entry := range entries {
+		stop := TripStop{TripStopEntry: entry}
+		if location, ok := byID[entry.LocationID]; ok {
+			stop.Name = location.Name
+			stop.Visible = true
+			if coordinatesVisible[entry.LocationID] {
+				stop.Latitude, stop.Longitude = location.Latitude, location.Longitude
+				stop.CoordinatesVisible = true
+			}
+		}
+		stops = append(stops, stop)
+	}
+	return stops, nil
+}
+
+// InsertStop inserts a stop at a position of the trip, counted from 0, the
+// following stops moving one position further. A position beyond the last
+// stop appends it.
+func (tripRepository *tripRepository) InsertStop(id uint, stop *TripStopEntry, position int) error {
+	return tripRepository.db.Transaction(func(tx *gorm.DB) error {
+		stopIDs, err := tripStopIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		if position < 0 || position > len(stopIDs) {
+			position = len(stopIDs)
+		}
+		stop.TripID = id
+		stop.Position = position
+		if err := tx.Omit("Trip", "Location").Create(stop).Error; err != nil {
+			return err
+		}
+		ordered := make([]uint, 0, len(stopIDs)+1)
+		ordered = append(ordered, stopIDs[:position]...)
+		ordered = append(ordered, stop.ID)
+		ordered = append(ordered, stopIDs[position:]...)
+		return setTripStopPositions(tx, ordered)
+	})
+}
+
+// RemoveStop removes a stop of the trip, the following stops moving one
+// position back.
+func (tripRepository *tripRepository) RemoveStop(id uint, stopID uint) error {
+	return tripRepository.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND trip_id = ?", stopID, id).Delete(&TripStopEntry{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTripStopNotFound
+		}
+		stopIDs, err := tripStopIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		return setTripStopPositions(tx, stopIDs)
+	})
+}
+
+// ReorderStops visits the stops of the trip in the order of stopIDs, which
+// must hold every stop of the trip once.
+func (tripRepository *tripRepository) ReorderStops(id uint, stopIDs []uint) error {
+	return tripRepository.db.Transaction(func(tx *gorm.DB) error {
+		current, err := tripStopIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		if len(current) != len(stopIDs) {
+			return ErrTripStopNotFound
+		}
+		remaining := make(map[uint]bool, len(current))
+		for _, stopID := range current {
+			remaining[stopID] = true
+		}
+		for _, stopID := range stopIDs {
+			if !remaining[stopID] {
+				return ErrTripStopNotFound
+			}
+			delete(remaining, stopID)
+		}
+		return setTripStopPositions(tx, stopIDs)
+	})
+}
+
+func tripStopIDs(tx *gorm.DB, id uint) ([]uint, error) {
+	var stopIDs []uint
+	err := tx.Model(&TripStopEntry{}).Where("trip_id = ?", id).Order("position, id").Pluck("id", &stopIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return stopIDs, nil
+}
+
+// setTripStopPositions numbers the stops from 0 in the order of stopIDs.
+func setTripStopPositions(tx *gorm.DB, stopIDs []uint) error {
+	for position, stopID := range stopIDs {
+		if err := tx.Model(&TripStopEntry{}).Where("id = ?", stopID).UpdateColumn("position", position).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tripRepository *tripRepository) FindGroupIDsForTrip(id uint) ([]uint, error) {
+	var groupIDs []uint
+	err := tripRepository.db.Model(&TripGroupEntry{}).Where("trip_id = ?", id).Order("group_id").Pluck("group_id", &groupIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return groupIDs, nil
+}
+
+// Share shares the trip into a group, doing nothing when it already is.
+func (tripRepository *tripRepository) Share(id uint, groupID uint) error {
+	var count int64
+	err := tripRepository.db.Model(&TripGroupEntry{}).Where("trip_id = ? AND group_id = ?", id, groupID).Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+	return tripRepository.db.Omit("Trip", "Group").Create(&TripGroupEntry{TripID: id, GroupID: groupID}).Error
+}
+
+func (tripRepository *tripRepository) Unshare(id uint, groupID uint) error {
+	return tripRepository.db.Where("trip_id = ? AND group_id = ?", id, groupID).Delete(&TripGroupEntry{}).Error
+}
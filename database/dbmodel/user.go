@@ -8,20 +8,27 @@ type UserEntry struct {
 	Password string        `json:"password" gorm:"not null"`
 	Username string        `json:"username" gorm:"not null;unique"`
 	Groups   []*GroupEntry `gorm:"many2many:group_user_entries;constraint:OnDelete:CASCADE;" json:"groups"`
+
+	// Days the position history is kept, the server default when nil and no
+	// history is recorded when 0.
+	HistoryRetentionDays *int `json:"history_retention_days"`
 }
 
 type UserRepository interface {
 	Create(entry *UserEntry) (*UserEntry, error)
-	FindAll() ([]UserEntry, error)
+	FindAll(options QueryOptions) ([]UserEntry, PageInfo, error)
 	FindById(id uint) (*UserEntry, error)
 	FindByEmail(email string) (*UserEntry, error)
 	FindByUsername(username string) (*UserEntry, error)
-	FindLocationsForUser(id uint) ([]LocationEntry, error)
-	FindGroupsForUser(id uint) ([]GroupEntry, error)
+	FindLocationsForUser(id uint, viewerID uint, options QueryOptions) ([]LocationEntry, PageInfo, error)
+	FindGroupsForUser(id uint, options QueryOptions) ([]GroupEntry, PageInfo, error)
 	Update(entry *UserEntry, id uint) (*UserEntry, error)
+	UpdateHistoryRetention(id uint, days *int) error
 	Delete(id uint) error
 }
 
+var userList = listQuery{table: "user_entries", nameColumn: "username", sortable: UserSortFields}
+
 type userRepository struct {
 	db *gorm.DB
 }
@@ -31,18 +38,20 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 func (userRepository *userRepository) Create(entry *UserEntry) (*UserEntry, error) {
-	if err := userRepository.db.Create(entry).Error; err != nil {
+	err := userRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return indexDocument(tx, SearchKindUser, entry.ID, entry.Username, "", "")
+	})
+	if err != nil {
 		return nil, err
 	}
 	return entry, nil
 }
 
-func (userRepository *userRepository) FindAll() ([]UserEntry, error) {
-	var users []UserEntry
-	if err := userRepository.db.Find(&users).Error; err != nil {
-		return nil, err
-	}
-	return users, nil
+func (userRepository *userRepository) FindAll(options QueryOptions) ([]UserEntry, PageInfo, error) {
+	return paginate[UserEntry](userRepository.db.Model(&UserEntry{}), userList, options)
 }
 
 func (userRepository *userRepository) FindById(id uint) (*UserEntry, error) {
@@ -69,36 +78,57 @@ func (userRepository *userRepository) FindByUsername(username string) (*UserEntr
 	return &user, nil
 }
 
-func (userRepository *userRepository) FindLocationsForUser(id uint) ([]LocationEntry, error) {
-	var locations []LocationEntry
-	if err := userRepository.db.Where("user_id = ?", id).Find(&locations).Error; err != nil {
-		return nil, err
+// FindLocationsForUser returns a page of the locations of a user the viewer
+// can see: all of them for the user, otherwise the ones shared in a group of
+// the viewer. The filters on the address, altitude and accuracy only match
+// the locations whose coordinates are visible to the viewer.
+func (userRepository *userRepository) FindLocationsForUser(id uint, viewerID uint, options QueryOptions) ([]LocationEntry, PageInfo, error) {
+	query := userRepository.db.Model(&LocationEntry{}).Where("location_entries.user_id = ?", id)
+	if id != viewerID {
+		query = query.Where("location_entries.id IN ("+sharedWithUser+")", viewerID, viewerID)
+		if options.filtersCoordinateDetails() {
+			query = query.Where("location_entries.id IN ("+sharedWithUser+" AND group_location_entries.is_visible_coordinates)", viewerID, viewerID)
+		}
 	}
-	return locations, nil
+	return paginate[LocationEntry](query, locationList, options)
 }
 
-func (userRepository *userRepository) FindGroupsForUser(id uint) ([]GroupEntry, error) {
-	var user UserEntry
-	if err := userRepository.db.Preload("Groups").First(&user, id).Error; err != nil {
-		return nil, err
-	}
-	groups := make([]GroupEntry, len(user.Groups))
-	for i, g := range user.Groups {
-		groups[i] = *g
+func (userRepository *userRepository) FindGroupsForUser(id uint, options QueryOptions) ([]GroupEntry, PageInfo, error) {
+	if err := userRepository.db.First(&UserEntry{}, id).Error; err != nil {
+		return nil, PageInfo{}, err
 	}
-	return groups, nil
+	query := userRepository.db.Model(&GroupEntry{}).
+		Joins("JOIN group_user_entries ON group_user_entries.group_entry_id = group_entries.id").
+		Where("group_user_entries.user_entry_id = ?", id)
+	return paginate[GroupEntry](query, groupList, options)
+}
+
+func (userRepository *userRepository) UpdateHistoryRetention(id uint, days *int) error {
+	return userRepository.db.Model(&UserEntry{}).Where("id = ?", id).Update("history_retention_days", days).Error
 }
 
 func (userRepository *userRepository) Update(entry *UserEntry, id uint) (*UserEntry, error) {
-	if err := userRepository.db.Model(&UserEntry{}).Where("id = ?", id).Updates(entry).Error; err != nil {
+	err := userRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&UserEntry{}).Where("id = ?", id).Updates(entry).Error; err != nil {
+			return err
+		}
+		var updated UserEntry
+		if err := tx.First(&updated, id).Error; err != nil {
+			return err
+		}
+		return indexDocument(tx, SearchKindUser, updated.ID, updated.Username, "", "")
+	})
+	if err != nil {
 		return nil, err
 	}
 	return entry, nil
 }
 
 func (userRepository *userRepository) Delete(id uint) error {
-	if err := userRepository.db.Delete(&UserEntry{}, id).Error; err != nil {
-		return err
-	}
-	return nil
+	return userRepository.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&UserEntry{}, id).Error; err != nil {
+			return err
+		}
+		return removeDocument(tx, SearchKindUser, id)
+	})
 }
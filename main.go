@@ -1,12 +1,24 @@
 package main
 
 import (
+	"flag"
 	"locate-this/config"
+	"locate-this/pkg/attachment"
 	"locate-this/pkg/authentication"
+	"locate-this/pkg/folder"
+	"locate-this/pkg/geocode"
+	"locate-this/pkg/geocoder"
+	"locate-this/pkg/geofence"
 	"locate-this/pkg/group"
 	"locate-this/pkg/group_location"
 	"locate-this/pkg/group_user"
+	"locate-this/pkg/live"
 	"locate-this/pkg/location"
+	"locate-this/pkg/mapview"
+	"locate-this/pkg/search"
+	"locate-this/pkg/sharerule"
+	"locate-this/pkg/tag"
+	"locate-this/pkg/trip"
 	"locate-this/pkg/user"
 	"log"
 	"net/http"
@@ -33,6 +45,13 @@ func Routes(configuration *config.Config) *chi.Mux {
 
 	router.Mount("/api/auth", authentication.Routes(configuration))
 
+	// EventSource and WebSocket clients cannot set headers
+	router.Group(func(r chi.Router) {
+		r.Use(authentication.QueryTokenMiddleware)
+		r.Use(authentication.AuthMiddleware(os.Getenv("JWT_SECRET")))
+		r.Mount("/api/groups/{id}/events", group.EventRoutes(configuration))
+	})
+
 	router.Group(func(r chi.Router) {
 		r.Use(authentication.AuthMiddleware(os.Getenv("JWT_SECRET")))
 		r.Mount("/api/groups", group.Routes(configuration))
@@ -40,18 +59,48 @@ func Routes(configuration *config.Config) *chi.Mux {
 		r.Mount("/api/group-user", group_user.Routes(configuration))
 		r.Mount("/api/locations", location.Routes(configuration))
 		r.Mount("/api/users", user.Routes(configuration))
+		r.Mount("/api/search", search.Routes(configuration))
+		r.Mount("/api/tags", tag.Routes(configuration))
+		r.Mount("/api/attachments", attachment.Routes(configuration))
+		r.Mount("/api/live", live.Routes(configuration))
+		r.Mount("/api/geofences", geofence.Routes(configuration))
+		r.Mount("/api/geocode", geocode.Routes(configuration))
+		r.Mount("/api/reverse-geocode", geocode.ReverseRoutes(configuration))
+		r.Mount("/api/map", mapview.Routes(configuration))
+		r.Mount("/api/tiles", mapview.TileRoutes(configuration))
+		r.Mount("/api/trips", trip.Routes(configuration))
+		r.Mount("/api/folders", folder.Routes(configuration))
+		r.Mount("/api/share-rules", sharerule.Routes(configuration))
 	})
 
 	return router
 }
 
 func main() {
+	importPlaces := flag.String("import-places", "", "import the places of a GeoNames dump for the offline geocoding, then exit")
+	flag.Parse()
+
+	godotenv.Load()
 	// Initialisation de la configuration
 	configuration, err := config.New()
 	if err != nil {
 		log.Panicln("Configuration error:", err)
 	}
-	godotenv.Load()
+
+	// Import du jeu de données de géocodage
+	if *importPlaces != "" {
+		file, err := os.Open(*importPlaces)
+		if err != nil {
+			log.Fatalln("Failed to open the places:", err)
+		}
+		defer file.Close()
+		count, err := geocoder.ImportGeoNames(file, configuration.PlaceRepository)
+		if err != nil {
+			log.Fatalln("Failed to import the places:", err)
+		}
+		log.Println("Imported", count, "places")
+		return
+	}
 	// Initialisation des routes
 	router := Routes(configuration)
 
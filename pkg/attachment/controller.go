@@ -0,0 +1,322 @@
+package attachment
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"locate-this/config"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/models"
+	"locate-this/pkg/storage"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// allowedContentTypes are the detected MIME types accepted for upload.
+var allowedContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"application/pdf": true,
+}
+
+type AttachmentConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *AttachmentConfig {
+	return &AttachmentConfig{configuration}
+}
+
+// @Summary		Upload an attachment
+// @Description	Attach a photo (JPEG, PNG, GIF) or a PDF to a location visible to the caller. The GPS position embedded in photos is removed.
+// @Tags			attachments
+// @Accept			multipart/form-data
+// @Produce		json
+// @Param			id		path		int		true	"Location ID"
+// @Param			file	formData	file	true	"File to attach"
+// @Success		200		{object}	models.AttachmentResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/attachments [post]
+func (config *AttachmentConfig) PostAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	caller, ok := config.visibleLocation(w, r, id)
+	if !ok {
+		return
+	}
+
+	maxSize := config.Constants.AttachmentMaxSize
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: file is required and must not exceed " + strconv.FormatInt(maxSize, 10) + " bytes"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to read file"})
+		return
+	}
+	if int64(len(data)) > maxSize {
+		render.JSON(w, r, map[string]string{"error": "file must not exceed " + strconv.FormatInt(maxSize, 10) + " bytes"})
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowedContentTypes[contentType] {
+		render.JSON(w, r, map[string]string{"error": "file type " + contentType + " is not supported"})
+		return
+	}
+
+	if contentType != "application/pdf" {
+		if err := checkImageSize(data); errors.Is(err, errImageTooLarge) {
+			render.JSON(w, r, map[string]string{"error": "image must not exceed " + strconv.Itoa(maxImagePixels) + " pixels"})
+			return
+		} else if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Invalid image"})
+			return
+		}
+	}
+
+	data = stripLocationMetadata(data, contentType)
+	blobKey, err := config.putBlob(r, id, data, contentType)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to store file"})
+		return
+	}
+
+	var thumbnailKey string
+	if contentType != "application/pdf" {
+		preview, err := thumbnail(data, contentType)
+		if err != nil {
+			config.deleteBlobs(r, blobKey)
+			render.JSON(w, r, map[string]string{"error": "Invalid image"})
+			return
+		}
+		thumbnailKey, err = config.putBlob(r, id, preview, "image/jpeg")
+		if err != nil {
+			config.deleteBlobs(r, blobKey)
+			render.JSON(w, r, map[string]string{"error": "Failed to store thumbnail"})
+			return
+		}
+	}
+
+	attachmentEntry := &dbmodel.AttachmentEntry{
+		LocationID:   uint(id),
+		UserID:       caller.ID,
+		Filename:     filepath.Base(header.Filename),
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		BlobKey:      blobKey,
+		ThumbnailKey: thumbnailKey,
+	}
+	res, err := config.AttachmentRepository.Create(attachmentEntry)
+	if err != nil {
+		config.deleteBlobs(r, blobKey, thumbnailKey)
+		render.JSON(w, r, map[string]string{"error": "Failed to create attachment"})
+		return
+	}
+
+	render.JSON(w, r, models.NewAttachmentResponse(res))
+}
+
+// @Summary		Get attachments for a location
+// @Description	Retrieve the attachments of a location visible to the caller
+// @Tags			attachments
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Location ID"
+// @Success		200	{array}		models.AttachmentResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/attachments [get]
+func (config *AttachmentConfig) GetAttachmentsForLocationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if _, ok := config.visibleLocation(w, r, id); !ok {
+		return
+	}
+
+	attachments, err := config.AttachmentRepository.FindAttachmentsForLocation(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve attachments"})
+		return
+	}
+
+	attachmentsResponse := make([]models.AttachmentResponse, 0)
+	for _, attachment := range attachments {
+		attachmentsResponse = append(attachmentsResponse, models.NewAttachmentResponse(&attachment))
+	}
+
+	render.JSON(w, r, attachmentsResponse)
+}
+
+// @Summary		Download an attachment
+// @Description	Download the content of an attachment
+// @Tags			attachments
+// @Produce		octet-stream
+// @Param			id	path		int	true	"Attachment ID"
+// @Success		200	{file}		file
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/attachments/{id} [get]
+func (config *AttachmentConfig) GetAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := config.visibleAttachment(w, r)
+	if !ok {
+		return
+	}
+	disposition := mime.FormatMediaType("inline", map[string]string{"filename": attachment.Filename})
+	config.serveBlob(w, r, attachment.BlobKey, attachment.ContentType, disposition)
+}
+
+// @Summary		Download an attachment thumbnail
+// @Description	Download the JPEG thumbnail of an image attachment
+// @Tags			attachments
+// @Produce		jpeg
+// @Param			id	path		int	true	"Attachment ID"
+// @Success		200	{file}		file
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/attachments/{id}/thumbnail [get]
+func (config *AttachmentConfig) GetAttachmentThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := config.visibleAttachment(w, r)
+	if !ok {
+		return
+	}
+	if attachment.ThumbnailKey == "" {
+		render.JSON(w, r, map[string]string{"error": "Attachment has no thumbnail"})
+		return
+	}
+	config.serveBlob(w, r, attachment.ThumbnailKey, "image/jpeg", "inline")
+}
+
+// @Summary		Delete an attachment
+// @Description	Delete an attachment, allowed to its uploader and to the owner of the location
+// @Tags			attachments
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Attachment ID"
+// @Success		200	{object}	map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/attachments/{id} [delete]
+func (config *AttachmentConfig) DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := config.visibleAttachment(w, r)
+	if !ok {
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	location, err := config.LocationEntryRepository.FindById(attachment.LocationID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return
+	}
+	if attachment.UserID != caller.ID && location.UserID != caller.ID {
+		render.JSON(w, r, map[string]string{"error": "Only the uploader or the owner of the location can delete an attachment"})
+		return
+	}
+
+	if err := config.AttachmentRepository.Delete(attachment.ID); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to delete attachment"})
+		return
+	}
+	config.deleteBlobs(r, attachment.BlobKey, attachment.ThumbnailKey)
+
+	render.JSON(w, r, map[string]string{"message": "Attachment deleted successfully"})
+}
+
+// visibleLocation checks that the caller can see the location, rendering the
+// error otherwise.
+func (config *AttachmentConfig) visibleLocation(w http.ResponseWriter, r *http.Request, id int) (*dbmodel.UserEntry, bool) {
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return nil, false
+	}
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, false
+	}
+	visible, err := config.LocationEntryRepository.IsVisibleTo(uint(id), caller.ID)
+	if err != nil || !visible {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return nil, false
+	}
+	return caller, true
+}
+
+func (config *AttachmentConfig) visibleAttachment(w http.ResponseWriter, r *http.Request) (*dbmodel.AttachmentEntry, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return nil, false
+	}
+	attachment, err := config.AttachmentRepository.FindById(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve attachment"})
+		return nil, false
+	}
+	if _, ok := config.visibleLocation(w, r, int(attachment.LocationID)); !ok {
+		return nil, false
+	}
+	return attachment, true
+}
+
+func (config *AttachmentConfig) putBlob(r *http.Request, locationID int, data []byte, contentType string) (string, error) {
+	key, err := storage.NewKey("locations/" + strconv.Itoa(locationID))
+	if err != nil {
+		return "", err
+	}
+	if err := config.BlobStore.Put(r.Context(), key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (config *AttachmentConfig) serveBlob(w http.ResponseWriter, r *http.Request, key, contentType, disposition string) {
+	blob, err := config.BlobStore.Get(r.Context(), key)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve file"})
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Println("Failed to send attachment:", err)
+	}
+}
+
+func (config *AttachmentConfig) deleteBlobs(r *http.Request, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := config.BlobStore.Delete(r.Context(), key); err != nil {
+			log.Println("Failed to delete blob", key+":", err)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	thumbnailSize = 256
+	// maxImagePixels bounds the size of the decoded images, a small file can
+	// declare dimensions whose pixels do not fit in memory
+	maxImagePixels = 50_000_000
+)
+
+var errImageTooLarge = errors.New("image dimensions are too large")
+
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	jpegXMPHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// stripLocationMetadata removes the GPS position embedded in a photo so that
+// sharing an attachment never reveals more than the location it belongs to.
+// Other metadata (orientation, camera...) is kept.
+func stripLocationMetadata(data []byte, contentType string) []byte {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	}
+	return data
+}
+
+// stripJPEG empties the GPS directory of the EXIF segment and drops the XMP
+// segments, which can hold the same coordinates.
+func stripJPEG(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return data
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	offset := 2
+	for offset+4 <= len(data) && data[offset] == 0xFF {
+		marker := data[offset+1]
+		// start of scan, the rest is compressed image data
+		if marker == 0xDA {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		end := offset + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		segment := data[offset:end]
+		payload := segment[4:]
+		if marker == 0xE1 && bytes.HasPrefix(payload, jpegXMPHeader) {
+			offset = end
+			continue
+		}
+		if marker == 0xE1 && bytes.HasPrefix(payload, jpegExifHeader) {
+			segment = append([]byte(nil), segment...)
+			clearGPS(segment[4+len(jpegExifHeader):])
+		}
+		out = append(out, segment...)
+		offset = end
+	}
+	return append(out, data[offset:]...)
+}
+
+// tiff walks the directories of an EXIF block.
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func newTIFF(data []byte) (*tiff, uint32, bool) {
+	if len(data) < 8 {
+		return nil, 0, false
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0, false
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return nil, 0, false
+	}
+	return &tiff{data: data, order: order}, order.Uint32(data[4:]), true
+}
+
+// entry returns the tag, type, count and value offset of the i-th entry of
+// the directory at offset.
+func (t *tiff) entry(offset uint32, i int) (uint16, uint16, uint32, int, bool) {
+	start := int(offset) + 2 + 12*i
+	if start+12 > len(t.data) {
+		return 0, 0, 0, 0, false
+	}
+	return t.order.Uint16(t.data[start:]), t.order.Uint16(t.data[start+2:]), t.order.Uint32(t.data[start+4:]), start + 8, true
+}
+
+func (t *tiff) entries(offset uint32) int {
+	if int(offset)+2 > len(t.data) {
+		return 0
+	}
+	return int(t.order.Uint16(t.data[offset:]))
+}
+
+func (t *tiff) find(offset uint32, tag uint16) (uint16, uint32, int, bool) {
+	for i := 0; i < t.entries(offset); i++ {
+		entryTag, kind, count, value, ok := t.entry(offset, i)
+		if !ok {
+			break
+		}
+		if entryTag == tag {
+			return kind, count, value, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+func tiffTypeSize(kind uint16) int {
+	switch kind {
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	}
+	return 1
+}
+
+// clearGPS zeroes the values of the GPS directory and leaves it empty.
+func clearGPS(exif []byte) {
+	t, ifd0, ok := newTIFF(exif)
+	if !ok {
+		return
+	}
+	_, _, value, found := t.find(ifd0, 0x8825)
+	if !found {
+		return
+	}
+	gps := t.order.Uint32(exif[value:])
+	if int(gps) >= len(exif) {
+		return
+	}
+	count := t.entries(gps)
+	for i := 0; i < count; i++ {
+		_, kind, n, value, ok := t.entry(gps, i)
+		if !ok {
+			break
+		}
+		if size := tiffTypeSize(kind) * int(n); size > 4 {
+			start := int(t.order.Uint32(exif[value:]))
+			if start >= 0 && start+size <= len(exif) {
+				clear(exif[start : start+size])
+			}
+		}
+	}
+	end := int(gps) + 2 + 12*count + 4
+	if end > len(exif) {
+		end = len(exif)
+	}
+	clear(exif[gps:end])
+}
+
+// jpegOrientation reads the EXIF orientation of a JPEG, 1 when absent.
+func jpegOrientation(data []byte) int {
+	offset := 2
+	for offset+4 <= len(data) && data[offset] == 0xFF && data[offset+1] != 0xDA {
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		end := offset + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		payload := data[offset+4 : end]
+		if data[offset+1] == 0xE1 && bytes.HasPrefix(payload, jpegExifHeader) {
+			t, ifd0, ok := newTIFF(payload[len(jpegExifHeader):])
+			if !ok {
+				return 1
+			}
+			if _, _, value, found := t.find(ifd0, 0x0112); found {
+				return int(t.order.Uint16(t.data[value:]))
+			}
+			return 1
+		}
+		offset = end
+	}
+	return 1
+}
+
+// stripPNG drops the eXIf chunk and the XMP text chunk.
+func stripPNG(data []byte) []byte {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return data
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	offset := len(pngSignature)
+	for offset+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		end := offset + 12 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+		kind := string(data[offset+4 : offset+8])
+		body := data[offset+8 : offset+8+length]
+		if kind == "eXIf" || (kind == "iTXt" && bytes.HasPrefix(body, []byte("XML:com.adobe.xmp\x00"))) {
+			offset = end
+			continue
+		}
+		out = append(out, data[offset:end]...)
+		offset = end
+	}
+	return append(out, data[offset:]...)
+}
+
+// checkImageSize reads the dimensions declared in the header of an image and
+// rejects those above maxImagePixels before anything is decoded.
+func checkImageSize(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return errImageTooLarge
+	}
+	return nil
+}
+
+// thumbnail renders a JPEG of at most thumbnailSize pixels per side.
+func thumbnail(data []byte, contentType string) ([]byte, error) {
+	if err := checkImageSize(data); err != nil {
+		return nil, err
+	}
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "image/jpeg" {
+		source = orient(source, jpegOrientation(data))
+	}
+
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			width, height = thumbnailSize, max(1, height*thumbnailSize/width)
+		} else {
+			width, height = max(1, width*thumbnailSize/height), thumbnailSize
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, scale(source, width, height), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// scale resizes by averaging the source pixels covered by each target pixel,
+// transparent areas are flattened on white.
+func scale(source image.Image, width, height int) image.Image {
+	bounds := source.Bounds()
+	target := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := source.At(sx, sy).RGBA()
+					white := uint64(0xFFFF - ca)
+					r += uint64(cr) + white
+					g += uint64(cg) + white
+					b += uint64(cb) + white
+					n++
+				}
+			}
+			target.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: 0xFFFF})
+		}
+	}
+	return target
+}
+
+// orient applies the common EXIF orientations (rotations by 90°, 180° and 270°).
+func orient(source image.Image, orientation int) image.Image {
+	if orientation != 3 && orientation != 6 && orientation != 8 {
+		return source
+	}
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	var target *image.RGBA
+	if orientation == 3 {
+		target = image.NewRGBA(image.Rect(0, 0, width, height))
+	} else {
+		target = image.NewRGBA(image.Rect(0, 0, height, width))
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pixel := source.At(bounds.Min.X+x, bounds.Min.Y+y)
+			switch orientation {
+			case 3:
+				target.Set(width-1-x, height-1-y, pixel)
+			case 6:
+				target.Set(height-1-y, x, pixel)
+			case 8:
+				target.Set(y, width-1-x, pixel)
+			}
+		}
+	}
+	return target
+}
@@ -0,0 +1,55 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+// pngHeader returns a PNG declaring width×height pixels with no image data,
+// as a decompression bomb would.
+func pngHeader(width, height uint32) []byte {
+	chunk := func(kind string, body []byte) []byte {
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(body)))
+		out = append(out, kind...)
+		out = append(out, body...)
+		return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(append([]byte(kind), body...)))
+	}
+	header := binary.BigEndian.AppendUint32(nil, width)
+	header = binary.BigEndian.AppendUint32(header, height)
+	header = append(header, 8, 0, 0, 0, 0)
+	data := append([]byte{}, pngSignature...)
+	data = append(data, chunk("IHDR", header)...)
+	return append(data, chunk("IEND", nil)...)
+}
+
+func TestThumbnailRejectsHugeDimensions(t *testing.T) {
+	for _, size := range [][2]uint32{{50000, 50000}, {1 << 30, 1}, {8000, 7000}} {
+		_, err := thumbnail(pngHeader(size[0], size[1]), "image/png")
+		if !errors.Is(err, errImageTooLarge) {
+			t.Errorf("%d×%d: err = %v, want errImageTooLarge", size[0], size[1], err)
+		}
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	var source bytes.Buffer
+	if err := png.Encode(&source, image.NewRGBA(image.Rect(0, 0, 1024, 512))); err != nil {
+		t.Fatal(err)
+	}
+	preview, err := thumbnail(source.Bytes(), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(preview))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" || config.Width != thumbnailSize || config.Height != thumbnailSize/2 {
+		t.Errorf("thumbnail is a %d×%d %s, want a %d×%d jpeg", config.Width, config.Height, format, thumbnailSize, thumbnailSize/2)
+	}
+}
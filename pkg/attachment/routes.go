@@ -0,0 +1,25 @@
+package attachment
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Attachments:
+- GET /attachments/{id}
+- GET /attachments/{id}/thumbnail
+- DELETE /attachments/{id}
+
+Upload and listing are routed under /locations/{id}/attachments.
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	AttachmentConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Get("/{id}", AttachmentConfig.GetAttachmentHandler)
+	router.Get("/{id}/thumbnail", AttachmentConfig.GetAttachmentThumbnailHandler)
+	router.Delete("/{id}", AttachmentConfig.DeleteAttachmentHandler)
+	return router
+}
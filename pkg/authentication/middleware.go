@@ -2,8 +2,9 @@ package authentication
 
 import (
 	"context"
+	"locate-this/database/dbmodel"
 	"net/http"
-) 
+)
 
 func AuthMiddleware(secret string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -28,8 +29,27 @@ func AuthMiddleware(secret string) func(http.Handler) http.Handler {
 	}	
 }
 
+// QueryTokenMiddleware takes the token from the access_token query parameter
+// when the Authorization header is missing, for the EventSource and WebSocket
+// clients which cannot set headers. It must only be used on the event streams,
+// as tokens in URLs end up in logs and browser histories.
+func QueryTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("Authorization", token)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func GetUserFromContext(ctx context.Context) string {
 	id, _ := ctx.Value("id").(string)
 
 	return id
-}
\ No newline at end of file
+}
+
+// GetCurrentUser loads the user authenticated by AuthMiddleware.
+func GetCurrentUser(r *http.Request, users dbmodel.UserRepository) (*dbmodel.UserEntry, error) {
+	return users.FindByEmail(GetUserFromContext(r.Context()))
+}
@@ -0,0 +1,260 @@
+package checkin
+
+import (
+	"errors"
+	"fmt"
+	"locate-this/config"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/events"
+	"locate-this/pkg/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"gorm.io/gorm"
+)
+
+type CheckinConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *CheckinConfig {
+	return &CheckinConfig{configuration}
+}
+
+// @Summary		Check in at a location
+// @Description	Tell the members of a group that the caller is at a location shared in it, until they check out, check in somewhere else or the check-in expires. group_id is needed when the location is shared in several groups of the caller; the owner of a location shared in none of their groups checks in for themselves only.
+// @Tags			checkins
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int						true	"Location ID"
+// @Param			request	body		models.CheckinRequest	true	"Check-in data"
+// @Success		200		{object}	models.CheckinResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/checkins [post]
+func (config *CheckinConfig) PostCheckinHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.CheckinRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	location, caller, ok := config.visibleLocation(w, r)
+	if !ok {
+		return
+	}
+	groupID, ok := config.checkinGroup(w, r, location, caller, req.GroupID)
+	if !ok {
+		return
+	}
+
+	duration := config.Constants.CheckinDuration
+	if req.DurationMinutes > 0 {
+		duration = time.Duration(req.DurationMinutes) * time.Minute
+	}
+	now := time.Now()
+	checkinEntry := &dbmodel.CheckinEntry{
+		LocationID: location.ID,
+		UserID:     caller.ID,
+		GroupID:    groupID,
+		Note:       req.Note,
+		CreatedAt:  now,
+		EndsAt:     now.Add(duration),
+	}
+	res, err := config.CheckinRepository.Create(checkinEntry)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to check in"})
+		return
+	}
+	res.User = *caller
+
+	if groupID != nil {
+		config.EventHub.Publish(events.Event{Type: events.CheckedIn, GroupID: *groupID, LocationID: location.ID, UserID: caller.ID})
+	}
+
+	render.JSON(w, r, models.NewCheckinResponse(res, now))
+}
+
+// @Summary		Check out of a location
+// @Description	End the active check-in of the caller at a location
+// @Tags			checkins
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Location ID"
+// @Success		200	{object}	models.CheckinResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/checkins [delete]
+func (config *CheckinConfig) DeleteCheckinHandler(w http.ResponseWriter, r *http.Request) {
+	location, caller, ok := config.visibleLocation(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	res, err := config.CheckinRepository.CheckOut(location.ID, caller.ID, now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		render.JSON(w, r, map[string]string{"error": "You are not checked in at this location"})
+		return
+	}
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to check out"})
+		return
+	}
+	res.User = *caller
+
+	if res.GroupID != nil {
+		config.EventHub.Publish(events.Event{Type: events.CheckedOut, GroupID: *res.GroupID, LocationID: location.ID, UserID: caller.ID})
+	}
+
+	render.JSON(w, r, models.NewCheckinResponse(res, now))
+}
+
+// @Summary		Get the check-ins of a location
+// @Description	Retrieve the check-ins at a location visible to the caller, the latest first: their own and those made in their groups while the location is shared in them. active=true gives who is at the location now.
+// @Tags			checkins
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int		true	"Location ID"
+// @Param			active	query		bool	false	"Only the check-ins not ended yet"
+// @Param			from	query		string	false	"Start of the period (RFC 3339)"
+// @Param			to		query		string	false	"End of the period (RFC 3339)"
+// @Param			limit	query		int		false	"Maximum number of check-ins (default 100, at most 1000)"
+// @Success		200		{array}		models.CheckinResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/checkins [get]
+func (config *CheckinConfig) GetCheckinsHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	query, err := models.ParseCheckinQuery(r, now)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	location, caller, ok := config.visibleLocation(w, r)
+	if !ok {
+		return
+	}
+
+	checkins, err := config.CheckinRepository.FindCheckinsForLocation(location.ID, caller.ID, query)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve check-ins"})
+		return
+	}
+
+	checkinsResponse := make([]models.CheckinResponse, 0, len(checkins))
+	for i := range checkins {
+		checkinsResponse = append(checkinsResponse, models.NewCheckinResponse(&checkins[i], now))
+	}
+
+	render.JSON(w, r, checkinsResponse)
+}
+
+// @Summary		Get the visit statistics of a location
+// @Description	Count the check-ins at a location visible to the caller over a period: visitors, who is there now, mean duration, check-ins by day of the week and hour of the day, and the most frequent visitors
+// @Tags			checkins
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int		true	"Location ID"
+// @Param			from	query		string	false	"Start of the period (RFC 3339)"
+// @Param			to		query		string	false	"End of the period (RFC 3339)"
+// @Success		200		{object}	models.CheckinStatsResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/checkins/stats [get]
+func (config *CheckinConfig) GetCheckinStatsHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := models.ParseCheckinPeriod(r)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	location, caller, ok := config.visibleLocation(w, r)
+	if !ok {
+		return
+	}
+
+	checkins, err := config.CheckinRepository.FindCheckinsForLocation(location.ID, caller.ID, query)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve check-ins"})
+		return
+	}
+
+	render.JSON(w, r, models.NewCheckinStatsResponse(checkins, time.Now()))
+}
+
+// visibleLocation returns the location of the id parameter when the caller
+// owns it or belongs to a group it is shared in, rendering the error
+// otherwise.
+func (config *CheckinConfig) visibleLocation(w http.ResponseWriter, r *http.Request) (*dbmodel.LocationEntry, *dbmodel.UserEntry, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return nil, nil, false
+	}
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, nil, false
+	}
+	visible, err := config.LocationEntryRepository.IsVisibleTo(uint(id), caller.ID)
+	if err != nil || !visible {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return nil, nil, false
+	}
+	location, err := config.LocationEntryRepository.FindById(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return nil, nil, false
+	}
+	return location, caller, true
+}
+
+// checkinGroup returns the group a check-in is shared with: the requested
+// one, which must be a group of the caller the location is shared in, or the
+// only such group. It is nil for the owner of a location shared in none of
+// their groups.
+func (config *CheckinConfig) checkinGroup(w http.ResponseWriter, r *http.Request, location *dbmodel.LocationEntry, caller *dbmodel.UserEntry, requested *uint) (*uint, bool) {
+	groupIDs, err := config.LocationEntryRepository.FindGroupIDsForLocation(location.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve groups"})
+		return nil, false
+	}
+	var candidates []uint
+	for _, groupID := range groupIDs {
+		if requested != nil && groupID != *requested {
+			continue
+		}
+		member, err := config.GroupEntryRepository.IsMember(groupID, caller.ID)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve groups"})
+			return nil, false
+		}
+		if member {
+			candidates = append(candidates, groupID)
+		}
+	}
+
+	switch {
+	case len(candidates) == 1:
+		return &candidates[0], true
+	case requested != nil:
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return nil, false
+	case len(candidates) > 1:
+		render.JSON(w, r, map[string]string{"error": "group_id is required, the location is shared in several of your groups"})
+		return nil, false
+	case location.UserID == caller.ID:
+		return nil, true
+	}
+	render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+	return nil, false
+}
@@ -0,0 +1,502 @@
+package comment
+
+import (
+	"errors"
+	"fmt"
+	"locate-this/config"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/events"
+	"locate-this/pkg/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type CommentConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *CommentConfig {
+	return &CommentConfig{configuration}
+}
+
+// share is a location shared in a group, the anchor of comments and
+// reactions, as seen by a member of the group.
+type share struct {
+	groupID    uint
+	locationID uint
+	caller     *dbmodel.UserEntry
+}
+
+// @Summary		Get the comments on a shared location
+// @Description	Retrieve a page of the comments of the members of a group on a location shared in it: the comments starting a thread, or the replies to parent_id. Removed comments that still have replies are kept without their body.
+// @Tags			comments
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int		true	"Group ID"
+// @Param			locationID	path		int		true	"Location ID"
+// @Param			parent_id	query		int		false	"Comment whose replies are listed"
+// @Param			owner		query		int		false	"Only the comments of this author"
+// @Param			limit		query		int		false	"Page size"
+// @Param			after		query		string	false	"Cursor returned by the previous page"
+// @Param			sort		query		string	false	"Sort field, prefix with - for descending order"
+// @Param			order		query		string	false	"asc (oldest first) or desc"
+// @Success		200			{object}	models.PageResponse{data=[]models.CommentResponse}
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/group-location/{id}/locations/{locationID}/comments [get]
+func (config *CommentConfig) GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := models.ParseQueryOptions(r, dbmodel.CommentSortFields)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	var parentID *uint
+	if value := r.URL.Query().Get("parent_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			render.JSON(w, r, map[string]string{"error": "parent_id must be >= 1"})
+			return
+		}
+		parentID = new(uint)
+		*parentID = uint(id)
+	}
+
+	share, ok := config.memberShare(w, r)
+	if !ok {
+		return
+	}
+	if parentID != nil {
+		if _, ok := config.shareComment(w, r, share, *parentID); !ok {
+			return
+		}
+	}
+
+	comments, page, err := config.CommentRepository.FindComments(share.groupID, share.locationID, parentID, options)
+	if errors.Is(err, dbmodel.ErrInvalidCursor) {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve comments"})
+		return
+	}
+
+	commentsResponse, err := config.commentResponses(share, comments)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve comments"})
+		return
+	}
+
+	render.JSON(w, r, models.NewPageResponse(commentsResponse, page))
+}
+
+// @Summary		Comment on a shared location
+// @Description	Post a comment on a location shared in a group of the caller, or a reply to a comment with parent_id. The members following the group receive a comment.created event.
+// @Tags			comments
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int						true	"Group ID"
+// @Param			locationID	path		int						true	"Location ID"
+// @Param			request		body		models.CommentRequest	true	"Comment"
+// @Success		200			{object}	models.CommentResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/group-location/{id}/locations/{locationID}/comments [post]
+func (config *CommentConfig) PostCommentHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.CommentRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	share, ok := config.memberShare(w, r)
+	if !ok {
+		return
+	}
+	if req.ParentID != nil {
+		parent, ok := config.shareComment(w, r, share, *req.ParentID)
+		if !ok {
+			return
+		}
+		if parent.RemovedAt != nil {
+			render.JSON(w, r, map[string]string{"error": "A removed comment cannot be answered"})
+			return
+		}
+	}
+
+	commentEntry := &dbmodel.CommentEntry{
+		GroupID:    share.groupID,
+		LocationID: share.locationID,
+		ParentID:   req.ParentID,
+		UserID:     share.caller.ID,
+		Body:       req.Body,
+	}
+	res, err := config.CommentRepository.Create(commentEntry)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to create comment"})
+		return
+	}
+	config.EventHub.Publish(events.Event{Type: events.CommentCreated, GroupID: share.groupID, LocationID: share.locationID, UserID: share.caller.ID, CommentID: res.ID})
+
+	render.JSON(w, r, models.NewCommentResponse(res, 0, nil))
+}
+
+// @Summary		Edit a comment
+// @Description	Change the body of a comment, allowed to its author
+// @Tags			comments
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int						true	"Group ID"
+// @Param			locationID	path		int						true	"Location ID"
+// @Param			commentID	path		int						true	"Comment ID"
+// @Param			request		body		models.CommentRequest	true	"Comment"
+// @Success		200			{object}	models.CommentResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/group-location/{id}/locations/{locationID}/comments/{commentID} [put]
+func (config *CommentConfig) PutCommentHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.CommentRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	share, comment, ok := config.commentParam(w, r)
+	if !ok {
+		return
+	}
+	if comment.UserID != share.caller.ID {
+		render.JSON(w, r, map[string]string{"error": "Only the author of the comment can edit it"})
+		return
+	}
+	if comment.RemovedAt != nil {
+		render.JSON(w, r, map[string]string{"error": "A removed comment cannot be edited"})
+		return
+	}
+
+	res, err := config.CommentRepository.UpdateBody(comment.ID, req.Body, time.Now())
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to update comment"})
+		return
+	}
+	config.EventHub.Publish(events.Event{Type: events.CommentUpdated, GroupID: share.groupID, LocationID: share.locationID, UserID: share.caller.ID, CommentID: res.ID})
+
+	responses, err := config.commentResponses(share, []dbmodel.CommentEntry{*res})
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve comment"})
+		return
+	}
+	render.JSON(w, r, responses[0])
+}
+
+// @Summary		Delete a comment
+// @Description	Remove a comment, allowed to its author and, to moderate the discussion, to the group admin. A comment with replies stays in the thread without its body.
+// @Tags			comments
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int	true	"Group ID"
+// @Param			locationID	path		int	true	"Location ID"
+// @Param			commentID	path		int	true	"Comment ID"
+// @Success		200			{object}	map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/group-location/{id}/locations/{locationID}/comments/{commentID} [delete]
+func (config *CommentConfig) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	share, comment, ok := config.commentParam(w, r)
+	if !ok {
+		return
+	}
+	if comment.RemovedAt != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve comment"})
+		return
+	}
+	if comment.UserID != share.caller.ID {
+		group, err := config.GroupEntryRepository.FindById(share.groupID)
+		if err != nil || group.AdminID != share.caller.ID {
+			render.JSON(w, r, map[string]string{"error": "Only the author of the comment or the group admin can do this"})
+			return
+		}
+	}
+
+	if err := config.CommentRepository.Remove(comment.ID, share.caller.ID, time.Now()); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to delete comment"})
+		return
+	}
+	config.EventHub.Publish(events.Event{Type: events.CommentDeleted, GroupID: share.groupID, LocationID: share.locationID, UserID: share.caller.ID, CommentID: comment.ID})
+
+	render.JSON(w, r, map[string]string{"message": "Comment deleted successfully"})
+}
+
+// @Summary		Get the reactions to a shared location
+// @Description	Count the emojis the members of a group put on a location shared in it
+// @Tags			comments
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int	true	"Group ID"
+// @Param			locationID	path		int	true	"Location ID"
+// @Success		200			{array}		models.ReactionResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/group-location/{id}/locations/{locationID}/reactions [get]
+func (config *CommentConfig) GetReactionsHandler(w http.ResponseWriter, r *http.Request) {
+	share, ok := config.memberShare(w, r)
+	if !ok {
+		return
+	}
+
+	config.renderReactions(w, r, share, 0)
+}
+
+// @Summary		React to a shared location
+// @Description	Put an emoji on a location shared in a group of the caller
+// @Tags			comments
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int						true	"Group ID"
+// @Param			locationID	path		int						true	"Location ID"
+// @Param			request		body		models.ReactionRequest	true	"Emoji"
+// @Success		200			{array}		models.ReactionResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/group-location/{id}/locations/{locationID}/reactions [post]
+func (config *CommentConfig) PostReactionHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.ReactionRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	share, ok := config.memberShare(w, r)
+	if !ok {
+		return
+	}
+
+	config.addReaction(w, r, share, 0, req.Emoji)
+}
+
+// @Summary		Remove a reaction to a shared location
+// @Description	Take back an emoji the caller put on a shared location
+// @Tags			comments
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int		true	"Group ID"
+// @Param			locationID	path		int		true	"Location ID"
+// @Param			emoji		path		string	true	"Emoji"
+// @Success		200			{array}		models.ReactionResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/group-location/{id}/locations/{locationID}/reactions/{emoji} [delete]
+func (config *CommentConfig) DeleteReactionHandler(w http.ResponseWriter, r *http.Request) {
+	share, ok := config.memberShare(w, r)
+	if !ok {
+		return
+	}
+
+	config.removeReaction(w, r, share, 0, chi.URLParam(r, "emoji"))
+}
+
+// @Summary		React to a comment
+// @Description	Put an emoji on a comment about a shared location
+// @Tags			comments
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int						true	"Group ID"
+// @Param			locationID	path		int						true	"Location ID"
+// @Param			commentID	path		int						true	"Comment ID"
+// @Param			request		body		models.ReactionRequest	true	"Emoji"
+// @Success		200			{array}		models.ReactionResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/group-location/{id}/locations/{locationID}/comments/{commentID}/reactions [post]
+func (config *CommentConfig) PostCommentReactionHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.ReactionRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	share, comment, ok := config.commentParam(w, r)
+	if !ok {
+		return
+	}
+	if comment.RemovedAt != nil {
+		render.JSON(w, r, map[string]string{"error": "A removed comment cannot get reactions"})
+		return
+	}
+
+	config.addReaction(w, r, share, comment.ID, req.Emoji)
+}
+
+// @Summary		Remove a reaction to a comment
+// @Description	Take back an emoji the caller put on a comment
+// @Tags			comments
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int		true	"Group ID"
+// @Param			locationID	path		int		true	"Location ID"
+// @Param			commentID	path		int		true	"Comment ID"
+// @Param			emoji		path		string	true	"Emoji"
+// @Success		200			{array}		models.ReactionResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/group-location/{id}/locations/{locationID}/comments/{commentID}/reactions/{emoji} [delete]
+func (config *CommentConfig) DeleteCommentReactionHandler(w http.ResponseWriter, r *http.Request) {
+	share, comment, ok := config.commentParam(w, r)
+	if !ok {
+		return
+	}
+
+	config.removeReaction(w, r, share, comment.ID, chi.URLParam(r, "emoji"))
+}
+
+// memberShare returns the shared location of the id and locationID
+// parameters when the caller belongs to the group, rendering the error
+// otherwise.
+func (config *CommentConfig) memberShare(w http.ResponseWriter, r *http.Request) (share, bool) {
+	groupID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	locationID, err := strconv.Atoi(chi.URLParam(r, "locationID"))
+	if err != nil {
+		fmt.Println("Error during locationID convertion")
+	}
+	if groupID < 1 || locationID < 1 {
+		render.JSON(w, r, map[string]string{"error": "id and locationID must be >= 1"})
+		return share{}, false
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return share{}, false
+	}
+	member, err := config.GroupEntryRepository.IsMember(uint(groupID), caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return share{}, false
+	}
+	shared, err := config.GroupLocationEntryRepository.IsShared(uint(groupID), uint(locationID))
+	if err != nil || !shared {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return share{}, false
+	}
+	return share{groupID: uint(groupID), locationID: uint(locationID), caller: caller}, true
+}
+
+// shareComment returns a comment about the shared location, rendering the
+// error otherwise.
+func (config *CommentConfig) shareComment(w http.ResponseWriter, r *http.Request, share share, id uint) (*dbmodel.CommentEntry, bool) {
+	comment, err := config.CommentRepository.FindById(id)
+	if err != nil || comment.GroupID != share.groupID || comment.LocationID != share.locationID {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve comment"})
+		return nil, false
+	}
+	return comment, true
+}
+
+// commentParam returns the shared location and the comment of the URL
+// parameters, rendering the error otherwise.
+func (config *CommentConfig) commentParam(w http.ResponseWriter, r *http.Request) (share, *dbmodel.CommentEntry, bool) {
+	commentID, err := strconv.Atoi(chi.URLParam(r, "commentID"))
+	if err != nil {
+		fmt.Println("Error during commentID convertion")
+	}
+	if commentID < 1 {
+		render.JSON(w, r, map[string]string{"error": "commentID must be >= 1"})
+		return share{}, nil, false
+	}
+	share, ok := config.memberShare(w, r)
+	if !ok {
+		return share, nil, false
+	}
+	comment, ok := config.shareComment(w, r, share, uint(commentID))
+	if !ok {
+		return share, nil, false
+	}
+	return share, comment, true
+}
+
+// commentResponses adds their reply counts and reactions to the comments.
+func (config *CommentConfig) commentResponses(share share, comments []dbmodel.CommentEntry) ([]models.CommentResponse, error) {
+	ids := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	replies, err := config.CommentRepository.CountReplies(ids)
+	if err != nil {
+		return nil, err
+	}
+	reactions := map[uint][]models.ReactionResponse{}
+	if len(ids) > 0 {
+		entries, err := config.CommentRepository.FindReactions(share.groupID, share.locationID, ids)
+		if err != nil {
+			return nil, err
+		}
+		reactions = models.NewReactionResponses(entries, share.caller.ID)
+	}
+
+	responses := make([]models.CommentResponse, 0, len(comments))
+	for i := range comments {
+		responses = append(responses, models.NewCommentResponse(&comments[i], replies[comments[i].ID], reactions[comments[i].ID]))
+	}
+	return responses, nil
+}
+
+func (config *CommentConfig) addReaction(w http.ResponseWriter, r *http.Request, share share, commentID uint, emoji string) {
+	reactionEntry := &dbmodel.ReactionEntry{
+		GroupID:    share.groupID,
+		LocationID: share.locationID,
+		CommentID:  commentID,
+		UserID:     share.caller.ID,
+		Emoji:      emoji,
+	}
+	if err := config.CommentRepository.AddReaction(reactionEntry); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to add reaction"})
+		return
+	}
+
+	config.renderReactions(w, r, share, commentID)
+}
+
+func (config *CommentConfig) removeReaction(w http.ResponseWriter, r *http.Request, share share, commentID uint, emoji string) {
+	reactionEntry := &dbmodel.ReactionEntry{
+		GroupID:    share.groupID,
+		LocationID: share.locationID,
+		CommentID:  commentID,
+		UserID:     share.caller.ID,
+		Emoji:      emoji,
+	}
+	removed, err := config.CommentRepository.RemoveReaction(reactionEntry)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to remove reaction"})
+		return
+	}
+	if !removed {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve reaction"})
+		return
+	}
+
+	config.renderReactions(w, r, share, commentID)
+}
+
+// renderReactions renders the reactions to the shared location, for
+// commentID 0, or to one of its comments.
+func (config *CommentConfig) renderReactions(w http.ResponseWriter, r *http.Request, share share, commentID uint) {
+	reactions, err := config.CommentRepository.FindReactions(share.groupID, share.locationID, []uint{commentID})
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve reactions"})
+		return
+	}
+	responses := models.NewReactionResponses(reactions, share.caller.ID)[commentID]
+	if responses == nil {
+		responses = make([]models.ReactionResponse, 0)
+	}
+
+	render.JSON(w, r, responses)
+}
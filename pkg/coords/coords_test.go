@@ -0,0 +1,507 @@
+package coords
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// near reports whether two positions are less than tolerance degrees apart.
+func near(latitude, longitude, wantLatitude, wantLongitude, tolerance float64) bool {
+	return math.Abs(latitude-wantLatitude) <= tolerance && math.Abs(longitude-wantLongitude) <= tolerance
+}
+
+// within reports whether two positions are less than the given meters apart,
+// 180° and -180° being the same meridian.
+func within(latitude, longitude, wantLatitude, wantLongitude, meters float64) bool {
+	east := (math.Mod(longitude-wantLongitude+540, 360) - 180) * math.Cos(wantLatitude*math.Pi/180)
+	return math.Hypot(latitude-wantLatitude, east)*111320 <= meters
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		ok   bool
+	}{
+		{"48.8584", 48.8584, true},
+		{"48,8584", 48.8584, true},
+		{" -0,5 ", -0.5, true},
+		{"2", 2, true},
+		{"1e3", 1000, true},
+		{"1,234.5", 0, false},
+		{"1,2,3", 0, false},
+		{"", 0, false},
+		{"north", 0, false},
+	}
+	for _, test := range tests {
+		got, err := ParseNumber(test.text)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("ParseNumber(%q) = %v, %v, want %v (ok %v)", test.text, got, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestParsePair(t *testing.T) {
+	tests := []struct {
+		text                string
+		latitude, longitude float64
+	}{
+		{"48.8584, 2.2945", 48.8584, 2.2945},
+		{"48,8584; 2,2945", 48.8584, 2.2945},
+		{"48,8584 2,2945", 48.8584, 2.2945},
+		{"-33.8568/151.2153", -33.8568, 151.2153},
+		{`48°51'30.24"N 2°17'40.20"E`, 48.8584, 2.2945},
+		{`2°17'40.20"E 48°51'30.24"N`, 48.8584, 2.2945},
+		{"N 48°51.504' E 2°17.67'", 48.8584, 2.2945},
+		{"48 51 30,24 N, 2 17 40,2 E", 48.8584, 2.2945},
+		{`33°51'24.48"S 151°12'55.08"E`, -33.8568, 151.2153},
+		{"90, 180", 90, 180},
+		{"-90 -180", -90, -180},
+		{`0°00'00.00"N 180°00'00.00"W`, 0, -180},
+	}
+	for _, test := range tests {
+		latitude, longitude, err := ParsePair(test.text)
+		if err != nil || !near(latitude, longitude, test.latitude, test.longitude, 1e-7) {
+			t.Errorf("ParsePair(%q) = %v, %v, %v, want %v, %v", test.text, latitude, longitude, err, test.latitude, test.longitude)
+		}
+	}
+
+	for _, text := range []string{
+		"",
+		"48.8584",
+		"91, 2",
+		"48, 181",
+		"-48N 2E",
+		`48°61'N 2°E`,
+		`48.5°30'N 2°E`,
+		"48 N 2 N",
+		"1, 2, 3",
+		"north, east",
+	} {
+		if latitude, longitude, err := ParsePair(text); err == nil {
+			t.Errorf("ParsePair(%q) = %v, %v, want an error", text, latitude, longitude)
+		}
+	}
+}
+
+func TestEncodeDMS(t *testing.T) {
+	tests := []struct {
+		latitude, longitude float64
+		want                string
+	}{
+		{48.8584, 2.2945, `48°51'30.24"N 2°17'40.20"E`},
+		{-33.8568, 151.2153, `33°51'24.48"S 151°12'55.08"E`},
+		{40.6892, -74.0445, `40°41'21.12"N 74°02'40.20"W`},
+		{0, 0, `0°00'00.00"N 0°00'00.00"E`},
+		{90, 180, `90°00'00.00"N 180°00'00.00"E`},
+		{-90, -180, `90°00'00.00"S 180°00'00.00"W`},
+		// 59.9999 seconds carry over to the minute
+		{10.9999999, 0, `11°00'00.00"N 0°00'00.00"E`},
+	}
+	for _, test := range tests {
+		got := EncodeDMS(test.latitude, test.longitude)
+		if got != test.want {
+			t.Errorf("EncodeDMS(%v, %v) = %s, want %s", test.latitude, test.longitude, got, test.want)
+		}
+		latitude, longitude, err := ParsePair(got)
+		if err != nil || !near(latitude, longitude, test.latitude, test.longitude, 0.01/3600) {
+			t.Errorf("ParsePair(%s) = %v, %v, %v", got, latitude, longitude, err)
+		}
+	}
+}
+
+// The UTM references are those of the Krüger series on WGS84 that published
+// converters use, which agree to the millimeter.
+func TestUTM(t *testing.T) {
+	tests := []struct {
+		name                string
+		latitude, longitude float64
+		want                string
+	}{
+		{"origin", 0, 0, "31N 166021 0"},
+		{"eiffel tower", 48.85837, 2.294481, "31U 448251 5411952"},
+		{"statue of liberty", 40.689247, -74.044502, "18T 580736 4504700"},
+		{"sydney opera house", -33.856784, 151.215297, "56H 334900 6252291"},
+		{"antimeridian east", 0, 180, "60N 833979 0"},
+		{"antimeridian west", 0, -180, "1N 166021 0"},
+		{"northern limit", 84, 0, "31X 465005 9329005"},
+		{"southern limit", -80, 0, "31C 441868 1116915"},
+		// southwestern Norway is in zone 32 from 3°E
+		{"bergen", 60.39299, 5.32415, "32V 297477 6700830"},
+		{"norway west of 3°E", 60, 2.9, "31V 494422 6651415"},
+		{"norway band edge", 56, 3, "32V 126050 6222336"},
+		{"below the norway band", 55.99, 3, "31U 500000 6204967"},
+		// Svalbard has zones 31, 33, 35 and 37 only
+		{"svalbard zone 31", 78, 8.9, "31X 636717 8665262"},
+		{"svalbard zone 33", 78.22, 15.65, "33X 514814 8683004"},
+		{"svalbard zone 35", 78, 21, "35X 360974 8665497"},
+		{"svalbard zone 37", 80, 41.9, "37X 556196 8882987"},
+		{"east of svalbard", 80, 42, "38X 441868 8883085"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			utm, err := ToUTM(test.latitude, test.longitude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := utm.String(); got != test.want {
+				t.Errorf("ToUTM = %s, want %s", got, test.want)
+			}
+			latitude, longitude := utm.LatLng()
+			if !within(latitude, longitude, test.latitude, test.longitude, 0.001) {
+				t.Errorf("LatLng = %v, %v, want %v, %v", latitude, longitude, test.latitude, test.longitude)
+			}
+			parsed, err := ParseUTM(test.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the written position is rounded to the meter
+			if latitude, longitude := parsed.LatLng(); !within(latitude, longitude, test.latitude, test.longitude, 1) {
+				t.Errorf("ParseUTM(%s) = %v, %v, want %v, %v", test.want, latitude, longitude, test.latitude, test.longitude)
+			}
+		})
+	}
+}
+
+func TestUTMErrors(t *testing.T) {
+	for _, position := range [][2]float64{{84.0001, 0}, {90, 0}, {-80.0001, 0}, {-90, 0}, {0, 180.5}, {0, -181}} {
+		if utm, err := ToUTM(position[0], position[1]); err == nil {
+			t.Errorf("ToUTM(%v) = %s, want an error", position, utm)
+		}
+		if _, ok := Encode(FormatUTM, position[0], position[1]); ok {
+			t.Errorf("Encode(utm, %v) succeeded", position)
+		}
+	}
+
+	for _, text := range []string{
+		"",
+		"31U",
+		"31U 448251",
+		"0U 448251 5411932",
+		"61U 448251 5411932",
+		"31I 448251 5411932",
+		"31U 99999 5411932",
+		"31U 900001 5411932",
+		"31U 448251 10000001",
+		// N is the band, not the hemisphere
+		"31N 448251 5411932",
+		"31S 448251 5411932",
+	} {
+		if utm, err := ParseUTM(text); err == nil {
+			t.Errorf("ParseUTM(%q) = %s, want an error", text, utm)
+		}
+	}
+}
+
+func TestMGRS(t *testing.T) {
+	tests := []struct {
+		name                string
+		latitude, longitude float64
+		want                string
+	}{
+		{"origin", 0, 0, "31N AA 66021 00000"},
+		{"eiffel tower", 48.85837, 2.294481, "31U DQ 48250 11951"},
+		{"statue of liberty", 40.689247, -74.044502, "18T WL 80735 04700"},
+		{"sydney opera house", -33.856784, 151.215297, "56H LH 34900 52290"},
+		{"bergen", 60.39299, 5.32415, "32V KN 97477 00830"},
+		{"svalbard", 78.22, 15.65, "33X WG 14813 83004"},
+		{"antimeridian", 0, 180, "60N ZF 33978 00000"},
+		{"southern limit", -80, 0, "31C DM 41867 16915"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mgrs, err := ToMGRS(test.latitude, test.longitude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := mgrs.String(); got != test.want {
+				t.Errorf("ToMGRS = %s, want %s", got, test.want)
+			}
+			for _, text := range []string{test.want, strings.ReplaceAll(test.want, " ", ""), strings.ToLower(test.want)} {
+				parsed, err := ParseMGRS(text)
+				if err != nil {
+					t.Fatalf("ParseMGRS(%q): %v", text, err)
+				}
+				// the center of the meter square
+				if latitude, longitude := parsed.LatLng(); !within(latitude, longitude, test.latitude, test.longitude, 1) {
+					t.Errorf("ParseMGRS(%q) = %v, %v, want %v, %v", text, latitude, longitude, test.latitude, test.longitude)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMGRSPrecision(t *testing.T) {
+	tests := []struct {
+		text                string
+		easting, northing   float64
+		latitude, tolerance float64
+	}{
+		{"31U DQ 48250 11951", 448250.5, 5411951.5, 48.85837, 1e-5},
+		{"31U DQ 4825 1195", 448255, 5411955, 48.85837, 1e-4},
+		{"31U DQ 48 11", 448500, 5411500, 48.85837, 1e-2},
+		{"31U DQ 4 1", 445000, 5415000, 48.85837, 0.1},
+		{"31U DQ", 450000, 5450000, 48.85837, 1},
+	}
+	for _, test := range tests {
+		mgrs, err := ParseMGRS(test.text)
+		if err != nil {
+			t.Fatalf("ParseMGRS(%q): %v", test.text, err)
+		}
+		if mgrs.UTM.Easting != test.easting || mgrs.UTM.Northing != test.northing {
+			t.Errorf("ParseMGRS(%q) = %v %v, want %v %v", test.text, mgrs.UTM.Easting, mgrs.UTM.Northing, test.easting, test.northing)
+		}
+		if latitude, _ := mgrs.LatLng(); math.Abs(latitude-test.latitude) > test.tolerance {
+			t.Errorf("ParseMGRS(%q) latitude = %v, want %v", test.text, latitude, test.latitude)
+		}
+	}
+
+	for _, text := range []string{
+		"",
+		"31U DQ 4825 119",
+		"31U DQ 482511 119321",
+		"61U DQ 48251 11932",
+		"31U SQ 48251 11932",
+		"31U DW 48251 11932",
+		"31I DQ 48251 11932",
+		// the square is not in the band
+		"31N DQ 48251 11932",
+	} {
+		if mgrs, err := ParseMGRS(text); err == nil {
+			t.Errorf("ParseMGRS(%q) = %s, want an error", text, mgrs)
+		}
+	}
+	if _, err := ToMGRS(85, 0); err == nil || !strings.HasPrefix(err.Error(), "MGRS") {
+		t.Errorf("ToMGRS at 85°N: err = %v, want an MGRS error", err)
+	}
+}
+
+func TestGeohash(t *testing.T) {
+	tests := []struct {
+		hash                string
+		latitude, longitude float64
+	}{
+		{"ezs42", 42.605, -5.603},
+		{"u4pruydqqvj", 57.64911, 10.40744},
+		{"u09tunq", 48.85837, 2.294481},
+		{"r3gx2ux9g", -33.856784, 151.215297},
+		{"7zzzzzzzz", -0.0000001, -0.0000001},
+		{"s00000000", 0, 0},
+		{"upbpbpbpb", 90, 0},
+		{"h00000000", -90, 0},
+		{"xbpbpbpbp", 0, 179.99999},
+		{"800000000", 0, -180},
+		{"zzzzzzzzzzzz", 90, 180},
+	}
+	for _, test := range tests {
+		if got := EncodeGeohash(test.latitude, test.longitude, len(test.hash)); got != test.hash {
+			t.Errorf("EncodeGeohash(%v, %v, %d) = %s, want %s", test.latitude, test.longitude, len(test.hash), got, test.hash)
+		}
+		latitude, longitude, err := DecodeGeohash(test.hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		height, width := geohashCellSize(len(test.hash))
+		if math.Abs(latitude-test.latitude) > height/2 || math.Abs(longitude-test.longitude) > width/2 {
+			t.Errorf("DecodeGeohash(%s) = %v, %v, want within the cell of %v, %v", test.hash, latitude, longitude, test.latitude, test.longitude)
+		}
+		if upper, _, err := DecodeGeohash(strings.ToUpper(test.hash)); err != nil || upper != latitude {
+			t.Errorf("DecodeGeohash(%s) = %v, %v", strings.ToUpper(test.hash), upper, err)
+		}
+	}
+
+	for _, hash := range []string{"", "u09tunquc1234", "u09a", "u09i", "u09l", "u09o", "u09 t"} {
+		if _, _, err := DecodeGeohash(hash); err == nil {
+			t.Errorf("DecodeGeohash(%q) did not fail", hash)
+		}
+	}
+}
+
+func TestGeohashCells(t *testing.T) {
+	// every position of the box has one of the cells as a prefix
+	south, west, north, east := 45.70, 4.75, 45.82, 4.92
+	cells := GeohashCells(south, west, north, east, 16)
+	if len(cells) > 16 {
+		t.Fatalf("%d cells, want at most 16", len(cells))
+	}
+	for latitude := south; latitude <= north; latitude += 0.01 {
+		for longitude := west; longitude <= east; longitude += 0.01 {
+			hash := EncodeGeohash(latitude, longitude, GeohashPrecision)
+			covered := false
+			for _, cell := range cells {
+				covered = covered || strings.HasPrefix(hash, cell)
+			}
+			if !covered {
+				t.Fatalf("%v, %v (%s) is not covered by %v", latitude, longitude, hash, cells)
+			}
+		}
+	}
+
+	// the ranges cover the same cells
+	var expanded []string
+	for _, r := range GeohashRanges(south, west, north, east, 16) {
+		for cell := r[0]; ; cell = nextGeohash(cell) {
+			expanded = append(expanded, cell)
+			if cell == r[1] {
+				break
+			}
+		}
+	}
+	if len(expanded) != len(cells) {
+		t.Errorf("ranges cover %d cells, want %d", len(expanded), len(cells))
+	}
+
+	if cells := GeohashCells(-90, -180, 90, 180, 32); len(cells) != 32 {
+		t.Errorf("the world is covered by %d cells, want 32", len(cells))
+	}
+	if next := nextGeohash("zz"); next != "" {
+		t.Errorf("nextGeohash(zz) = %q, want none", next)
+	}
+	if next := nextGeohash("u0z"); next != "u10" {
+		t.Errorf("nextGeohash(u0z) = %q, want u10", next)
+	}
+}
+
+func TestPlusCode(t *testing.T) {
+	tests := []struct {
+		code                string
+		latitude, longitude float64
+	}{
+		{"8FVC9G8F+6X", 47.365590, 8.524997},
+		{"7FG49QCJ+2V", 20.3700625, 2.7821875},
+		{"8FVC2222+22", 47.0000625, 8.0000625},
+		{"4VCPPQGP+Q9", -41.2730625, 174.7859375},
+		{"8FW4V75V+8Q", 48.85837, 2.294481},
+		{"4RRH46V8+74", -33.856784, 151.215297},
+		{"22222222+22", -89.9999375, -179.9999375},
+		{"6FG22222+22", 0.0000625, 0.0000625},
+		// the north pole belongs to the last cell
+		{"CFX2X2X2+X2", 90, 0},
+		// 180° is -180°
+		{"62G22222+22", 0.0000625, 180.0000625},
+		{"6VGXXXXX+XX", 0.9999375, 179.9999375},
+	}
+	for _, test := range tests {
+		if got := EncodePlusCode(test.latitude, test.longitude); got != test.code {
+			t.Errorf("EncodePlusCode(%v, %v) = %s, want %s", test.latitude, test.longitude, got, test.code)
+		}
+		latitude, longitude, err := DecodePlusCode(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantLongitude := math.Mod(test.longitude+540, 360) - 180
+		if !near(latitude, longitude, math.Min(test.latitude, 90-0.000125/2), wantLongitude, 0.000125/2+1e-9) {
+			t.Errorf("DecodePlusCode(%s) = %v, %v, want %v, %v", test.code, latitude, longitude, test.latitude, wantLongitude)
+		}
+	}
+}
+
+func TestDecodePlusCode(t *testing.T) {
+	tests := []struct {
+		code                string
+		latitude, longitude float64
+	}{
+		{"7FG49Q00+", 20.375, 2.775},
+		{"7FG40000+", 20.5, 2.5},
+		{"62G20000+", 0.5, -179.5},
+		{"22220000+", -89.5, -179.5},
+		// the 11th digit divides the cell in 4 by 5
+		{"6FH32222+222", 1.0000125, 1.000015625},
+		{"8fvc9g8f+6x", 47.3655625, 8.5249375},
+	}
+	for _, test := range tests {
+		latitude, longitude, err := DecodePlusCode(test.code)
+		if err != nil || !near(latitude, longitude, test.latitude, test.longitude, 1e-9) {
+			t.Errorf("DecodePlusCode(%s) = %v, %v, %v, want %v, %v", test.code, latitude, longitude, err, test.latitude, test.longitude)
+		}
+	}
+
+	for _, code := range []string{
+		"",
+		"8FVC9G8F6X",
+		"9G8F+6X",
+		"8FVC9G8F++6X",
+		"8FVC9G8F+6",
+		"8FVC9G80+",
+		"8FV00000+00",
+		"8FVC9G8F+6XAB",
+		"8FVC9G8F+6X222222",
+		"D2222222+22",
+		"2X222222+22",
+	} {
+		if latitude, longitude, err := DecodePlusCode(code); err == nil {
+			t.Errorf("DecodePlusCode(%q) = %v, %v, want an error", code, latitude, longitude)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text                string
+		latitude, longitude float64
+		tolerance           float64
+	}{
+		{"48.85837, 2.294481", 48.85837, 2.294481, 0},
+		{"48,85837; 2,294481", 48.85837, 2.294481, 0},
+		{`48°51'30.13"N 2°17'40.13"E`, 48.85837, 2.294481, 0.00001},
+		{"8FW4V75V+8Q", 48.85837, 2.294481, 0.000125},
+		{"u09tunq", 48.85837, 2.294481, 0.0007},
+		{"31U 448251 5411952", 48.85837, 2.294481, 0.00002},
+		{"31u 448251mE 5411952mN", 48.85837, 2.294481, 0.00002},
+		{"31U DQ 48250 11951", 48.85837, 2.294481, 0.00002},
+		{"31UDQ4825011951", 48.85837, 2.294481, 0.00002},
+	}
+	for _, test := range tests {
+		latitude, longitude, err := Parse(test.text)
+		if err != nil || !near(latitude, longitude, test.latitude, test.longitude, test.tolerance+1e-9) {
+			t.Errorf("Parse(%q) = %v, %v, %v, want %v, %v", test.text, latitude, longitude, err, test.latitude, test.longitude)
+		}
+	}
+
+	for _, text := range []string{"", "8FW4V75V+8Q+", "u09tunqucu09tu", "31U 448251", "somewhere"} {
+		if latitude, longitude, err := Parse(text); err == nil {
+			t.Errorf("Parse(%q) = %v, %v, want an error", text, latitude, longitude)
+		}
+	}
+}
+
+// TestEncodeRoundTrip writes positions all over the globe in every format and
+// reads them back.
+func TestEncodeRoundTrip(t *testing.T) {
+	// in meters, half the diagonal of the cell each format writes
+	tolerances := map[Format]float64{
+		FormatDMS:      0.5,
+		FormatUTM:      1,
+		FormatMGRS:     1,
+		FormatGeohash:  3.5,
+		FormatPlusCode: 10,
+	}
+	for latitude := -89.5; latitude <= 89.5; latitude += 7.3 {
+		for longitude := -179.9; longitude <= 180; longitude += 11.7 {
+			for _, format := range Formats {
+				text, ok := Encode(format, latitude, longitude)
+				if !ok {
+					if (format == FormatUTM || format == FormatMGRS) && (latitude < -80 || latitude > 84) {
+						continue
+					}
+					t.Fatalf("Encode(%s, %v, %v) failed", format, latitude, longitude)
+				}
+				parsedLatitude, parsedLongitude, err := Parse(text)
+				if err != nil || !within(parsedLatitude, parsedLongitude, latitude, longitude, tolerances[format]) {
+					t.Errorf("Parse(Encode(%s, %v, %v) = %q) = %v, %v, %v", format, latitude, longitude, text, parsedLatitude, parsedLongitude, err)
+				}
+			}
+		}
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	formats, err := ParseFormats(" DMS, olc,,geohash ")
+	if err != nil || len(formats) != 3 || formats[0] != FormatDMS || formats[1] != FormatPlusCode || formats[2] != FormatGeohash {
+		t.Errorf("ParseFormats = %v, %v", formats, err)
+	}
+	if _, err := ParseFormats("dms,w3w"); err == nil {
+		t.Error("ParseFormats accepted an unknown format")
+	}
+}
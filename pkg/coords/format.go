@@ -0,0 +1,147 @@
+package coords
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Format is a notation of coordinates that positions can be written in.
+type Format string
+
+const (
+	FormatDMS      Format = "dms"
+	FormatUTM      Format = "utm"
+	FormatMGRS     Format = "mgrs"
+	FormatGeohash  Format = "geohash"
+	FormatPlusCode Format = "pluscode"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{FormatDMS, FormatUTM, FormatMGRS, FormatGeohash, FormatPlusCode}
+
+var geohashPattern = regexp.MustCompile(`^[0-9b-hjkmnp-z]{1,12}$`)
+
+// ParseFormats reads a comma separated list of formats, such as "dms,utm".
+// "olc" is accepted for Plus Codes.
+func ParseFormats(text string) ([]Format, error) {
+	var formats []Format
+	for _, name := range strings.Split(text, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "olc" {
+			name = string(FormatPlusCode)
+		}
+		if !isFormat(Format(name)) {
+			return nil, fmt.Errorf("formats must be among %s", joinFormats(Formats))
+		}
+		formats = append(formats, Format(name))
+	}
+	return formats, nil
+}
+
+func isFormat(format Format) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func joinFormats(formats []Format) string {
+	names := make([]string, len(formats))
+	for i, format := range formats {
+		names[i] = string(format)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Encode writes a position in a format. The second result is false when the
+// position cannot be written in it, UTM and MGRS excluding the polar regions.
+func Encode(format Format, latitude, longitude float64) (string, bool) {
+	switch format {
+	case FormatDMS:
+		return EncodeDMS(latitude, longitude), true
+	case FormatUTM:
+		utm, err := ToUTM(latitude, longitude)
+		if err != nil {
+			return "", false
+		}
+		return utm.String(), true
+	case FormatMGRS:
+		mgrs, err := ToMGRS(latitude, longitude)
+		if err != nil {
+			return "", false
+		}
+		return mgrs.String(), true
+	case FormatGeohash:
+		return EncodeGeohash(latitude, longitude, GeohashPrecision), true
+	case FormatPlusCode:
+		return EncodePlusCode(latitude, longitude), true
+	}
+	return "", false
+}
+
+// Parse reads a position written in any supported notation, telling them
+// apart by their shape:
+//   - a Plus Code contains a + (8FW4V75V+8Q)
+//   - a geohash is a single lowercase word of the geohash alphabet (u09tunq)
+//   - MGRS and UTM start with a zone and a band (31U DQ 48251 11932,
+//     31UDQ4825111932, 31U 448251 5411932)
+//   - otherwise, a latitude and a longitude in decimal degrees or degrees,
+//     minutes and seconds, as read by ParsePair
+func Parse(text string) (latitude, longitude float64, err error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.ContainsRune(text, plusCodeSeparator):
+		if latitude, longitude, err = DecodePlusCode(text); err != nil {
+			return 0, 0, err
+		}
+	case geohashPattern.MatchString(text):
+		if latitude, longitude, err = DecodeGeohash(text); err != nil {
+			return 0, 0, err
+		}
+	case utmPattern.MatchString(strings.ToUpper(text)):
+		utm, err := ParseUTM(text)
+		if err != nil {
+			return 0, 0, err
+		}
+		latitude, longitude = utm.LatLng()
+	case mgrsPattern.MatchString(strings.Join(strings.Fields(strings.ToUpper(text)), "")):
+		mgrs, err := ParseMGRS(text)
+		if err != nil {
+			return 0, 0, err
+		}
+		latitude, longitude = mgrs.LatLng()
+	default:
+		return ParsePair(text)
+	}
+	// 1e-7 degree is about a centimeter, below the precision of the codes
+	return math.Round(latitude*1e7) / 1e7, math.Round(longitude*1e7) / 1e7, nil
+}
+
+// EncodeDMS writes a position in degrees, minutes and seconds, such as
+// 48°51'30.24"N 2°17'40.20"E, which ParsePair reads back.
+func EncodeDMS(latitude, longitude float64) string {
+	north, east := 'N', 'E'
+	if latitude < 0 {
+		north = 'S'
+	}
+	if longitude < 0 {
+		east = 'W'
+	}
+	return formatDMS(math.Abs(latitude), north) + " " + formatDMS(math.Abs(longitude), east)
+}
+
+func formatDMS(value float64, hemisphere rune) string {
+	// hundredths of a second, rounded once so that 59.999 carries over
+	hundredths := int64(math.Round(value * 3600 * 100))
+	degrees := hundredths / (3600 * 100)
+	minutes := hundredths / (60 * 100) % 60
+	seconds := float64(hundredths%(60*100)) / 100
+	return fmt.Sprintf("%d°%02d'%05.2f\"%c", degrees, minutes, seconds, hemisphere)
+}
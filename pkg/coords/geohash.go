@@ -0,0 +1,167 @@
+package coords
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashPrecision is the length of the geohashes written by Encode, a cell
+// of about 5 meters.
+const GeohashPrecision = 9
+
+// EncodeGeohash returns the geohash of a position with precision characters,
+// between 1 and 12.
+func EncodeGeohash(latitude, longitude float64, precision int) string {
+	minLatitude, maxLatitude := -90.0, 90.0
+	minLongitude, maxLongitude := -180.0, 180.0
+	hash := make([]byte, 0, precision)
+	// bits alternate between the longitude and the latitude, 5 per character
+	index, bit, even := 0, 0, true
+	for len(hash) < precision {
+		if even {
+			middle := (minLongitude + maxLongitude) / 2
+			if longitude >= middle {
+				index = index*2 + 1
+				minLongitude = middle
+			} else {
+				index *= 2
+				maxLongitude = middle
+			}
+		} else {
+			middle := (minLatitude + maxLatitude) / 2
+			if latitude >= middle {
+				index = index*2 + 1
+				minLatitude = middle
+			} else {
+				index *= 2
+				maxLatitude = middle
+			}
+		}
+		even = !even
+		if bit++; bit == 5 {
+			hash = append(hash, geohashAlphabet[index])
+			index, bit = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+// DecodeGeohash returns the center of the cell of a geohash.
+func DecodeGeohash(hash string) (latitude, longitude float64, err error) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if hash == "" || len(hash) > 12 {
+		return 0, 0, errors.New("geohash must have between 1 and 12 characters")
+	}
+	minLatitude, maxLatitude := -90.0, 90.0
+	minLongitude, maxLongitude := -180.0, 180.0
+	even := true
+	for i := 0; i < len(hash); i++ {
+		index := strings.IndexByte(geohashAlphabet, hash[i])
+		if index < 0 {
+			return 0, 0, errors.New("geohash must only contain the characters 0-9 and b-z except i, l and o")
+		}
+		for shift := 4; shift >= 0; shift-- {
+			set := index>>shift&1 == 1
+			if even {
+				middle := (minLongitude + maxLongitude) / 2
+				if set {
+					minLongitude = middle
+				} else {
+					maxLongitude = middle
+				}
+			} else {
+				middle := (minLatitude + maxLatitude) / 2
+				if set {
+					minLatitude = middle
+				} else {
+					maxLatitude = middle
+				}
+			}
+			even = !even
+		}
+	}
+	return (minLatitude + maxLatitude) / 2, (minLongitude + maxLongitude) / 2, nil
+}
+
+// GeohashCells returns the geohashes of the cells covering a box that does
+// not cross the antimeridian, with the longest precision needing at most
+// maxCells of them. Every position in the box has one of them as a prefix.
+func GeohashCells(south, west, north, east float64, maxCells int) []string {
+	precision := 1
+	for precision < 12 && geohashCellCount(south, west, north, east, precision+1) <= maxCells {
+		precision++
+	}
+	height, width := geohashCellSize(precision)
+	firstRow, lastRow := geohashCellIndex(south, -90, height), geohashCellIndex(north, -90, height)
+	firstColumn, lastColumn := geohashCellIndex(west, -180, width), geohashCellIndex(east, -180, width)
+	cells := make([]string, 0, (lastRow-firstRow+1)*(lastColumn-firstColumn+1))
+	for row := firstRow; row <= lastRow; row++ {
+		for column := firstColumn; column <= lastColumn; column++ {
+			// the center of the cell, away from its edges
+			latitude := -90 + (float64(row)+0.5)*height
+			longitude := -180 + (float64(column)+0.5)*width
+			cells = append(cells, EncodeGeohash(latitude, longitude, precision))
+		}
+	}
+	return cells
+}
+
+func geohashCellCount(south, west, north, east float64, precision int) int {
+	height, width := geohashCellSize(precision)
+	rows := geohashCellIndex(north, -90, height) - geohashCellIndex(south, -90, height) + 1
+	columns := geohashCellIndex(east, -180, width) - geohashCellIndex(west, -180, width) + 1
+	return rows * columns
+}
+
+// geohashCellSize returns the height and width in degrees of the cells of a
+// precision, whose 5 bits per character alternate from the longitude.
+func geohashCellSize(precision int) (height, width float64) {
+	bits := 5 * precision
+	return 180 / math.Pow(2, float64(bits/2)), 360 / math.Pow(2, float64(bits-bits/2))
+}
+
+// geohashCellIndex returns the index of the cell containing a coordinate,
+// the last cell containing the upper limit.
+func geohashCellIndex(value, origin, size float64) int {
+	index := int(math.Floor((value - origin) / size))
+	if last := int(math.Round((-2*origin)/size)) - 1; index > last {
+		index = last
+	}
+	return index
+}
+
+// GeohashRanges returns the cells of GeohashCells as ranges of their first
+// and last geohashes, merging the cells following each other in the geohash
+// order, so that a row of cells makes a single range of an index.
+func GeohashRanges(south, west, north, east float64, maxCells int) [][2]string {
+	cells := GeohashCells(south, west, north, east, maxCells)
+	sort.Strings(cells)
+	var ranges [][2]string
+	for _, cell := range cells {
+		if last := len(ranges) - 1; last >= 0 && nextGeohash(ranges[last][1]) == cell {
+			ranges[last][1] = cell
+			continue
+		}
+		ranges = append(ranges, [2]string{cell, cell})
+	}
+	return ranges
+}
+
+// nextGeohash returns the geohash of the same length following a geohash,
+// or "" after the last one.
+func nextGeohash(hash string) string {
+	next := []byte(hash)
+	for i := len(next) - 1; i >= 0; i-- {
+		index := strings.IndexByte(geohashAlphabet, next[i])
+		if index < len(geohashAlphabet)-1 {
+			next[i] = geohashAlphabet[index+1]
+			return string(next)
+		}
+		next[i] = geohashAlphabet[0]
+	}
+	return ""
+}
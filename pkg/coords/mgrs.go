@@ -0,0 +1,111 @@
+package coords
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The 100 km squares of MGRS are named by a column letter, from a set of 8
+// repeating every 3 zones, and a row letter, from a set of 20 shifted by 5 in
+// the even zones.
+var mgrsColumns = [3]string{"STUVWXYZ", "ABCDEFGH", "JKLMNPQR"}
+
+const mgrsRows = "ABCDEFGHJKLMNPQRSTUV"
+
+var mgrsPattern = regexp.MustCompile(`^(\d{1,2})([C-HJ-NP-X])([A-HJ-NP-Z])([A-HJ-NP-V])(\d*)$`)
+
+// MGRS is a position in the Military Grid Reference System, a UTM position
+// where the 100 km square is named by two letters.
+type MGRS struct {
+	UTM UTM
+}
+
+// ToMGRS converts a position to MGRS, which shares the limits of UTM.
+func ToMGRS(latitude, longitude float64) (MGRS, error) {
+	utm, err := ToUTM(latitude, longitude)
+	if err != nil {
+		return MGRS{}, errors.New("MGRS" + strings.TrimPrefix(err.Error(), "UTM"))
+	}
+	return MGRS{UTM: utm}, nil
+}
+
+// String writes the reference as "31U DQ 48251 11932", the meter square
+// containing the position.
+func (mgrs MGRS) String() string {
+	// a centimeter of tolerance below the meter lines, since decimal degrees
+	// rounded to 1e-7 may fall just before them
+	easting := int(math.Floor(mgrs.UTM.Easting + 0.01))
+	northing := int(math.Floor(mgrs.UTM.Northing + 0.01))
+	zone := mgrs.UTM.Zone
+	row := northing / 100000 % 20
+	if zone%2 == 0 {
+		row = (row + 5) % 20
+	}
+	return fmt.Sprintf("%d%c %c%c %05d %05d", zone, mgrs.UTM.Band,
+		mgrsColumns[zone%3][easting/100000-1], mgrsRows[row],
+		easting%100000, northing%100000)
+}
+
+// LatLng returns the latitude and longitude of the position.
+func (mgrs MGRS) LatLng() (latitude, longitude float64) {
+	return mgrs.UTM.LatLng()
+}
+
+// ParseMGRS reads an MGRS reference such as "31U DQ 48251 11932" or
+// "31UDQ4825111932", with 0 to 5 digits for the easting and northing within
+// the 100 km square. The position is the center of the designated square.
+func ParseMGRS(text string) (MGRS, error) {
+	compact := strings.Join(strings.Fields(strings.ToUpper(text)), "")
+	match := mgrsPattern.FindStringSubmatch(compact)
+	if match == nil || len(match[5])%2 != 0 || len(match[5]) > 10 {
+		return MGRS{}, errors.New("MGRS coordinates must be written as 31U DQ 48251 11932")
+	}
+	zone, _ := strconv.Atoi(match[1])
+	if zone < 1 || zone > 60 {
+		return MGRS{}, errors.New("MGRS zone must be between 1 and 60")
+	}
+	band := match[2][0]
+
+	column := strings.IndexByte(mgrsColumns[zone%3], match[3][0])
+	row := strings.IndexByte(mgrsRows, match[4][0])
+	if column < 0 {
+		return MGRS{}, fmt.Errorf("MGRS column %s is not used in zone %d", match[3], zone)
+	}
+	if zone%2 == 0 {
+		row = (row + 15) % 20
+	}
+
+	digits := match[5]
+	precision := len(digits) / 2
+	scale := math.Pow10(5 - precision)
+	easting, northing := scale/2, scale/2
+	if precision > 0 {
+		value, _ := strconv.Atoi(digits[:precision])
+		easting += float64(value) * scale
+		value, _ = strconv.Atoi(digits[precision:])
+		northing += float64(value) * scale
+	}
+	easting += float64(column+1) * 100000
+	northing += float64(row) * 100000
+
+	// the row letters repeat every 2000 km, the band tells which cycle
+	bandBottom := float64(strings.IndexByte(utmBands, band)*8 - 80)
+	bottom, err := ToUTM(bandBottom, float64((zone-1)*6-180+3))
+	if err != nil {
+		return MGRS{}, err
+	}
+	minimum := math.Floor(bottom.Northing/100000) * 100000
+	for northing < minimum {
+		northing += 2000000
+	}
+
+	utm := UTM{Zone: zone, Band: band, Easting: easting, Northing: northing}
+	if err := utm.validate(); err != nil {
+		return MGRS{}, errors.New("MGRS" + strings.TrimPrefix(err.Error(), "UTM"))
+	}
+	return MGRS{UTM: utm}, nil
+}
@@ -0,0 +1,186 @@
+// Package coords reads and writes the notations of geographic coordinates.
+package coords
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseNumber reads a decimal number written with a decimal point or a
+// decimal comma, as spreadsheets do in many locales.
+func ParseNumber(text string) (float64, error) {
+	text = strings.TrimSpace(text)
+	if !strings.Contains(text, ".") && strings.Count(text, ",") == 1 {
+		text = strings.Replace(text, ",", ".", 1)
+	}
+	return strconv.ParseFloat(text, 64)
+}
+
+// ParseLatitude reads a latitude in decimal degrees (48.8584, 48,8584, -33.9),
+// in degrees, minutes and seconds (48°51'30.2"N, 48 51 30.2 N) or in degrees
+// and decimal minutes (N 48°51.503').
+func ParseLatitude(text string) (float64, error) {
+	value, hemisphere, err := parseAngle(text)
+	if err != nil {
+		return 0, errors.New("latitude: " + err.Error())
+	}
+	switch hemisphere {
+	case 0, 'N':
+	case 'S':
+		value = -value
+	default:
+		return 0, errors.New("latitude must be in the N or S hemisphere")
+	}
+	if value < -90 || value > 90 {
+		return 0, errors.New("latitude must be between -90 and 90")
+	}
+	return value, nil
+}
+
+// ParseLongitude reads a longitude in the notations of ParseLatitude, with the
+// E and W hemispheres.
+func ParseLongitude(text string) (float64, error) {
+	value, hemisphere, err := parseAngle(text)
+	if err != nil {
+		return 0, errors.New("longitude: " + err.Error())
+	}
+	switch hemisphere {
+	case 0, 'E':
+	case 'W':
+		value = -value
+	default:
+		return 0, errors.New("longitude must be in the E or W hemisphere")
+	}
+	if value < -180 || value > 180 {
+		return 0, errors.New("longitude must be between -180 and 180")
+	}
+	return value, nil
+}
+
+// ParsePair reads a latitude and a longitude written together, such as
+// "48.8584, 2.2945", "48,8584; 2,2945" or 48°51'30"N 2°17'40"E. With
+// hemispheres, the coordinates may be given in any order.
+func ParsePair(text string) (latitude, longitude float64, err error) {
+	upper := strings.ToUpper(strings.TrimSpace(text))
+	first, second, ok := splitHemispheres(upper)
+	if !ok {
+		first, second, ok = splitDecimals(upper)
+	}
+	if !ok {
+		return 0, 0, errors.New("coordinates must be a latitude and a longitude")
+	}
+	if strings.ContainsAny(first, "EW") || strings.ContainsAny(second, "NS") {
+		first, second = second, first
+	}
+	if latitude, err = ParseLatitude(first); err != nil {
+		return 0, 0, err
+	}
+	if longitude, err = ParseLongitude(second); err != nil {
+		return 0, 0, err
+	}
+	return latitude, longitude, nil
+}
+
+// splitHemispheres splits a pair at its hemisphere letters, which are either
+// all prefixes (N 48°51' E 2°17') or all suffixes (48°51'N 2°17'E).
+func splitHemispheres(text string) (string, string, bool) {
+	var letters []int
+	for i, r := range text {
+		if strings.ContainsRune("NSEW", r) {
+			letters = append(letters, i)
+		}
+	}
+	if len(letters) != 2 {
+		return "", "", false
+	}
+	split := letters[1]
+	if letters[0] != 0 {
+		split = letters[0] + 1
+	}
+	return trimSeparators(text[:split]), trimSeparators(text[split:]), true
+}
+
+// splitDecimals splits a pair of decimal numbers separated by a semicolon, a
+// slash, spaces or a comma.
+func splitDecimals(text string) (string, string, bool) {
+	for _, separator := range []string{";", "/"} {
+		if parts := strings.Split(text, separator); len(parts) == 2 {
+			return parts[0], parts[1], true
+		}
+	}
+	var fields []string
+	for _, field := range strings.Fields(text) {
+		if field = trimSeparators(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 2 {
+		return fields[0], fields[1], true
+	}
+	if len(fields) == 1 && strings.Count(fields[0], ",") == 1 {
+		parts := strings.Split(fields[0], ",")
+		return parts[0], parts[1], true
+	}
+	return "", "", false
+}
+
+func trimSeparators(text string) string {
+	return strings.Trim(text, " \t,;")
+}
+
+// parseAngle reads an angle in decimal degrees or in degrees with minutes and
+// seconds, and its hemisphere letter when it has one.
+func parseAngle(text string) (float64, byte, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	var hemisphere byte
+	if text != "" && strings.ContainsRune("NSEW", rune(text[0])) {
+		hemisphere = text[0]
+		text = text[1:]
+	} else if text != "" && strings.ContainsRune("NSEW", rune(text[len(text)-1])) {
+		hemisphere = text[len(text)-1]
+		text = text[:len(text)-1]
+	}
+
+	text = strings.NewReplacer("°", " ", "º", " ", "′", " ", "″", " ", "''", " ", "'", " ", `"`, " ", ":", " ").Replace(text)
+	fields := strings.Fields(text)
+	if len(fields) == 0 || len(fields) > 3 {
+		return 0, 0, errors.New("invalid coordinate")
+	}
+
+	negative := strings.HasPrefix(fields[0], "-")
+	if negative && hemisphere != 0 {
+		return 0, 0, errors.New("a coordinate cannot have both a sign and a hemisphere")
+	}
+	var value float64
+	for i, field := range fields {
+		part, err := ParseNumber(strings.TrimPrefix(field, "-"))
+		if err != nil || part < 0 {
+			return 0, 0, errors.New("invalid coordinate")
+		}
+		if i > 0 && part >= 60 {
+			return 0, 0, errors.New("minutes and seconds must be less than 60")
+		}
+		if i < len(fields)-1 && part != float64(int(part)) {
+			return 0, 0, errors.New("only the last part of a coordinate can have decimals")
+		}
+		switch i {
+		case 0:
+			value = part
+		case 1:
+			value += part / 60
+		case 2:
+			value += part / 3600
+		}
+	}
+	if len(fields) > 1 {
+		// 1e-7 degree is about a centimeter, it hides the rounding errors
+		// of the conversion.
+		value = math.Round(value*1e7) / 1e7
+	}
+	if negative {
+		value = -value
+	}
+	return value, hemisphere, nil
+}
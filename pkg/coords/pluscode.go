@@ -0,0 +1,106 @@
+package coords
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Open Location Code (Plus Code) alphabet, without vowels and easily
+// confused characters.
+const (
+	plusCodeAlphabet  = "23456789CFGHJMPQRVWX"
+	plusCodeSeparator = '+'
+	plusCodePadding   = '0'
+	// position of the separator in a full code
+	plusCodeSeparatorPosition = 8
+	// digits of the pairs section, which encodes latitude and longitude in
+	// turn with cells of 20°, 1°, 1/20°, 1/400° and 1/8000°
+	plusCodePairLength = 10
+	// grid digits refine the cell 5 times in latitude, 4 in longitude
+	plusCodeGridRows    = 5
+	plusCodeGridColumns = 4
+	plusCodeMaxLength   = 15
+)
+
+// EncodePlusCode returns the full Plus Code of a position, such as
+// "8FW4V75V+8Q", whose cell is about 14 meters wide.
+func EncodePlusCode(latitude, longitude float64) string {
+	latitude = math.Max(-90, math.Min(90, latitude))
+	longitude = math.Mod(longitude+180, 360)
+	if longitude < 0 {
+		longitude += 360
+	}
+	// integer cells of 1/8000 degree, rounded first so that the decimals of
+	// a position such as 48.85 do not fall in the previous cell
+	latitudeCell := int64(math.Floor(math.Round((latitude+90)*8000*1e6) / 1e6))
+	longitudeCell := int64(math.Floor(math.Round(longitude*8000*1e6) / 1e6))
+	// the north pole belongs to the last cell
+	if latitudeCell >= 180*8000 {
+		latitudeCell = 180*8000 - 1
+	}
+	if longitudeCell >= 360*8000 {
+		longitudeCell -= 360 * 8000
+	}
+
+	code := make([]byte, plusCodePairLength)
+	for i := plusCodePairLength/2 - 1; i >= 0; i-- {
+		code[2*i] = plusCodeAlphabet[latitudeCell%20]
+		code[2*i+1] = plusCodeAlphabet[longitudeCell%20]
+		latitudeCell /= 20
+		longitudeCell /= 20
+	}
+	return string(code[:plusCodeSeparatorPosition]) + string(plusCodeSeparator) + string(code[plusCodeSeparatorPosition:])
+}
+
+// DecodePlusCode returns the center of the cell of a full Plus Code. Short
+// codes, which need a nearby reference position, are not supported.
+func DecodePlusCode(code string) (latitude, longitude float64, err error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	separator := strings.IndexByte(code, plusCodeSeparator)
+	if separator < 0 || strings.Count(code, string(plusCodeSeparator)) != 1 {
+		return 0, 0, errors.New("plus code must contain one +")
+	}
+	if separator != plusCodeSeparatorPosition {
+		return 0, 0, errors.New("plus code must be a full code such as 8FW4V75V+8Q, short codes are not supported")
+	}
+	// padded codes such as 8FW40000+ designate a larger cell
+	digits := code[:separator] + code[separator+1:]
+	if padding := strings.IndexByte(digits, plusCodePadding); padding >= 0 {
+		if padding == 0 || padding%2 != 0 || strings.Trim(digits[padding:], string(plusCodePadding)) != "" {
+			return 0, 0, errors.New("plus code has an invalid padding")
+		}
+		digits = digits[:padding]
+	}
+	if len(digits) < 2 || len(digits) > plusCodeMaxLength || len(digits) == plusCodeSeparatorPosition+1 {
+		return 0, 0, errors.New("plus code has an invalid length")
+	}
+
+	south, west := -90.0, -180.0
+	latitudeSize, longitudeSize := 400.0, 400.0
+	for i := 0; i < len(digits); i++ {
+		value := strings.IndexByte(plusCodeAlphabet, digits[i])
+		if value < 0 {
+			return 0, 0, errors.New("plus code must only contain the characters " + plusCodeAlphabet)
+		}
+		switch {
+		case i < plusCodePairLength && i%2 == 0:
+			latitudeSize /= 20
+			south += float64(value) * latitudeSize
+		case i < plusCodePairLength:
+			longitudeSize /= 20
+			west += float64(value) * longitudeSize
+		default:
+			latitudeSize /= plusCodeGridRows
+			longitudeSize /= plusCodeGridColumns
+			south += float64(value/plusCodeGridColumns) * latitudeSize
+			west += float64(value%plusCodeGridColumns) * longitudeSize
+		}
+	}
+	if south >= 90 || west >= 180 {
+		return 0, 0, errors.New("plus code is outside of the world")
+	}
+	latitude = math.Min(south+latitudeSize/2, 90)
+	longitude = west + longitudeSize/2
+	return latitude, longitude, nil
+}
@@ -0,0 +1,196 @@
+package coords
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WGS 84 ellipsoid and UTM projection constants.
+const (
+	wgs84Radius     = 6378137.0
+	wgs84Flattening = 1 / 298.257223563
+	utmScale        = 0.9996
+	utmFalseEasting = 500000.0
+	// false northing of the southern hemisphere
+	utmFalseNorthing = 10000000.0
+)
+
+// utmBands are the latitude bands of 8 degrees from 80°S, the last one, X,
+// spanning 12 degrees up to 84°N.
+const utmBands = "CDEFGHJKLMNPQRSTUVWX"
+
+var utmPattern = regexp.MustCompile(`^(\d{1,2})\s*([C-HJ-NP-X])\s+(\d+(?:\.\d+)?)\s*(?:M?E)?\s+(\d+(?:\.\d+)?)\s*(?:M?N)?$`)
+
+// UTM is a position in the Universal Transverse Mercator system. Band is the
+// latitude band letter, which also tells the hemisphere.
+type UTM struct {
+	Zone     int
+	Band     byte
+	Easting  float64
+	Northing float64
+}
+
+// String writes the position as "31U 448251 5411932", to the meter.
+func (utm UTM) String() string {
+	return fmt.Sprintf("%d%c %d %d", utm.Zone, utm.Band, int(math.Round(utm.Easting)), int(math.Round(utm.Northing)))
+}
+
+// transverse Mercator series of Krüger, to the third order of the third
+// flattening, precise to less than a millimeter within a zone.
+var (
+	utmEccentricity = math.Sqrt(wgs84Flattening * (2 - wgs84Flattening))
+	utmN            = wgs84Flattening / (2 - wgs84Flattening)
+	// radius of the rectifying sphere
+	utmA     = wgs84Radius / (1 + utmN) * (1 + utmN*utmN/4 + utmN*utmN*utmN*utmN/64)
+	utmAlpha = [3]float64{
+		utmN/2 - 2*utmN*utmN/3 + 5*utmN*utmN*utmN/16,
+		13*utmN*utmN/48 - 3*utmN*utmN*utmN/5,
+		61 * utmN * utmN * utmN / 240,
+	}
+	utmBeta = [3]float64{
+		utmN/2 - 2*utmN*utmN/3 + 37*utmN*utmN*utmN/96,
+		utmN*utmN/48 + utmN*utmN*utmN/15,
+		17 * utmN * utmN * utmN / 480,
+	}
+)
+
+// ToUTM converts a position to UTM. UTM is only defined between 80°S and
+// 84°N, the polar regions using another system.
+func ToUTM(latitude, longitude float64) (UTM, error) {
+	if latitude < -80 || latitude > 84 {
+		return UTM{}, errors.New("UTM is only defined between 80°S and 84°N")
+	}
+	if longitude < -180 || longitude > 180 {
+		return UTM{}, errors.New("longitude must be between -180 and 180")
+	}
+	band := utmBand(latitude)
+	zone := int(math.Floor((longitude+180)/6)) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	// exceptions of southwestern Norway and Svalbard
+	if band == 'V' && zone == 31 && longitude >= 3 {
+		zone = 32
+	}
+	if band == 'X' && longitude >= 0 && longitude < 42 {
+		zone = 2*int(math.Floor((longitude+3)/12)) + 31
+	}
+
+	centralMeridian := float64((zone-1)*6-180+3) * math.Pi / 180
+	phi := latitude * math.Pi / 180
+	lambda := longitude*math.Pi/180 - centralMeridian
+
+	tau := math.Tan(phi)
+	sigma := math.Sinh(utmEccentricity * math.Atanh(utmEccentricity*tau/math.Sqrt(1+tau*tau)))
+	conformalTau := tau*math.Sqrt(1+sigma*sigma) - sigma*math.Sqrt(1+tau*tau)
+	xiPrime := math.Atan2(conformalTau, math.Cos(lambda))
+	etaPrime := math.Asinh(math.Sin(lambda) / math.Sqrt(conformalTau*conformalTau+math.Cos(lambda)*math.Cos(lambda)))
+
+	xi, eta := xiPrime, etaPrime
+	for j, alpha := range utmAlpha {
+		k := float64(2 * (j + 1))
+		xi += alpha * math.Sin(k*xiPrime) * math.Cosh(k*etaPrime)
+		eta += alpha * math.Cos(k*xiPrime) * math.Sinh(k*etaPrime)
+	}
+
+	northing := utmScale * utmA * xi
+	if latitude < 0 {
+		northing += utmFalseNorthing
+	}
+	return UTM{Zone: zone, Band: band, Easting: utmScale*utmA*eta + utmFalseEasting, Northing: northing}, nil
+}
+
+// LatLng converts the position back to a latitude and a longitude.
+func (utm UTM) LatLng() (latitude, longitude float64) {
+	northing := utm.Northing
+	if utm.Band < 'N' {
+		northing -= utmFalseNorthing
+	}
+	eta := (utm.Easting - utmFalseEasting) / (utmScale * utmA)
+	xi := northing / (utmScale * utmA)
+
+	xiPrime, etaPrime := xi, eta
+	for j, beta := range utmBeta {
+		k := float64(2 * (j + 1))
+		xiPrime -= beta * math.Sin(k*xi) * math.Cosh(k*eta)
+		etaPrime -= beta * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+
+	sinhEta, sinXi, cosXi := math.Sinh(etaPrime), math.Sin(xiPrime), math.Cos(xiPrime)
+	conformalTau := sinXi / math.Sqrt(sinhEta*sinhEta+cosXi*cosXi)
+	// Newton's method on the conformal latitude
+	e2 := utmEccentricity * utmEccentricity
+	tau := conformalTau
+	for i := 0; i < 10; i++ {
+		sigma := math.Sinh(utmEccentricity * math.Atanh(utmEccentricity*tau/math.Sqrt(1+tau*tau)))
+		tauPrime := tau*math.Sqrt(1+sigma*sigma) - sigma*math.Sqrt(1+tau*tau)
+		delta := (conformalTau - tauPrime) / math.Sqrt(1+tauPrime*tauPrime) *
+			(1 + (1-e2)*tau*tau) / ((1 - e2) * math.Sqrt(1+tau*tau))
+		tau += delta
+		if math.Abs(delta) < 1e-12 {
+			break
+		}
+	}
+
+	centralMeridian := float64((utm.Zone-1)*6 - 180 + 3)
+	latitude = math.Atan(tau) * 180 / math.Pi
+	longitude = math.Atan2(sinhEta, cosXi)*180/math.Pi + centralMeridian
+	if longitude > 180 {
+		longitude -= 360
+	} else if longitude < -180 {
+		longitude += 360
+	}
+	return latitude, longitude
+}
+
+// ParseUTM reads a UTM position written as "31U 448251 5411932", the easting
+// and northing being optionally followed by E and N. The letter after the
+// zone is the latitude band, as in MGRS, not the hemisphere.
+func ParseUTM(text string) (UTM, error) {
+	match := utmPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(text)))
+	if match == nil {
+		return UTM{}, errors.New("UTM coordinates must be written as 31U 448251 5411932")
+	}
+	zone, _ := strconv.Atoi(match[1])
+	easting, _ := strconv.ParseFloat(match[3], 64)
+	northing, _ := strconv.ParseFloat(match[4], 64)
+	utm := UTM{Zone: zone, Band: match[2][0], Easting: easting, Northing: northing}
+	if err := utm.validate(); err != nil {
+		return UTM{}, err
+	}
+	return utm, nil
+}
+
+func (utm UTM) validate() error {
+	if utm.Zone < 1 || utm.Zone > 60 {
+		return errors.New("UTM zone must be between 1 and 60")
+	}
+	// zones are 668 km wide at most, at the equator
+	if utm.Easting < 100000 || utm.Easting > 900000 {
+		return errors.New("UTM easting must be between 100000 and 900000")
+	}
+	if utm.Northing < 0 || utm.Northing > utmFalseNorthing {
+		return errors.New("UTM northing must be between 0 and 10000000")
+	}
+	latitude, _ := utm.LatLng()
+	// about a meter of tolerance at the band edges
+	nearEdge := math.Abs(latitude-math.Round(latitude/8)*8) < 1e-5
+	if band := utmBand(latitude); band != utm.Band && !nearEdge {
+		return fmt.Errorf("UTM northing is outside of the latitude band %c (the letter after the zone is the band, not the hemisphere)", utm.Band)
+	}
+	return nil
+}
+
+func utmBand(latitude float64) byte {
+	index := int(math.Floor(latitude/8 + 10))
+	if index < 0 {
+		index = 0
+	} else if index >= len(utmBands) {
+		index = len(utmBands) - 1
+	}
+	return utmBands[index]
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	LocationShared   = "location.shared"
+	LocationUpdated  = "location.updated"
+	LocationUnshared = "location.unshared"
+	LocationDeleted  = "location.deleted"
+	MemberJoined     = "member.joined"
+	MemberLeft       = "member.left"
+	GeofenceEntered  = "geofence.entered"
+	GeofenceExited   = "geofence.exited"
+	CheckedIn        = "member.checked_in"
+	CheckedOut       = "member.checked_out"
+	CommentCreated   = "comment.created"
+	CommentUpdated   = "comment.updated"
+	CommentDeleted   = "comment.deleted"
+	// Reset tells a reconnecting client that the events it missed are no
+	// longer available and that it must reload the group.
+	Reset = "reset"
+)
+
+const (
+	// historySize is the number of events kept per group for replay.
+	historySize = 256
+	// bufferSize is the number of events a subscriber can fall behind before
+	// it is disconnected.
+	bufferSize = 64
+)
+
+type Event struct {
+	ID         uint64    `json:"id"`
+	Type       string    `json:"type"`
+	GroupID    uint      `json:"group_id"`
+	LocationID uint      `json:"location_id,omitempty"`
+	UserID     uint      `json:"user_id,omitempty"`
+	GeofenceID uint      `json:"geofence_id,omitempty"`
+	CommentID  uint      `json:"comment_id,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// Hub dispatches the events of each group to its subscribers and keeps the
+// latest ones so that clients can catch up after a reconnection.
+type Hub struct {
+	mutex       sync.Mutex
+	lastID      uint64
+	history     map[uint][]Event
+	trimmed     map[uint]uint64
+	subscribers map[uint]map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	hub     *Hub
+	groupID uint
+	events  chan Event
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		history:     make(map[uint][]Event),
+		trimmed:     make(map[uint]uint64),
+		subscribers: make(map[uint]map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event to the subscribers of its group. A subscriber whose
+// buffer is full is disconnected rather than slowing down the publisher, it
+// gets the missed events back when it reconnects with its last event ID.
+func (hub *Hub) Publish(event Event) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	hub.lastID++
+	event.ID = hub.lastID
+	event.Time = time.Now()
+
+	history := append(hub.history[event.GroupID], event)
+	if len(history) > historySize {
+		hub.trimmed[event.GroupID] = history[len(history)-historySize-1].ID
+		history = history[len(history)-historySize:]
+	}
+	hub.history[event.GroupID] = history
+
+	for subscription := range hub.subscribers[event.GroupID] {
+		select {
+		case subscription.events <- event:
+		default:
+			hub.remove(subscription)
+		}
+	}
+}
+
+// Subscribe registers a subscriber to a group. The events published after
+// lastEventID are returned to be sent first, preceded by a Reset event when
+// some of them are no longer kept.
+func (hub *Hub) Subscribe(groupID uint, lastEventID uint64) (*Subscription, []Event) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	subscription := &Subscription{hub: hub, groupID: groupID, events: make(chan Event, bufferSize)}
+	if hub.subscribers[groupID] == nil {
+		hub.subscribers[groupID] = make(map[*Subscription]struct{})
+	}
+	hub.subscribers[groupID][subscription] = struct{}{}
+
+	replay := make([]Event, 0)
+	if lastEventID == 0 {
+		return subscription, replay
+	}
+	// the ID comes from another process or the events since then were trimmed
+	if lastEventID > hub.lastID || lastEventID < hub.trimmed[groupID] {
+		replay = append(replay, Event{ID: hub.lastID, Type: Reset, GroupID: groupID, Time: time.Now()})
+		return subscription, replay
+	}
+	for _, event := range hub.history[groupID] {
+		if event.ID > lastEventID {
+			replay = append(replay, event)
+		}
+	}
+	return subscription, replay
+}
+
+// Events returns the channel of the subscription, it is closed when the
+// subscriber is disconnected.
+func (subscription *Subscription) Events() <-chan Event {
+	return subscription.events
+}
+
+func (subscription *Subscription) Unsubscribe() {
+	subscription.hub.mutex.Lock()
+	defer subscription.hub.mutex.Unlock()
+	subscription.hub.remove(subscription)
+}
+
+func (hub *Hub) remove(subscription *Subscription) {
+	subscribers := hub.subscribers[subscription.groupID]
+	if _, ok := subscribers[subscription]; !ok {
+		return
+	}
+	delete(subscribers, subscription)
+	if len(subscribers) == 0 {
+		delete(hub.subscribers, subscription.groupID)
+	}
+	close(subscription.events)
+}
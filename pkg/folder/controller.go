@@ -0,0 +1,574 @@
+package folder
+
+import (
+	"errors"
+	"fmt"
+	"locate-this/config"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/events"
+	"locate-this/pkg/models"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type FolderConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *FolderConfig {
+	return &FolderConfig{configuration}
+}
+
+// @Summary		Create a folder
+// @Description	Create a folder to organize the locations of the caller, at the root or in parent_id, after the folders already there
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			request	body		models.FolderRequest	true	"Folder data"
+// @Success		200		{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders [post]
+func (config *FolderConfig) PostFolderHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.FolderRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	if req.ParentID != nil && !config.ownParent(w, r, *req.ParentID, caller) {
+		return
+	}
+
+	folderEntry := &dbmodel.FolderEntry{UserID: caller.ID, ParentID: req.ParentID, Name: req.Name}
+	res, err := config.FolderRepository.Create(folderEntry)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to create folder"})
+		return
+	}
+
+	config.renderFolder(w, r, res, caller, false)
+}
+
+// @Summary		Get the folders
+// @Description	Retrieve the folders of the caller as a tree, each folder in the order of its parent with the number of its locations and the groups it is shared in
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Success		200	{array}		models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders [get]
+func (config *FolderConfig) GetFoldersHandler(w http.ResponseWriter, r *http.Request) {
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	tree, err := config.folderTree(caller, nil)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve folders"})
+		return
+	}
+
+	render.JSON(w, r, tree)
+}
+
+// @Summary		Get a folder
+// @Description	Retrieve a folder of the caller with its subfolders and its locations in order
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Folder ID"
+// @Success		200	{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id} [get]
+func (config *FolderConfig) GetFolderByIDHandler(w http.ResponseWriter, r *http.Request) {
+	folder, caller, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+
+	config.renderFolder(w, r, folder, caller, true)
+}
+
+// @Summary		Rename a folder
+// @Description	Change the name of a folder of the caller
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int						true	"Folder ID"
+// @Param			request	body		models.FolderRequest	true	"Folder data"
+// @Success		200		{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id} [put]
+func (config *FolderConfig) PutFolderHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.FolderRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	folder, caller, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := config.FolderRepository.Update(&dbmodel.FolderEntry{Name: req.Name}, folder.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to update folder"})
+		return
+	}
+
+	config.renderFolder(w, r, res, caller, false)
+}
+
+// @Summary		Delete a folder
+// @Description	Delete a folder of the caller with its subfolders. Their locations are kept, and stay shared in the groups they were shared in.
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Folder ID"
+// @Success		200	{object}	map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id} [delete]
+func (config *FolderConfig) DeleteFolderHandler(w http.ResponseWriter, r *http.Request) {
+	folder, _, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+
+	if err := config.FolderRepository.Delete(folder.ID); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to delete folder"})
+		return
+	}
+
+	render.JSON(w, r, map[string]string{"message": "Folder deleted successfully"})
+}
+
+// @Summary		Move a folder
+// @Description	Put a folder of the caller in another of their folders, or at the root without parent_id, at a position among the folders there. Its locations are shared in the groups its new parents are shared in.
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int							true	"Folder ID"
+// @Param			request	body		models.FolderMoveRequest	true	"New parent and position"
+// @Success		200		{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id}/move [post]
+func (config *FolderConfig) PostFolderMoveHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.FolderMoveRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	folder, caller, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+	if req.ParentID != nil && !config.ownParent(w, r, *req.ParentID, caller) {
+		return
+	}
+
+	shares, err := config.FolderRepository.Move(folder.ID, req.ParentID, folderPosition(req.Position))
+	if errors.Is(err, dbmodel.ErrFolderCycle) {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to move folder"})
+		return
+	}
+	config.publishShares(shares)
+
+	res, err := config.FolderRepository.FindById(folder.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve folder"})
+		return
+	}
+	config.renderFolder(w, r, res, caller, false)
+}
+
+// @Summary		Add locations to a folder
+// @Description	Put locations of the caller in a folder at a position, or after its last location, keeping them in the other folders they are in. They are shared in the groups the folder or one of its parents is shared in.
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int								true	"Folder ID"
+// @Param			request	body		models.FolderLocationsRequest	true	"Locations and position"
+// @Success		200		{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id}/locations [post]
+func (config *FolderConfig) PostFolderLocationsHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.FolderLocationsRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	folder, caller, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+	if !config.ownLocations(w, r, req.LocationIDs, caller) {
+		return
+	}
+
+	shares, err := config.FolderRepository.AddLocations(folder.ID, req.LocationIDs, folderPosition(req.Position))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to add locations to folder"})
+		return
+	}
+	config.publishShares(shares)
+
+	config.renderFolder(w, r, folder, caller, true)
+}
+
+// @Summary		Move locations to a folder
+// @Description	Take locations out of the folder from_folder_id and put them in a folder like adding them
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int								true	"Folder ID"
+// @Param			request	body		models.FolderLocationsRequest	true	"Locations, folder they are moved from and position"
+// @Success		200		{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id}/locations/move [post]
+func (config *FolderConfig) PostFolderLocationsMoveHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.FolderLocationsRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+	if req.FromFolderID < 1 {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: from_folder_id must be >= 1"})
+		return
+	}
+
+	folder, caller, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+	if req.FromFolderID == folder.ID {
+		render.JSON(w, r, map[string]string{"error": "The locations are already in this folder"})
+		return
+	}
+	from, err := config.FolderRepository.FindById(req.FromFolderID)
+	if err != nil || from.UserID != caller.ID {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve folder " + strconv.Itoa(int(req.FromFolderID))})
+		return
+	}
+
+	shares, err := config.FolderRepository.MoveLocations(folder.ID, from.ID, req.LocationIDs, folderPosition(req.Position))
+	if errors.Is(err, dbmodel.ErrFolderLocationNotFound) {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to move locations"})
+		return
+	}
+	config.publishShares(shares)
+
+	config.renderFolder(w, r, folder, caller, true)
+}
+
+// @Summary		Reorder the locations of a folder
+// @Description	Order the locations of a folder of the caller as location_ids, which must hold each of them once
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int							true	"Folder ID"
+// @Param			request	body		models.FolderOrderRequest	true	"Locations in their new order"
+// @Success		200		{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id}/locations/order [put]
+func (config *FolderConfig) PutFolderLocationOrderHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.FolderOrderRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	folder, caller, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+
+	err := config.FolderRepository.ReorderLocations(folder.ID, req.LocationIDs)
+	if errors.Is(err, dbmodel.ErrFolderLocationNotFound) {
+		render.JSON(w, r, map[string]string{"error": "location_ids must hold every location of the folder once"})
+		return
+	}
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to reorder locations"})
+		return
+	}
+
+	config.renderFolder(w, r, folder, caller, true)
+}
+
+// @Summary		Remove a location from a folder
+// @Description	Take a location out of a folder of the caller. The location is kept, and stays shared in the groups it was shared in.
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int	true	"Folder ID"
+// @Param			locationID	path		int	true	"Location ID"
+// @Success		200			{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id}/locations/{locationID} [delete]
+func (config *FolderConfig) DeleteFolderLocationHandler(w http.ResponseWriter, r *http.Request) {
+	locationID, err := strconv.Atoi(chi.URLParam(r, "locationID"))
+	if err != nil {
+		fmt.Println("Error during locationID convertion")
+	}
+	if locationID < 1 {
+		render.JSON(w, r, map[string]string{"error": "locationID must be >= 1"})
+		return
+	}
+
+	folder, caller, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+
+	err = config.FolderRepository.RemoveLocation(folder.ID, uint(locationID))
+	if errors.Is(err, dbmodel.ErrFolderLocationNotFound) {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to remove location from folder"})
+		return
+	}
+
+	config.renderFolder(w, r, folder, caller, true)
+}
+
+// @Summary		Share a folder into a group
+// @Description	Share the locations of a folder of the caller and of its subfolders in one of their groups, and those added to them later. Sharing it again changes whether the coordinates of the locations shared from then on are visible.
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int							true	"Folder ID"
+// @Param			request	body		models.FolderShareRequest	true	"Group ID and visibility"
+// @Success		200		{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id}/groups [post]
+func (config *FolderConfig) PostFolderGroupHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.FolderShareRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	folder, caller, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+	member, err := config.GroupEntryRepository.IsMember(req.GroupID, caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
+	}
+
+	folderGroupEntry := &dbmodel.FolderGroupEntry{FolderID: folder.ID, GroupID: req.GroupID, IsVisibleCoordinates: req.IsVisibleCoordinates}
+	shares, err := config.FolderRepository.Share(folderGroupEntry)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to share folder"})
+		return
+	}
+	config.publishShares(shares)
+
+	config.renderFolder(w, r, folder, caller, false)
+}
+
+// @Summary		Stop sharing a folder into a group
+// @Description	Stop sharing the locations added to a folder of the caller in a group. The locations already shared there stay shared.
+// @Tags			folders
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int	true	"Folder ID"
+// @Param			groupID	path		int	true	"Group ID"
+// @Success		200		{object}	models.FolderResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/folders/{id}/groups/{groupID} [delete]
+func (config *FolderConfig) DeleteFolderGroupHandler(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		fmt.Println("Error during groupID convertion")
+	}
+	if groupID < 1 {
+		render.JSON(w, r, map[string]string{"error": "groupID must be >= 1"})
+		return
+	}
+
+	folder, caller, ok := config.ownFolder(w, r)
+	if !ok {
+		return
+	}
+
+	if err := config.FolderRepository.Unshare(folder.ID, uint(groupID)); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to unshare folder"})
+		return
+	}
+
+	config.renderFolder(w, r, folder, caller, false)
+}
+
+// ownFolder returns the folder of the id parameter when the caller owns it,
+// rendering the error otherwise.
+func (config *FolderConfig) ownFolder(w http.ResponseWriter, r *http.Request) (*dbmodel.FolderEntry, *dbmodel.UserEntry, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return nil, nil, false
+	}
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, nil, false
+	}
+	folder, err := config.FolderRepository.FindById(uint(id))
+	if err != nil || folder.UserID != caller.ID {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve folder"})
+		return nil, nil, false
+	}
+	return folder, caller, true
+}
+
+// ownParent checks that the caller owns the folder a folder is put in,
+// rendering the error otherwise.
+func (config *FolderConfig) ownParent(w http.ResponseWriter, r *http.Request, parentID uint, caller *dbmodel.UserEntry) bool {
+	parent, err := config.FolderRepository.FindById(parentID)
+	if err != nil || parent.UserID != caller.ID {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve parent folder"})
+		return false
+	}
+	return true
+}
+
+// ownLocations checks that the caller owns the locations put in a folder,
+// rendering the error otherwise.
+func (config *FolderConfig) ownLocations(w http.ResponseWriter, r *http.Request, locationIDs []uint, caller *dbmodel.UserEntry) bool {
+	for _, locationID := range locationIDs {
+		location, err := config.LocationEntryRepository.FindById(locationID)
+		if err != nil || location.UserID != caller.ID {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve location " + strconv.Itoa(int(locationID))})
+			return false
+		}
+	}
+	return true
+}
+
+// publishShares tells the groups about the locations a shared folder shared
+// in them.
+func (config *FolderConfig) publishShares(shares []dbmodel.LocationShare) {
+	invalidated := make(map[uint]bool)
+	for _, share := range shares {
+		config.EventHub.Publish(events.Event{Type: events.LocationShared, GroupID: share.GroupID, LocationID: share.LocationID})
+		if invalidated[share.LocationID] {
+			continue
+		}
+		invalidated[share.LocationID] = true
+		location, err := config.LocationEntryRepository.FindById(share.LocationID)
+		if err != nil {
+			log.Println("Failed to retrieve location", share.LocationID, err)
+			continue
+		}
+		config.TileCache.InvalidatePoint(location.Latitude, location.Longitude)
+	}
+}
+
+// folderTree returns the folders of the caller nested under parentID, or the
+// root folders when it is nil.
+func (config *FolderConfig) folderTree(caller *dbmodel.UserEntry, parentID *uint) ([]models.FolderResponse, error) {
+	folders, err := config.FolderRepository.FindFoldersForUser(caller.ID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := config.FolderRepository.CountLocations(caller.ID)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := config.FolderRepository.FindGroups(caller.ID)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewFolderTree(folders, counts, groups, parentID), nil
+}
+
+// renderFolder renders a folder with its subfolders, and its locations when
+// withLocations is set.
+func (config *FolderConfig) renderFolder(w http.ResponseWriter, r *http.Request, folder *dbmodel.FolderEntry, caller *dbmodel.UserEntry, withLocations bool) {
+	siblings, err := config.folderTree(caller, folder.ParentID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve folder"})
+		return
+	}
+	var folderResponse *models.FolderResponse
+	for i := range siblings {
+		if siblings[i].ID == folder.ID {
+			folderResponse = &siblings[i]
+		}
+	}
+	if folderResponse == nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve folder"})
+		return
+	}
+
+	if withLocations {
+		locations, err := config.FolderRepository.FindLocations(folder.ID)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+			return
+		}
+		folderResponse.Locations = make([]models.LocationResponse, 0, len(locations))
+		for i := range locations {
+			folderResponse.Locations = append(folderResponse.Locations, models.NewLocationResponse(&locations[i]))
+		}
+		tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(folderResponse.Locations))
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve tags"})
+			return
+		}
+		models.AttachTags(folderResponse.Locations, tags)
+	}
+
+	render.JSON(w, r, folderResponse)
+}
+
+// folderPosition returns the requested position, or -1 to put the folder or
+// the locations last.
+func folderPosition(position *int) int {
+	if position == nil {
+		return -1
+	}
+	return *position
+}
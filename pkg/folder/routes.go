@@ -0,0 +1,43 @@
+package folder
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Folders:
+- POST /folders
+- GET /folders
+- GET /folders/{id}
+- PUT /folders/{id}
+- DELETE /folders/{id}
+- POST /folders/{id}/move
+
+- POST /folders/{id}/locations
+- POST /folders/{id}/locations/move
+- PUT /folders/{id}/locations/order
+- DELETE /folders/{id}/locations/{locationID}
+
+- POST /folders/{id}/groups
+- DELETE /folders/{id}/groups/{groupID}
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	FolderConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Post("/", FolderConfig.PostFolderHandler)
+	router.Get("/", FolderConfig.GetFoldersHandler)
+	router.Get("/{id}", FolderConfig.GetFolderByIDHandler)
+	router.Put("/{id}", FolderConfig.PutFolderHandler)
+	router.Delete("/{id}", FolderConfig.DeleteFolderHandler)
+	router.Post("/{id}/move", FolderConfig.PostFolderMoveHandler)
+	router.Post("/{id}/locations", FolderConfig.PostFolderLocationsHandler)
+	router.Post("/{id}/locations/move", FolderConfig.PostFolderLocationsMoveHandler)
+	router.Put("/{id}/locations/order", FolderConfig.PutFolderLocationOrderHandler)
+	router.Delete("/{id}/locations/{locationID}", FolderConfig.DeleteFolderLocationHandler)
+	router.Post("/{id}/groups", FolderConfig.PostFolderGroupHandler)
+	router.Delete("/{id}/groups/{groupID}", FolderConfig.DeleteFolderGroupHandler)
+	return router
+}
@@ -0,0 +1,76 @@
+package geo
+
+import "math"
+
+// Bounds is a latitude and longitude box. A box crossing the antimeridian has
+// its west edge east of its east edge (West 170, East -170).
+type Bounds struct {
+	South float64
+	West  float64
+	North float64
+	East  float64
+}
+
+// WorldBounds covers the whole globe.
+var WorldBounds = Bounds{South: -90, West: -180, North: 90, East: 180}
+
+// CrossesAntimeridian reports whether the box spans the 180th meridian.
+func (bounds Bounds) CrossesAntimeridian() bool {
+	return bounds.West > bounds.East
+}
+
+// Split returns the box as boxes that do not cross the antimeridian, two
+// when it does.
+func (bounds Bounds) Split() []Bounds {
+	if !bounds.CrossesAntimeridian() {
+		return []Bounds{bounds}
+	}
+	return []Bounds{
+		{South: bounds.South, West: bounds.West, North: bounds.North, East: 180},
+		{South: bounds.South, West: -180, North: bounds.North, East: bounds.East},
+	}
+}
+
+func (bounds Bounds) Contains(p Point) bool {
+	if p.Latitude < bounds.South || p.Latitude > bounds.North {
+		return false
+	}
+	if bounds.CrossesAntimeridian() {
+		return p.Longitude >= bounds.West || p.Longitude <= bounds.East
+	}
+	return p.Longitude >= bounds.West && p.Longitude <= bounds.East
+}
+
+// BoundsAround returns the smallest box containing the circle of radius
+// meters around the center, the whole globe once the circle reaches a pole
+// or goes around it.
+func BoundsAround(center Point, radius float64) Bounds {
+	angle := radius / EarthRadius * 180 / math.Pi
+	south, north := center.Latitude-angle, center.Latitude+angle
+	if south <= -90 || north >= 90 || angle >= 90 {
+		return Bounds{South: math.Max(south, -90), West: -180, North: math.Min(north, 90), East: 180}
+	}
+	// the widest part of the circle, at the latitude where the meridians are
+	// tangent to it
+	longitudeAngle := math.Asin(math.Sin(radians(angle))/math.Cos(radians(center.Latitude))) * 180 / math.Pi
+	if math.IsNaN(longitudeAngle) || longitudeAngle >= 180 {
+		return Bounds{South: south, West: -180, North: north, East: 180}
+	}
+	return Bounds{
+		South: south,
+		West:  normalizeLongitude(center.Longitude - longitudeAngle),
+		North: north,
+		East:  normalizeLongitude(center.Longitude + longitudeAngle),
+	}
+}
+
+// normalizeLongitude brings a longitude back between -180 and 180.
+func normalizeLongitude(longitude float64) float64 {
+	for longitude > 180 {
+		longitude -= 360
+	}
+	for longitude < -180 {
+		longitude += 360
+	}
+	return longitude
+}
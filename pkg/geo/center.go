@@ -0,0 +1,114 @@
+package geo
+
+import "math"
+
+const (
+	// medianTolerance is the step in meters under which the search of the
+	// geometric median stops.
+	medianTolerance = 0.01
+	medianMaxSteps  = 1000
+)
+
+// vector is a position on the unit sphere, in Earth-centered coordinates.
+type vector struct {
+	x, y, z float64
+}
+
+func toVector(p Point) vector {
+	latitude, longitude := radians(p.Latitude), radians(p.Longitude)
+	return vector{math.Cos(latitude) * math.Cos(longitude), math.Cos(latitude) * math.Sin(longitude), math.Sin(latitude)}
+}
+
+func (v vector) point() Point {
+	return Point{
+		Latitude:  math.Atan2(v.z, math.Hypot(v.x, v.y)) * 180 / math.Pi,
+		Longitude: math.Atan2(v.y, v.x) * 180 / math.Pi,
+	}
+}
+
+func (v vector) add(w vector) vector {
+	return vector{v.x + w.x, v.y + w.y, v.z + w.z}
+}
+
+func (v vector) scale(factor float64) vector {
+	return vector{v.x * factor, v.y * factor, v.z * factor}
+}
+
+func (v vector) dot(w vector) float64 {
+	return v.x*w.x + v.y*w.y + v.z*w.z
+}
+
+func (v vector) norm() float64 {
+	return math.Sqrt(v.dot(v))
+}
+
+// Midpoint returns the geographic midpoint of the points, their center of
+// mass on the globe brought back to its surface. It reports false when the
+// points are spread evenly around the globe, such as two antipodes.
+func Midpoint(points []Point) (Point, bool) {
+	var sum vector
+	for _, p := range points {
+		sum = sum.add(toVector(p))
+	}
+	if len(points) == 0 || sum.norm() < 1e-9*float64(len(points)) {
+		return Point{}, false
+	}
+	return sum.point(), true
+}
+
+// GeometricMedian returns the point minimizing the sum of the great-circle
+// distances to the points, which unlike the midpoint is not pulled away by a
+// few distant points. It is searched with the Weiszfeld algorithm on the
+// sphere, from the midpoint, to about a centimeter. It reports false when
+// there is no midpoint to start from.
+func GeometricMedian(points []Point) (Point, bool) {
+	start, ok := Midpoint(points)
+	if !ok {
+		return Point{}, false
+	}
+	vectors := make([]vector, len(points))
+	for i, p := range points {
+		vectors[i] = toVector(p)
+	}
+
+	current := toVector(start)
+	for step := 0; step < medianMaxSteps; step++ {
+		// directions from the current point towards each point, in the plane
+		// tangent to the globe, weighted by the inverse of their distance
+		var direction vector
+		var weights float64
+		coincident := 0
+		for _, v := range vectors {
+			angle := math.Acos(math.Max(-1, math.Min(1, current.dot(v))))
+			if angle < 1e-12 {
+				coincident++
+				continue
+			}
+			tangent := v.add(current.scale(-current.dot(v)))
+			direction = direction.add(tangent.scale(1 / tangent.norm()))
+			weights += 1 / angle
+		}
+		// a point pulled less by the others than by its own weight is the
+		// median
+		if weights == 0 || direction.norm() <= float64(coincident) {
+			break
+		}
+		move := direction.scale(1 / weights)
+		length := move.norm()
+		current = current.scale(math.Cos(length)).add(move.scale(math.Sin(length) / length))
+		if length*EarthRadius < medianTolerance {
+			break
+		}
+	}
+	return current.point(), true
+}
+
+// TotalDistance returns the sum of the distances in meters from a point to
+// the others.
+func TotalDistance(from Point, points []Point) float64 {
+	var total float64
+	for _, p := range points {
+		total += Distance(from, p)
+	}
+	return total
+}
@@ -0,0 +1,187 @@
+// Package geo holds the geometry helpers shared by the location features.
+package geo
+
+import "math"
+
+// EarthRadius is the mean radius of the Earth in meters.
+const EarthRadius = 6371008.8
+
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// Distance returns the great-circle distance between two points in meters,
+// using the haversine formula.
+func Distance(a, b Point) float64 {
+	dLat := radians(b.Latitude - a.Latitude)
+	dLng := radians(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(radians(a.Latitude))*math.Cos(radians(b.Latitude))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// PathLength returns the length of a path in meters.
+func PathLength(points []Point) float64 {
+	var length float64
+	for i := 1; i < len(points); i++ {
+		length += Distance(points[i-1], points[i])
+	}
+	return length
+}
+
+// NearestNeighbourOrder orders the points from the first one by going each
+// time to the closest point not visited yet, a quick approximation of the
+// shortest path through them. The last point stays last when keepLast is set.
+// It returns the indexes of the points in their new order.
+func NearestNeighbourOrder(points []Point, keepLast bool) []int {
+	order := make([]int, 0, len(points))
+	if len(points) == 0 {
+		return order
+	}
+	end := len(points)
+	if keepLast && len(points) > 1 {
+		end--
+	}
+	visited := make([]bool, len(points))
+	current := 0
+	visited[0] = true
+	order = append(order, 0)
+	for len(order) < end {
+		next, nextDistance := -1, math.Inf(1)
+		for i := 1; i < end; i++ {
+			if visited[i] {
+				continue
+			}
+			if distance := Distance(points[current], points[i]); distance < nextDistance {
+				next, nextDistance = i, distance
+			}
+		}
+		visited[next] = true
+		order = append(order, next)
+		current = next
+	}
+	if end < len(points) {
+		order = append(order, end)
+	}
+	return order
+}
+
+// Simplify reduces a path with the Douglas–Peucker algorithm, dropping the
+// points closer than tolerance meters to the simplified line. The first and
+// last points are always kept. It returns the indexes of the kept points.
+func Simplify(points []Point, tolerance float64) []int {
+	if len(points) <= 2 || tolerance <= 0 {
+		indexes := make([]int, len(points))
+		for i := range points {
+			indexes[i] = i
+		}
+		return indexes
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+	// iterative to stay safe on very long tracks
+	stack := [][2]int{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		segment := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		first, last := segment[0], segment[1]
+
+		farthest, maxDistance := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			if d := segmentDistance(points[i], points[first], points[last]); d > maxDistance {
+				farthest, maxDistance = i, d
+			}
+		}
+		if farthest != -1 {
+			keep[farthest] = true
+			stack = append(stack, [2]int{first, farthest}, [2]int{farthest, last})
+		}
+	}
+
+	indexes := make([]int, 0)
+	for i, kept := range keep {
+		if kept {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// segmentDistance returns the distance in meters from p to the segment [a, b],
+// projecting the points on a plane tangent at p, which is precise enough at
+// the scale of a track segment.
+func segmentDistance(p, a, b Point) float64 {
+	scale := math.Cos(radians(p.Latitude))
+	project := func(q Point) (float64, float64) {
+		return radians(longitudeOffset(p.Longitude, q.Longitude)) * scale * EarthRadius, radians(q.Latitude-p.Latitude) * EarthRadius
+	}
+	ax, ay := project(a)
+	bx, by := project(b)
+
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+// longitudeOffset returns the difference b - a in degrees, taking the
+// shortest way around the antimeridian.
+func longitudeOffset(a, b float64) float64 {
+	offset := b - a
+	if offset > 180 {
+		offset -= 360
+	} else if offset < -180 {
+		offset += 360
+	}
+	return offset
+}
+
+// InPolygon reports whether p lies inside the polygon, with the ray casting
+// algorithm. The polygon is a ring of vertices, closed or not, that may cross
+// the antimeridian but must span less than half the globe.
+func InPolygon(p Point, polygon []Point) bool {
+	if len(polygon) == 0 {
+		return false
+	}
+	// unwrap the ring so that its longitudes follow each other without
+	// jumping at the antimeridian, then bring p within 360° east of its
+	// westernmost vertex
+	longitudes := make([]float64, len(polygon))
+	longitudes[0] = polygon[0].Longitude
+	west := longitudes[0]
+	for i := 1; i < len(polygon); i++ {
+		longitudes[i] = longitudes[i-1] + longitudeOffset(polygon[i-1].Longitude, polygon[i].Longitude)
+		west = math.Min(west, longitudes[i])
+	}
+	x := west + math.Mod(math.Mod(p.Longitude-west, 360)+360, 360)
+
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		ax, bx := longitudes[i], longitudes[j]
+		if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) {
+			if ax+(p.Latitude-a.Latitude)*(bx-ax)/(b.Latitude-a.Latitude) > x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// PolygonBoundaryDistance returns the distance in meters from p to the
+// closest edge of the polygon.
+func PolygonBoundaryDistance(p Point, polygon []Point) float64 {
+	distance := math.Inf(1)
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		distance = math.Min(distance, segmentDistance(p, polygon[j], polygon[i]))
+	}
+	return distance
+}
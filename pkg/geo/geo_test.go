@@ -0,0 +1,317 @@
+package geo
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{"same point", Point{45.5, 4.8}, Point{45.5, 4.8}, 0},
+		{"paris london", Point{48.8566, 2.3522}, Point{51.5074, -0.1278}, 343556.5},
+		{"jfk heathrow", Point{40.6413, -73.7781}, Point{51.4700, -0.4543}, 5540019.0},
+		{"one degree of latitude", Point{10, 20}, Point{11, 20}, 111195.1},
+		{"across the antimeridian", Point{0, 179.5}, Point{0, -179.5}, 111195.1},
+		{"pole to pole", Point{90, 0}, Point{-90, 0}, math.Pi * EarthRadius},
+		{"antipodes", Point{30, 40}, Point{-30, -140}, math.Pi * EarthRadius},
+		{"meridians meet at the pole", Point{90, 0}, Point{90, 120}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Distance(test.a, test.b); math.Abs(got-test.want) > 1 {
+				t.Errorf("Distance = %.1f, want %.1f", got, test.want)
+			}
+			if got, reverse := Distance(test.a, test.b), Distance(test.b, test.a); math.Abs(got-reverse) > 1e-6 {
+				t.Errorf("Distance is not symmetric: %f and %f", got, reverse)
+			}
+		})
+	}
+}
+
+// square returns a square ring of side 2×half degrees centered on center.
+func square(center Point, half float64) []Point {
+	return []Point{
+		{center.Latitude - half, center.Longitude - half},
+		{center.Latitude - half, center.Longitude + half},
+		{center.Latitude + half, center.Longitude + half},
+		{center.Latitude + half, center.Longitude - half},
+	}
+}
+
+// withHole joins an inner ring to an outer ring with a zero-width bridge from
+// their first vertices, the way a polygon with a hole is drawn as one ring.
+func withHole(outer, inner []Point) []Point {
+	ring := append([]Point{}, outer...)
+	ring = append(ring, outer[0])
+	for i := len(inner) - 1; i >= 0; i-- {
+		ring = append(ring, inner[i])
+	}
+	return append(ring, inner[len(inner)-1])
+}
+
+func TestInPolygon(t *testing.T) {
+	// a U open to the north
+	concave := []Point{{0, 0}, {0, 3}, {3, 3}, {3, 2}, {1, 2}, {1, 1}, {3, 1}, {3, 0}}
+	// closed ring, the first vertex repeated
+	closed := append(square(Point{45, 5}, 1), Point{44, 4})
+	antimeridian := []Point{{-10, 170}, {-10, -170}, {10, -170}, {10, 170}}
+	donut := withHole(square(Point{0, 0}, 2), square(Point{0, 0}, 1))
+
+	tests := []struct {
+		name    string
+		polygon []Point
+		point   Point
+		want    bool
+	}{
+		{"square center", square(Point{45, 5}, 1), Point{45, 5}, true},
+		{"square outside", square(Point{45, 5}, 1), Point{47, 5}, false},
+		{"closed ring", closed, Point{45.5, 5.5}, true},
+		{"closed ring outside", closed, Point{43.5, 5.5}, false},
+		{"concave left arm", concave, Point{2, 0.5}, true},
+		{"concave right arm", concave, Point{2, 2.5}, true},
+		{"concave notch", concave, Point{2, 1.5}, false},
+		{"concave base", concave, Point{0.5, 1.5}, true},
+		{"concave beyond the notch", concave, Point{4, 1.5}, false},
+		{"ray through a vertex", concave, Point{1, 0.5}, true},
+		{"ray along an edge", square(Point{0, 0}, 1), Point{-1, -5}, false},
+		{"donut ring", donut, Point{1.5, 0}, true},
+		{"donut ring south", donut, Point{-1.5, -1.5}, true},
+		{"donut hole", donut, Point{0, 0}, false},
+		{"donut hole corner", donut, Point{0.9, 0.9}, false},
+		{"donut outside", donut, Point{3, 0}, false},
+		{"antimeridian east side", antimeridian, Point{0, 175}, true},
+		{"antimeridian west side", antimeridian, Point{0, -175}, true},
+		{"antimeridian on it", antimeridian, Point{0, 180}, true},
+		{"antimeridian on it west", antimeridian, Point{0, -180}, true},
+		{"antimeridian outside", antimeridian, Point{0, 0}, false},
+		{"antimeridian outside east", antimeridian, Point{0, -160}, false},
+		{"empty polygon", nil, Point{0, 0}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := InPolygon(test.point, test.polygon); got != test.want {
+				t.Errorf("InPolygon(%v) = %v, want %v", test.point, got, test.want)
+			}
+		})
+	}
+}
+
+// TestInPolygonBoundary checks that the side of the points near an edge is
+// right a meter away from it, and that their distance to the boundary tells
+// they are too close to decide, which is what the geofences rely on.
+func TestInPolygonBoundary(t *testing.T) {
+	polygon := square(Point{45, 5}, 0.01)
+	meter := 1 / (EarthRadius * math.Pi / 180)
+	edge := 44.99
+
+	tests := []struct {
+		name   string
+		point  Point
+		inside bool
+	}{
+		{"inside the south edge", Point{edge + meter, 5}, true},
+		{"outside the south edge", Point{edge - meter, 5}, false},
+		{"inside the corner", Point{edge + meter, 4.99 + 2*meter}, true},
+		{"outside the corner", Point{edge - meter, 4.99 - meter}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := InPolygon(test.point, polygon); got != test.inside {
+				t.Errorf("InPolygon = %v, want %v", got, test.inside)
+			}
+			if distance := PolygonBoundaryDistance(test.point, polygon); distance > 3 {
+				t.Errorf("PolygonBoundaryDistance = %.2f, want less than 3", distance)
+			}
+		})
+	}
+
+	for _, vertex := range polygon {
+		if distance := PolygonBoundaryDistance(vertex, polygon); distance > 1e-6 {
+			t.Errorf("PolygonBoundaryDistance(%v) = %f, want 0 on a vertex", vertex, distance)
+		}
+	}
+	midEdge := Point{edge, 5}
+	if distance := PolygonBoundaryDistance(midEdge, polygon); distance > 1e-6 {
+		t.Errorf("PolygonBoundaryDistance(%v) = %f, want 0 on an edge", midEdge, distance)
+	}
+}
+
+func TestPolygonBoundaryDistance(t *testing.T) {
+	polygon := square(Point{0, 0}, 1)
+	donut := withHole(square(Point{0, 0}, 2), square(Point{0, 0}, 1))
+	degree := EarthRadius * math.Pi / 180
+
+	tests := []struct {
+		name    string
+		polygon []Point
+		point   Point
+		want    float64
+	}{
+		{"center", polygon, Point{0, 0}, degree},
+		{"outside north", polygon, Point{1.5, 0}, degree / 2},
+		{"outside a corner", polygon, Point{2, 2}, Distance(Point{2, 2}, Point{1, 1})},
+		{"hole center", donut, Point{0, 0}, degree},
+		{"donut ring", donut, Point{1.5, 0}, degree / 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the projection on a tangent plane is off by a few hundred
+			// meters at this scale
+			if got := PolygonBoundaryDistance(test.point, test.polygon); math.Abs(got-test.want) > test.want/100 {
+				t.Errorf("PolygonBoundaryDistance = %.0f, want %.0f", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNearestNeighbourOrder(t *testing.T) {
+	lyon := Point{45.76, 4.83}
+	tests := []struct {
+		name     string
+		points   []Point
+		keepLast bool
+		want     []int
+	}{
+		{"no point", nil, false, []int{}},
+		{"one point", []Point{lyon}, false, []int{0}},
+		{"one point kept last", []Point{lyon}, true, []int{0}},
+		{"two points kept last", path(lyon, [2]float64{0, 0}, [2]float64{0, 1000}), true, []int{0, 1}},
+		{"along a street", path(lyon, [2]float64{0, 0}, [2]float64{0, 3000}, [2]float64{0, 1000}, [2]float64{0, 4000}, [2]float64{0, 2000}), false, []int{0, 2, 4, 1, 3}},
+		{"last point last", path(lyon, [2]float64{0, 0}, [2]float64{0, 3000}, [2]float64{0, 1000}, [2]float64{0, 500}), true, []int{0, 2, 1, 3}},
+		{"last point anywhere", path(lyon, [2]float64{0, 0}, [2]float64{0, 3000}, [2]float64{0, 1000}, [2]float64{0, 500}), false, []int{0, 3, 2, 1}},
+		// greedy, and longer than going west first
+		{"from the middle", path(lyon, [2]float64{0, 0}, [2]float64{0, -1000}, [2]float64{0, 400}, [2]float64{0, 2000}), false, []int{0, 2, 1, 3}},
+		{"across the antimeridian", []Point{{0, 179.9}, {0, -179.95}, {0, 170}, {0, -179.5}}, false, []int{0, 1, 3, 2}},
+		{"equally close", path(lyon, [2]float64{0, 0}, [2]float64{0, 1000}, [2]float64{0, -1000}), false, []int{0, 1, 2}},
+		{"the same location twice", path(lyon, [2]float64{0, 0}, [2]float64{1000, 0}, [2]float64{0, 0}), false, []int{0, 2, 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NearestNeighbourOrder(test.points, test.keepLast); !slices.Equal(got, test.want) {
+				t.Errorf("NearestNeighbourOrder = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// TestNearestNeighbourOrderVisitsEveryPoint checks on random stops that the
+// order visits each of them once, from the first, going each time to the
+// closest one left.
+func TestNearestNeighbourOrderVisitsEveryPoint(t *testing.T) {
+	random := rand.New(rand.NewPCG(4, 6))
+	for n := 2; n < 40; n++ {
+		points := make([]Point, n)
+		for i := range points {
+			points[i] = Point{45 + random.Float64(), 4 + random.Float64()}
+		}
+		for _, keepLast := range []bool{false, true} {
+			order := NearestNeighbourOrder(points, keepLast)
+			if len(order) != n || order[0] != 0 || (keepLast && order[n-1] != n-1) {
+				t.Fatalf("NearestNeighbourOrder(%d points, %v) = %v", n, keepLast, order)
+			}
+			sorted := slices.Sorted(slices.Values(order))
+			for i, index := range sorted {
+				if index != i {
+					t.Fatalf("NearestNeighbourOrder(%d points, %v) = %v, not every point once", n, keepLast, order)
+				}
+			}
+			for i := 1; i < len(order)-1; i++ {
+				step := Distance(points[order[i-1]], points[order[i]])
+				for _, later := range order[i+1:] {
+					if keepLast && later == n-1 {
+						continue
+					}
+					if Distance(points[order[i-1]], points[later]) < step {
+						t.Fatalf("NearestNeighbourOrder(%d points, %v) = %v goes to %d before the closer %d", n, keepLast, order, order[i], later)
+					}
+				}
+			}
+		}
+	}
+}
+
+// offset returns the point north and east meters away from p.
+func offset(p Point, north, east float64) Point {
+	degree := EarthRadius * math.Pi / 180
+	return Point{p.Latitude + north/degree, p.Longitude + east/(degree*math.Cos(radians(p.Latitude)))}
+}
+
+// path returns the points at the offsets in meters from start, as
+// {north, east} pairs.
+func path(start Point, offsets ...[2]float64) []Point {
+	points := make([]Point, len(offsets))
+	for i, o := range offsets {
+		points[i] = offset(start, o[0], o[1])
+	}
+	return points
+}
+
+func TestSimplify(t *testing.T) {
+	lyon := Point{45.76, 4.83}
+	zigzag := path(lyon, [2]float64{0, 0}, [2]float64{50, 100}, [2]float64{0, 200}, [2]float64{50, 300}, [2]float64{0, 400})
+
+	tests := []struct {
+		name      string
+		points    []Point
+		tolerance float64
+		want      []int
+	}{
+		{"no point", nil, 10, []int{}},
+		{"one point", []Point{lyon}, 10, []int{0}},
+		{"two points", path(lyon, [2]float64{0, 0}, [2]float64{0, 1000}), 10, []int{0, 1}},
+		{"no tolerance", zigzag, 0, []int{0, 1, 2, 3, 4}},
+		{"straight line", path(lyon, [2]float64{0, 0}, [2]float64{100, 0}, [2]float64{250, 0}, [2]float64{1000, 0}), 1, []int{0, 3}},
+		{"zigzag above the tolerance", zigzag, 30, []int{0, 1, 2, 3, 4}},
+		{"zigzag below the tolerance", zigzag, 60, []int{0, 4}},
+		{"spike", path(lyon, [2]float64{0, 0}, [2]float64{5, 100}, [2]float64{500, 200}, [2]float64{-5, 300}, [2]float64{0, 400}), 100, []int{0, 2, 4}},
+		{"corner", path(lyon, [2]float64{0, 0}, [2]float64{0, 500}, [2]float64{0, 1000}, [2]float64{500, 1000}, [2]float64{1000, 1000}), 10, []int{0, 2, 4}},
+		// the distance is to the segment, not to the line through its ends
+		{"behind the start", path(lyon, [2]float64{0, 0}, [2]float64{0, -300}, [2]float64{0, 1000}), 100, []int{0, 1, 2}},
+		// a loop back to its start measures the distances to the start
+		{"loop", path(lyon, [2]float64{0, 0}, [2]float64{0, 250}, [2]float64{0, 500}, [2]float64{250, 500}, [2]float64{500, 500}, [2]float64{500, 250}, [2]float64{500, 0}, [2]float64{250, 0}, [2]float64{0, 0}), 10, []int{0, 2, 4, 6, 8}},
+		{"across the antimeridian", []Point{{0.0001, 179.99}, {0, 179.999}, {-0.0001, -179.995}, {0, -179.98}}, 50, []int{0, 3}},
+		{"zigzag across the antimeridian", []Point{{0, 179.99}, {0.01, 179.999}, {0, -179.99}}, 50, []int{0, 1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Simplify(test.points, test.tolerance); !slices.Equal(got, test.want) {
+				t.Errorf("Simplify = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// TestSimplifyKeepsTheShape checks on random walks that every dropped point
+// is within the tolerance of the simplified segment replacing it, and that
+// the kept points are the track in its order.
+func TestSimplifyKeepsTheShape(t *testing.T) {
+	random := rand.New(rand.NewPCG(3, 4))
+	for _, start := range []Point{{45.76, 4.83}, {-33.87, 151.21}, {64.15, -21.94}, {0.5, 179.999}} {
+		points := []Point{start}
+		for range 2000 {
+			points = append(points, offset(points[len(points)-1], random.NormFloat64()*20, random.NormFloat64()*20+5))
+		}
+		for _, tolerance := range []float64{1, 15, 100} {
+			kept := Simplify(points, tolerance)
+			if kept[0] != 0 || kept[len(kept)-1] != len(points)-1 || !slices.IsSorted(kept) {
+				t.Fatalf("%v, %v m: kept %v", start, tolerance, kept)
+			}
+			for k := 1; k < len(kept); k++ {
+				for i := kept[k-1] + 1; i < kept[k]; i++ {
+					if d := segmentDistance(points[i], points[kept[k-1]], points[kept[k]]); d > tolerance {
+						t.Fatalf("%v, %v m: point %d dropped %.1f m away from the track", start, tolerance, i, d)
+					}
+				}
+			}
+			if tolerance == 100 && len(kept) > len(points)/5 {
+				t.Errorf("%v, %v m: kept %d points of %d", start, tolerance, len(kept), len(points))
+			}
+		}
+	}
+}
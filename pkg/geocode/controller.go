@@ -0,0 +1,104 @@
+package geocode
+
+import (
+	"errors"
+	"locate-this/config"
+	"locate-this/pkg/coords"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/geocoder"
+	"locate-this/pkg/models"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultGeocodeLimit = 5
+	maxGeocodeLimit     = 20
+)
+
+type GeocodeConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *GeocodeConfig {
+	return &GeocodeConfig{configuration}
+}
+
+// @Summary		Geocode an address
+// @Description	Find the coordinates of an address or a place name, best matches first. The offline geocoder knows cities only, an address resolves to its city.
+// @Tags			geocoding
+// @Produce		json
+// @Param			q		query		string	true	"Address or place name, end with a country code to restrict it (Paris, US)"
+// @Param			limit	query		int		false	"Maximum number of results, 5 by default and at most 20"
+// @Success		200	{array}	models.GeocodeResultResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/geocode [get]
+func (config *GeocodeConfig) GeocodeHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		render.JSON(w, r, map[string]string{"error": "q must not be empty"})
+		return
+	}
+
+	limit := defaultGeocodeLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxGeocodeLimit {
+			render.JSON(w, r, map[string]string{"error": "limit must be between 1 and " + strconv.Itoa(maxGeocodeLimit)})
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := config.Geocoder.Geocode(r.Context(), query, limit)
+	if err != nil {
+		log.Println("Failed to geocode:", err)
+		render.JSON(w, r, map[string]string{"error": "Failed to geocode"})
+		return
+	}
+
+	geocodeResponse := make([]models.GeocodeResultResponse, 0)
+	for _, result := range results {
+		geocodeResponse = append(geocodeResponse, models.NewGeocodeResultResponse(result))
+	}
+	render.JSON(w, r, geocodeResponse)
+}
+
+// @Summary		Reverse geocode a point
+// @Description	Find the address of a point. The offline geocoder returns the nearest city.
+// @Tags			geocoding
+// @Produce		json
+// @Param			lat	query		string	true	"Latitude, in decimal degrees or degrees, minutes and seconds"
+// @Param			lng	query		string	true	"Longitude, in decimal degrees or degrees, minutes and seconds"
+// @Success		200	{object}	models.GeocodeResultResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/reverse-geocode [get]
+func (config *GeocodeConfig) ReverseGeocodeHandler(w http.ResponseWriter, r *http.Request) {
+	latitude, err := coords.ParseLatitude(r.URL.Query().Get("lat"))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	longitude, err := coords.ParseLongitude(r.URL.Query().Get("lng"))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	result, err := config.Geocoder.Reverse(r.Context(), geo.Point{Latitude: latitude, Longitude: longitude})
+	if errors.Is(err, geocoder.ErrNotFound) {
+		render.JSON(w, r, map[string]string{"error": "No address found"})
+		return
+	}
+	if err != nil {
+		log.Println("Failed to reverse geocode:", err)
+		render.JSON(w, r, map[string]string{"error": "Failed to reverse geocode"})
+		return
+	}
+	render.JSON(w, r, models.NewGeocodeResultResponse(*result))
+}
@@ -0,0 +1,27 @@
+package geocode
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Geocoding:
+- GET /geocode?q=
+- GET /reverse-geocode?lat=&lng=
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	GeocodeConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Get("/", GeocodeConfig.GeocodeHandler)
+	return router
+}
+
+func ReverseRoutes(configuration *config.Config) chi.Router {
+	GeocodeConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Get("/", GeocodeConfig.ReverseGeocodeHandler)
+	return router
+}
@@ -0,0 +1,104 @@
+package geocoder
+
+import (
+	"container/list"
+	"context"
+	"locate-this/pkg/geo"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedGeocoder keeps the results of another geocoder for a while, evicting
+// the least recently used ones beyond its size. Reverse lookups are cached by
+// point rounded to about a meter.
+type CachedGeocoder struct {
+	next  Geocoder
+	ttl   time.Duration
+	size  int
+	mutex sync.Mutex
+	// entries by key, most recently used first
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	results []Result
+	// notFound records a reverse lookup that found nothing
+	notFound bool
+	expires  time.Time
+}
+
+func NewCachedGeocoder(next Geocoder, ttl time.Duration, size int) *CachedGeocoder {
+	return &CachedGeocoder{next: next, ttl: ttl, size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (cache *CachedGeocoder) Geocode(ctx context.Context, query string, limit int) ([]Result, error) {
+	key := "geocode:" + strconv.Itoa(limit) + ":" + strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	if entry, ok := cache.get(key); ok {
+		return entry.results, nil
+	}
+	results, err := cache.next.Geocode(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(&cacheEntry{key: key, results: results})
+	return results, nil
+}
+
+func (cache *CachedGeocoder) Reverse(ctx context.Context, point geo.Point) (*Result, error) {
+	key := "reverse:" + strconv.FormatFloat(point.Latitude, 'f', 5, 64) + "," + strconv.FormatFloat(point.Longitude, 'f', 5, 64)
+	if entry, ok := cache.get(key); ok {
+		if entry.notFound {
+			return nil, ErrNotFound
+		}
+		result := entry.results[0]
+		return &result, nil
+	}
+	result, err := cache.next.Reverse(ctx, point)
+	if err == ErrNotFound {
+		cache.put(&cacheEntry{key: key, notFound: true})
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache.put(&cacheEntry{key: key, results: []Result{*result}})
+	return result, nil
+}
+
+func (cache *CachedGeocoder) get(key string) (*cacheEntry, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	return entry, true
+}
+
+func (cache *CachedGeocoder) put(entry *cacheEntry) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry.expires = time.Now().Add(cache.ttl)
+	if element, ok := cache.entries[entry.key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[entry.key] = cache.order.PushFront(entry)
+	for cache.order.Len() > cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
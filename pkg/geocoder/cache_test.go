@@ -0,0 +1,150 @@
+package geocoder
+
+import (
+	"context"
+	"errors"
+	"locate-this/pkg/geo"
+	"testing"
+	"time"
+)
+
+// countingGeocoder answers every query with a place named after it, counting
+// the calls that reach it.
+type countingGeocoder struct {
+	geocodes int
+	reverses int
+	err      error
+}
+
+func (geocoder *countingGeocoder) Geocode(ctx context.Context, query string, limit int) ([]Result, error) {
+	geocoder.geocodes++
+	if geocoder.err != nil {
+		return nil, geocoder.err
+	}
+	return []Result{{Name: query, Latitude: 45, Longitude: 5}}, nil
+}
+
+func (geocoder *countingGeocoder) Reverse(ctx context.Context, point geo.Point) (*Result, error) {
+	geocoder.reverses++
+	if geocoder.err != nil {
+		return nil, geocoder.err
+	}
+	if point.Latitude == 0 && point.Longitude == 0 {
+		return nil, ErrNotFound
+	}
+	return &Result{Name: "somewhere", Latitude: point.Latitude, Longitude: point.Longitude}, nil
+}
+
+func TestCachedGeocoderServesRepeatedQueries(t *testing.T) {
+	next := &countingGeocoder{}
+	cache := NewCachedGeocoder(next, time.Hour, 100)
+	ctx := context.Background()
+
+	for _, query := range []string{"Place Bellecour", "place bellecour", "  Place   BELLECOUR "} {
+		results, err := cache.Geocode(ctx, query, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results[0].Name != "Place Bellecour" {
+			t.Errorf("Geocode(%q) = %+v, want the first answer", query, results)
+		}
+	}
+	if next.geocodes != 1 {
+		t.Errorf("%d geocoding queries reached the geocoder, want 1", next.geocodes)
+	}
+
+	// another limit is another query
+	if _, err := cache.Geocode(ctx, "place bellecour", 1); err != nil {
+		t.Fatal(err)
+	}
+	if next.geocodes != 2 {
+		t.Errorf("%d geocoding queries reached the geocoder, want 2", next.geocodes)
+	}
+
+	// points less than a meter apart share their reverse lookup
+	for _, point := range []geo.Point{{Latitude: 45.757814, Longitude: 4.832011}, {Latitude: 45.7578141, Longitude: 4.8320112}} {
+		if result, err := cache.Reverse(ctx, point); err != nil || result.Name != "somewhere" {
+			t.Fatalf("Reverse(%v) = %+v, %v", point, result, err)
+		}
+	}
+	if _, err := cache.Reverse(ctx, geo.Point{Latitude: 45.75783, Longitude: 4.832011}); err != nil {
+		t.Fatal(err)
+	}
+	if next.reverses != 2 {
+		t.Errorf("%d reverse lookups reached the geocoder, want 2", next.reverses)
+	}
+
+	// places not found are cached too
+	for range 2 {
+		if _, err := cache.Reverse(ctx, geo.Point{}); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Reverse: err = %v, want ErrNotFound", err)
+		}
+	}
+	if next.reverses != 3 {
+		t.Errorf("%d reverse lookups reached the geocoder, want 3", next.reverses)
+	}
+}
+
+func TestCachedGeocoderDoesNotCacheErrors(t *testing.T) {
+	next := &countingGeocoder{err: errors.New("geocoder: /search responded 503 Service Unavailable")}
+	cache := NewCachedGeocoder(next, time.Hour, 100)
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := cache.Geocode(ctx, "lyon", 1); err == nil {
+			t.Fatal("Geocode did not fail")
+		}
+		if _, err := cache.Reverse(ctx, geo.Point{Latitude: 1, Longitude: 1}); err == nil || errors.Is(err, ErrNotFound) {
+			t.Fatalf("Reverse: err = %v, want the failure", err)
+		}
+	}
+	if next.geocodes != 2 || next.reverses != 2 {
+		t.Errorf("%d geocoding queries and %d reverse lookups reached the geocoder, want 2 of each", next.geocodes, next.reverses)
+	}
+
+	next.err = nil
+	if results, err := cache.Geocode(ctx, "lyon", 1); err != nil || results[0].Name != "lyon" {
+		t.Errorf("Geocode after a failure = %+v, %v", results, err)
+	}
+}
+
+func TestCachedGeocoderExpiry(t *testing.T) {
+	next := &countingGeocoder{}
+	cache := NewCachedGeocoder(next, 20*time.Millisecond, 100)
+	ctx := context.Background()
+
+	cache.Geocode(ctx, "lyon", 1)
+	cache.Geocode(ctx, "lyon", 1)
+	time.Sleep(30 * time.Millisecond)
+	cache.Geocode(ctx, "lyon", 1)
+	if next.geocodes != 2 {
+		t.Errorf("%d geocoding queries reached the geocoder, want 2", next.geocodes)
+	}
+}
+
+func TestCachedGeocoderEviction(t *testing.T) {
+	next := &countingGeocoder{}
+	cache := NewCachedGeocoder(next, time.Hour, 2)
+	ctx := context.Background()
+
+	cache.Geocode(ctx, "lyon", 1)
+	cache.Geocode(ctx, "paris", 1)
+	// lyon becomes the most recently used, paris is evicted by marseille
+	cache.Geocode(ctx, "lyon", 1)
+	cache.Geocode(ctx, "marseille", 1)
+	if next.geocodes != 3 {
+		t.Fatalf("%d geocoding queries reached the geocoder, want 3", next.geocodes)
+	}
+
+	cache.Geocode(ctx, "lyon", 1)
+	if next.geocodes != 3 {
+		t.Errorf("lyon was evicted")
+	}
+	cache.Geocode(ctx, "paris", 1)
+	if next.geocodes != 4 {
+		t.Errorf("paris was not evicted")
+	}
+	if len(cache.entries) != 2 || cache.order.Len() != 2 {
+		t.Errorf("cache holds %d entries and %d in order, want 2", len(cache.entries), cache.order.Len())
+	}
+}
@@ -0,0 +1,65 @@
+// Package geocoder turns addresses into coordinates and coordinates into
+// addresses.
+package geocoder
+
+import (
+	"context"
+	"errors"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/geo"
+	"os"
+	"strconv"
+	"time"
+)
+
+var ErrNotFound = errors.New("no place found")
+
+type Result struct {
+	Name        string
+	Address     string
+	Latitude    float64
+	Longitude   float64
+	CountryCode string
+	// Kind of place, city for the offline dataset
+	Kind string
+}
+
+type Geocoder interface {
+	// Geocode returns up to limit places matching an address, the best first.
+	Geocode(ctx context.Context, query string, limit int) ([]Result, error)
+	// Reverse returns the place at a point, ErrNotFound when there is none.
+	Reverse(ctx context.Context, point geo.Point) (*Result, error)
+}
+
+// New builds the geocoder selected by the GEOCODER environment variable,
+// "local" (default) for the imported GeoNames dataset or "http" for a
+// Nominatim compatible service at GEOCODER_URL. Results are cached for
+// GEOCODER_CACHE_TTL, up to GEOCODER_CACHE_SIZE of them.
+func New(places dbmodel.PlaceRepository) (Geocoder, error) {
+	var geocoder Geocoder
+	switch os.Getenv("GEOCODER") {
+	case "", "local":
+		geocoder = NewLocalGeocoder(places)
+	case "http":
+		url := os.Getenv("GEOCODER_URL")
+		if url == "" {
+			url = "https://nominatim.openstreetmap.org"
+		}
+		geocoder = NewHTTPGeocoder(url)
+	default:
+		return nil, errors.New("unknown GEOCODER")
+	}
+
+	ttl := 24 * time.Hour
+	if value, err := time.ParseDuration(os.Getenv("GEOCODER_CACHE_TTL")); err == nil && value >= 0 {
+		ttl = value
+	}
+	size := 1000
+	if value, err := strconv.Atoi(os.Getenv("GEOCODER_CACHE_SIZE")); err == nil && value >= 0 {
+		size = value
+	}
+	if ttl == 0 || size == 0 {
+		return geocoder, nil
+	}
+	return NewCachedGeocoder(geocoder, ttl, size), nil
+}
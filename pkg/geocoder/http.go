@@ -0,0 +1,122 @@
+package geocoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"locate-this/pkg/geo"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const httpGeocoderTimeout = 10 * time.Second
+
+// HTTPGeocoder queries a service implementing the search and reverse
+// endpoints of the Nominatim API, such as a self-hosted Nominatim.
+type HTTPGeocoder struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewHTTPGeocoder(baseURL string) *HTTPGeocoder {
+	return &HTTPGeocoder{client: &http.Client{Timeout: httpGeocoderTimeout}, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// nominatimPlace is a place of the jsonv2 format, where the coordinates are
+// strings.
+type nominatimPlace struct {
+	Latitude    string `json:"lat"`
+	Longitude   string `json:"lon"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+	Address     struct {
+		CountryCode string `json:"country_code"`
+	} `json:"address"`
+	Error string `json:"error"`
+}
+
+func (place *nominatimPlace) result() (Result, error) {
+	latitude, err := strconv.ParseFloat(place.Latitude, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("geocoder: invalid latitude %q", place.Latitude)
+	}
+	longitude, err := strconv.ParseFloat(place.Longitude, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("geocoder: invalid longitude %q", place.Longitude)
+	}
+	name := place.Name
+	if name == "" {
+		name, _, _ = strings.Cut(place.DisplayName, ",")
+	}
+	return Result{
+		Name:        name,
+		Address:     place.DisplayName,
+		Latitude:    latitude,
+		Longitude:   longitude,
+		CountryCode: strings.ToUpper(place.Address.CountryCode),
+		Kind:        place.Type,
+	}, nil
+}
+
+func (geocoder *HTTPGeocoder) Geocode(ctx context.Context, query string, limit int) ([]Result, error) {
+	parameters := url.Values{"q": {query}, "limit": {strconv.Itoa(limit)}}
+	var places []nominatimPlace
+	if err := geocoder.get(ctx, "/search", parameters, &places); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(places))
+	for _, place := range places {
+		result, err := place.result()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (geocoder *HTTPGeocoder) Reverse(ctx context.Context, point geo.Point) (*Result, error) {
+	parameters := url.Values{
+		"lat": {strconv.FormatFloat(point.Latitude, 'f', -1, 64)},
+		"lon": {strconv.FormatFloat(point.Longitude, 'f', -1, 64)},
+	}
+	var place nominatimPlace
+	if err := geocoder.get(ctx, "/reverse", parameters, &place); err != nil {
+		return nil, err
+	}
+	// Nominatim answers 200 with an error when nothing is found
+	if place.Error != "" {
+		return nil, ErrNotFound
+	}
+	result, err := place.result()
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (geocoder *HTTPGeocoder) get(ctx context.Context, path string, parameters url.Values, target any) error {
+	parameters.Set("format", "jsonv2")
+	parameters.Set("addressdetails", "1")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, geocoder.baseURL+path+"?"+parameters.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	// the Nominatim usage policy requires an identifying user agent
+	request.Header.Set("User-Agent", "LocateThis")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := geocoder.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("geocoder: %s responded %s", path, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
@@ -0,0 +1,175 @@
+package geocoder
+
+import (
+	"context"
+	"errors"
+	"locate-this/pkg/geo"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nominatimStub answers the search and reverse endpoints of the Nominatim API
+// with canned jsonv2 responses, recording the queries it receives.
+type nominatimStub struct {
+	queries []url.Values
+	paths   []string
+	agent   string
+	respond func(w http.ResponseWriter, r *http.Request)
+}
+
+func newNominatimStub(t *testing.T, respond func(w http.ResponseWriter, r *http.Request)) (*nominatimStub, *HTTPGeocoder) {
+	stub := &nominatimStub{respond: respond}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.paths = append(stub.paths, r.URL.Path)
+		stub.queries = append(stub.queries, r.URL.Query())
+		stub.agent = r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		stub.respond(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return stub, NewHTTPGeocoder(server.URL + "/")
+}
+
+const bellecourSearch = `[
+	{"place_id":1,"lat":"45.7578137","lon":"4.8320114","name":"Place Bellecour","display_name":"Place Bellecour, Bellecour, Lyon 2e Arrondissement, Lyon, Métropole de Lyon, Auvergne-Rhône-Alpes, France métropolitaine, 69002, France","type":"square","address":{"country_code":"fr"}},
+	{"place_id":2,"lat":"45.7570","lon":"4.8325","name":"","display_name":"Bellecour, Lyon, France","type":"subway","address":{"country_code":"fr"}}
+]`
+
+func TestHTTPGeocoderGeocode(t *testing.T) {
+	stub, geocoder := newNominatimStub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(bellecourSearch))
+	})
+
+	results, err := geocoder.Geocode(context.Background(), "place bellecour, lyon", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Result{
+		{Name: "Place Bellecour", Address: "Place Bellecour, Bellecour, Lyon 2e Arrondissement, Lyon, Métropole de Lyon, Auvergne-Rhône-Alpes, France métropolitaine, 69002, France", Latitude: 45.7578137, Longitude: 4.8320114, CountryCode: "FR", Kind: "square"},
+		// the name falls back on the first part of the display name
+		{Name: "Bellecour", Address: "Bellecour, Lyon, France", Latitude: 45.757, Longitude: 4.8325, CountryCode: "FR", Kind: "subway"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Geocode =\n%+v\nwant\n%+v", results, want)
+	}
+
+	if stub.paths[0] != "/search" {
+		t.Errorf("path = %q, want /search", stub.paths[0])
+	}
+	query := stub.queries[0]
+	if query.Get("q") != "place bellecour, lyon" || query.Get("limit") != "2" || query.Get("format") != "jsonv2" || query.Get("addressdetails") != "1" {
+		t.Errorf("query = %v", query)
+	}
+	if stub.agent != "LocateThis" {
+		t.Errorf("User-Agent = %q, want LocateThis", stub.agent)
+	}
+}
+
+func TestHTTPGeocoderReverse(t *testing.T) {
+	stub, geocoder := newNominatimStub(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("lat") == "0" {
+			// Nominatim answers 200 when nothing is found
+			w.Write([]byte(`{"error":"Unable to geocode"}`))
+			return
+		}
+		w.Write([]byte(`{"lat":"-33.8567844","lon":"151.2152967","name":"Sydney Opera House","display_name":"Sydney Opera House, Bennelong Point, Sydney NSW 2000, Australia","type":"arts_centre","address":{"country_code":"au"}}`))
+	})
+
+	result, err := geocoder.Reverse(context.Background(), geo.Point{Latitude: -33.8568, Longitude: 151.2153})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Result{Name: "Sydney Opera House", Address: "Sydney Opera House, Bennelong Point, Sydney NSW 2000, Australia", Latitude: -33.8567844, Longitude: 151.2152967, CountryCode: "AU", Kind: "arts_centre"}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Reverse = %+v, want %+v", result, want)
+	}
+	if stub.paths[0] != "/reverse" || stub.queries[0].Get("lat") != "-33.8568" || stub.queries[0].Get("lon") != "151.2153" {
+		t.Errorf("request = %s %v", stub.paths[0], stub.queries[0])
+	}
+
+	if _, err := geocoder.Reverse(context.Background(), geo.Point{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Reverse in the ocean: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestHTTPGeocoderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+	}{
+		{"server error", http.StatusInternalServerError, `{}`, "responded 500"},
+		{"rate limited", http.StatusTooManyRequests, ``, "responded 429"},
+		{"invalid JSON", http.StatusOK, `<html>`, "invalid character"},
+		{"unexpected shape", http.StatusOK, `{"lat":"1"}`, "cannot unmarshal"},
+		{"invalid latitude", http.StatusOK, `[{"lat":"north","lon":"4.8"}]`, `invalid latitude "north"`},
+		{"invalid longitude", http.StatusOK, `[{"lat":"45.7","lon":""}]`, `invalid longitude ""`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, geocoder := newNominatimStub(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			})
+			results, err := geocoder.Geocode(context.Background(), "lyon", 1)
+			if err == nil || !strings.Contains(err.Error(), test.message) {
+				t.Errorf("Geocode = %v, %v, want an error containing %q", results, err, test.message)
+			}
+			if errors.Is(err, ErrNotFound) {
+				t.Errorf("Geocode: err = %v, a failure is not ErrNotFound", err)
+			}
+		})
+	}
+
+	t.Run("no results", func(t *testing.T) {
+		_, geocoder := newNominatimStub(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[]`))
+		})
+		results, err := geocoder.Geocode(context.Background(), "nowhere", 5)
+		if err != nil || len(results) != 0 {
+			t.Errorf("Geocode = %v, %v, want no results", results, err)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		if _, err := NewHTTPGeocoder(server.URL).Geocode(context.Background(), "lyon", 1); err == nil {
+			t.Error("Geocode on a closed server did not fail")
+		}
+	})
+}
+
+func TestHTTPGeocoderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	_, geocoder := newNominatimStub(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer close(release)
+
+	geocoder.client.Timeout = 50 * time.Millisecond
+	start := time.Now()
+	if _, err := geocoder.Geocode(context.Background(), "lyon", 1); err == nil {
+		t.Fatal("Geocode on a stalled server did not fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Geocode gave up after %v", elapsed)
+	}
+
+	// the caller's deadline applies as well
+	geocoder.client.Timeout = httpGeocoderTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := geocoder.Reverse(ctx, geo.Point{Latitude: 1, Longitude: 1}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Reverse past the deadline: err = %v, want context.DeadlineExceeded", err)
+	}
+}
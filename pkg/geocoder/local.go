@@ -0,0 +1,190 @@
+package geocoder
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/geo"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// reverseRadiuses are the half sizes in degrees of the boxes searched in turn
+// for the place nearest to a point.
+var reverseRadiuses = []float64{0.1, 0.5, 2, 10}
+
+// LocalGeocoder resolves places with the GeoNames dataset imported into the
+// database. It knows cities, not streets: an address resolves to its city.
+type LocalGeocoder struct {
+	places dbmodel.PlaceRepository
+}
+
+func NewLocalGeocoder(places dbmodel.PlaceRepository) *LocalGeocoder {
+	return &LocalGeocoder{places: places}
+}
+
+// Geocode looks for the city of an address. The parts of the address between
+// commas are tried in turn, after the whole of it, so that "10 rue de Rivoli,
+// 75001 Paris, France" finds Paris. A last part of two letters is taken as a
+// country code ("Paris, US").
+func (geocoder *LocalGeocoder) Geocode(ctx context.Context, query string, limit int) ([]Result, error) {
+	var parts []string
+	for _, part := range strings.Split(strings.ToLower(query), ",") {
+		// house numbers and postal codes are not in the dataset
+		words := strings.FieldsFunc(part, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsDigit(r) })
+		if part = strings.Join(words, " "); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	country := ""
+	if len(parts) > 1 && len(parts[len(parts)-1]) == 2 {
+		country = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) == 0 {
+		return []Result{}, nil
+	}
+
+	candidates := []string{strings.Join(parts, " ")}
+	if len(parts) > 1 {
+		candidates = append(candidates, parts[1:]...)
+		candidates = append(candidates, parts[0])
+	}
+	for _, prefix := range []bool{false, true} {
+		for _, candidate := range candidates {
+			places, err := geocoder.places.FindByName(candidate, country, prefix, limit)
+			if err != nil {
+				return nil, err
+			}
+			if len(places) > 0 {
+				results := make([]Result, 0, len(places))
+				for _, place := range places {
+					results = append(results, placeResult(place))
+				}
+				return results, nil
+			}
+		}
+	}
+	return []Result{}, nil
+}
+
+// Reverse returns the city nearest to the point, searching boxes of growing
+// size around it.
+func (geocoder *LocalGeocoder) Reverse(ctx context.Context, point geo.Point) (*Result, error) {
+	for _, radius := range reverseRadiuses {
+		longitudeRadius := radius / math.Max(math.Cos(point.Latitude*math.Pi/180), 0.01)
+		places, err := geocoder.places.FindWithin(
+			point.Latitude-radius, point.Latitude+radius,
+			point.Longitude-longitudeRadius, point.Longitude+longitudeRadius,
+			5000,
+		)
+		if err != nil {
+			return nil, err
+		}
+		var nearest *dbmodel.PlaceEntry
+		best := math.Inf(1)
+		for i := range places {
+			distance := geo.Distance(point, geo.Point{Latitude: places[i].Latitude, Longitude: places[i].Longitude})
+			if distance < best {
+				nearest, best = &places[i], distance
+			}
+		}
+		if nearest != nil {
+			result := placeResult(*nearest)
+			return &result, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func placeResult(place dbmodel.PlaceEntry) Result {
+	address := place.Name
+	if place.CountryCode != "" {
+		address += ", " + place.CountryCode
+	}
+	return Result{
+		Name:        place.Name,
+		Address:     address,
+		Latitude:    place.Latitude,
+		Longitude:   place.Longitude,
+		CountryCode: place.CountryCode,
+		Kind:        "city",
+	}
+}
+
+// ImportGeoNames replaces the places with the populated places of a GeoNames
+// dump (cities500.txt, cities15000.txt, allCountries.txt, ...), read line by
+// line. It returns the number of imported places.
+func ImportGeoNames(r io.Reader, places dbmodel.PlaceRepository) (int, error) {
+	if err := places.DeleteAll(); err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	batch := make([]dbmodel.PlaceEntry, 0, 1000)
+	count, line := 0, 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 15 {
+			return count, fmt.Errorf("line %d: expected the 19 columns of a GeoNames dump", line)
+		}
+		// feature class P: cities, towns and villages
+		if fields[6] != "P" {
+			continue
+		}
+		place, err := parseGeoNamesPlace(fields)
+		if err != nil {
+			return count, fmt.Errorf("line %d: %w", line, err)
+		}
+		batch = append(batch, place)
+		if len(batch) == cap(batch) {
+			if err := places.CreateBatch(batch); err != nil {
+				return count, err
+			}
+			count += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return count, err
+	}
+	if len(batch) > 0 {
+		if err := places.CreateBatch(batch); err != nil {
+			return count, err
+		}
+		count += len(batch)
+	}
+	return count, nil
+}
+
+func parseGeoNamesPlace(fields []string) (dbmodel.PlaceEntry, error) {
+	id, err := strconv.ParseUint(fields[0], 10, 32)
+	if err != nil {
+		return dbmodel.PlaceEntry{}, fmt.Errorf("invalid geonameid %q", fields[0])
+	}
+	latitude, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil {
+		return dbmodel.PlaceEntry{}, fmt.Errorf("invalid latitude %q", fields[4])
+	}
+	longitude, err := strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return dbmodel.PlaceEntry{}, fmt.Errorf("invalid longitude %q", fields[5])
+	}
+	population, _ := strconv.ParseInt(fields[14], 10, 64)
+	return dbmodel.PlaceEntry{
+		ID:          uint(id),
+		Name:        fields[1],
+		SearchName:  strings.ToLower(fields[1]),
+		ASCIIName:   strings.ToLower(fields[2]),
+		CountryCode: fields[8],
+		Latitude:    latitude,
+		Longitude:   longitude,
+		Population:  population,
+	}, nil
+}
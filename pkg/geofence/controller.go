@@ -0,0 +1,318 @@
+package geofence
+
+import (
+	"fmt"
+	"locate-this/config"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type GeofenceConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *GeofenceConfig {
+	return &GeofenceConfig{configuration}
+}
+
+// @Summary		Create a geofence
+// @Description	Create a circle or polygon geofence in a group of the caller. Members sharing their position live with the group are notified when they enter or exit it.
+// @Tags			geofences
+// @Accept			json
+// @Produce		json
+// @Param			request	body		models.GeofenceRequest	true	"Geofence data"
+// @Success		200		{object}	models.GeofenceResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/geofences [post]
+func (config *GeofenceConfig) PostGeofenceHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.GeofenceRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	caller, ok := config.groupMember(w, r, req.GroupID)
+	if !ok {
+		return
+	}
+	if !config.visibleCenter(w, r, req, caller) {
+		return
+	}
+
+	geofenceEntry := newGeofenceEntry(req)
+	geofenceEntry.GroupID = req.GroupID
+	geofenceEntry.UserID = caller.ID
+	res, err := config.GeofenceRepository.Create(geofenceEntry)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to create geofence"})
+		return
+	}
+
+	render.JSON(w, r, geofenceResponse(res, caller, 0))
+}
+
+// @Summary		Get the geofences of a group
+// @Description	Retrieve the geofences of a group of the caller
+// @Tags			geofences
+// @Accept			json
+// @Produce		json
+// @Param			group_id	query		int	true	"Group ID"
+// @Success		200			{array}		models.GeofenceResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/geofences [get]
+func (config *GeofenceConfig) GetGeofencesHandler(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.Atoi(r.URL.Query().Get("group_id"))
+	if err != nil || groupID < 1 {
+		render.JSON(w, r, map[string]string{"error": "group_id must be >= 1"})
+		return
+	}
+	caller, ok := config.groupMember(w, r, uint(groupID))
+	if !ok {
+		return
+	}
+	adminID, err := config.groupAdminID(uint(groupID))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
+	}
+
+	geofences, err := config.GeofenceRepository.FindGeofencesForGroup(uint(groupID))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve geofences"})
+		return
+	}
+
+	geofencesResponse := make([]models.GeofenceResponse, 0)
+	for _, geofence := range geofences {
+		geofencesResponse = append(geofencesResponse, geofenceResponse(&geofence, caller, adminID))
+	}
+
+	render.JSON(w, r, geofencesResponse)
+}
+
+// @Summary		Get a geofence
+// @Description	Retrieve a geofence of a group of the caller
+// @Tags			geofences
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Geofence ID"
+// @Success		200	{object}	models.GeofenceResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/geofences/{id} [get]
+func (config *GeofenceConfig) GetGeofenceByIDHandler(w http.ResponseWriter, r *http.Request) {
+	geofence, caller, ok := config.memberGeofence(w, r)
+	if !ok {
+		return
+	}
+	adminID, err := config.groupAdminID(geofence.GroupID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
+	}
+
+	render.JSON(w, r, geofenceResponse(geofence, caller, adminID))
+}
+
+// @Summary		Get who is inside a geofence
+// @Description	Retrieve the side of the geofence each member sharing live was last seen on
+// @Tags			geofences
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Geofence ID"
+// @Success		200	{array}		models.GeofenceStateResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/geofences/{id}/states [get]
+func (config *GeofenceConfig) GetGeofenceStatesHandler(w http.ResponseWriter, r *http.Request) {
+	geofence, _, ok := config.memberGeofence(w, r)
+	if !ok {
+		return
+	}
+
+	states, err := config.GeofenceRepository.FindStatesForGeofence(geofence.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve geofence states"})
+		return
+	}
+
+	statesResponse := make([]models.GeofenceStateResponse, 0)
+	for _, state := range states {
+		statesResponse = append(statesResponse, models.GeofenceStateResponse{UserID: state.UserID, Inside: state.Inside, ChangedAt: state.ChangedAt})
+	}
+
+	render.JSON(w, r, statesResponse)
+}
+
+// @Summary		Update a geofence
+// @Description	Replace a geofence, allowed to its creator and to the group admin. The group cannot change.
+// @Tags			geofences
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int						true	"Geofence ID"
+// @Param			request	body		models.GeofenceRequest	true	"Geofence data"
+// @Success		200		{object}	models.GeofenceResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/geofences/{id} [put]
+func (config *GeofenceConfig) PutGeofenceHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.GeofenceRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	geofence, caller, ok := config.editableGeofence(w, r)
+	if !ok {
+		return
+	}
+	if req.GroupID != geofence.GroupID {
+		render.JSON(w, r, map[string]string{"error": "The group of a geofence cannot change"})
+		return
+	}
+	if !config.visibleCenter(w, r, req, caller) {
+		return
+	}
+
+	res, err := config.GeofenceRepository.Update(newGeofenceEntry(req), geofence.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to update geofence"})
+		return
+	}
+
+	render.JSON(w, r, models.NewGeofenceResponse(res))
+}
+
+// @Summary		Delete a geofence
+// @Description	Delete a geofence, allowed to its creator and to the group admin
+// @Tags			geofences
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Geofence ID"
+// @Success		200	{object}	map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/geofences/{id} [delete]
+func (config *GeofenceConfig) DeleteGeofenceHandler(w http.ResponseWriter, r *http.Request) {
+	geofence, _, ok := config.editableGeofence(w, r)
+	if !ok {
+		return
+	}
+
+	if err := config.GeofenceRepository.Delete(geofence.ID); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to delete geofence"})
+		return
+	}
+
+	render.JSON(w, r, map[string]string{"message": "Geofence deleted successfully"})
+}
+
+// groupMember checks that the caller administrates or belongs to the group,
+// rendering the error otherwise.
+func (config *GeofenceConfig) groupMember(w http.ResponseWriter, r *http.Request, groupID uint) (*dbmodel.UserEntry, bool) {
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, false
+	}
+	member, err := config.GroupEntryRepository.IsMember(groupID, caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return nil, false
+	}
+	return caller, true
+}
+
+func (config *GeofenceConfig) memberGeofence(w http.ResponseWriter, r *http.Request) (*dbmodel.GeofenceEntry, *dbmodel.UserEntry, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return nil, nil, false
+	}
+	geofence, err := config.GeofenceRepository.FindById(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve geofence"})
+		return nil, nil, false
+	}
+	caller, ok := config.groupMember(w, r, geofence.GroupID)
+	if !ok {
+		return nil, nil, false
+	}
+	return geofence, caller, true
+}
+
+func (config *GeofenceConfig) editableGeofence(w http.ResponseWriter, r *http.Request) (*dbmodel.GeofenceEntry, *dbmodel.UserEntry, bool) {
+	geofence, caller, ok := config.memberGeofence(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	if geofence.UserID == caller.ID {
+		return geofence, caller, true
+	}
+	group, err := config.GroupEntryRepository.FindById(geofence.GroupID)
+	if err != nil || group.AdminID != caller.ID {
+		render.JSON(w, r, map[string]string{"error": "Only the creator of the geofence or the group admin can do this"})
+		return nil, nil, false
+	}
+	return geofence, caller, true
+}
+
+// groupAdminID returns the ID of the admin of the group.
+func (config *GeofenceConfig) groupAdminID(groupID uint) (uint, error) {
+	group, err := config.GroupEntryRepository.FindById(groupID)
+	if err != nil {
+		return 0, err
+	}
+	return group.AdminID, nil
+}
+
+// visibleCenter checks that a circle around a location uses a location whose
+// coordinates the caller can see, rendering the error otherwise. The enter
+// and exit events would tell where the others are.
+func (config *GeofenceConfig) visibleCenter(w http.ResponseWriter, r *http.Request, req *models.GeofenceRequest, caller *dbmodel.UserEntry) bool {
+	if req.LocationID == nil {
+		return true
+	}
+	visible, err := config.LocationEntryRepository.AreCoordinatesVisibleTo(*req.LocationID, caller.ID)
+	if err != nil || !visible {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return false
+	}
+	return true
+}
+
+// geofenceResponse returns the geofence, with its webhook for its creator and
+// the group admin only.
+func geofenceResponse(geofence *dbmodel.GeofenceEntry, caller *dbmodel.UserEntry, adminID uint) models.GeofenceResponse {
+	response := models.NewGeofenceResponse(geofence)
+	if geofence.UserID != caller.ID && adminID != caller.ID {
+		response.HideWebhook()
+	}
+	return response
+}
+
+func newGeofenceEntry(req *models.GeofenceRequest) *dbmodel.GeofenceEntry {
+	return &dbmodel.GeofenceEntry{
+		Name:            req.Name,
+		Shape:           req.Shape,
+		LocationID:      req.LocationID,
+		Latitude:        req.Latitude,
+		Longitude:       req.Longitude,
+		Radius:          req.Radius,
+		Polygon:         req.Polygon,
+		Hysteresis:      *req.Hysteresis,
+		DebounceSeconds: *req.DebounceSeconds,
+		WebhookURL:      req.WebhookURL,
+	}
+}
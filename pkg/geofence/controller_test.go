@@ -0,0 +1,146 @@
+package geofence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"locate-this/config"
+	"locate-this/database"
+	"locate-this/database/dbmodel"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// geofenceTest is a group of alice, its admin, with bob and carol as members.
+type geofenceTest struct {
+	router            chi.Router
+	configuration     *config.Config
+	alice, bob, carol *dbmodel.UserEntry
+	group             *dbmodel.GroupEntry
+	visible, hidden   *dbmodel.LocationEntry
+}
+
+func newGeofenceTest(t *testing.T) *geofenceTest {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.Migrate(db)
+	configuration := &config.Config{
+		UserEntryRepository:          dbmodel.NewUserRepository(db),
+		GroupEntryRepository:         dbmodel.NewGroupRepository(db),
+		GroupUserEntryRepository:     dbmodel.NewGroupUserRepository(db),
+		GroupLocationEntryRepository: dbmodel.NewGroupLocationRepository(db),
+		LocationEntryRepository:      dbmodel.NewLocationRepository(db),
+		GeofenceRepository:           dbmodel.NewGeofenceRepository(db),
+	}
+	test := &geofenceTest{router: chi.NewRouter(), configuration: configuration}
+	test.router.Mount("/geofences", Routes(configuration))
+
+	users := make([]*dbmodel.UserEntry, 3)
+	for i, name := range []string{"alice", "bob", "carol"} {
+		users[i], err = configuration.UserEntryRepository.Create(&dbmodel.UserEntry{Email: name + "@example.com", Password: "-", Username: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	test.alice, test.bob, test.carol = users[0], users[1], users[2]
+	test.group, err = configuration.GroupEntryRepository.Create(&dbmodel.GroupEntry{Name: "family", AdminID: test.alice.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []*dbmodel.UserEntry{test.bob, test.carol} {
+		if _, err := configuration.GroupUserEntryRepository.Create(&dbmodel.GroupUserEntry{UserEntryID: member.ID, GroupEntryID: test.group.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// two locations of alice shared in the group, one with its coordinates hidden
+	for i, location := range []**dbmodel.LocationEntry{&test.visible, &test.hidden} {
+		*location, _, err = configuration.LocationEntryRepository.Create(&dbmodel.LocationEntry{UserID: test.alice.ID, Name: fmt.Sprint("home ", i), Latitude: 45.76, Longitude: 4.83})
+		if err != nil {
+			t.Fatal(err)
+		}
+		share := &dbmodel.GroupLocationEntry{GroupEntryID: test.group.ID, LocationEntryID: (*location).ID, IsVisibleCoordinates: i == 0}
+		if _, err := configuration.GroupLocationEntryRepository.Create(share); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return test
+}
+
+// request sends the request as the user and decodes the response.
+func (test *geofenceTest) request(t *testing.T, user *dbmodel.UserEntry, method, path, body string, response any) {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request = request.WithContext(context.WithValue(request.Context(), "id", user.Email))
+	w := httptest.NewRecorder()
+	test.router.ServeHTTP(w, request)
+	if err := json.Unmarshal(w.Body.Bytes(), response); err != nil {
+		t.Fatalf("%s %s = %s: %v", method, path, w.Body, err)
+	}
+}
+
+func TestPostGeofenceAroundALocation(t *testing.T) {
+	test := newGeofenceTest(t)
+	tests := []struct {
+		name     string
+		user     *dbmodel.UserEntry
+		location *dbmodel.LocationEntry
+		created  bool
+	}{
+		{"member around visible coordinates", test.bob, test.visible, true},
+		{"member around hidden coordinates", test.bob, test.hidden, false},
+		{"owner around their location", test.alice, test.hidden, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response map[string]any
+			body := fmt.Sprintf(`{"group_id": %d, "name": "home", "shape": "circle", "location_id": %d, "radius": 100}`, test.group.ID, tt.location.ID)
+			test.request(t, tt.user, "POST", "/geofences", body, &response)
+			if _, created := response["geofence_id"]; created != tt.created {
+				t.Errorf("response = %v, want created %v", response, tt.created)
+			}
+		})
+	}
+}
+
+func TestGeofenceWebhookIsOnlyShownToItsEditors(t *testing.T) {
+	test := newGeofenceTest(t)
+	var created map[string]any
+	body := fmt.Sprintf(`{"group_id": %d, "name": "school", "shape": "circle", "latitude": 45.75, "longitude": 4.85, "radius": 200, "webhook_url": "https://hooks.example.com/bob"}`, test.group.ID)
+	test.request(t, test.bob, "POST", "/geofences", body, &created)
+	if created["webhook_url"] != "https://hooks.example.com/bob" {
+		t.Fatalf("created = %v", created)
+	}
+	path := fmt.Sprintf("/geofences/%v", created["geofence_id"])
+
+	tests := []struct {
+		user    *dbmodel.UserEntry
+		webhook bool
+	}{
+		{test.bob, true},
+		{test.alice, true},
+		{test.carol, false},
+	}
+	for _, tt := range tests {
+		var geofence map[string]any
+		test.request(t, tt.user, "GET", path, "", &geofence)
+		var list []map[string]any
+		test.request(t, tt.user, "GET", fmt.Sprint("/geofences?group_id=", test.group.ID), "", &list)
+		if len(list) != 1 || geofence["name"] != "school" {
+			t.Fatalf("%s: geofence = %v, list = %v", tt.user.Username, geofence, list)
+		}
+		_, inGeofence := geofence["webhook_url"]
+		_, inList := list[0]["webhook_url"]
+		if inGeofence != tt.webhook || inList != tt.webhook {
+			t.Errorf("%s sees the webhook: %v in the geofence and %v in the list, want %v", tt.user.Username, inGeofence, inList, tt.webhook)
+		}
+	}
+}
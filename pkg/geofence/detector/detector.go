@@ -0,0 +1,167 @@
+// Package detector tells when the members sharing their position live enter
+// or exit the geofences of their groups.
+package detector
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/events"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/models"
+	"locate-this/pkg/notify"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Detector struct {
+	geofences dbmodel.GeofenceRepository
+	locations dbmodel.LocationRepository
+	eventHub  *events.Hub
+	notifier  notify.Notifier
+}
+
+func NewDetector(geofences dbmodel.GeofenceRepository, locations dbmodel.LocationRepository, eventHub *events.Hub, notifier notify.Notifier) *Detector {
+	return &Detector{geofences: geofences, locations: locations, eventHub: eventHub, notifier: notifier}
+}
+
+// CheckPosition evaluates the geofences of a group against a position a
+// member published live, and notifies the group and the webhooks of the
+// geofences the member entered or exited.
+func (detector *Detector) CheckPosition(user *dbmodel.UserEntry, groupID uint, position geo.Point, accuracy float64, now time.Time) {
+	geofences, err := detector.geofences.FindGeofencesForGroup(groupID)
+	if err != nil {
+		log.Println("Failed to retrieve geofences of group", groupID, err)
+		return
+	}
+
+	for i := range geofences {
+		geofence := &geofences[i]
+		distance, ok := detector.signedDistance(geofence, position)
+		if !ok {
+			continue
+		}
+		// positions within the margin or less precise than it do not tell the side
+		var observed *bool
+		margin := max(geofence.Hysteresis, accuracy)
+		if distance < -margin {
+			observed = new(bool)
+			*observed = true
+		} else if distance > margin {
+			observed = new(bool)
+		}
+
+		state, err := detector.geofences.FindState(geofence.ID, user.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// the first known side is recorded without notifying
+			if observed != nil {
+				state = &dbmodel.GeofenceStateEntry{GeofenceID: geofence.ID, UserID: user.ID, Inside: *observed, ChangedAt: now}
+				if err := detector.geofences.SaveState(state); err != nil {
+					log.Println("Failed to save geofence state", geofence.ID, err)
+				}
+			}
+			continue
+		} else if err != nil {
+			log.Println("Failed to retrieve geofence state", geofence.ID, err)
+			continue
+		}
+
+		changed, dirty := debounce(state, observed, time.Duration(geofence.DebounceSeconds)*time.Second, now)
+		if dirty {
+			if err := detector.geofences.SaveState(state); err != nil {
+				log.Println("Failed to save geofence state", geofence.ID, err)
+				continue
+			}
+		}
+		if changed {
+			detector.notify(geofence, user, state.Inside, position, now)
+		}
+	}
+}
+
+// debounce moves the state towards the observed side. A change is pending
+// until the member has been observed on the new side for the delay, and is
+// forgotten if they are observed back on the previous side meanwhile.
+func debounce(state *dbmodel.GeofenceStateEntry, observed *bool, delay time.Duration, now time.Time) (changed bool, dirty bool) {
+	if observed == nil {
+		return false, false
+	}
+	if *observed == state.Inside {
+		if state.PendingInside == nil {
+			return false, false
+		}
+		state.PendingInside, state.PendingSince = nil, nil
+		return false, true
+	}
+
+	if state.PendingInside == nil || *state.PendingInside != *observed {
+		state.PendingInside, state.PendingSince = observed, &now
+	}
+	if now.Sub(*state.PendingSince) < delay {
+		return false, true
+	}
+	state.Inside, state.ChangedAt = *observed, now
+	state.PendingInside, state.PendingSince = nil, nil
+	return true, true
+}
+
+// signedDistance returns the distance in meters between the position and the
+// boundary of the geofence, negative inside.
+func (detector *Detector) signedDistance(geofence *dbmodel.GeofenceEntry, position geo.Point) (float64, bool) {
+	switch geofence.Shape {
+	case dbmodel.GeofenceShapeCircle:
+		var center geo.Point
+		if geofence.LocationID != nil {
+			// the coordinates of the location may have been hidden from the
+			// creator of the geofence since, its events would tell them
+			visible, err := detector.locations.AreCoordinatesVisibleTo(*geofence.LocationID, geofence.UserID)
+			if err != nil || !visible {
+				return 0, false
+			}
+			location, err := detector.locations.FindById(*geofence.LocationID)
+			if err != nil {
+				return 0, false
+			}
+			center = geo.Point{Latitude: location.Latitude, Longitude: location.Longitude}
+		} else if geofence.Latitude != nil && geofence.Longitude != nil {
+			center = geo.Point{Latitude: *geofence.Latitude, Longitude: *geofence.Longitude}
+		} else {
+			return 0, false
+		}
+		return geo.Distance(center, position) - geofence.Radius, true
+	case dbmodel.GeofenceShapePolygon:
+		polygon := make([]geo.Point, len(geofence.Polygon))
+		for i, vertex := range geofence.Polygon {
+			polygon[i] = geo.Point{Latitude: vertex[0], Longitude: vertex[1]}
+		}
+		distance := geo.PolygonBoundaryDistance(position, polygon)
+		if geo.InPolygon(position, polygon) {
+			return -distance, true
+		}
+		return distance, true
+	}
+	return 0, false
+}
+
+func (detector *Detector) notify(geofence *dbmodel.GeofenceEntry, user *dbmodel.UserEntry, inside bool, position geo.Point, now time.Time) {
+	eventType := events.GeofenceExited
+	if inside {
+		eventType = events.GeofenceEntered
+	}
+	detector.eventHub.Publish(events.Event{Type: eventType, GroupID: geofence.GroupID, UserID: user.ID, GeofenceID: geofence.ID})
+
+	if geofence.WebhookURL != "" {
+		detector.notifier.Notify(geofence.WebhookURL, models.GeofenceEventPayload{
+			Event:        eventType,
+			GeofenceID:   geofence.ID,
+			GeofenceName: geofence.Name,
+			GroupID:      geofence.GroupID,
+			UserID:       user.ID,
+			Username:     user.Username,
+			Latitude:     position.Latitude,
+			Longitude:    position.Longitude,
+			Time:         now,
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package detector
+
+import (
+	"locate-this/database"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/events"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/models"
+	"math"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type recordingNotifier struct {
+	mutex    sync.Mutex
+	payloads []models.GeofenceEventPayload
+}
+
+func (notifier *recordingNotifier) Notify(url string, payload any) {
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+	notifier.payloads = append(notifier.payloads, payload.(models.GeofenceEventPayload))
+}
+
+type fixture struct {
+	db           *gorm.DB
+	detector     *Detector
+	geofences    dbmodel.GeofenceRepository
+	locations    dbmodel.LocationRepository
+	notifier     *recordingNotifier
+	subscription *events.Subscription
+	user         *dbmodel.UserEntry
+	group        *dbmodel.GroupEntry
+}
+
+func newFixture(t *testing.T) *fixture {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.Migrate(db)
+
+	user, err := dbmodel.NewUserRepository(db).Create(&dbmodel.UserEntry{Email: "a@example.com", Password: "-", Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := dbmodel.NewGroupRepository(db).Create(&dbmodel.GroupEntry{Name: "family", AdminID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hub := events.NewHub()
+	subscription, _ := hub.Subscribe(group.ID, 0)
+	t.Cleanup(subscription.Unsubscribe)
+	f := &fixture{
+		db:           db,
+		geofences:    dbmodel.NewGeofenceRepository(db),
+		locations:    dbmodel.NewLocationRepository(db),
+		notifier:     &recordingNotifier{},
+		subscription: subscription,
+		user:         user,
+		group:        group,
+	}
+	f.detector = NewDetector(f.geofences, f.locations, hub, f.notifier)
+	return f
+}
+
+func (f *fixture) createGeofence(t *testing.T, geofence *dbmodel.GeofenceEntry) *dbmodel.GeofenceEntry {
+	geofence.GroupID, geofence.UserID = f.group.ID, f.user.ID
+	geofence.WebhookURL = "https://hooks.example.com/geofence"
+	res, err := f.geofences.Create(geofence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// nextEvents returns the events published since the previous call.
+func (f *fixture) nextEvents() []events.Event {
+	published := make([]events.Event, 0)
+	for {
+		select {
+		case event := <-f.subscription.Events():
+			published = append(published, event)
+		default:
+			return published
+		}
+	}
+}
+
+var center = geo.Point{Latitude: 45, Longitude: 5}
+
+// north returns the point the given meters north of the center.
+func north(meters float64) geo.Point {
+	return geo.Point{Latitude: center.Latitude + meters/(geo.EarthRadius*math.Pi/180), Longitude: center.Longitude}
+}
+
+func floatPointer(value float64) *float64 {
+	return &value
+}
+
+func TestCheckPositionCircle(t *testing.T) {
+	f := newFixture(t)
+	geofence := f.createGeofence(t, &dbmodel.GeofenceEntry{
+		Name: "home", Shape: dbmodel.GeofenceShapeCircle,
+		Latitude: floatPointer(center.Latitude), Longitude: floatPointer(center.Longitude),
+		Radius: 100, Hysteresis: 10, DebounceSeconds: 30,
+	})
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name     string
+		position geo.Point
+		accuracy float64
+		after    time.Duration
+		event    string
+		inside   bool
+	}{
+		{"first position is recorded silently", north(500), 5, 0, "", false},
+		{"entering starts the debounce", north(50), 5, 10 * time.Second, "", false},
+		{"still debouncing", north(40), 5, 30 * time.Second, "", false},
+		{"entered after the delay", north(30), 5, 45 * time.Second, events.GeofenceEntered, true},
+		{"within the hysteresis", north(105), 5, 100 * time.Second, "", true},
+		{"beyond the hysteresis but less precise", north(180), 100, 110 * time.Second, "", true},
+		{"brief exit", north(150), 5, 120 * time.Second, "", true},
+		{"back inside cancels the exit", north(20), 5, 140 * time.Second, "", true},
+		{"exit again", north(150), 5, 200 * time.Second, "", true},
+		{"imprecise positions do not confirm", north(150), 500, 240 * time.Second, "", true},
+		{"exited after the delay", north(200), 5, 240 * time.Second, events.GeofenceExited, false},
+		{"staying outside", north(300), 5, 400 * time.Second, "", false},
+	}
+	for _, step := range steps {
+		now := start.Add(step.after)
+		f.detector.CheckPosition(f.user, f.group.ID, step.position, step.accuracy, now)
+
+		published := f.nextEvents()
+		if step.event == "" && len(published) != 0 {
+			t.Fatalf("%s: published %v", step.name, published)
+		}
+		if step.event != "" && (len(published) != 1 || published[0].Type != step.event || published[0].GeofenceID != geofence.ID || published[0].UserID != f.user.ID) {
+			t.Fatalf("%s: published %v, want one %s event", step.name, published, step.event)
+		}
+		state, err := f.geofences.FindState(geofence.ID, f.user.ID)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if state.Inside != step.inside {
+			t.Fatalf("%s: inside = %v, want %v", step.name, state.Inside, step.inside)
+		}
+	}
+
+	if len(f.notifier.payloads) != 2 {
+		t.Fatalf("%d webhooks sent, want 2", len(f.notifier.payloads))
+	}
+	entered := f.notifier.payloads[0]
+	if entered.Event != events.GeofenceEntered || entered.GeofenceName != "home" || entered.Username != "alice" || !entered.Time.Equal(start.Add(45*time.Second)) {
+		t.Errorf("webhook payload = %+v", entered)
+	}
+	if f.notifier.payloads[1].Event != events.GeofenceExited {
+		t.Errorf("second webhook is %s, want %s", f.notifier.payloads[1].Event, events.GeofenceExited)
+	}
+}
+
+func TestCheckPositionLocationCircle(t *testing.T) {
+	f := newFixture(t)
+	location, _, err := f.locations.Create(&dbmodel.LocationEntry{UserID: f.user.ID, Name: "office", Latitude: center.Latitude, Longitude: center.Longitude})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.createGeofence(t, &dbmodel.GeofenceEntry{Name: "office", Shape: dbmodel.GeofenceShapeCircle, LocationID: &location.ID, Radius: 100})
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	f.detector.CheckPosition(f.user, f.group.ID, north(500), 0, now)
+	f.detector.CheckPosition(f.user, f.group.ID, north(50), 0, now.Add(time.Minute))
+	if published := f.nextEvents(); len(published) != 1 || published[0].Type != events.GeofenceEntered {
+		t.Fatalf("published %v, want an enter event", published)
+	}
+
+	// the circle follows its location
+	location.Latitude = north(1000).Latitude
+	if _, _, err := f.locations.Update(location, location.ID); err != nil {
+		t.Fatal(err)
+	}
+	f.detector.CheckPosition(f.user, f.group.ID, north(50), 0, now.Add(2*time.Minute))
+	if published := f.nextEvents(); len(published) != 1 || published[0].Type != events.GeofenceExited {
+		t.Fatalf("published %v, want an exit event", published)
+	}
+}
+
+func TestCheckPositionHiddenLocationCircle(t *testing.T) {
+	f := newFixture(t)
+	other, err := dbmodel.NewUserRepository(f.db).Create(&dbmodel.UserEntry{Email: "b@example.com", Password: "-", Username: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	location, _, err := f.locations.Create(&dbmodel.LocationEntry{UserID: other.ID, Name: "home", Latitude: center.Latitude, Longitude: center.Longitude})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupLocations := dbmodel.NewGroupLocationRepository(f.db)
+	share := &dbmodel.GroupLocationEntry{GroupEntryID: f.group.ID, LocationEntryID: location.ID, IsVisibleCoordinates: true}
+	if _, err := groupLocations.Create(share); err != nil {
+		t.Fatal(err)
+	}
+	f.createGeofence(t, &dbmodel.GeofenceEntry{Name: "bob's home", Shape: dbmodel.GeofenceShapeCircle, LocationID: &location.ID, Radius: 100})
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	f.detector.CheckPosition(f.user, f.group.ID, north(500), 0, now)
+	f.detector.CheckPosition(f.user, f.group.ID, north(50), 0, now.Add(time.Minute))
+	if published := f.nextEvents(); len(published) != 1 || published[0].Type != events.GeofenceEntered {
+		t.Fatalf("published %v, want an enter event", published)
+	}
+
+	// once its coordinates are hidden from the creator, the circle is ignored
+	share.IsVisibleCoordinates = false
+	if _, err := groupLocations.Update(share); err != nil {
+		t.Fatal(err)
+	}
+	f.detector.CheckPosition(f.user, f.group.ID, north(500), 0, now.Add(2*time.Minute))
+	f.detector.CheckPosition(f.user, f.group.ID, north(50), 0, now.Add(3*time.Minute))
+	if published := f.nextEvents(); len(published) != 0 {
+		t.Fatalf("published %v for a location whose coordinates are hidden", published)
+	}
+}
+
+func TestCheckPositionPolygon(t *testing.T) {
+	f := newFixture(t)
+	// a U open to the north, about 3 km wide
+	f.createGeofence(t, &dbmodel.GeofenceEntry{
+		Name: "campus", Shape: dbmodel.GeofenceShapePolygon, Hysteresis: 20,
+		Polygon: [][2]float64{{45, 5}, {45, 5.03}, {45.03, 5.03}, {45.03, 5.02}, {45.01, 5.02}, {45.01, 5.01}, {45.03, 5.01}, {45.03, 5}},
+	})
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name     string
+		position geo.Point
+		event    string
+	}{
+		{"in the notch", geo.Point{Latitude: 45.02, Longitude: 5.015}, ""},
+		{"in an arm", geo.Point{Latitude: 45.02, Longitude: 5.005}, events.GeofenceEntered},
+		{"on the edge of the notch", geo.Point{Latitude: 45.02, Longitude: 5.01}, ""},
+		{"across the notch", geo.Point{Latitude: 45.02, Longitude: 5.015}, events.GeofenceExited},
+		{"in the other arm", geo.Point{Latitude: 45.02, Longitude: 5.025}, events.GeofenceEntered},
+	}
+	for i, step := range steps {
+		f.detector.CheckPosition(f.user, f.group.ID, step.position, 0, now.Add(time.Duration(i)*time.Minute))
+		published := f.nextEvents()
+		if step.event == "" && len(published) != 0 || step.event != "" && (len(published) != 1 || published[0].Type != step.event) {
+			t.Fatalf("%s: published %v, want %q", step.name, published, step.event)
+		}
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	inside, outside := true, false
+	pending := func(side bool, since time.Duration) *dbmodel.GeofenceStateEntry {
+		pendingSince := start.Add(since)
+		return &dbmodel.GeofenceStateEntry{Inside: !side, ChangedAt: start, PendingInside: &side, PendingSince: &pendingSince}
+	}
+
+	tests := []struct {
+		name     string
+		state    *dbmodel.GeofenceStateEntry
+		observed *bool
+		delay    time.Duration
+		now      time.Duration
+		changed  bool
+		dirty    bool
+		inside   bool
+		pending  bool
+	}{
+		{"unknown side", &dbmodel.GeofenceStateEntry{Inside: true}, nil, time.Minute, 0, false, false, true, false},
+		{"same side", &dbmodel.GeofenceStateEntry{Inside: true}, &inside, time.Minute, 0, false, false, true, false},
+		{"no delay", &dbmodel.GeofenceStateEntry{Inside: false}, &inside, 0, 0, true, true, true, false},
+		{"starts pending", &dbmodel.GeofenceStateEntry{Inside: false}, &inside, time.Minute, 0, false, true, false, true},
+		{"still pending", pending(inside, 0), &inside, time.Minute, 59 * time.Second, false, true, false, true},
+		{"confirmed", pending(inside, 0), &inside, time.Minute, time.Minute, true, true, true, false},
+		{"back before the delay", pending(inside, 0), &outside, time.Minute, 30 * time.Second, false, true, false, false},
+		{"unknown while pending", pending(inside, 0), nil, time.Minute, 2 * time.Minute, false, false, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changed, dirty := debounce(test.state, test.observed, test.delay, start.Add(test.now))
+			if changed != test.changed || dirty != test.dirty {
+				t.Errorf("debounce = %v, %v, want %v, %v", changed, dirty, test.changed, test.dirty)
+			}
+			if test.state.Inside != test.inside || (test.state.PendingInside != nil) != test.pending {
+				t.Errorf("state = %+v, want inside %v and pending %v", test.state, test.inside, test.pending)
+			}
+		})
+	}
+}
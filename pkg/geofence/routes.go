@@ -0,0 +1,30 @@
+package geofence
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Geofences:
+- POST /geofences
+- GET /geofences?group_id=
+- GET /geofences/{id}
+- PUT /geofences/{id}
+- DELETE /geofences/{id}
+
+- GET /geofences/{id}/states
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	GeofenceConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Post("/", GeofenceConfig.PostGeofenceHandler)
+	router.Get("/", GeofenceConfig.GetGeofencesHandler)
+	router.Get("/{id}", GeofenceConfig.GetGeofenceByIDHandler)
+	router.Put("/{id}", GeofenceConfig.PutGeofenceHandler)
+	router.Delete("/{id}", GeofenceConfig.DeleteGeofenceHandler)
+	router.Get("/{id}/states", GeofenceConfig.GetGeofenceStatesHandler)
+	return router
+}
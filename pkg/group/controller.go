@@ -1,9 +1,12 @@
 package group
 
 import (
+	"errors"
 	"fmt"
 	"locate-this/config"
 	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/location"
 	"locate-this/pkg/models"
 	"net/http"
 	"strconv"
@@ -37,28 +40,48 @@ func (config *GroupConfig) PostGroupHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	groupEntry := &dbmodel.GroupEntry{Name: req.Name}
+	admin, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	groupEntry := &dbmodel.GroupEntry{Name: req.Name, AdminID: admin.ID}
 	res, err := config.GroupEntryRepository.Create(groupEntry)
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to create group"})
 		return
 	}
 
-	groupResponse := &models.GroupResponse{ID: res.ID, Name: res.Name}
+	groupResponse := &models.GroupResponse{ID: res.ID, Name: res.Name, AdminID: res.AdminID}
 	render.JSON(w, r, groupResponse)
 }
 
 // @Summary		Get all groups
-// @Description	Retrieve a list of all groups
+// @Description	Retrieve a page of all groups
 // @Tags			groups
 // @Accept			json
 // @Produce		json
-// @Success		200	{array}	models.GroupResponse
+// @Param			limit			query		int		false	"Page size"
+// @Param			after			query		string	false	"Cursor returned by the previous page"
+// @Param			sort			query		string	false	"Sort field, prefix with - for descending order"
+// @Param			order			query		string	false	"asc or desc"
+// @Param			name			query		string	false	"Name contains"
+// @Param			created_before	query		string	false	"RFC 3339 date"
+// @Param			created_after	query		string	false	"RFC 3339 date"
+// @Param			owner			query		int		false	"Admin user ID"
+// @Success		200	{object}	models.PageResponse{data=[]models.GroupResponse}
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
 // @Router			/groups [get]
 func (config *GroupConfig) GetAllGroupHandler(w http.ResponseWriter, r *http.Request) {
-	entries, err := config.GroupEntryRepository.FindAll()
+	options, err := models.ParseQueryOptions(r, dbmodel.GroupSortFields)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	entries, page, err := config.GroupEntryRepository.FindAll(options)
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to retrieve groups"})
 		return
@@ -67,12 +90,13 @@ func (config *GroupConfig) GetAllGroupHandler(w http.ResponseWriter, r *http.Req
 	groupsResponse := make([]models.GroupResponse, 0)
 	for _, group := range entries {
 		groupsResponse = append(groupsResponse, models.GroupResponse{
-			ID:   group.ID,
-			Name: group.Name,
+			ID:      group.ID,
+			Name:    group.Name,
+			AdminID: group.AdminID,
 		})
 	}
 
-	render.JSON(w, r, groupsResponse)
+	render.JSON(w, r, models.NewPageResponse(groupsResponse, page))
 }
 
 // @Summary		Get group by ID
@@ -107,20 +131,42 @@ func (config *GroupConfig) GetGroupByIDHandler(w http.ResponseWriter, r *http.Re
 
 	var locations []models.LocationResponse
 	for _, location := range entry.Locations {
-		locations = append(locations, models.LocationResponse{ID: location.ID, Name: location.Name, Latitude: location.Latitude, Longitude: location.Longitude, UserID: location.User.ID})
+		locations = append(locations, models.NewLocationResponse(location))
 	}
 
-	groupResponse := &models.GroupResponse{ID: entry.ID, Name: entry.Name, Users: users, Locations: locations}
+	groupResponse := &models.GroupResponse{ID: entry.ID, Name: entry.Name, AdminID: entry.AdminID, Users: users, Locations: locations}
 	render.JSON(w, r, groupResponse)
 }
 
 // @Summary		Get locations for a group
-// @Description	Retrieve all locations belonging to a group
+// @Description	Retrieve all locations belonging to a group of the caller. The coordinates, altitude, accuracy and address of the locations whose coordinates are hidden from the caller are left out, and coordinates_hidden is set; the address, altitude and accuracy filters do not match them.
 // @Tags			groups
 // @Accept			json
 // @Produce		json
-// @Param			id	path		int	true	"Group ID"
-// @Success		200	{array}		models.LocationResponse
+// @Param			id				path		int		true	"Group ID"
+// @Param			limit			query		int		false	"Page size"
+// @Param			after			query		string	false	"Cursor returned by the previous page"
+// @Param			sort			query		string	false	"Sort field, prefix with - for descending order"
+// @Param			order			query		string	false	"asc or desc"
+// @Param			name			query		string	false	"Name contains"
+// @Param			created_before	query		string	false	"RFC 3339 date"
+// @Param			created_after	query		string	false	"RFC 3339 date"
+// @Param			owner			query		int		false	"Owner user ID"
+// @Param			category		query		string	false	"Category"
+// @Param			description		query		string	false	"Description contains"
+// @Param			address			query		string	false	"Address contains"
+// @Param			color			query		string	false	"Color"
+// @Param			icon			query		string	false	"Icon"
+// @Param			min_altitude	query		number	false	"Minimum altitude in meters"
+// @Param			max_altitude	query		number	false	"Maximum altitude in meters"
+// @Param			max_accuracy	query		number	false	"Maximum accuracy radius in meters"
+// @Param			tag				query		[]string	false	"Tags" collectionFormat(multi)
+// @Param			tag_mode		query		string	false	"any (default) or all of the tags"
+// @Param			format			query		string	false	"json (default) or csv to download every page"
+// @Param			delimiter		query		string	false	"CSV delimiter: comma (default), semicolon, pipe or tab"
+// @Param			decimal			query		string	false	"CSV decimal separator: point (default) or comma"
+// @Param			formats			query		string	false	"Comma separated coordinate formats to add: dms, utm, mgrs, geohash, pluscode"
+// @Success		200	{object}	models.PageResponse{data=[]models.LocationResponse}
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
 // @Router			/groups/{id}/locations [get]
@@ -134,23 +180,51 @@ func (config *GroupConfig) GetLocationsForGroupHandler(w http.ResponseWriter, r
 		return
 	}
 
-	locations, err := config.GroupEntryRepository.FindLocationsForGroup(uint(id))
+	options, err := models.ParseQueryOptions(r, dbmodel.LocationSortFields)
 	if err != nil {
-		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		render.JSON(w, r, map[string]string{"error": err.Error()})
 		return
 	}
 
-	locationsResponse := make([]models.LocationResponse, 0)
-	for _, location := range locations {
-		locationsResponse = append(locationsResponse, models.LocationResponse{
-			ID:        location.ID,
-			Name:      location.Name,
-			Latitude:  location.Latitude,
-			Longitude: location.Longitude,
-		})
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	member, err := config.GroupEntryRepository.IsMember(uint(id), caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
 	}
+	options.TagViewerID = caller.ID
+
+	location.RenderLocationList(w, r, options, func(options dbmodel.QueryOptions) ([]models.LocationResponse, dbmodel.PageInfo, error) {
+		locations, page, err := config.GroupEntryRepository.FindLocationsForGroup(uint(id), caller.ID, options)
+		if err != nil {
+			return nil, page, errors.New("Failed to retrieve locations")
+		}
+
+		locationsResponse := make([]models.LocationResponse, 0)
+		for _, location := range locations {
+			locationsResponse = append(locationsResponse, models.NewLocationResponse(&location))
+		}
+		visible, err := config.LocationEntryRepository.FindVisibleLocationIDsInGroup(uint(id), caller.ID, models.LocationIDs(locationsResponse))
+		if err != nil {
+			return nil, page, errors.New("Failed to retrieve locations")
+		}
+		for i := range locationsResponse {
+			if !visible[locationsResponse[i].ID] {
+				locationsResponse[i].HideCoordinates()
+			}
+		}
 
-	render.JSON(w, r, locationsResponse)
+		tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(locationsResponse))
+		if err != nil {
+			return nil, page, errors.New("Failed to retrieve tags")
+		}
+		models.AttachTags(locationsResponse, tags)
+		return locationsResponse, page, nil
+	})
 }
 
 // @Summary		Get users for a group
@@ -158,8 +232,15 @@ func (config *GroupConfig) GetLocationsForGroupHandler(w http.ResponseWriter, r
 // @Tags			groups
 // @Accept			json
 // @Produce		json
-// @Param			id	path		int	true	"Group ID"
-// @Success		200	{array}		models.UserResponse
+// @Param			id				path		int		true	"Group ID"
+// @Param			limit			query		int		false	"Page size"
+// @Param			after			query		string	false	"Cursor returned by the previous page"
+// @Param			sort			query		string	false	"Sort field, prefix with - for descending order"
+// @Param			order			query		string	false	"asc or desc"
+// @Param			name			query		string	false	"Username contains"
+// @Param			created_before	query		string	false	"RFC 3339 date"
+// @Param			created_after	query		string	false	"RFC 3339 date"
+// @Success		200	{object}	models.PageResponse{data=[]models.UserResponse}
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
 // @Router			/groups/{id}/users [get]
@@ -173,7 +254,13 @@ func (config *GroupConfig) GetUsersForGroupHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	users, err := config.GroupEntryRepository.FindUsersForGroup(uint(id))
+	options, err := models.ParseQueryOptions(r, dbmodel.UserSortFields)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	users, page, err := config.GroupEntryRepository.FindUsersForGroup(uint(id), options)
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to retrieve users"})
 		return
@@ -188,7 +275,44 @@ func (config *GroupConfig) GetUsersForGroupHandler(w http.ResponseWriter, r *htt
 		})
 	}
 
-	render.JSON(w, r, usersResponse)
+	render.JSON(w, r, models.NewPageResponse(usersResponse, page))
+}
+
+// @Summary		Export the locations of a group
+// @Description	Stream the locations shared in a group as a GeoJSON FeatureCollection, GPX waypoints or KML placemarks. Locations whose coordinates are hidden from the caller have a null geometry and no address in GeoJSON and are left out of GPX and KML.
+// @Tags			groups
+// @Produce		json
+// @Produce		xml
+// @Param			id		path		int		true	"Group ID"
+// @Param			format	path		string	true	"geojson, gpx or kml"
+// @Success		200		{object}	models.LocationFeatureCollection
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/groups/{id}/locations.{format} [get]
+func (config *GroupConfig) GetLocationsExportForGroupHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	member, err := config.GroupEntryRepository.IsMember(uint(id), caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
+	}
+
+	location.ExportLocations(w, r, chi.URLParam(r, "format"), "group-"+strconv.Itoa(id)+"-locations", func(fn dbmodel.ExportBatchFunc) error {
+		return config.LocationEntryRepository.ExportLocationsForGroup(uint(id), caller.ID, fn)
+	})
 }
 
 // @Summary		Update a group
@@ -254,5 +378,7 @@ func (config *GroupConfig) DeleteGroupHandler(w http.ResponseWriter, r *http.Req
 		render.JSON(w, r, map[string]string{"error": "Failed to delete group"})
 		return
 	}
+	// the locations of the group disappear from the tiles of its members
+	config.TileCache.InvalidateAll()
 	render.JSON(w, r, "Succefully deleted entry")
 }
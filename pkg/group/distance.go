@@ -0,0 +1,155 @@
+package group
+
+import (
+	"errors"
+	"fmt"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/models"
+	"locate-this/pkg/routing"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// @Summary		Get the distances between the locations of a group
+// @Description	Compute the great-circle distance from each source to each destination, among the locations shared in the group whose coordinates are visible to the caller, and the travel time for each mode. Travel times are estimated from the distance lengthened by 30% at the average speed of the mode (walk 5 km/h, bike 15 km/h, car 50 km/h unless configured), or computed along the roads when a router is configured for the mode. Sources and destinations default to every location, 100 of each at most.
+// @Tags			groups
+// @Produce		json
+// @Param			id				path		int		true	"Group ID"
+// @Param			sources			query		string	false	"Comma separated location IDs"
+// @Param			destinations	query		string	false	"Comma separated location IDs"
+// @Param			modes			query		string	false	"Comma separated modes of travel: walk, bike, car (all by default)"
+// @Success		200				{object}	models.DistanceMatrixResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/groups/{id}/distance-matrix [get]
+func (config *GroupConfig) GetDistanceMatrixHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+	query := r.URL.Query()
+	sourceIDs, err := models.ParseIDList(query.Get("sources"))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "sources: " + err.Error()})
+		return
+	}
+	destinationIDs, err := models.ParseIDList(query.Get("destinations"))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "destinations: " + err.Error()})
+		return
+	}
+	modes, err := routing.ParseModes(query.Get("modes"))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	member, err := config.GroupEntryRepository.IsMember(uint(id), caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
+	}
+	locations, err := config.LocationEntryRepository.FindLocationPointsForGroup(uint(id), caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+	sources, err := selectLocations(locations, sourceIDs)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "sources: " + err.Error()})
+		return
+	}
+	destinations, err := selectLocations(locations, destinationIDs)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "destinations: " + err.Error()})
+		return
+	}
+
+	sourcePoints, destinationPoints := locationPoints(sources), locationPoints(destinations)
+	distances := routing.GreatCircleDistances(sourcePoints, destinationPoints)
+	matrixResponse := models.DistanceMatrixResponse{
+		Sources:      matrixLocations(sources),
+		Destinations: matrixLocations(destinations),
+		Distances:    distances,
+		Travel:       make([]models.TravelMatrixResponse, 0, len(modes)),
+	}
+	for _, mode := range modes {
+		travel := models.TravelMatrixResponse{Mode: string(mode), Method: "estimate"}
+		if config.Router != nil && len(sources) > 0 && len(destinations) > 0 {
+			table, err := config.Router.Table(r.Context(), mode, sourcePoints, destinationPoints)
+			if err == nil {
+				travel.Method, travel.Distances, travel.Durations = "route", table.Distances, table.Durations
+			} else if !errors.Is(err, routing.ErrModeNotRouted) {
+				log.Println("Failed to route mode", mode, "in group", id, err)
+			}
+		}
+		if travel.Durations == nil {
+			travel.Durations = routing.EstimateDurations(distances, mode)
+		}
+		matrixResponse.Travel = append(matrixResponse.Travel, travel)
+	}
+	render.JSON(w, r, matrixResponse)
+}
+
+// selectLocations returns the locations of the IDs, in their order, or every
+// location when there are none.
+func selectLocations(locations []dbmodel.LocationEntry, ids []uint) ([]dbmodel.LocationEntry, error) {
+	if ids == nil {
+		if len(locations) > routing.MaxTableSize {
+			return nil, errors.New("the group has more than 100 locations, choose some of them")
+		}
+		return locations, nil
+	}
+	if len(ids) > routing.MaxTableSize {
+		return nil, errors.New("at most 100 locations")
+	}
+	byID := make(map[uint]dbmodel.LocationEntry, len(locations))
+	for _, location := range locations {
+		byID[location.ID] = location
+	}
+	selected := make([]dbmodel.LocationEntry, 0, len(ids))
+	for _, id := range ids {
+		location, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("location %d is not shared in the group or its coordinates are hidden", id)
+		}
+		selected = append(selected, location)
+	}
+	return selected, nil
+}
+
+func locationPoints(locations []dbmodel.LocationEntry) []geo.Point {
+	points := make([]geo.Point, 0, len(locations))
+	for _, location := range locations {
+		points = append(points, geo.Point{Latitude: location.Latitude, Longitude: location.Longitude})
+	}
+	return points
+}
+
+func matrixLocations(locations []dbmodel.LocationEntry) []models.DistanceMatrixLocation {
+	matrixLocations := make([]models.DistanceMatrixLocation, 0, len(locations))
+	for _, location := range locations {
+		matrixLocations = append(matrixLocations, models.DistanceMatrixLocation{
+			ID:        location.ID,
+			Name:      location.Name,
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+		})
+	}
+	return matrixLocations
+}
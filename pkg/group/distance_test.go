@@ -0,0 +1,155 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"locate-this/config"
+	"locate-this/database"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/models"
+	"locate-this/pkg/routing"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// newDistanceTest returns the group controllers on a new database, with a
+// group of alice sharing three locations in Lyon.
+func newDistanceTest(t *testing.T, router routing.Router) (*GroupConfig, *dbmodel.UserEntry, *dbmodel.GroupEntry, []geo.Point) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.Migrate(db)
+	configuration := &config.Config{
+		UserEntryRepository:          dbmodel.NewUserRepository(db),
+		GroupEntryRepository:         dbmodel.NewGroupRepository(db),
+		GroupLocationEntryRepository: dbmodel.NewGroupLocationRepository(db),
+		LocationEntryRepository:      dbmodel.NewLocationRepository(db),
+		Router:                       router,
+	}
+	user, err := configuration.UserEntryRepository.Create(&dbmodel.UserEntry{Email: "a@example.com", Password: "-", Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := configuration.GroupEntryRepository.Create(&dbmodel.GroupEntry{Name: "lyon", AdminID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := []geo.Point{
+		{Latitude: 45.757814, Longitude: 4.832011},
+		{Latitude: 45.762207, Longitude: 4.822104},
+		{Latitude: 45.760585, Longitude: 4.859435},
+	}
+	for i, point := range points {
+		location, _, err := configuration.LocationEntryRepository.Create(&dbmodel.LocationEntry{UserID: user.ID, Name: "place " + strconv.Itoa(i), Latitude: point.Latitude, Longitude: point.Longitude})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = configuration.GroupLocationEntryRepository.Create(&dbmodel.GroupLocationEntry{GroupEntryID: group.ID, LocationEntryID: location.ID, IsVisibleCoordinates: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return New(configuration), user, group, points
+}
+
+// getDistanceMatrix requests the distance matrix of the group as the user.
+func getDistanceMatrix(t *testing.T, config *GroupConfig, user *dbmodel.UserEntry, group *dbmodel.GroupEntry, query string) models.DistanceMatrixResponse {
+	router := chi.NewRouter()
+	router.Get("/groups/{id}/distance-matrix", config.GetDistanceMatrixHandler)
+	request := httptest.NewRequest("GET", "/groups/"+strconv.Itoa(int(group.ID))+"/distance-matrix?"+query, nil)
+	request = request.WithContext(context.WithValue(request.Context(), "id", user.Email))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+
+	var response models.DistanceMatrixResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Travel == nil {
+		t.Fatalf("distance matrix = %s, %v", w.Body, err)
+	}
+	return response
+}
+
+func TestDistanceMatrixRoutes(t *testing.T) {
+	var paths []string
+	osrm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"code": "Ok", "durations": [[0, 420, null], [430, 0, 1260]], "distances": [[0, 1100, null], [1120, 0, 3600]]}`))
+	}))
+	defer osrm.Close()
+	config, user, group, points := newDistanceTest(t, routing.NewOSRMRouter(map[routing.Mode]string{routing.Car: osrm.URL}))
+
+	response := getDistanceMatrix(t, config, user, group, "sources=1,2&modes=car,walk")
+	if len(response.Sources) != 2 || len(response.Destinations) != 3 || len(response.Distances) != 2 {
+		t.Fatalf("response = %+v", response)
+	}
+	if len(paths) != 1 || !strings.HasPrefix(paths[0], "/table/v1/car/") {
+		t.Errorf("router requests = %v", paths)
+	}
+
+	car, walk := response.Travel[0], response.Travel[1]
+	if car.Mode != "car" || car.Method != "route" || *car.Durations[1][2] != 1260 || car.Durations[0][2] != nil || *car.Distances[0][1] != 1100 {
+		t.Errorf("car = %+v", car)
+	}
+	// walking has no server and is estimated
+	wantWalk := routing.EstimateDurations(routing.GreatCircleDistances(points[:2], points), routing.Walk)
+	if walk.Mode != "walk" || walk.Method != "estimate" || walk.Distances != nil || !reflect.DeepEqual(walk.Durations, wantWalk) {
+		t.Errorf("walk = %+v", walk)
+	}
+}
+
+func TestDistanceMatrixFallsBackOnEstimates(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond http.HandlerFunc
+	}{
+		{"router error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": "TooBig", "message": "Too many table coordinates"}`))
+		}},
+		{"router down", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`<html>Bad Gateway</html>`))
+		}},
+		{"table of another size", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"code": "Ok", "durations": [[0]]}`))
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			osrm := httptest.NewServer(test.respond)
+			defer osrm.Close()
+			config, user, group, points := newDistanceTest(t, routing.NewOSRMRouter(map[routing.Mode]string{routing.Bike: osrm.URL, routing.Car: osrm.URL}))
+
+			response := getDistanceMatrix(t, config, user, group, "")
+			distances := routing.GreatCircleDistances(points, points)
+			if !reflect.DeepEqual(response.Distances, distances) {
+				t.Errorf("distances = %v, want %v", response.Distances, distances)
+			}
+			for i, mode := range routing.Modes {
+				travel := response.Travel[i]
+				if travel.Mode != string(mode) || travel.Method != "estimate" || travel.Distances != nil || !reflect.DeepEqual(travel.Durations, routing.EstimateDurations(distances, mode)) {
+					t.Errorf("%s = %+v, want the estimates", mode, travel)
+				}
+			}
+		})
+	}
+
+	t.Run("unreachable router", func(t *testing.T) {
+		osrm := httptest.NewServer(http.NotFoundHandler())
+		osrm.Close()
+		config, user, group, _ := newDistanceTest(t, routing.NewOSRMRouter(map[routing.Mode]string{routing.Car: osrm.URL}))
+		if car := getDistanceMatrix(t, config, user, group, "modes=car").Travel[0]; car.Method != "estimate" || len(car.Durations) != 3 {
+			t.Errorf("car = %+v, want the estimates", car)
+		}
+	})
+}
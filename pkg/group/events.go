@@ -0,0 +1,190 @@
+package group
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/events"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// eventWriteTimeout disconnects the clients that stop reading.
+	eventWriteTimeout = 10 * time.Second
+	// eventKeepAlive keeps idle streams open through proxies.
+	eventKeepAlive = 25 * time.Second
+)
+
+// @Summary		Stream group events
+// @Description	Server-Sent Events stream of the changes of a group: locations shared, updated, unshared or deleted and members joining or leaving. Send the Last-Event-ID header (or the last_event_id parameter) when reconnecting to receive the missed events, a "reset" event means they are no longer available and the group must be reloaded. The token can be passed as the access_token parameter.
+// @Tags			groups
+// @Produce		text/event-stream
+// @Param			id				path		int		true	"Group ID"
+// @Param			Last-Event-ID	header		int		false	"ID of the last event received"
+// @Param			last_event_id	query		int		false	"ID of the last event received"
+// @Param			access_token	query		string	false	"JWT, for clients that cannot set the Authorization header"
+// @Success		200	{object}	events.Event
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/groups/{id}/events [get]
+func (config *GroupConfig) GetGroupEventsHandler(w http.ResponseWriter, r *http.Request) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	groupID, callerID, after, ok := config.eventSubscriber(w, r, lastEventID)
+	if !ok {
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	subscription, replay := config.EventHub.Subscribe(groupID, after)
+	defer subscription.Unsubscribe()
+
+	write := func(format string, args ...any) error {
+		controller.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+	send := func(event events.Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		return write("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	}
+
+	if err := write("retry: 3000\n\n"); err != nil {
+		return
+	}
+	for _, event := range replay {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-subscription.Events():
+			// closed when the client fell too far behind, it reconnects and catches up
+			if !open {
+				return
+			}
+			if err := send(event); err != nil {
+				return
+			}
+			if event.Type == events.MemberLeft && event.UserID == callerID {
+				return
+			}
+		case <-keepAlive.C:
+			if err := write(": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// @Summary		Stream group events over WebSocket
+// @Description	WebSocket variant of the group event stream, each message is a JSON event. Pass last_event_id when reconnecting to receive the missed events. The token can be passed as the access_token parameter.
+// @Tags			groups
+// @Param			id				path		int		true	"Group ID"
+// @Param			last_event_id	query		int		false	"ID of the last event received"
+// @Param			access_token	query		string	false	"JWT, for clients that cannot set the Authorization header"
+// @Success		101	{object}	events.Event
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/groups/{id}/events/ws [get]
+func (config *GroupConfig) GetGroupEventsWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	groupID, callerID, after, ok := config.eventSubscriber(w, r, r.URL.Query().Get("last_event_id"))
+	if !ok {
+		return
+	}
+
+	// authentication is done with the token, not with cookies, so the origin is not checked
+	websocket.Server{Handler: func(conn *websocket.Conn) {
+		subscription, replay := config.EventHub.Subscribe(groupID, after)
+		defer subscription.Unsubscribe()
+
+		closed := make(chan struct{})
+		go func() {
+			io.Copy(io.Discard, conn)
+			close(closed)
+		}()
+
+		send := func(event events.Event) error {
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			return websocket.JSON.Send(conn, event)
+		}
+		for _, event := range replay {
+			if err := send(event); err != nil {
+				return
+			}
+		}
+		for {
+			select {
+			case <-closed:
+				return
+			case event, open := <-subscription.Events():
+				if !open {
+					return
+				}
+				if err := send(event); err != nil {
+					return
+				}
+				if event.Type == events.MemberLeft && event.UserID == callerID {
+					return
+				}
+			}
+		}
+	}}.ServeHTTP(w, r)
+}
+
+// eventSubscriber checks that the caller belongs to the group before opening
+// a stream, rendering the error otherwise.
+func (config *GroupConfig) eventSubscriber(w http.ResponseWriter, r *http.Request, lastEventID string) (uint, uint, uint64, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return 0, 0, 0, false
+	}
+
+	var after uint64
+	if lastEventID != "" {
+		after, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "last event ID must be a positive integer"})
+			return 0, 0, 0, false
+		}
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return 0, 0, 0, false
+	}
+	member, err := config.GroupEntryRepository.IsMember(uint(id), caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return 0, 0, 0, false
+	}
+	return uint(id), caller.ID, after, true
+}
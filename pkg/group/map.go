@@ -0,0 +1,105 @@
+package group
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/staticmap"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultMapWidth  = 600
+	defaultMapHeight = 400
+	minMapSize       = 100
+	maxMapSize       = 2048
+)
+
+// @Summary		Get a map image of a group
+// @Description	Render a PNG snapshot of the locations shared in a group whose coordinates are visible to the caller, to be shared in a chat or an email. The markers take the color of their location and are labelled with its name where the labels fit. The map is in Web Mercator, fitted to show every marker, drawn on a plain background or on the basemap tiles of MAP_TILES_DIR when configured; no external service is called.
+// @Tags			groups
+// @Produce		png
+// @Param			id		path		int	true	"Group ID"
+// @Param			width	query		int	false	"Width in pixels, between 100 and 2048 (600 by default)"
+// @Param			height	query		int	false	"Height in pixels, between 100 and 2048 (400 by default)"
+// @Success		200		{file}		binary
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/groups/{id}/map.png [get]
+func (config *GroupConfig) GetGroupMapHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+	width, widthOK := parseMapSize(r.URL.Query().Get("width"), defaultMapWidth)
+	height, heightOK := parseMapSize(r.URL.Query().Get("height"), defaultMapHeight)
+	if !widthOK || !heightOK {
+		render.JSON(w, r, map[string]string{"error": "width and height must be between 100 and 2048"})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	member, err := config.GroupEntryRepository.IsMember(uint(id), caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
+	}
+	locations, err := config.LocationEntryRepository.FindLocationPointsForGroup(uint(id), caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+
+	markers := make([]staticmap.Marker, 0, len(locations))
+	for _, location := range locations {
+		markerColor, _ := staticmap.ParseColor(location.Color)
+		markers = append(markers, staticmap.Marker{
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+			Label:     location.Name,
+			Color:     markerColor,
+		})
+	}
+	img := staticmap.Render(markers, staticmap.Options{
+		Width:       width,
+		Height:      height,
+		TilesDir:    config.Constants.MapTilesDir,
+		Attribution: config.Constants.MapTilesAttribution,
+	})
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to render map"})
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Disposition", `inline; filename="group-`+strconv.Itoa(id)+`-map.png"`)
+	w.Header().Set("Content-Length", strconv.Itoa(buffer.Len()))
+	w.Write(buffer.Bytes())
+}
+
+// parseMapSize reads a width or a height, the default one when it is not
+// given.
+func parseMapSize(text string, defaultSize int) (int, bool) {
+	if text == "" {
+		return defaultSize, true
+	}
+	size, err := strconv.Atoi(text)
+	if err != nil || size < minMapSize || size > maxMapSize {
+		return 0, false
+	}
+	return size, true
+}
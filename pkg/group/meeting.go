@@ -0,0 +1,125 @@
+package group
+
+import (
+	"fmt"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/models"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// @Summary		Suggest a meeting point
+// @Description	Compute where to meet from a selection of locations shared in the group: the geographic midpoint, the geometric median (the point minimizing the total distance to the locations) and the shared location closest to the median. The coordinates of the selected locations must be visible to the caller: the points computed with hidden coordinates, even rounded, would allow to work them back.
+// @Tags			groups
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int							true	"Group ID"
+// @Param			request	body		models.MeetingPointRequest	true	"Locations to meet from"
+// @Success		200		{object}	models.MeetingPointResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/groups/{id}/meeting-point [post]
+func (config *GroupConfig) PostMeetingPointHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+
+	req := &models.MeetingPointRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	member, err := config.GroupEntryRepository.IsMember(uint(id), caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
+	}
+	selected, visible, err := config.LocationEntryRepository.FindSelectedLocationPointsInGroup(uint(id), caller.ID, req.LocationIDs)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+	if len(selected) != len(req.LocationIDs) {
+		render.JSON(w, r, map[string]string{"error": "location_ids must be locations shared in the group"})
+		return
+	}
+
+	points := make([]geo.Point, 0, len(selected))
+	for _, location := range selected {
+		if !visible[location.ID] {
+			render.JSON(w, r, map[string]string{"error": "location_ids must be locations whose coordinates are visible to you"})
+			return
+		}
+		points = append(points, geo.Point{Latitude: location.Latitude, Longitude: location.Longitude})
+	}
+	midpoint, ok := geo.Midpoint(points)
+	if !ok {
+		render.JSON(w, r, map[string]string{"error": "The locations are spread around the globe, they have no midpoint"})
+		return
+	}
+	median, _ := geo.GeometricMedian(points)
+
+	meetingResponse := models.MeetingPointResponse{
+		Midpoint:      models.PointResponse{Latitude: midpoint.Latitude, Longitude: midpoint.Longitude},
+		Median:        models.PointResponse{Latitude: median.Latitude, Longitude: median.Longitude},
+		TotalDistance: math.Round(geo.TotalDistance(median, points)),
+		Locations:     make([]models.MeetingLocationResponse, 0, len(selected)),
+	}
+	for i, location := range selected {
+		meetingResponse.Locations = append(meetingResponse.Locations, models.MeetingLocationResponse{
+			ID:        location.ID,
+			Name:      location.Name,
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+			Distance:  math.Round(geo.Distance(median, points[i])),
+		})
+	}
+
+	// the closest of every visible location of the group, selected or not
+	candidates, err := config.LocationEntryRepository.FindLocationPointsForGroup(uint(id), caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+	closestID, closestDistance := uint(0), math.Inf(1)
+	for _, candidate := range candidates {
+		distance := geo.Distance(median, geo.Point{Latitude: candidate.Latitude, Longitude: candidate.Longitude})
+		if distance < closestDistance {
+			closestID, closestDistance = candidate.ID, distance
+		}
+	}
+	if closestID != 0 {
+		closest, err := config.LocationEntryRepository.FindById(closestID)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+			return
+		}
+		locationResponse := []models.LocationResponse{models.NewLocationResponse(closest)}
+		tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(locationResponse))
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve tags"})
+			return
+		}
+		models.AttachTags(locationResponse, tags)
+		meetingResponse.Closest = &models.NearbyLocationResponse{LocationResponse: locationResponse[0], Distance: math.Round(closestDistance)}
+	}
+
+	render.JSON(w, r, meetingResponse)
+}
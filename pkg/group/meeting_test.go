@@ -0,0 +1,153 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"locate-this/config"
+	"locate-this/database"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/geo"
+	"math"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func TestMeetingPoint(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.Migrate(db)
+	configuration := &config.Config{
+		UserEntryRepository:          dbmodel.NewUserRepository(db),
+		GroupEntryRepository:         dbmodel.NewGroupRepository(db),
+		GroupUserEntryRepository:     dbmodel.NewGroupUserRepository(db),
+		GroupLocationEntryRepository: dbmodel.NewGroupLocationRepository(db),
+		LocationEntryRepository:      dbmodel.NewLocationRepository(db),
+		TagRepository:                dbmodel.NewTagRepository(db),
+	}
+	router := chi.NewRouter()
+	router.Post("/groups/{id}/meeting-point", New(configuration).PostMeetingPointHandler)
+
+	users := make([]*dbmodel.UserEntry, 3)
+	for i, name := range []string{"alice", "bob", "carol"} {
+		users[i], err = configuration.UserEntryRepository.Create(&dbmodel.UserEntry{Email: name + "@example.com", Password: "-", Username: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob, carol := users[0], users[1], users[2]
+	group, err := configuration.GroupEntryRepository.Create(&dbmodel.GroupEntry{Name: "lyon", AdminID: alice.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := configuration.GroupUserEntryRepository.Create(&dbmodel.GroupUserEntry{UserEntryID: bob.ID, GroupEntryID: group.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the office of alice is shared with its coordinates, her home without
+	// them, her cabin is not shared and the home of bob is shared
+	locations := make(map[string]*dbmodel.LocationEntry)
+	for _, location := range []struct {
+		user    *dbmodel.UserEntry
+		name    string
+		point   geo.Point
+		shared  bool
+		visible bool
+	}{
+		{alice, "office", geo.Point{Latitude: 45.7578, Longitude: 4.832}, true, true},
+		{alice, "home", geo.Point{Latitude: 45.7706, Longitude: 4.8591}, true, false},
+		{alice, "cabin", geo.Point{Latitude: 45.9, Longitude: 6.12}, false, false},
+		{bob, "bob's home", geo.Point{Latitude: 45.7485, Longitude: 4.8467}, true, true},
+	} {
+		locations[location.name], _, err = configuration.LocationEntryRepository.Create(&dbmodel.LocationEntry{
+			UserID: location.user.ID, Name: location.name, Latitude: location.point.Latitude, Longitude: location.point.Longitude,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if location.shared {
+			share := &dbmodel.GroupLocationEntry{GroupEntryID: group.ID, LocationEntryID: locations[location.name].ID, IsVisibleCoordinates: location.visible}
+			if _, err := configuration.GroupLocationEntryRepository.Create(share); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		user      *dbmodel.UserEntry
+		locations []string
+		err       string
+	}{
+		{"visible coordinates", bob, []string{"office", "bob's home"}, ""},
+		{"owner of the hidden coordinates", alice, []string{"office", "home", "bob's home"}, ""},
+		// a midpoint with a location of their own would give the hidden one
+		{"hidden coordinates", bob, []string{"home", "bob's home"}, "visible to you"},
+		{"hidden among visible coordinates", bob, []string{"office", "home", "bob's home"}, "visible to you"},
+		{"location not shared", alice, []string{"cabin", "office"}, "shared in the group"},
+		{"not a member", carol, []string{"office", "bob's home"}, "Failed to retrieve group"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ids := make([]string, len(test.locations))
+			points := make([]geo.Point, len(test.locations))
+			for i, name := range test.locations {
+				ids[i] = strconv.Itoa(int(locations[name].ID))
+				points[i] = geo.Point{Latitude: locations[name].Latitude, Longitude: locations[name].Longitude}
+			}
+			body := fmt.Sprintf(`{"location_ids": [%s]}`, strings.Join(ids, ","))
+			request := httptest.NewRequest("POST", "/groups/"+strconv.Itoa(int(group.ID))+"/meeting-point", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			request = request.WithContext(context.WithValue(request.Context(), "id", test.user.Email))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+
+			var response map[string]json.RawMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("response = %s", w.Body)
+			}
+			if test.err != "" {
+				if len(response) != 1 || !strings.Contains(string(response["error"]), test.err) {
+					t.Errorf("response = %s, want only the error %q", w.Body, test.err)
+				}
+				return
+			}
+
+			var meeting struct {
+				Midpoint      geo.Point `json:"midpoint"`
+				Median        geo.Point `json:"median"`
+				TotalDistance float64   `json:"total_distance"`
+				Closest       *struct {
+					ID uint `json:"id"`
+				} `json:"closest"`
+				Locations []struct {
+					ID        uint    `json:"id"`
+					Latitude  float64 `json:"latitude"`
+					Longitude float64 `json:"longitude"`
+				} `json:"locations"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &meeting); err != nil || len(meeting.Locations) != len(points) || meeting.Closest == nil {
+				t.Fatalf("response = %s", w.Body)
+			}
+			midpoint, _ := geo.Midpoint(points)
+			median, _ := geo.GeometricMedian(points)
+			if geo.Distance(meeting.Midpoint, midpoint) > 0.01 || geo.Distance(meeting.Median, median) > 0.01 || meeting.TotalDistance != math.Round(geo.TotalDistance(median, points)) {
+				t.Errorf("meeting = %+v, want the midpoint %v and the median %v", meeting, midpoint, median)
+			}
+			for i, location := range meeting.Locations {
+				if location.Latitude != points[i].Latitude || location.Longitude != points[i].Longitude {
+					t.Errorf("location %d = %+v, want %v", location.ID, location, points[i])
+				}
+			}
+		})
+	}
+}
@@ -16,6 +16,14 @@ Groups:
 
 - GET /groups/{id}/locations
 - GET /groups/{id}/users
+- GET /groups/{id}/locations.{format} (geojson, gpx or kml)
+- GET /groups/{id}/map.png?width=&height=
+- GET /groups/{id}/distance-matrix?sources=&destinations=&modes=
+- POST /groups/{id}/meeting-point
+
+Group events, mounted on /groups/{id}/events:
+- GET /groups/{id}/events (Server-Sent Events)
+- GET /groups/{id}/events/ws (WebSocket)
 
 */
 
@@ -29,5 +37,17 @@ func Routes(configuration *config.Config) chi.Router {
 	router.Delete("/{id}", GroupConfig.DeleteGroupHandler)
 	router.Get("/{id}/locations", GroupConfig.GetLocationsForGroupHandler)
 	router.Get("/{id}/users", GroupConfig.GetUsersForGroupHandler)
+	router.Get("/{id}/locations.{format}", GroupConfig.GetLocationsExportForGroupHandler)
+	router.Get("/{id}/map.png", GroupConfig.GetGroupMapHandler)
+	router.Get("/{id}/distance-matrix", GroupConfig.GetDistanceMatrixHandler)
+	router.Post("/{id}/meeting-point", GroupConfig.PostMeetingPointHandler)
+	return router
+}
+
+func EventRoutes(configuration *config.Config) chi.Router {
+	GroupConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Get("/", GroupConfig.GetGroupEventsHandler)
+	router.Get("/ws", GroupConfig.GetGroupEventsWebSocketHandler)
 	return router
 }
@@ -3,7 +3,9 @@ package group_location
 import (
 	"locate-this/config"
 	"locate-this/database/dbmodel"
+	"locate-this/pkg/events"
 	"locate-this/pkg/models"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -42,6 +44,8 @@ func (config *GroupLocationConfig) PostLocationToGroupHandler(w http.ResponseWri
 		render.JSON(w, r, map[string]string{"error": "Failed to share location in group"})
 		return
 	}
+	config.EventHub.Publish(events.Event{Type: events.LocationShared, GroupID: req.GroupID, LocationID: req.LocationID})
+	config.invalidateTiles(req.LocationID)
 
 	render.JSON(w, r, map[string]string{"message": "Location shared in group successfully"})
 }
@@ -115,6 +119,8 @@ func (config *GroupLocationConfig) PutLocationInGroupHandler(w http.ResponseWrit
 		render.JSON(w, r, map[string]string{"error": "Failed to update location in group"})
 		return
 	}
+	config.EventHub.Publish(events.Event{Type: events.LocationUpdated, GroupID: uint(groupID), LocationID: uint(locationID)})
+	config.invalidateTiles(uint(locationID))
 
 	render.JSON(w, r, map[string]string{"message": "Location updated in group successfully"})
 }
@@ -148,6 +154,18 @@ func (config *GroupLocationConfig) DeleteLocationFromGroupHandler(w http.Respons
 		render.JSON(w, r, map[string]string{"error": "Failed to remove location from group"})
 		return
 	}
+	config.EventHub.Publish(events.Event{Type: events.LocationUnshared, GroupID: uint(groupID), LocationID: uint(locationID)})
+	config.invalidateTiles(uint(locationID))
 
 	render.JSON(w, r, map[string]string{"message": "Location removed from group successfully"})
 }
+
+// invalidateTiles drops the map tiles of a location whose visibility changed.
+func (config *GroupLocationConfig) invalidateTiles(locationID uint) {
+	location, err := config.LocationEntryRepository.FindById(locationID)
+	if err != nil {
+		log.Println("Failed to retrieve location", locationID, err)
+		return
+	}
+	config.TileCache.InvalidatePoint(location.Latitude, location.Longitude)
+}
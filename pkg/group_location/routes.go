@@ -2,6 +2,7 @@ package group_location
 
 import (
 	"locate-this/config"
+	"locate-this/pkg/comment"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -12,13 +13,32 @@ Group_Location:
 - GET /group-location/
 - PUT /group-location/{id}/locations/{id}
 - DELETE /group-location/{id}/locations/{id}
+- GET /group-location/{id}/locations/{id}/comments
+- POST /group-location/{id}/locations/{id}/comments
+- PUT /group-location/{id}/locations/{id}/comments/{id}
+- DELETE /group-location/{id}/locations/{id}/comments/{id}
+- POST /group-location/{id}/locations/{id}/comments/{id}/reactions
+- DELETE /group-location/{id}/locations/{id}/comments/{id}/reactions/{emoji}
+- GET /group-location/{id}/locations/{id}/reactions
+- POST /group-location/{id}/locations/{id}/reactions
+- DELETE /group-location/{id}/locations/{id}/reactions/{emoji}
 */
 func Routes(configuration *config.Config) chi.Router {
 	GroupLocationConfig := New(configuration)
+	CommentConfig := comment.New(configuration)
 	router := chi.NewRouter()
 	router.Post("/", GroupLocationConfig.PostLocationToGroupHandler)
 	router.Get("/", GroupLocationConfig.GetAllGroupLocationHandler) // FOR DEBUG ONLY
 	router.Put("/{id}/locations/{locationID}", GroupLocationConfig.PutLocationInGroupHandler)
 	router.Delete("/{id}/locations/{locationID}", GroupLocationConfig.DeleteLocationFromGroupHandler)
+	router.Get("/{id}/locations/{locationID}/comments", CommentConfig.GetCommentsHandler)
+	router.Post("/{id}/locations/{locationID}/comments", CommentConfig.PostCommentHandler)
+	router.Put("/{id}/locations/{locationID}/comments/{commentID}", CommentConfig.PutCommentHandler)
+	router.Delete("/{id}/locations/{locationID}/comments/{commentID}", CommentConfig.DeleteCommentHandler)
+	router.Post("/{id}/locations/{locationID}/comments/{commentID}/reactions", CommentConfig.PostCommentReactionHandler)
+	router.Delete("/{id}/locations/{locationID}/comments/{commentID}/reactions/{emoji}", CommentConfig.DeleteCommentReactionHandler)
+	router.Get("/{id}/locations/{locationID}/reactions", CommentConfig.GetReactionsHandler)
+	router.Post("/{id}/locations/{locationID}/reactions", CommentConfig.PostReactionHandler)
+	router.Delete("/{id}/locations/{locationID}/reactions/{emoji}", CommentConfig.DeleteReactionHandler)
 	return router
 }
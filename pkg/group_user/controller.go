@@ -3,6 +3,7 @@ package group_user
 import (
 	"locate-this/config"
 	"locate-this/database/dbmodel"
+	"locate-this/pkg/events"
 	"locate-this/pkg/models"
 	"net/http"
 	"strconv"
@@ -42,6 +43,8 @@ func (config *GroupUserConfig) PostUserToGroupHandler(w http.ResponseWriter, r *
 		render.JSON(w, r, map[string]string{"error": "Failed to add user to group"})
 		return
 	}
+	config.EventHub.Publish(events.Event{Type: events.MemberJoined, GroupID: req.GroupID, UserID: req.UserID})
+	config.TileCache.InvalidateUser(req.UserID)
 
 	render.JSON(w, r, map[string]string{"message": "User added to group successfully"})
 }
@@ -102,6 +105,8 @@ func (config *GroupUserConfig) DeleteUserFromGroupHandler(w http.ResponseWriter,
 		render.JSON(w, r, map[string]string{"error": "Failed to remove user from group"})
 		return
 	}
+	config.EventHub.Publish(events.Event{Type: events.MemberLeft, GroupID: uint(groupID), UserID: uint(userID)})
+	config.TileCache.InvalidateUser(uint(userID))
 
 	render.JSON(w, r, map[string]string{"message": "User removed from group successfully"})
 }
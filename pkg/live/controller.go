@@ -0,0 +1,308 @@
+package live
+
+import (
+	"fmt"
+	"locate-this/config"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/models"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type LiveConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *LiveConfig {
+	return &LiveConfig{configuration}
+}
+
+// @Summary		Start a live share
+// @Description	Start sharing the caller's position live with a group they belong to, for a limited time when duration_minutes is set. Starting again restarts the share.
+// @Tags			live
+// @Accept			json
+// @Produce		json
+// @Param			share	body		models.LiveShareRequest	true	"Live share to start"
+// @Success		200		{object}	models.LiveShareResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/live/shares [post]
+func (config *LiveConfig) PostLiveShareHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.LiveShareRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	caller, ok := config.groupMember(w, r, req.GroupID)
+	if !ok {
+		return
+	}
+	config.deleteExpiredShares()
+
+	shareEntry := &dbmodel.LiveShareEntry{UserID: caller.ID, GroupID: req.GroupID, CreatedAt: time.Now()}
+	if req.DurationMinutes > 0 {
+		expiresAt := shareEntry.CreatedAt.Add(time.Duration(req.DurationMinutes) * time.Minute)
+		shareEntry.ExpiresAt = &expiresAt
+	}
+	res, err := config.LiveShareRepository.Start(shareEntry)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to start live share"})
+		return
+	}
+
+	render.JSON(w, r, models.NewLiveShareResponse(res))
+}
+
+// @Summary		Get my live shares
+// @Description	Retrieve the live shares of the caller that have not ended
+// @Tags			live
+// @Accept			json
+// @Produce		json
+// @Success		200	{array}		models.LiveShareResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/live/shares [get]
+func (config *LiveConfig) GetLiveSharesHandler(w http.ResponseWriter, r *http.Request) {
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	shares, err := config.LiveShareRepository.FindSharesForUser(caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve live shares"})
+		return
+	}
+
+	sharesResponse := make([]models.LiveShareResponse, 0)
+	for _, share := range shares {
+		sharesResponse = append(sharesResponse, models.NewLiveShareResponse(&share))
+	}
+
+	render.JSON(w, r, sharesResponse)
+}
+
+// @Summary		Stop a live share
+// @Description	Stop sharing the caller's position with a group, the last position is removed
+// @Tags			live
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Group ID"
+// @Success		200	{object}	map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/live/groups/{id}/share [delete]
+func (config *LiveConfig) DeleteLiveShareHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	if err := config.LiveShareRepository.Stop(caller.ID, uint(id)); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to stop live share"})
+		return
+	}
+
+	render.JSON(w, r, map[string]string{"message": "Live share stopped successfully"})
+}
+
+// @Summary		Publish a live position
+// @Description	Publish the caller's current position into a group where they have started a live share
+// @Tags			live
+// @Accept			json
+// @Produce		json
+// @Param			position	body		models.LivePositionRequest	true	"Current position"
+// @Success		200			{object}	models.LiveShareResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/live/positions [post]
+func (config *LiveConfig) PostLivePositionHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.LivePositionRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	share, err := config.LiveShareRepository.FindActiveShare(caller.ID, req.GroupID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "No live share in progress with this group"})
+		return
+	}
+
+	recordedAt := time.Now()
+	share.Latitude = &req.Latitude
+	share.Longitude = &req.Longitude
+	share.Accuracy = req.Accuracy
+	share.Heading = req.Heading
+	share.Speed = req.Speed
+	share.RecordedAt = &recordedAt
+	res, err := config.LiveShareRepository.Publish(share)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to publish position"})
+		return
+	}
+	config.recordLivePosition(caller, res)
+	var accuracy float64
+	if req.Accuracy != nil {
+		accuracy = *req.Accuracy
+	}
+	config.GeofenceDetector.CheckPosition(caller, req.GroupID, geo.Point{Latitude: req.Latitude, Longitude: req.Longitude}, accuracy, recordedAt)
+
+	render.JSON(w, r, models.NewLiveShareResponse(res))
+}
+
+// @Summary		Get live positions of a group
+// @Description	Retrieve the latest position of each member sharing live with the group. Positions older than the configured TTL are left out.
+// @Tags			live
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Group ID"
+// @Success		200	{array}		models.LiveShareResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/live/groups/{id}/positions [get]
+func (config *LiveConfig) GetLivePositionsForGroupHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+	if _, ok := config.groupMember(w, r, uint(id)); !ok {
+		return
+	}
+
+	positions, err := config.LiveShareRepository.FindPositionsForGroup(uint(id), time.Now().Add(-config.Constants.LivePositionTTL))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve live positions"})
+		return
+	}
+
+	positionsResponse := make([]models.LiveShareResponse, 0)
+	for _, position := range positions {
+		positionsResponse = append(positionsResponse, models.NewLiveShareResponse(&position))
+	}
+
+	render.JSON(w, r, positionsResponse)
+}
+
+// @Summary		Get the live history of a member
+// @Description	Retrieve the positions a member published live in a group, the distance travelled and a track downsampled with the Douglas–Peucker algorithm
+// @Tags			live
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int		true	"Group ID"
+// @Param			userID		path		int		true	"User ID"
+// @Param			from		query		string	false	"Start of the period (RFC 3339)"
+// @Param			to			query		string	false	"End of the period (RFC 3339)"
+// @Param			tolerance	query		number	false	"Simplification tolerance in meters"
+// @Param			max_points	query		int		false	"Maximum number of points returned (default 1000)"
+// @Success		200	{object}	models.HistoryResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/live/groups/{id}/users/{userID}/history [get]
+func (config *LiveConfig) GetLiveHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 || userID < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+
+	query, err := models.ParseHistoryQuery(r)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	if _, ok := config.groupMember(w, r, uint(id)); !ok {
+		return
+	}
+
+	history, err := config.HistoryRepository.FindHistoryForLiveShare(uint(userID), uint(id), query.From, query.To)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve history"})
+		return
+	}
+
+	render.JSON(w, r, models.NewHistoryResponse(history, query))
+}
+
+// recordLivePosition appends a published position to the history of the
+// user and applies their retention policy.
+func (config *LiveConfig) recordLivePosition(user *dbmodel.UserEntry, share *dbmodel.LiveShareEntry) {
+	days := config.HistoryRetentionDays(user)
+	if days == 0 {
+		return
+	}
+	groupID := share.GroupID
+	entry := &dbmodel.LocationHistoryEntry{
+		UserID:     user.ID,
+		GroupID:    &groupID,
+		Latitude:   *share.Latitude,
+		Longitude:  *share.Longitude,
+		Accuracy:   share.Accuracy,
+		RecordedAt: *share.RecordedAt,
+	}
+	if err := config.HistoryRepository.Append(entry); err != nil {
+		log.Println("Failed to record live history of user", user.ID, err)
+		return
+	}
+	if err := config.HistoryRepository.DeleteBefore(user.ID, time.Now().AddDate(0, 0, -days)); err != nil {
+		log.Println("Failed to apply history retention of user", user.ID, err)
+	}
+}
+
+// groupMember checks that the caller administrates or belongs to the group,
+// rendering the error otherwise.
+func (config *LiveConfig) groupMember(w http.ResponseWriter, r *http.Request, groupID uint) (*dbmodel.UserEntry, bool) {
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, false
+	}
+	member, err := config.GroupEntryRepository.IsMember(groupID, caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return nil, false
+	}
+	return caller, true
+}
+
+func (config *LiveConfig) deleteExpiredShares() {
+	if err := config.LiveShareRepository.DeleteExpired(); err != nil {
+		log.Println("Failed to delete expired live shares:", err)
+	}
+}
@@ -0,0 +1,30 @@
+package live
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Live:
+- POST /live/shares
+- GET /live/shares
+- DELETE /live/groups/{id}/share
+
+- POST /live/positions
+- GET /live/groups/{id}/positions
+- GET /live/groups/{id}/users/{userID}/history
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	LiveConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Post("/shares", LiveConfig.PostLiveShareHandler)
+	router.Get("/shares", LiveConfig.GetLiveSharesHandler)
+	router.Delete("/groups/{id}/share", LiveConfig.DeleteLiveShareHandler)
+	router.Post("/positions", LiveConfig.PostLivePositionHandler)
+	router.Get("/groups/{id}/positions", LiveConfig.GetLivePositionsForGroupHandler)
+	router.Get("/groups/{id}/users/{userID}/history", LiveConfig.GetLiveHistoryHandler)
+	return router
+}
@@ -1,12 +1,17 @@
 package location
 
 import (
+	"errors"
 	"fmt"
 	"locate-this/config"
 	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/events"
 	"locate-this/pkg/models"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -21,11 +26,12 @@ func New(configuration *config.Config) *LocationConfig {
 }
 
 // @Summary		Create a new location
-// @Description	Create a new location entry
+// @Description	Create a new location entry. When only the address is given, without latitude and longitude, the coordinates are found by geocoding it.
 // @Tags			locations
 // @Accept			json
 // @Produce		json
 // @Param			request	body		models.LocationRequest	true	"Location data"
+// @Param			formats	query		string	false	"Comma separated coordinate formats to add: dms, utm, mgrs, geohash, pluscode"
 // @Success		200		{object}	models.LocationResponse
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
@@ -33,49 +39,97 @@ func New(configuration *config.Config) *LocationConfig {
 func (config *LocationConfig) PostLocationHandler(w http.ResponseWriter, r *http.Request) {
 	req := &models.LocationRequest{}
 	if err := render.Bind(r, req); err != nil {
-		render.JSON(w, r, map[string]string{"error": "Invalid request payload"})
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+	formats, err := models.ParseCoordinateFormats(r)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
 		return
 	}
 
-	locationEntry := &dbmodel.LocationEntry{Name: req.Name, Latitude: req.Latitude, Longitude: req.Longitude, UserID: req.UserID}
-	res, err := config.LocationEntryRepository.Create(locationEntry)
+	if !config.geocodeRequest(w, r, req) {
+		return
+	}
+
+	locationEntry := newLocationEntry(req)
+	locationEntry.UserID = req.UserID
+	res, changes, err := config.LocationEntryRepository.Create(locationEntry)
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to create location"})
 		return
 	}
+	config.TileCache.InvalidatePoint(res.Latitude, res.Longitude)
+	config.SharePublisher.Publish(changes)
 
-	locationResponse := &models.LocationResponse{ID: res.ID, Name: res.Name, Latitude: res.Latitude, Longitude: res.Longitude, UserID: req.UserID}
-	render.JSON(w, r, locationResponse)
+	locationResponse := []models.LocationResponse{models.NewLocationResponse(res)}
+	models.AddCoordinateFormats(locationResponse, formats)
+	render.JSON(w, r, locationResponse[0])
 }
 
 // @Summary		Get all locations
-// @Description	Retrieve a list of all locations
+// @Description	Retrieve a page of all locations
 // @Tags			locations
 // @Accept			json
 // @Produce		json
-// @Success		200	{array}	models.LocationResponse
+// @Param			limit			query		int		false	"Page size"
+// @Param			after			query		string	false	"Cursor returned by the previous page"
+// @Param			sort			query		string	false	"Sort field, prefix with - for descending order"
+// @Param			order			query		string	false	"asc or desc"
+// @Param			name			query		string	false	"Name contains"
+// @Param			created_before	query		string	false	"RFC 3339 date"
+// @Param			created_after	query		string	false	"RFC 3339 date"
+// @Param			owner			query		int		false	"Owner user ID"
+// @Param			category		query		string	false	"Category"
+// @Param			description		query		string	false	"Description contains"
+// @Param			address			query		string	false	"Address contains"
+// @Param			color			query		string	false	"Color"
+// @Param			icon			query		string	false	"Icon"
+// @Param			min_altitude	query		number	false	"Minimum altitude in meters"
+// @Param			max_altitude	query		number	false	"Maximum altitude in meters"
+// @Param			max_accuracy	query		number	false	"Maximum accuracy radius in meters"
+// @Param			tag				query		[]string	false	"Tags" collectionFormat(multi)
+// @Param			tag_mode		query		string	false	"any (default) or all of the tags"
+// @Param			format			query		string	false	"json (default) or csv to download every page"
+// @Param			delimiter		query		string	false	"CSV delimiter: comma (default), semicolon, pipe or tab"
+// @Param			decimal			query		string	false	"CSV decimal separator: point (default) or comma"
+// @Param			formats			query		string	false	"Comma separated coordinate formats to add: dms, utm, mgrs, geohash, pluscode"
+// @Success		200	{object}	models.PageResponse{data=[]models.LocationResponse}
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
 // @Router			/locations [get]
 func (config *LocationConfig) GetAllLocationHandler(w http.ResponseWriter, r *http.Request) {
-	entries, err := config.LocationEntryRepository.FindAll()
+	options, err := models.ParseQueryOptions(r, dbmodel.LocationSortFields)
 	if err != nil {
-		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		render.JSON(w, r, map[string]string{"error": err.Error()})
 		return
 	}
 
-	locationsResponse := make([]models.LocationResponse, 0)
-	for _, location := range entries {
-		locationsResponse = append(locationsResponse, models.LocationResponse{
-			ID:        location.ID,
-			Name:      location.Name,
-			Latitude:  location.Latitude,
-			Longitude: location.Longitude,
-			UserID:    location.UserID,
-		})
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
 	}
+	options.TagViewerID = caller.ID
+
+	RenderLocationList(w, r, options, func(options dbmodel.QueryOptions) ([]models.LocationResponse, dbmodel.PageInfo, error) {
+		entries, page, err := config.LocationEntryRepository.FindAll(options)
+		if err != nil {
+			return nil, page, errors.New("Failed to retrieve locations")
+		}
 
-	render.JSON(w, r, locationsResponse)
+		locationsResponse := make([]models.LocationResponse, 0)
+		for _, location := range entries {
+			locationsResponse = append(locationsResponse, models.NewLocationResponse(&location))
+		}
+
+		tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(locationsResponse))
+		if err != nil {
+			return nil, page, errors.New("Failed to retrieve tags")
+		}
+		models.AttachTags(locationsResponse, tags)
+		return locationsResponse, page, nil
+	})
 }
 
 // @Summary		Get location by ID
@@ -83,7 +137,8 @@ func (config *LocationConfig) GetAllLocationHandler(w http.ResponseWriter, r *ht
 // @Tags			locations
 // @Accept			json
 // @Produce		json
-// @Param			id	path		int	true	"Location ID"
+// @Param			id		path		int		true	"Location ID"
+// @Param			formats	query		string	false	"Comma separated coordinate formats to add: dms, utm, mgrs, geohash, pluscode"
 // @Success		200	{object}	models.LocationResponse
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
@@ -97,13 +152,31 @@ func (config *LocationConfig) GetLocationByIDHandler(w http.ResponseWriter, r *h
 		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
 		return
 	}
+	formats, err := models.ParseCoordinateFormats(r)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
 	entry, err := config.LocationEntryRepository.FindById(uint(id))
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
 		return
 	}
-	locationResponse := &models.LocationResponse{ID: entry.ID, Name: entry.Name, Latitude: entry.Latitude, Longitude: entry.Longitude, UserID: entry.UserID}
-	render.JSON(w, r, locationResponse)
+	locationResponse := []models.LocationResponse{models.NewLocationResponse(entry)}
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(locationResponse))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve tags"})
+		return
+	}
+	models.AttachTags(locationResponse, tags)
+	models.AddCoordinateFormats(locationResponse, formats)
+
+	render.JSON(w, r, locationResponse[0])
 }
 
 // @Summary		Get groups for a location
@@ -111,8 +184,16 @@ func (config *LocationConfig) GetLocationByIDHandler(w http.ResponseWriter, r *h
 // @Tags			locations
 // @Accept			json
 // @Produce		json
-// @Param			id	path		int	true	"Location ID"
-// @Success		200	{array}	models.GroupResponse
+// @Param			id				path		int		true	"Location ID"
+// @Param			limit			query		int		false	"Page size"
+// @Param			after			query		string	false	"Cursor returned by the previous page"
+// @Param			sort			query		string	false	"Sort field, prefix with - for descending order"
+// @Param			order			query		string	false	"asc or desc"
+// @Param			name			query		string	false	"Name contains"
+// @Param			created_before	query		string	false	"RFC 3339 date"
+// @Param			created_after	query		string	false	"RFC 3339 date"
+// @Param			owner			query		int		false	"Admin user ID"
+// @Success		200	{object}	models.PageResponse{data=[]models.GroupResponse}
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
 // @Router			/locations/{id}/groups [get]
@@ -126,7 +207,13 @@ func (config *LocationConfig) GetGroupsForLocationHandler(w http.ResponseWriter,
 		return
 	}
 
-	groups, err := config.LocationEntryRepository.FindGroupsForLocation(uint(id))
+	options, err := models.ParseQueryOptions(r, dbmodel.GroupSortFields)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	groups, page, err := config.LocationEntryRepository.FindGroupsForLocation(uint(id), options)
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to retrieve groups"})
 		return
@@ -135,21 +222,23 @@ func (config *LocationConfig) GetGroupsForLocationHandler(w http.ResponseWriter,
 	groupsResponse := make([]models.GroupResponse, 0)
 	for _, group := range groups {
 		groupsResponse = append(groupsResponse, models.GroupResponse{
-			ID:   group.ID,
-			Name: group.Name,
+			ID:      group.ID,
+			Name:    group.Name,
+			AdminID: group.AdminID,
 		})
 	}
 
-	render.JSON(w, r, groupsResponse)
+	render.JSON(w, r, models.NewPageResponse(groupsResponse, page))
 }
 
 // @Summary		Update a location
-// @Description	Update an existing location entry
+// @Description	Replace the fields of an existing location entry: the fields left out are emptied. When only the address is given, without latitude and longitude, the coordinates are found by geocoding it.
 // @Tags			locations
 // @Accept			json
 // @Produce		json
 // @Param			id		path		int					true	"Location ID"
 // @Param			request	body		models.LocationRequest	true	"Location data"
+// @Param			formats	query		string	false	"Comma separated coordinate formats to add: dms, utm, mgrs, geohash, pluscode"
 // @Success		200		{object}	models.LocationResponse
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
@@ -162,7 +251,7 @@ func (config *LocationConfig) PutLocationHandler(w http.ResponseWriter, r *http.
 
 	req := &models.LocationRequest{}
 	if err := render.Bind(r, req); err != nil {
-		render.JSON(w, r, map[string]string{"error": "Invalid request payload"})
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
 		return
 	}
 
@@ -170,16 +259,56 @@ func (config *LocationConfig) PutLocationHandler(w http.ResponseWriter, r *http.
 		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
 		return
 	}
+	formats, err := models.ParseCoordinateFormats(r)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	previous, err := config.LocationEntryRepository.FindById(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return
+	}
+	if !config.geocodeRequest(w, r, req) {
+		return
+	}
 
-	locationEntry := &dbmodel.LocationEntry{Name: req.Name, Latitude: req.Latitude, Longitude: req.Longitude}
-	updated, err := config.LocationEntryRepository.Update(locationEntry, uint(id))
+	locationEntry := newLocationEntry(req)
+	_, changes, err := config.LocationEntryRepository.Update(locationEntry, uint(id))
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to update location"})
 		return
 	}
+	config.publishLocationEvent(events.LocationUpdated, uint(id))
+
+	updated, err := config.LocationEntryRepository.FindById(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return
+	}
+	config.TileCache.InvalidatePoint(previous.Latitude, previous.Longitude)
+	if updated.Latitude != previous.Latitude || updated.Longitude != previous.Longitude {
+		config.TileCache.InvalidatePoint(updated.Latitude, updated.Longitude)
+		config.recordLocationMove(previous, updated)
+	}
+	config.SharePublisher.Publish(changes)
+
+	locationResponse := []models.LocationResponse{models.NewLocationResponse(updated)}
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(locationResponse))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve tags"})
+		return
+	}
+	models.AttachTags(locationResponse, tags)
+	models.AddCoordinateFormats(locationResponse, formats)
 
-	locationResponse := &models.LocationResponse{ID: uint(id), Name: updated.Name, Latitude: updated.Latitude, Longitude: updated.Longitude}
-	render.JSON(w, r, locationResponse)
+	render.JSON(w, r, locationResponse[0])
 }
 
 // @Summary		Delete a location
@@ -201,10 +330,291 @@ func (config *LocationConfig) DeleteLocationHandler(w http.ResponseWriter, r *ht
 		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
 		return
 	}
-	err = config.LocationEntryRepository.Delete(uint(id))
+	location, err := config.LocationEntryRepository.FindById(uint(id))
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to delete location"})
 		return
 	}
+	groupIDs, err := config.LocationEntryRepository.FindGroupIDsForLocation(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to delete location"})
+		return
+	}
+	attachments, changes, err := config.LocationEntryRepository.Delete(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to delete location"})
+		return
+	}
+	for _, attachment := range attachments {
+		for _, key := range []string{attachment.BlobKey, attachment.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := config.BlobStore.Delete(r.Context(), key); err != nil {
+				log.Println("Failed to delete blob", key+":", err)
+			}
+		}
+	}
+	config.TileCache.InvalidatePoint(location.Latitude, location.Longitude)
+	config.SharePublisher.Publish(changes)
+	for _, groupID := range groupIDs {
+		config.EventHub.Publish(events.Event{Type: events.LocationDeleted, GroupID: groupID, LocationID: uint(id)})
+	}
 	render.JSON(w, r, "Succefully deleted entry")
 }
+
+// @Summary		Get the history of a location
+// @Description	Retrieve the past positions of a location, the distance travelled and a track downsampled with the Douglas–Peucker algorithm. Only available to the owner and to the members of groups where its coordinates are visible.
+// @Tags			locations
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int		true	"Location ID"
+// @Param			from		query		string	false	"Start of the period (RFC 3339)"
+// @Param			to			query		string	false	"End of the period (RFC 3339)"
+// @Param			tolerance	query		number	false	"Simplification tolerance in meters"
+// @Param			max_points	query		int		false	"Maximum number of points returned (default 1000)"
+// @Success		200	{object}	models.HistoryResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/history [get]
+func (config *LocationConfig) GetLocationHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+
+	query, err := models.ParseHistoryQuery(r)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	visible, err := config.LocationEntryRepository.AreCoordinatesVisibleTo(uint(id), caller.ID)
+	if err != nil || !visible {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return
+	}
+
+	history, err := config.HistoryRepository.FindHistoryForLocation(uint(id), query.From, query.To)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve history"})
+		return
+	}
+
+	render.JSON(w, r, models.NewHistoryResponse(history, query))
+}
+
+// @Summary		Get tags for a location
+// @Description	Retrieve the tags of a location owned by the caller or shared in one of the caller's groups
+// @Tags			locations
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Location ID"
+// @Success		200	{array}	models.TagResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/tags [get]
+func (config *LocationConfig) GetTagsForLocationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, []uint{uint(id)})
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve tags"})
+		return
+	}
+
+	tagsResponse := make([]string, 0)
+	tagsResponse = append(tagsResponse, tags[uint(id)]...)
+	render.JSON(w, r, tagsResponse)
+}
+
+// @Summary		Tag a location
+// @Description	Add tags to a location owned by the caller, creating the tags that do not exist yet
+// @Tags			locations
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int					true	"Location ID"
+// @Param			request	body		models.TagRequest	true	"Tags to add"
+// @Success		200		{array}		models.TagResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/tags [post]
+func (config *LocationConfig) PostTagsToLocationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+
+	req := &models.TagRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	location, ok := config.ownedLocation(w, r, id)
+	if !ok {
+		return
+	}
+
+	tags, changes, err := config.TagRepository.AddTagsToLocation(location, req.Tags)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to tag location"})
+		return
+	}
+	config.publishLocationEvent(events.LocationUpdated, location.ID)
+	config.SharePublisher.Publish(changes)
+
+	tagsResponse := make([]models.TagResponse, 0)
+	for _, tag := range tags {
+		tagsResponse = append(tagsResponse, models.TagResponse{ID: tag.ID, Name: tag.Name})
+	}
+
+	render.JSON(w, r, tagsResponse)
+}
+
+// @Summary		Remove a tag from a location
+// @Description	Remove a tag from a location owned by the caller
+// @Tags			locations
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int		true	"Location ID"
+// @Param			tag	path		string	true	"Tag name"
+// @Success		200	{object}	map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/{id}/tags/{tag} [delete]
+func (config *LocationConfig) DeleteTagFromLocationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+
+	location, ok := config.ownedLocation(w, r, id)
+	if !ok {
+		return
+	}
+
+	changes, err := config.TagRepository.RemoveTagFromLocation(location, models.NormalizeTag(chi.URLParam(r, "tag")))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to remove tag from location"})
+		return
+	}
+	config.publishLocationEvent(events.LocationUpdated, location.ID)
+	config.SharePublisher.Publish(changes)
+
+	render.JSON(w, r, map[string]string{"message": "Tag removed from location successfully"})
+}
+
+// ownedLocation loads a location and checks that it belongs to the caller,
+// rendering the error otherwise.
+func (config *LocationConfig) ownedLocation(w http.ResponseWriter, r *http.Request, id int) (*dbmodel.LocationEntry, bool) {
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return nil, false
+	}
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, false
+	}
+	location, err := config.LocationEntryRepository.FindById(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location"})
+		return nil, false
+	}
+	if location.UserID != caller.ID {
+		render.JSON(w, r, map[string]string{"error": "Only the owner of the location can do this"})
+		return nil, false
+	}
+	return location, true
+}
+
+// recordLocationMove appends the new position to the history of the location
+// and applies the retention policy of its owner.
+func (config *LocationConfig) recordLocationMove(previous, current *dbmodel.LocationEntry) {
+	owner, err := config.UserEntryRepository.FindById(current.UserID)
+	if err != nil {
+		log.Println("Failed to retrieve owner of location", current.ID, err)
+		return
+	}
+	days := config.HistoryRetentionDays(owner)
+	if days == 0 {
+		return
+	}
+	if err := config.HistoryRepository.AppendLocationMove(previous, current); err != nil {
+		log.Println("Failed to record history of location", current.ID, err)
+		return
+	}
+	if err := config.HistoryRepository.DeleteBefore(owner.ID, time.Now().AddDate(0, 0, -days)); err != nil {
+		log.Println("Failed to apply history retention of user", owner.ID, err)
+	}
+}
+
+// publishLocationEvent notifies the groups where the location is shared.
+func (config *LocationConfig) publishLocationEvent(eventType string, id uint) {
+	groupIDs, err := config.LocationEntryRepository.FindGroupIDsForLocation(id)
+	if err != nil {
+		log.Println("Failed to retrieve groups of location", id, err)
+		return
+	}
+	for _, groupID := range groupIDs {
+		config.EventHub.Publish(events.Event{Type: eventType, GroupID: groupID, LocationID: id})
+	}
+}
+
+// geocodeRequest finds the coordinates of a location given by its address
+// only, rendering the error when it fails.
+func (config *LocationConfig) geocodeRequest(w http.ResponseWriter, r *http.Request, req *models.LocationRequest) bool {
+	if !req.NeedsGeocoding() {
+		return true
+	}
+	results, err := config.Geocoder.Geocode(r.Context(), req.Address, 1)
+	if err != nil {
+		log.Println("Failed to geocode:", err)
+		render.JSON(w, r, map[string]string{"error": "Failed to geocode address"})
+		return false
+	}
+	if len(results) == 0 {
+		render.JSON(w, r, map[string]string{"error": "No coordinates found for the address"})
+		return false
+	}
+	req.Latitude = results[0].Latitude
+	req.Longitude = results[0].Longitude
+	return true
+}
+
+func newLocationEntry(req *models.LocationRequest) *dbmodel.LocationEntry {
+	return &dbmodel.LocationEntry{
+		Name:        req.Name,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Description: req.Description,
+		Address:     req.Address,
+		Category:    req.Category,
+		Color:       req.Color,
+		Icon:        req.Icon,
+		Altitude:    req.Altitude,
+		Accuracy:    req.Accuracy,
+	}
+}
@@ -0,0 +1,216 @@
+package location
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/models"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// @Summary		Import locations from CSV
+// @Description	Create locations for the caller from the rows of a CSV file, sent as the body or as the multipart field file. Columns are recognised by their header (name, latitude, longitude, description, address, category, color, icon, altitude, accuracy, tags, or a single coordinates column) unless mapping says otherwise. Numbers may use a decimal comma and coordinates may be written in degrees, minutes and seconds. The parameters can also be sent as multipart fields. With report=csv, the rows that were not imported are returned as a CSV file with their errors, to be fixed and imported again.
+// @Tags			locations
+// @Accept			plain
+// @Accept			mpfd
+// @Produce		json
+// @Produce		plain
+// @Param			dry_run			query		bool	false	"Only validate the rows"
+// @Param			mode			query		string	false	"skip (default) to import the valid rows, or atomic to import nothing when one is invalid"
+// @Param			dedupe_radius	query		number	false	"Duplicate distance in meters, 25 by default, 0 disables the detection"
+// @Param			mapping			query		string	false	"JSON object mapping fields to column headers"
+// @Param			delimiter		query		string	false	"comma, semicolon, pipe or tab, detected from the header by default"
+// @Param			report			query		string	false	"json (default) or csv for the error report"
+// @Param			file			formData	file	false	"CSV file"
+// @Success		200				{object}	models.ImportReport
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/import.csv [post]
+func (config *LocationConfig) PostImportLocationsCSVHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxSize)
+	values := r.URL.Query()
+	var body io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Invalid request payload: file is required"})
+			return
+		}
+		defer file.Close()
+		body = file
+		values = r.Form
+	}
+
+	options, err := parseImportOptions(values)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	csvOptions := models.CSVOptions{}
+	if csvOptions.Delimiter, err = models.ParseCSVDelimiter(values.Get("delimiter")); err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	if mapping := values.Get("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &csvOptions.Mapping); err != nil {
+			render.JSON(w, r, map[string]string{"error": "mapping must be a JSON object of field names to column headers"})
+			return
+		}
+	}
+	errorReport := false
+	switch values.Get("report") {
+	case "", "json":
+	case "csv":
+		errorReport = true
+	default:
+		render.JSON(w, r, map[string]string{"error": "report must be json or csv"})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	file, err := models.ParseCSVLocations(body, csvOptions)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+	report, err := config.importLocations(caller, file.Locations, options)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+	if !errorReport {
+		render.JSON(w, r, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "import-errors.csv"}))
+	if err := writeImportErrors(w, file, report); err != nil {
+		log.Println("Failed to send the import report:", err)
+	}
+}
+
+// writeImportErrors writes the rows that were not imported, as they were in
+// the file, followed by their line, status and errors.
+func writeImportErrors(w io.Writer, file *models.CSVImport, report models.ImportReport) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = file.Delimiter
+	header := append(append([]string{}, file.Header...), "import_line", "import_status", "import_errors")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, item := range report.Items {
+		if item.Status == models.ImportStatusValid || item.Status == models.ImportStatusImported {
+			continue
+		}
+		messages := item.Errors
+		if item.Status == models.ImportStatusDuplicate {
+			messages = []string{"duplicate of an existing location"}
+			if item.DuplicateOf != nil {
+				messages = []string{"duplicate of line " + strconv.Itoa(report.Items[*item.DuplicateOf].Line)}
+			}
+		}
+		row := make([]string, len(file.Header), len(header))
+		copy(row, file.Rows[item.Index])
+		row = append(row, strconv.Itoa(item.Line), item.Status, strings.Join(messages, "; "))
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// LocationPageFunc loads a page of a location list.
+type LocationPageFunc func(options dbmodel.QueryOptions) ([]models.LocationResponse, dbmodel.PageInfo, error)
+
+// RenderLocationList renders a page of a location list as JSON, with the
+// coordinates in the formats of the formats parameter, or, with format=csv,
+// every page of the list as a CSV file whose delimiter and decimal separator
+// are set by the delimiter and decimal parameters.
+func RenderLocationList(w http.ResponseWriter, r *http.Request, options dbmodel.QueryOptions, fetch LocationPageFunc) {
+	query := r.URL.Query()
+	switch query.Get("format") {
+	case "", "json":
+		formats, err := models.ParseCoordinateFormats(r)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": err.Error()})
+			return
+		}
+		locations, page, err := fetch(options)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": err.Error()})
+			return
+		}
+		models.AddCoordinateFormats(locations, formats)
+		render.JSON(w, r, models.NewPageResponse(locations, page))
+		return
+	case "csv":
+	default:
+		render.JSON(w, r, map[string]string{"error": "format must be json or csv"})
+		return
+	}
+
+	delimiter, err := models.ParseCSVDelimiter(query.Get("delimiter"))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	if delimiter == 0 {
+		delimiter = ','
+	}
+	decimalComma := false
+	switch query.Get("decimal") {
+	case "", "point":
+	case "comma":
+		decimalComma = true
+	default:
+		render.JSON(w, r, map[string]string{"error": "decimal must be point or comma"})
+		return
+	}
+
+	options.Limit = dbmodel.MaxPageLimit
+	locations, page, err := fetch(options)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "locations.csv"}))
+	writer := models.NewCSVLocationWriter(w, delimiter, decimalComma)
+	err = writer.WriteHeader()
+	for err == nil {
+		for _, location := range locations {
+			if err = writer.Write(location); err != nil {
+				break
+			}
+		}
+		if err != nil || page.NextCursor == "" {
+			break
+		}
+		if err = writer.Flush(); err != nil {
+			break
+		}
+		options.After = page.NextCursor
+		locations, page, err = fetch(options)
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		log.Println("Failed to export locations:", err)
+	}
+}
@@ -0,0 +1,65 @@
+package location
+
+import (
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/models"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// ExportLocations streams the locations produced by export as a GeoJSON, GPX
+// or KML file named name. The document is only started with the first batch
+// so that a failure before it is still reported as a JSON error.
+func ExportLocations(w http.ResponseWriter, r *http.Request, format, name string, export func(fn dbmodel.ExportBatchFunc) error) {
+	contentType, ok := models.ExportFormats[format]
+	if !ok {
+		render.JSON(w, r, map[string]string{"error": "format must be geojson, gpx or kml"})
+		return
+	}
+	encoder, err := models.NewLocationEncoder(format, w)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	started := false
+	begin := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + "." + format}))
+		return encoder.Begin()
+	}
+	err = export(func(locations []dbmodel.LocationEntry, visible map[uint]bool) error {
+		if err := begin(); err != nil {
+			return err
+		}
+		for i := range locations {
+			if err := encoder.Encode(&locations[i], visible[locations[i].ID]); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+	if err == nil {
+		err = begin()
+	}
+	if err == nil {
+		err = encoder.End()
+	}
+	if err != nil {
+		log.Println("Failed to export locations:", err)
+	}
+}
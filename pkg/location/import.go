@@ -0,0 +1,247 @@
+package location
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// importMaxSize bounds the size of an imported file.
+const importMaxSize = 10 << 20
+
+// @Summary		Import locations
+// @Description	Create locations for the caller from a GeoJSON FeatureCollection of Points, the waypoints of a GPX file or the Point placemarks of a KML file; the format is detected from the content. The GeoJSON properties use the names of the GeoJSON export (name, description, address, category, color, icon, accuracy, tags). A location with the same name as an existing location of the caller, or an earlier one of the file, closer than dedupe_radius meters is reported as a duplicate and skipped. Invalid locations are reported and skipped, or abort the whole import in the atomic mode; with dry_run=true nothing is created and every location is only validated.
+// @Tags			locations
+// @Accept			json
+// @Accept			xml
+// @Produce		json
+// @Param			dry_run			query		bool								false	"Only validate the locations"
+// @Param			mode			query		string								false	"skip (default) to import the valid locations, or atomic to import nothing when one is invalid"
+// @Param			dedupe_radius	query		number								false	"Duplicate distance in meters, 25 by default, 0 disables the detection"
+// @Param			request			body		models.LocationFeatureCollection	true	"GeoJSON FeatureCollection, GPX or KML document"
+// @Success		200				{object}	models.ImportReport
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/import [post]
+func (config *LocationConfig) PostImportLocationsHandler(w http.ResponseWriter, r *http.Request) {
+	options, err := parseImportOptions(r.URL.Query())
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, importMaxSize))
+	var locations []models.LocationImport
+	if isXML(body) {
+		locations, err = models.ParseXMLLocations(body)
+	} else {
+		var data []byte
+		data, err = io.ReadAll(body)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Invalid request payload: file must not exceed " + strconv.Itoa(importMaxSize) + " bytes"})
+			return
+		}
+		locations, err = models.ParseGeoJSONLocations(data)
+	}
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	report, err := config.importLocations(caller, locations, options)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+	render.JSON(w, r, report)
+}
+
+// isXML reports whether the body starts with an XML tag, after an optional
+// byte order mark and spaces.
+func isXML(body *bufio.Reader) bool {
+	head, _ := body.Peek(512)
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	head = bytes.TrimLeft(head, " \t\r\n")
+	return len(head) > 0 && head[0] == '<'
+}
+
+type importOptions struct {
+	dryRun bool
+	// atomic imports either all the locations or none of them.
+	atomic bool
+	// dedupeRadius is the distance in meters under which a location with the
+	// same name is a duplicate, 0 disables the detection.
+	dedupeRadius float64
+}
+
+// parseImportOptions reads the dry_run, mode and dedupe_radius parameters.
+func parseImportOptions(values url.Values) (importOptions, error) {
+	options := importOptions{dedupeRadius: models.DefaultDedupeRadius}
+	if value := values.Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, errors.New("dry_run must be true or false")
+		}
+		options.dryRun = parsed
+	}
+	switch values.Get("mode") {
+	case "", "skip":
+	case "atomic":
+		options.atomic = true
+	default:
+		return options, errors.New("mode must be skip or atomic")
+	}
+	if value := values.Get("dedupe_radius"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			return options, errors.New("dedupe_radius must be a positive number")
+		}
+		options.dedupeRadius = parsed
+	}
+	return options, nil
+}
+
+// importedPoint is a named position a new location is compared with to
+// detect duplicates, either an existing location or an earlier imported one.
+type importedPoint struct {
+	point      geo.Point
+	locationID uint
+	index      int
+}
+
+// importLocations creates the valid locations for the caller, unless dryRun,
+// skipping the duplicates, and reports the outcome of each of them. In the
+// atomic mode, nothing is created when a location is invalid or one of them
+// fails.
+func (config *LocationConfig) importLocations(caller *dbmodel.UserEntry, locations []models.LocationImport, options importOptions) (models.ImportReport, error) {
+	report := models.ImportReport{DryRun: options.dryRun, Mode: "skip", Total: len(locations), Items: make([]models.ImportItemReport, 0, len(locations))}
+	if options.atomic {
+		report.Mode = "atomic"
+	}
+
+	named := make(map[string][]importedPoint)
+	if options.dedupeRadius > 0 {
+		existing, err := config.LocationEntryRepository.FindLocationPointsForUser(caller.ID)
+		if err != nil {
+			return report, err
+		}
+		for _, location := range existing {
+			key := dedupeKey(location.Name)
+			named[key] = append(named[key], importedPoint{point: geo.Point{Latitude: location.Latitude, Longitude: location.Longitude}, locationID: location.ID, index: -1})
+		}
+	}
+
+	var valid []int
+	for i := range locations {
+		location := &locations[i]
+		item := models.ImportItemReport{Index: i, Line: location.Line, Name: location.Request.Name, Errors: location.Errors}
+		key := dedupeKey(location.Request.Name)
+		point := geo.Point{Latitude: location.Request.Latitude, Longitude: location.Request.Longitude}
+		if len(location.Errors) > 0 {
+			item.Status = models.ImportStatusInvalid
+			report.Invalid++
+		} else if duplicate, ok := findDuplicate(named[key], point, options.dedupeRadius); ok {
+			item.Status = models.ImportStatusDuplicate
+			if duplicate.index >= 0 {
+				item.DuplicateOf = &duplicate.index
+			} else {
+				item.LocationID = duplicate.locationID
+			}
+			report.Duplicates++
+		} else {
+			if options.dedupeRadius > 0 {
+				named[key] = append(named[key], importedPoint{point: point, index: i})
+			}
+			item.Status = models.ImportStatusValid
+			report.Valid++
+			valid = append(valid, i)
+		}
+		report.Items = append(report.Items, item)
+	}
+	if options.dryRun || (options.atomic && report.Invalid > 0) {
+		return report, nil
+	}
+
+	if options.atomic {
+		entries := make([]*dbmodel.LocationEntry, 0, len(valid))
+		tags := make([][]string, 0, len(valid))
+		for _, i := range valid {
+			locationEntry := newLocationEntry(&locations[i].Request)
+			locationEntry.UserID = caller.ID
+			entries = append(entries, locationEntry)
+			tags = append(tags, locations[i].Tags)
+		}
+		changes, err := config.LocationEntryRepository.CreateAll(entries, tags)
+		for j, i := range valid {
+			item := &report.Items[i]
+			if err != nil {
+				item.Status = models.ImportStatusFailed
+				item.Errors = []string{"Failed to create locations"}
+				continue
+			}
+			config.TileCache.InvalidatePoint(entries[j].Latitude, entries[j].Longitude)
+			item.Status = models.ImportStatusImported
+			item.LocationID = entries[j].ID
+			report.Imported++
+		}
+		config.SharePublisher.Publish(changes)
+		return report, nil
+	}
+
+	for _, i := range valid {
+		location := &locations[i]
+		item := &report.Items[i]
+		locationEntry := newLocationEntry(&location.Request)
+		locationEntry.UserID = caller.ID
+		res, changes, err := config.LocationEntryRepository.Create(locationEntry)
+		if err != nil {
+			item.Status = models.ImportStatusFailed
+			item.Errors = []string{"Failed to create location"}
+			continue
+		}
+		config.TileCache.InvalidatePoint(res.Latitude, res.Longitude)
+		config.SharePublisher.Publish(changes)
+		if len(location.Tags) > 0 {
+			_, changes, err := config.TagRepository.AddTagsToLocation(res, location.Tags)
+			if err != nil {
+				item.Errors = []string{"Failed to tag location"}
+			}
+			config.SharePublisher.Publish(changes)
+		}
+		item.Status = models.ImportStatusImported
+		item.LocationID = res.ID
+		report.Imported++
+	}
+	return report, nil
+}
+
+func dedupeKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// findDuplicate returns the first of the points closer than radius meters.
+func findDuplicate(points []importedPoint, point geo.Point, radius float64) (importedPoint, bool) {
+	for _, candidate := range points {
+		if geo.Distance(candidate.point, point) <= radius {
+			return candidate, true
+		}
+	}
+	return importedPoint{}, false
+}
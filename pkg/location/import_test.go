@@ -0,0 +1,266 @@
+package location
+
+import (
+	"bytes"
+	"locate-this/config"
+	"locate-this/database"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/events"
+	"locate-this/pkg/models"
+	"locate-this/pkg/sharerule/publisher"
+	"locate-this/pkg/tiles"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestConfig returns the configuration of the location controllers on a
+// new database, with a user to import the locations for.
+func newTestConfig(t *testing.T) (*LocationConfig, *dbmodel.UserEntry) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.Migrate(db)
+	configuration := &config.Config{
+		UserEntryRepository:     dbmodel.NewUserRepository(db),
+		LocationEntryRepository: dbmodel.NewLocationRepository(db),
+		TagRepository:           dbmodel.NewTagRepository(db),
+		ShareRuleRepository:     dbmodel.NewShareRuleRepository(db),
+		EventHub:                events.NewHub(),
+		TileCache:               tiles.NewCache(0),
+	}
+	configuration.SharePublisher = publisher.NewPublisher(configuration.LocationEntryRepository, configuration.EventHub, configuration.TileCache)
+	user, err := configuration.UserEntryRepository.Create(&dbmodel.UserEntry{Email: "a@example.com", Password: "-", Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(configuration), user
+}
+
+func parseTestdata(t *testing.T, name string) []models.LocationImport {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	locations, err := models.ParseXMLLocations(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return locations
+}
+
+// export renders the locations of the user as the export endpoints do.
+func export(t *testing.T, config *LocationConfig, user *dbmodel.UserEntry, format string) []byte {
+	w := httptest.NewRecorder()
+	ExportLocations(w, httptest.NewRequest("GET", "/locations."+format, nil), format, "locations", func(fn dbmodel.ExportBatchFunc) error {
+		return config.LocationEntryRepository.ExportLocationsForUser(user.ID, user.ID, fn)
+	})
+	if contentType := w.Header().Get("Content-Type"); contentType != models.ExportFormats[format] {
+		t.Fatalf("export content type = %q, body %s", contentType, w.Body)
+	}
+	return w.Body.Bytes()
+}
+
+func floatPointer(value float64) *float64 {
+	return &value
+}
+
+func TestParseGPX(t *testing.T) {
+	locations := parseTestdata(t, "lyon.gpx")
+	want := []models.LocationImport{
+		{Request: models.LocationRequest{Name: "Place Bellecour", Latitude: 45.757814, Longitude: 4.832011, Altitude: floatPointer(169.5), Description: "Main square of the Presqu'île", Icon: "Flag, Blue", Category: "meeting point"}},
+		{Request: models.LocationRequest{Name: "Bouchon Les Lyonnais", Latitude: 45.762207, Longitude: 4.822104, Description: "Book ahead", Category: "restaurant"}},
+		{Request: models.LocationRequest{Name: "Parking Antonin Poncet", Latitude: 45.7596, Longitude: 4.8286, Icon: "Parking Area"}},
+		{Request: models.LocationRequest{Name: "Sydney Opera House", Latitude: -33.868820, Longitude: 151.209296, Altitude: floatPointer(-2)}},
+		{Request: models.LocationRequest{Name: "Broken latitude", Longitude: 4.83}, Errors: []string{"lat must be a number"}},
+		{Request: models.LocationRequest{Name: "Too high", Latitude: 45.75, Longitude: 4.83, Altitude: floatPointer(9500)}, Errors: []string{"altitude must be between -11000 and 9000 meters"}},
+		{Request: models.LocationRequest{Name: "place bellecour", Latitude: 45.7579, Longitude: 4.8321}},
+	}
+	if !reflect.DeepEqual(locations, want) {
+		t.Errorf("ParseXMLLocations =\n%+v\nwant\n%+v", locations, want)
+	}
+}
+
+func TestParseKML(t *testing.T) {
+	locations := parseTestdata(t, "places.kml")
+	want := []models.LocationImport{
+		{
+			Request: models.LocationRequest{
+				Name: "Eiffel Tower", Latitude: 48.858370, Longitude: 2.294481, Altitude: floatPointer(35),
+				Address: "Champ de Mars, 5 Avenue Anatole France, 75007 Paris", Description: "<b>Iron</b> lattice tower",
+				Category: "other", Color: "#ff0000", Icon: "tower", Accuracy: floatPointer(12.5),
+			},
+			Tags: []string{"paris", "landmark"},
+		},
+		{Request: models.LocationRequest{Name: "Colosseum", Latitude: 41.890210, Longitude: 12.492231}},
+		{Request: models.LocationRequest{Name: "Via Appia"}, Errors: []string{"geometry must be a Point"}},
+		{Request: models.LocationRequest{Name: "Fiji", Latitude: -16.5, Longitude: -179.999}, Tags: []string{"island"}},
+		{Request: models.LocationRequest{Name: "Bad point"}, Errors: []string{"coordinates must be longitude,latitude or longitude,latitude,altitude"}},
+		{Request: models.LocationRequest{Name: "COLOSSEUM", Latitude: 41.890250, Longitude: 12.492300}},
+	}
+	if !reflect.DeepEqual(locations, want) {
+		t.Errorf("ParseXMLLocations =\n%+v\nwant\n%+v", locations, want)
+	}
+}
+
+func TestParseXMLRejectsOtherDocuments(t *testing.T) {
+	for _, document := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg"></svg>`,
+		`<gpx><wpt lat="1" lon="2"><name>a</wpt></gpx>`,
+		``,
+	} {
+		if _, err := models.ParseXMLLocations(strings.NewReader(document)); err == nil {
+			t.Errorf("ParseXMLLocations(%q) did not fail", document)
+		}
+	}
+}
+
+// importStatuses returns the status of each item of a report.
+func importStatuses(report models.ImportReport) []string {
+	statuses := make([]string, 0, len(report.Items))
+	for _, item := range report.Items {
+		statuses = append(statuses, item.Status)
+	}
+	return statuses
+}
+
+// exportTimes matches the times of the GPX waypoints, the creation date of
+// the locations.
+var exportTimes = regexp.MustCompile(`<time>[^<]*</time>`)
+
+func TestImportExportRoundTrip(t *testing.T) {
+	tests := []struct {
+		format   string
+		testdata string
+		statuses []string
+		// the file item each duplicate repeats
+		duplicateOf map[int]int
+	}{
+		{
+			format:      "gpx",
+			testdata:    "lyon.gpx",
+			statuses:    []string{"imported", "imported", "imported", "imported", "invalid", "invalid", "duplicate"},
+			duplicateOf: map[int]int{6: 0},
+		},
+		{
+			format:      "kml",
+			testdata:    "places.kml",
+			statuses:    []string{"imported", "imported", "invalid", "imported", "invalid", "duplicate"},
+			duplicateOf: map[int]int{5: 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			config, user := newTestConfig(t)
+			options := importOptions{dedupeRadius: models.DefaultDedupeRadius}
+			locations := parseTestdata(t, test.testdata)
+			report, err := config.importLocations(user, locations, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if statuses := importStatuses(report); !reflect.DeepEqual(statuses, test.statuses) {
+				t.Fatalf("statuses = %v, want %v", statuses, test.statuses)
+			}
+			for i, item := range report.Items {
+				if index, ok := test.duplicateOf[i]; ok && (item.DuplicateOf == nil || *item.DuplicateOf != index) {
+					t.Errorf("item %d duplicates %v, want %d", i, item.DuplicateOf, index)
+				}
+			}
+
+			exported := export(t, config, user, test.format)
+			reimported, err := models.ParseXMLLocations(bytes.NewReader(exported))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var imported []models.LocationImport
+			for i, item := range report.Items {
+				if item.Status == models.ImportStatusImported {
+					imported = append(imported, locations[i])
+				}
+			}
+			if test.format == "gpx" {
+				// GPX waypoints have no field for the rest
+				for i := range imported {
+					imported[i].Request.Address, imported[i].Request.Color, imported[i].Request.Accuracy, imported[i].Tags = "", "", nil, nil
+				}
+			}
+			if !reflect.DeepEqual(reimported, imported) {
+				t.Errorf("exported locations read back as\n%+v\nwant\n%+v", reimported, imported)
+			}
+
+			// importing the export again only finds duplicates of the
+			// existing locations
+			again, err := config.importLocations(user, reimported, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.Duplicates != len(reimported) || again.Imported != 0 {
+				t.Errorf("import of the export: %d duplicates and %d imported, want %d duplicates", again.Duplicates, again.Imported, len(reimported))
+			}
+			for i, item := range again.Items {
+				if item.LocationID != report.Items[importedIndex(report, i)].LocationID || item.DuplicateOf != nil {
+					t.Errorf("item %d is a duplicate of location %d, want %d", i, item.LocationID, report.Items[importedIndex(report, i)].LocationID)
+				}
+			}
+			if second := export(t, config, user, test.format); !bytes.Equal(second, exported) {
+				t.Errorf("export changed after importing duplicates:\n%s\nwant\n%s", second, exported)
+			}
+
+			// the export imported by another user is exported the same
+			other, otherUser := newTestConfig(t)
+			if _, err := other.importLocations(otherUser, reimported, options); err != nil {
+				t.Fatal(err)
+			}
+			otherExport := export(t, other, otherUser, test.format)
+			if got, want := exportTimes.ReplaceAll(otherExport, nil), exportTimes.ReplaceAll(exported, nil); !bytes.Equal(got, want) {
+				t.Errorf("export → import → export =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// importedIndex returns the item of the report that created the nth location.
+func importedIndex(report models.ImportReport, n int) int {
+	for i, item := range report.Items {
+		if item.Status == models.ImportStatusImported {
+			if n == 0 {
+				return i
+			}
+			n--
+		}
+	}
+	return -1
+}
+
+func TestImportDedupeRadius(t *testing.T) {
+	locations := parseTestdata(t, "lyon.gpx")
+	tests := []struct {
+		radius     float64
+		duplicates int
+	}{
+		// the two Place Bellecour are 12 m apart
+		{0, 0},
+		{5, 0},
+		{25, 1},
+		// Place Bellecour and Parking Antonin Poncet have other names
+		{100000, 1},
+	}
+	for _, test := range tests {
+		config, user := newTestConfig(t)
+		report, err := config.importLocations(user, locations, importOptions{dryRun: true, dedupeRadius: test.radius})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Duplicates != test.duplicates || report.Imported != 0 {
+			t.Errorf("radius %v: %d duplicates and %d imported, want %d duplicates", test.radius, report.Duplicates, report.Imported, test.duplicates)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package location
+
+import (
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/coords"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/models"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultNearbyLimit = 10
+	maxNearbyLimit     = 100
+)
+
+// @Summary		Get the nearest locations
+// @Description	Retrieve the locations closest to a point among those whose coordinates are visible to the caller, the closest first, with their distance in meters.
+// @Tags			locations
+// @Produce		json
+// @Param			lat				query		string	true	"Latitude, in decimal degrees or degrees, minutes and seconds"
+// @Param			lng				query		string	true	"Longitude, in decimal degrees or degrees, minutes and seconds"
+// @Param			limit			query		int		false	"Number of locations, 10 by default, 100 at most"
+// @Param			max_distance	query		number	false	"Maximum distance in meters"
+// @Param			formats			query		string	false	"Comma separated coordinate formats to add: dms, utm, mgrs, geohash, pluscode"
+// @Success		200	{array}		models.NearbyLocationResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/locations/nearby [get]
+func (config *LocationConfig) GetNearbyLocationsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	latitude, err := coords.ParseLatitude(query.Get("lat"))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	longitude, err := coords.ParseLongitude(query.Get("lng"))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	limit := defaultNearbyLimit
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxNearbyLimit {
+			render.JSON(w, r, map[string]string{"error": "limit must be between 1 and 100"})
+			return
+		}
+	}
+	maxDistance := 0.0
+	if value := query.Get("max_distance"); value != "" {
+		if maxDistance, err = strconv.ParseFloat(value, 64); err != nil || maxDistance <= 0 || math.IsInf(maxDistance, 0) {
+			render.JSON(w, r, map[string]string{"error": "max_distance must be a positive number"})
+			return
+		}
+	}
+	formats, err := models.ParseCoordinateFormats(r)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	nearby, err := config.LocationEntryRepository.FindNearest(geo.Point{Latitude: latitude, Longitude: longitude}, caller.ID, limit, maxDistance)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+
+	locationsResponse := make([]models.LocationResponse, 0, len(nearby))
+	for _, location := range nearby {
+		locationsResponse = append(locationsResponse, models.NewLocationResponse(&location.LocationEntry))
+	}
+	tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(locationsResponse))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve tags"})
+		return
+	}
+	models.AttachTags(locationsResponse, tags)
+	models.AddCoordinateFormats(locationsResponse, formats)
+
+	nearbyResponse := make([]models.NearbyLocationResponse, 0, len(nearby))
+	for i, location := range nearby {
+		nearbyResponse = append(nearbyResponse, models.NearbyLocationResponse{LocationResponse: locationsResponse[i], Distance: location.Distance})
+	}
+	render.JSON(w, r, nearbyResponse)
+}
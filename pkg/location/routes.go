@@ -2,6 +2,8 @@ package location
 
 import (
 	"locate-this/config"
+	"locate-this/pkg/attachment"
+	"locate-this/pkg/checkin"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -10,19 +12,52 @@ import (
 Locations:
 - POST /locations
 - GET /locations
+- GET /locations/nearby?lat=&lng=&limit=&max_distance=
 - GET /locations/{id}
 - PUT /locations/{id}
 - DELETE /locations/{id}
+- POST /locations/import?dry_run=
+- POST /locations/import.csv?mapping=&mode=&report=
+
+- GET /locations/{id}/groups
+- GET /locations/{id}/tags
+- POST /locations/{id}/tags
+- DELETE /locations/{id}/tags/{tag}
+
+- GET /locations/{id}/history?from=&to=
+
+- GET /locations/{id}/attachments
+- POST /locations/{id}/attachments
+
+- POST /locations/{id}/checkins
+- GET /locations/{id}/checkins?active=&from=&to=&limit=
+- DELETE /locations/{id}/checkins
+- GET /locations/{id}/checkins/stats?from=&to=
 */
 
 func Routes(configuration *config.Config) chi.Router {
 	LocationConfig := New(configuration)
+	AttachmentConfig := attachment.New(configuration)
+	CheckinConfig := checkin.New(configuration)
 	router := chi.NewRouter()
 	router.Post("/", LocationConfig.PostLocationHandler)
 	router.Get("/", LocationConfig.GetAllLocationHandler) // FOR DEBUG ONLY
+	router.Get("/nearby", LocationConfig.GetNearbyLocationsHandler)
+	router.Post("/import", LocationConfig.PostImportLocationsHandler)
+	router.Post("/import.csv", LocationConfig.PostImportLocationsCSVHandler)
 	router.Get("/{id}", LocationConfig.GetLocationByIDHandler)
 	router.Put("/{id}", LocationConfig.PutLocationHandler)
 	router.Delete("/{id}", LocationConfig.DeleteLocationHandler)
 	router.Get("/{id}/groups", LocationConfig.GetGroupsForLocationHandler)
+	router.Get("/{id}/tags", LocationConfig.GetTagsForLocationHandler)
+	router.Post("/{id}/tags", LocationConfig.PostTagsToLocationHandler)
+	router.Delete("/{id}/tags/{tag}", LocationConfig.DeleteTagFromLocationHandler)
+	router.Get("/{id}/history", LocationConfig.GetLocationHistoryHandler)
+	router.Get("/{id}/attachments", AttachmentConfig.GetAttachmentsForLocationHandler)
+	router.Post("/{id}/attachments", AttachmentConfig.PostAttachmentHandler)
+	router.Post("/{id}/checkins", CheckinConfig.PostCheckinHandler)
+	router.Get("/{id}/checkins", CheckinConfig.GetCheckinsHandler)
+	router.Delete("/{id}/checkins", CheckinConfig.DeleteCheckinHandler)
+	router.Get("/{id}/checkins/stats", CheckinConfig.GetCheckinStatsHandler)
 	return router
 }
@@ -0,0 +1,75 @@
+package mapview
+
+import (
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/tiles"
+	"math"
+	"sort"
+)
+
+const (
+	// clusterCellsPerTile is the number of grid cells along a side of a map
+	// tile, cells of 64 pixels with the usual tiles of 256 pixels.
+	clusterCellsPerTile = 4
+	// clusterSampleSize is the number of location IDs given with a cluster.
+	clusterSampleSize = 5
+)
+
+type cluster struct {
+	latitudeSum  float64
+	longitudeSum float64
+	ids          []uint
+}
+
+// clusterPoints groups the points falling in the same cell of a grid of the
+// map at a zoom level. The grid is fixed on the world, in Web Mercator, so
+// that the clusters stay the same when the map is panned. It returns the
+// clusters, the biggest first, and the points alone in their cell.
+func clusterPoints(points []dbmodel.LocationEntry, zoom int) ([]*cluster, []uint) {
+	cellsPerSide := float64(clusterCellsPerTile) * math.Exp2(float64(zoom))
+	cells := make(map[[2]int]*cluster)
+	var order []*cluster
+	for _, point := range points {
+		x, y := tiles.Mercator(point.Latitude, point.Longitude)
+		key := [2]int{cellIndex(x, cellsPerSide), cellIndex(y, cellsPerSide)}
+		c, ok := cells[key]
+		if !ok {
+			c = &cluster{}
+			cells[key] = c
+			order = append(order, c)
+		}
+		c.latitudeSum += point.Latitude
+		c.longitudeSum += point.Longitude
+		c.ids = append(c.ids, point.ID)
+	}
+
+	var clusters []*cluster
+	var singles []uint
+	for _, c := range order {
+		if len(c.ids) == 1 {
+			singles = append(singles, c.ids[0])
+		} else {
+			clusters = append(clusters, c)
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return len(clusters[i].ids) > len(clusters[j].ids) })
+	return clusters, singles
+}
+
+func cellIndex(value, cellsPerSide float64) int {
+	return int(math.Min(math.Floor(value*cellsPerSide), cellsPerSide-1))
+}
+
+// centroid returns the mean position of the points of the cluster. A cell
+// never spans the antimeridian, so the longitudes can be averaged.
+func (c *cluster) centroid() (float64, float64) {
+	count := float64(len(c.ids))
+	return c.latitudeSum / count, c.longitudeSum / count
+}
+
+func (c *cluster) sample() []uint {
+	if len(c.ids) > clusterSampleSize {
+		return c.ids[:clusterSampleSize]
+	}
+	return c.ids
+}
@@ -0,0 +1,179 @@
+package mapview
+
+import (
+	"context"
+	"encoding/json"
+	"locate-this/config"
+	"locate-this/database"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/models"
+	"math"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func TestClusterPoints(t *testing.T) {
+	type want struct {
+		ids                 []uint
+		latitude, longitude float64
+	}
+	tests := []struct {
+		name     string
+		zoom     int
+		points   [][2]float64
+		clusters []want
+		singles  []uint
+	}{
+		{
+			name:   "on both sides of the antimeridian",
+			zoom:   5,
+			points: [][2]float64{{10, 179.5}, {10, 179.9}, {10, -179.9}, {10, -179.5}},
+			// the cells stop at the antimeridian, so the centroids stay on
+			// their side instead of averaging to the prime meridian
+			clusters: []want{{[]uint{1, 2}, 10, 179.7}, {[]uint{3, 4}, 10, -179.7}},
+		},
+		{
+			name:     "180 is in the last cell and -180 in the first",
+			zoom:     5,
+			points:   [][2]float64{{0, 180}, {0, 179.9}, {0, -180}},
+			clusters: []want{{[]uint{1, 2}, 0, 179.95}},
+			singles:  []uint{3},
+		},
+		{
+			name:     "a quarter of the world per cell at zoom 0",
+			zoom:     0,
+			points:   [][2]float64{{10, 179}, {10, 91}, {10, -179}, {10, -91}, {10, 89}},
+			clusters: []want{{[]uint{1, 2}, 10, 135}, {[]uint{3, 4}, 10, -135}},
+			singles:  []uint{5},
+		},
+		{
+			name:     "beyond the latitudes of Web Mercator",
+			zoom:     5,
+			points:   [][2]float64{{90, 0}, {89.9, 0.1}, {-90, 0}, {-86, 0.1}},
+			clusters: []want{{[]uint{1, 2}, 89.95, 0.05}, {[]uint{3, 4}, -88, 0.05}},
+		},
+		{
+			name:     "the biggest cluster first",
+			zoom:     10,
+			points:   [][2]float64{{45.76, 4.83}, {45.7601, 4.8301}, {-33.8568, 151.2153}, {-33.8569, 151.2154}, {-33.857, 151.2155}},
+			clusters: []want{{[]uint{3, 4, 5}, -33.8569, 151.2154}, {[]uint{1, 2}, 45.76005, 4.83005}},
+		},
+		{
+			name:    "apart from zoom 15",
+			zoom:    15,
+			points:  [][2]float64{{45.76, 4.83}, {45.77, 4.84}},
+			singles: []uint{1, 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			points := make([]dbmodel.LocationEntry, len(test.points))
+			for i, point := range test.points {
+				points[i] = dbmodel.LocationEntry{Latitude: point[0], Longitude: point[1]}
+				points[i].ID = uint(i + 1)
+			}
+			clusters, singles := clusterPoints(points, test.zoom)
+			if len(clusters) != len(test.clusters) || !slices.Equal(singles, test.singles) {
+				t.Fatalf("clusterPoints = %d clusters and singles %v, want %d and %v", len(clusters), singles, len(test.clusters), test.singles)
+			}
+			for i, c := range clusters {
+				latitude, longitude := c.centroid()
+				want := test.clusters[i]
+				if !slices.Equal(c.ids, want.ids) || math.Abs(latitude-want.latitude) > 1e-9 || math.Abs(longitude-want.longitude) > 1e-9 {
+					t.Errorf("cluster %d = %v at %v, %v, want %v at %v, %v", i, c.ids, latitude, longitude, want.ids, want.latitude, want.longitude)
+				}
+			}
+		})
+	}
+}
+
+func TestClusterSample(t *testing.T) {
+	points := make([]dbmodel.LocationEntry, 8)
+	for i := range points {
+		points[i] = dbmodel.LocationEntry{Latitude: 45.76, Longitude: 4.83}
+		points[i].ID = uint(i + 1)
+	}
+	clusters, _ := clusterPoints(points, 3)
+	if len(clusters) != 1 || len(clusters[0].ids) != 8 || !slices.Equal(clusters[0].sample(), []uint{1, 2, 3, 4, 5}) {
+		t.Errorf("clusters = %v", clusters)
+	}
+}
+
+func TestGetMapAcrossTheAntimeridian(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.Migrate(db)
+	configuration := &config.Config{
+		UserEntryRepository:     dbmodel.NewUserRepository(db),
+		LocationEntryRepository: dbmodel.NewLocationRepository(db),
+		TagRepository:           dbmodel.NewTagRepository(db),
+	}
+	router := chi.NewRouter()
+	router.Mount("/map", Routes(configuration))
+
+	users := make([]*dbmodel.UserEntry, 2)
+	for i, name := range []string{"alice", "bob"} {
+		users[i], err = configuration.UserEntryRepository.Create(&dbmodel.UserEntry{Email: name + "@example.com", Password: "-", Username: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob := users[0], users[1]
+	for _, location := range []dbmodel.LocationEntry{
+		{UserID: alice.ID, Name: "Suva", Latitude: -18.14, Longitude: 178.44},
+		{UserID: alice.ID, Name: "Nausori", Latitude: -18.03, Longitude: 178.56},
+		{UserID: alice.ID, Name: "Apia", Latitude: -13.83, Longitude: -171.76},
+		{UserID: alice.ID, Name: "Nuku'alofa", Latitude: -21.14, Longitude: -175.2},
+		{UserID: alice.ID, Name: "Lyon", Latitude: 45.76, Longitude: 4.83},
+		// not shared with alice
+		{UserID: bob.ID, Name: "Levuka", Latitude: -17.68, Longitude: 178.84},
+	} {
+		if _, _, err := configuration.LocationEntryRepository.Create(&location); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query     string
+		total     int
+		clusters  []int
+		locations []string
+	}{
+		{"bbox=170,-25,-170,-10&zoom=5", 4, []int{2}, []string{"Apia", "Nuku'alofa"}},
+		{"bbox=170,-25,190,-10&zoom=5", 4, []int{2}, []string{"Apia", "Nuku'alofa"}},
+		{"bbox=170,-25,-170,-10&zoom=15", 4, nil, []string{"Apia", "Nausori", "Nuku'alofa", "Suva"}},
+		{"bbox=-176,-25,-170,-10&zoom=5", 2, nil, []string{"Apia", "Nuku'alofa"}},
+		{"bbox=175,-25,180,-10&zoom=5", 2, []int{2}, nil},
+		{"bbox=-180,-90,180,90&zoom=0", 5, []int{2, 2}, []string{"Lyon"}},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest("GET", "/map?"+test.query, nil)
+		request = request.WithContext(context.WithValue(request.Context(), "id", alice.Email))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+		var response models.MapResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: response = %s", test.query, w.Body)
+		}
+		var clusters []int
+		for _, c := range response.Clusters {
+			clusters = append(clusters, c.Count)
+		}
+		var locations []string
+		for _, location := range response.Locations {
+			locations = append(locations, location.Name)
+		}
+		slices.Sort(locations)
+		if response.Total != test.total || !slices.Equal(clusters, test.clusters) || !slices.Equal(locations, test.locations) {
+			t.Errorf("%s: total %d, clusters %v and locations %v, want %d, %v and %v", test.query, response.Total, clusters, locations, test.total, test.clusters, test.locations)
+		}
+	}
+}
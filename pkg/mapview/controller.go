@@ -0,0 +1,104 @@
+package mapview
+
+import (
+	"locate-this/config"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	maxZoom = 22
+	// ClusterMaxZoom is the zoom level from which the locations are
+	// returned one by one instead of clustered.
+	ClusterMaxZoom = 15
+	// MaxMapLocations is the number of individual locations returned at most.
+	MaxMapLocations = 1000
+)
+
+type MapConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *MapConfig {
+	return &MapConfig{configuration}
+}
+
+// @Summary		Get the locations of a map viewport
+// @Description	Retrieve the locations inside a box whose coordinates are visible to the caller. Below zoom 15, locations close to each other on the map are grouped in clusters (centroid, count and a few IDs) and the locations alone in their area are returned individually; from zoom 15, every location is returned individually, 1000 at most. The box may cross the antimeridian.
+// @Tags			map
+// @Produce		json
+// @Param			bbox	query		string	true	"minLng,minLat,maxLng,maxLat; minLng greater than maxLng, or maxLng beyond 180, for a box crossing the antimeridian"
+// @Param			zoom	query		int		true	"Zoom level of the map, between 0 and 22"
+// @Success		200	{object}	models.MapResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/map [get]
+func (config *MapConfig) GetMapHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bounds, err := models.ParseBoundingBox(query.Get("bbox"))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+	zoom, err := strconv.Atoi(query.Get("zoom"))
+	if err != nil || zoom < 0 || zoom > maxZoom {
+		render.JSON(w, r, map[string]string{"error": "zoom must be between 0 and 22"})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	points, err := config.LocationEntryRepository.FindPointsWithinBounds(bounds, caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+
+	mapResponse := models.MapResponse{Zoom: zoom, Total: len(points), Clusters: []models.MapClusterResponse{}}
+	var ids []uint
+	if zoom < ClusterMaxZoom {
+		var clusters []*cluster
+		clusters, ids = clusterPoints(points, zoom)
+		for _, c := range clusters {
+			latitude, longitude := c.centroid()
+			mapResponse.Clusters = append(mapResponse.Clusters, models.MapClusterResponse{
+				Latitude:  latitude,
+				Longitude: longitude,
+				Count:     len(c.ids),
+				SampleIDs: c.sample(),
+			})
+		}
+	} else {
+		for _, point := range points {
+			ids = append(ids, point.ID)
+		}
+	}
+	if len(ids) > MaxMapLocations {
+		ids = ids[:MaxMapLocations]
+		mapResponse.Truncated = true
+	}
+
+	locations, err := config.LocationEntryRepository.FindByIds(ids)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		return
+	}
+	mapResponse.Locations = make([]models.LocationResponse, 0, len(locations))
+	for _, location := range locations {
+		mapResponse.Locations = append(mapResponse.Locations, models.NewLocationResponse(&location))
+	}
+	tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(mapResponse.Locations))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve tags"})
+		return
+	}
+	models.AttachTags(mapResponse.Locations, tags)
+	render.JSON(w, r, mapResponse)
+}
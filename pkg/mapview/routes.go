@@ -0,0 +1,27 @@
+package mapview
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Map:
+- GET /map?bbox=&zoom=
+- GET /tiles/{z}/{x}/{y}.mvt
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	MapConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Get("/", MapConfig.GetMapHandler)
+	return router
+}
+
+func TileRoutes(configuration *config.Config) chi.Router {
+	MapConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Get("/{z}/{x}/{y}.mvt", MapConfig.GetTileHandler)
+	return router
+}
@@ -0,0 +1,133 @@
+package mapview
+
+import (
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/tiles"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// @Summary		Get a vector tile of the locations
+// @Description	Retrieve a Mapbox Vector Tile (version 2) of the locations whose coordinates are visible to the caller. The "locations" layer has a point per location, with the location ID as feature ID and its name, category, color, icon and owner as properties. Below zoom 15, locations close to each other are grouped as in GET /map, in the "clusters" layer with their count. Tiles are cached for each user and identified by an ETag, a request with a matching If-None-Match header gets a 304 response. A tile without location is empty.
+// @Tags			map
+// @Produce		application/vnd.mapbox-vector-tile
+// @Param			z				path		int		true	"Zoom level, between 0 and 22"
+// @Param			x				path		int		true	"Column of the tile, from the antimeridian eastwards"
+// @Param			y				path		int		true	"Row of the tile, from the north"
+// @Param			If-None-Match	header		string	false	"ETag of the tile held by the client"
+// @Success		200	{file}		binary
+// @Success		304	{string}	string	"Not modified"
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/tiles/{z}/{x}/{y}.mvt [get]
+func (config *MapConfig) GetTileHandler(w http.ResponseWriter, r *http.Request) {
+	var tile tiles.Tile
+	var errZ, errX, errY error
+	tile.Z, errZ = strconv.Atoi(chi.URLParam(r, "z"))
+	tile.X, errX = strconv.Atoi(chi.URLParam(r, "x"))
+	tile.Y, errY = strconv.Atoi(chi.URLParam(r, "y"))
+	if errZ != nil || errX != nil || errY != nil || !tile.Valid() {
+		render.JSON(w, r, map[string]string{"error": "Tile must be z/x/y with z between 0 and 22, and x and y between 0 and 2^z - 1"})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	entry, ok := config.TileCache.Get(caller.ID, tile)
+	if !ok {
+		generation := config.TileCache.Generation()
+		data, err := config.renderTile(tile, caller.ID)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+			return
+		}
+		entry = tiles.NewEntry(data)
+		config.TileCache.Put(caller.ID, tile, entry, generation)
+	}
+
+	w.Header().Set("ETag", entry.ETag)
+	// the tile changes with the locations, clients check it every time
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Vary", "Authorization")
+	if etagMatches(r.Header.Get("If-None-Match"), entry.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+	w.Header().Set("Content-Length", strconv.Itoa(len(entry.Data)))
+	w.Write(entry.Data)
+}
+
+// renderTile encodes the locations of a tile visible to a viewer, clustered
+// as in GetMapHandler below ClusterMaxZoom.
+func (config *MapConfig) renderTile(tile tiles.Tile, viewerID uint) ([]byte, error) {
+	clusterLayer := tiles.NewLayer("clusters")
+	var locations []dbmodel.LocationEntry
+	if tile.Z < ClusterMaxZoom {
+		points, err := config.LocationEntryRepository.FindPointsWithinBounds(tile.Bounds(), viewerID)
+		if err != nil {
+			return nil, err
+		}
+		clusters, ids := clusterPoints(inTile(points, tile), tile.Z)
+		for _, c := range clusters {
+			latitude, longitude := c.centroid()
+			x, y := tile.Project(latitude, longitude, tiles.Extent)
+			clusterLayer.AddPoint(0, x, y, map[string]any{"count": len(c.ids)})
+		}
+		locations, err = config.LocationEntryRepository.FindByIds(ids)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		found, err := config.LocationEntryRepository.FindWithinBounds(tile.Bounds(), viewerID, 0)
+		if err != nil {
+			return nil, err
+		}
+		locations = inTile(found, tile)
+	}
+
+	locationLayer := tiles.NewLayer("locations")
+	for _, location := range locations {
+		properties := map[string]any{"name": location.Name, "user_id": location.UserID}
+		for key, value := range map[string]string{"category": location.Category, "color": location.Color, "icon": location.Icon} {
+			if value != "" {
+				properties[key] = value
+			}
+		}
+		x, y := tile.Project(location.Latitude, location.Longitude, tiles.Extent)
+		locationLayer.AddPoint(uint64(location.ID), x, y, properties)
+	}
+	return tiles.Encode(locationLayer, clusterLayer), nil
+}
+
+// inTile keeps the locations of the tile, dropping those on the edge of a
+// neighbouring one so that a location is in a single tile.
+func inTile(locations []dbmodel.LocationEntry, tile tiles.Tile) []dbmodel.LocationEntry {
+	kept := locations[:0]
+	for _, location := range locations {
+		if tiles.TileAt(location.Latitude, location.Longitude, tile.Z) == tile {
+			kept = append(kept, location)
+		}
+	}
+	return kept
+}
+
+// etagMatches reports whether an If-None-Match header lists an ETag, weak
+// validators matching their strong counterpart.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"locate-this/database/dbmodel"
+	"strconv"
+	"time"
+)
+
+type AttachmentResponse struct {
+	ID           uint      `json:"attachment_id"`
+	LocationID   uint      `json:"location_id"`
+	UserID       uint      `json:"user_id"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func NewAttachmentResponse(entry *dbmodel.AttachmentEntry) AttachmentResponse {
+	url := "/api/attachments/" + strconv.FormatUint(uint64(entry.ID), 10)
+	response := AttachmentResponse{
+		ID:          entry.ID,
+		LocationID:  entry.LocationID,
+		UserID:      entry.UserID,
+		Filename:    entry.Filename,
+		ContentType: entry.ContentType,
+		Size:        entry.Size,
+		URL:         url,
+		CreatedAt:   entry.CreatedAt,
+	}
+	if entry.ThumbnailKey != "" {
+		response.ThumbnailURL = url + "/thumbnail"
+	}
+	return response
+}
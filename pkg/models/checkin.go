@@ -0,0 +1,195 @@
+package models
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// CheckinMaxDuration is the longest a check-in lasts, in minutes.
+	CheckinMaxDuration = 24 * 60
+	maxCheckinNote     = 500
+	defaultCheckins    = 100
+	maxCheckins        = 1000
+	topCheckinVisitors = 5
+)
+
+type CheckinRequest struct {
+	// Group to check in with, needed when the location is shared in several groups of the caller
+	GroupID *uint  `json:"group_id"`
+	Note    string `json:"note"`
+	// Minutes before the check-in expires, CHECKIN_DURATION when omitted
+	DurationMinutes int `json:"duration_minutes" example:"60"`
+}
+
+func (req *CheckinRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if req.GroupID != nil && *req.GroupID < 1 {
+		return errors.New("group_id must be >= 1")
+	} else if len([]rune(req.Note)) > maxCheckinNote {
+		return errors.New("note must not exceed 500 characters")
+	} else if req.DurationMinutes < 0 || req.DurationMinutes > CheckinMaxDuration {
+		return errors.New("duration_minutes must be between 0 and 1440")
+	}
+	return nil
+}
+
+// ParseCheckinQuery reads the active, from, to and limit parameters of the
+// check-in list.
+func ParseCheckinQuery(r *http.Request, now time.Time) (dbmodel.CheckinQuery, error) {
+	values := r.URL.Query()
+	query := dbmodel.CheckinQuery{Now: now, Limit: defaultCheckins}
+	if active := values.Get("active"); active != "" {
+		value, err := strconv.ParseBool(active)
+		if err != nil {
+			return query, errors.New("active must be true or false")
+		}
+		query.ActiveOnly = value
+	}
+	period, err := ParseCheckinPeriod(r)
+	if err != nil {
+		return query, err
+	}
+	query.From, query.To = period.From, period.To
+	if limit := values.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxCheckins {
+			return query, errors.New("limit must be between 1 and 1000")
+		}
+		query.Limit = value
+	}
+	return query, nil
+}
+
+// ParseCheckinPeriod reads the from and to parameters, RFC 3339 dates.
+func ParseCheckinPeriod(r *http.Request) (dbmodel.CheckinQuery, error) {
+	values := r.URL.Query()
+	var query dbmodel.CheckinQuery
+	if from := values.Get("from"); from != "" {
+		value, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return query, errors.New("from must be an RFC 3339 date")
+		}
+		query.From = &value
+	}
+	if to := values.Get("to"); to != "" {
+		value, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return query, errors.New("to must be an RFC 3339 date")
+		}
+		query.To = &value
+	}
+	if query.From != nil && query.To != nil && query.To.Before(*query.From) {
+		return query, errors.New("to must be after from")
+	}
+	return query, nil
+}
+
+type CheckinResponse struct {
+	ID         uint      `json:"checkin_id"`
+	LocationID uint      `json:"location_id"`
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	GroupID    *uint     `json:"group_id"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	// Whether the user is still at the location
+	Active bool `json:"active"`
+}
+
+func NewCheckinResponse(entry *dbmodel.CheckinEntry, now time.Time) CheckinResponse {
+	return CheckinResponse{
+		ID:         entry.ID,
+		LocationID: entry.LocationID,
+		UserID:     entry.UserID,
+		Username:   entry.User.Username,
+		GroupID:    entry.GroupID,
+		Note:       entry.Note,
+		CreatedAt:  entry.CreatedAt,
+		EndsAt:     entry.EndsAt,
+		Active:     entry.EndsAt.After(now),
+	}
+}
+
+type CheckinVisitorResponse struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Checkins int    `json:"checkins"`
+}
+
+type CheckinStatsResponse struct {
+	Checkins int `json:"checkins"`
+	// Number of different users who checked in
+	Visitors int `json:"visitors"`
+	// Number of users at the location now
+	Present      int        `json:"present"`
+	FirstCheckin *time.Time `json:"first_checkin,omitempty"`
+	LastCheckin  *time.Time `json:"last_checkin,omitempty"`
+	// Mean time spent at the location in seconds, until now for the active check-ins
+	AverageDuration float64 `json:"average_duration"`
+	// Check-ins by day of the week in UTC, from Monday
+	ByWeekday [7]int `json:"by_weekday"`
+	// Check-ins by hour of the day in UTC
+	ByHour [24]int `json:"by_hour"`
+	// Users who checked in the most
+	TopVisitors []CheckinVisitorResponse `json:"top_visitors"`
+}
+
+func NewCheckinStatsResponse(checkins []dbmodel.CheckinEntry, now time.Time) CheckinStatsResponse {
+	stats := CheckinStatsResponse{Checkins: len(checkins), TopVisitors: make([]CheckinVisitorResponse, 0)}
+	visitors := make(map[uint]*CheckinVisitorResponse)
+	present := make(map[uint]bool)
+	var totalDuration time.Duration
+	for _, checkin := range checkins {
+		createdAt := checkin.CreatedAt.UTC()
+		if stats.FirstCheckin == nil || createdAt.Before(*stats.FirstCheckin) {
+			stats.FirstCheckin = &createdAt
+		}
+		if stats.LastCheckin == nil || createdAt.After(*stats.LastCheckin) {
+			stats.LastCheckin = &createdAt
+		}
+		stats.ByWeekday[(int(createdAt.Weekday())+6)%7]++
+		stats.ByHour[createdAt.Hour()]++
+
+		end := checkin.EndsAt
+		if end.After(now) {
+			end = now
+			present[checkin.UserID] = true
+		}
+		if end.After(checkin.CreatedAt) {
+			totalDuration += end.Sub(checkin.CreatedAt)
+		}
+
+		visitor, ok := visitors[checkin.UserID]
+		if !ok {
+			visitor = &CheckinVisitorResponse{UserID: checkin.UserID, Username: checkin.User.Username}
+			visitors[checkin.UserID] = visitor
+		}
+		visitor.Checkins++
+	}
+	stats.Visitors = len(visitors)
+	stats.Present = len(present)
+	if len(checkins) > 0 {
+		stats.AverageDuration = (totalDuration / time.Duration(len(checkins))).Round(time.Second).Seconds()
+	}
+
+	for _, visitor := range visitors {
+		stats.TopVisitors = append(stats.TopVisitors, *visitor)
+	}
+	sort.Slice(stats.TopVisitors, func(i, j int) bool {
+		if stats.TopVisitors[i].Checkins != stats.TopVisitors[j].Checkins {
+			return stats.TopVisitors[i].Checkins > stats.TopVisitors[j].Checkins
+		}
+		return stats.TopVisitors[i].UserID < stats.TopVisitors[j].UserID
+	})
+	if len(stats.TopVisitors) > topCheckinVisitors {
+		stats.TopVisitors = stats.TopVisitors[:topCheckinVisitors]
+	}
+	return stats
+}
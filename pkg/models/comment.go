@@ -0,0 +1,147 @@
+package models
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxCommentBody = 2000
+	// maxEmojiRunes allows the emojis made of several code points, such as
+	// flags, skin tones and ZWJ sequences.
+	maxEmojiRunes = 10
+)
+
+type CommentRequest struct {
+	Body string `json:"body" example:"The gate is closed on Sundays"`
+	// Comment answered, only read when the comment is created
+	ParentID *uint `json:"parent_id"`
+}
+
+func (req *CommentRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		return errors.New("body must not be null")
+	} else if utf8.RuneCountInString(req.Body) > maxCommentBody {
+		return errors.New("body must not exceed 2000 characters")
+	} else if req.ParentID != nil && *req.ParentID < 1 {
+		return errors.New("parent_id must be >= 1")
+	}
+	return nil
+}
+
+type ReactionRequest struct {
+	Emoji string `json:"emoji" example:"👍"`
+}
+
+func (req *ReactionRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	}
+	req.Emoji = strings.TrimSpace(req.Emoji)
+	if !IsEmoji(req.Emoji) {
+		return errors.New("emoji must be a single emoji")
+	}
+	return nil
+}
+
+// IsEmoji reports whether text looks like a single emoji: symbols, with the
+// joiners, variation selectors and modifiers of the composed emojis.
+func IsEmoji(text string) bool {
+	count := utf8.RuneCountInString(text)
+	if count == 0 || count > maxEmojiRunes {
+		return false
+	}
+	symbol := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.So, r):
+			symbol = true
+		case r == '\u200d', r == '\u20e3', r >= '\ufe00' && r <= '\ufe0f', unicode.Is(unicode.Sk, r), r >= '\U000e0020' && r <= '\U000e007f':
+		default:
+			return false
+		}
+	}
+	return symbol
+}
+
+// ReactionResponse counts the users who put an emoji.
+type ReactionResponse struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+	// Whether the caller is one of them
+	Reacted bool `json:"reacted"`
+}
+
+// NewReactionResponses groups the reactions by comment ID, 0 for those put on
+// the shared location, and by emoji in the order they were first put.
+func NewReactionResponses(reactions []dbmodel.ReactionEntry, callerID uint) map[uint][]ReactionResponse {
+	responses := make(map[uint][]ReactionResponse)
+	for _, reaction := range reactions {
+		list := responses[reaction.CommentID]
+		index := -1
+		for i := range list {
+			if list[i].Emoji == reaction.Emoji {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			list = append(list, ReactionResponse{Emoji: reaction.Emoji})
+			index = len(list) - 1
+		}
+		list[index].Count++
+		if reaction.UserID == callerID {
+			list[index].Reacted = true
+		}
+		responses[reaction.CommentID] = list
+	}
+	return responses
+}
+
+// CommentResponse is a comment, without its body once it is removed.
+type CommentResponse struct {
+	ID         uint       `json:"comment_id"`
+	GroupID    uint       `json:"group_id"`
+	LocationID uint       `json:"location_id"`
+	ParentID   *uint      `json:"parent_id"`
+	UserID     uint       `json:"user_id"`
+	Username   string     `json:"username"`
+	Body       string     `json:"body"`
+	CreatedAt  time.Time  `json:"created_at"`
+	EditedAt   *time.Time `json:"edited_at,omitempty"`
+	Removed    bool       `json:"removed"`
+	// Whether it was removed by a group admin rather than by its author
+	Moderated  bool               `json:"moderated,omitempty"`
+	ReplyCount int                `json:"reply_count"`
+	Reactions  []ReactionResponse `json:"reactions"`
+}
+
+func NewCommentResponse(entry *dbmodel.CommentEntry, replyCount int, reactions []ReactionResponse) CommentResponse {
+	if reactions == nil {
+		reactions = make([]ReactionResponse, 0)
+	}
+	return CommentResponse{
+		ID:         entry.ID,
+		GroupID:    entry.GroupID,
+		LocationID: entry.LocationID,
+		ParentID:   entry.ParentID,
+		UserID:     entry.UserID,
+		Username:   entry.User.Username,
+		Body:       entry.Body,
+		CreatedAt:  entry.CreatedAt,
+		EditedAt:   entry.EditedAt,
+		Removed:    entry.RemovedAt != nil,
+		Moderated:  entry.RemovedBy != nil && *entry.RemovedBy != entry.UserID,
+		ReplyCount: replyCount,
+		Reactions:  reactions,
+	}
+}
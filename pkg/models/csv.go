@@ -0,0 +1,312 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"locate-this/pkg/coords"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvColumnAliases lists, for each field a CSV column can be mapped to, the
+// headers recognised without an explicit mapping. coordinates holds the
+// latitude and the longitude in a single column, or a UTM, MGRS, geohash or
+// Plus Code position.
+var csvColumnAliases = map[string][]string{
+	"name":        {"name", "title", "label", "nom"},
+	"latitude":    {"latitude", "lat"},
+	"longitude":   {"longitude", "lon", "lng", "long"},
+	"coordinates": {"coordinates", "coords", "position", "gps"},
+	"description": {"description", "desc", "notes"},
+	"address":     {"address", "adresse"},
+	"category":    {"category", "type"},
+	"color":       {"color", "colour"},
+	"icon":        {"icon", "symbol"},
+	"altitude":    {"altitude", "elevation", "ele"},
+	"accuracy":    {"accuracy"},
+	"tags":        {"tags", "tag", "labels"},
+}
+
+// CSVExportColumns are the columns of a CSV export, which it can be imported
+// back from.
+var CSVExportColumns = []string{"location_id", "name", "latitude", "longitude", "altitude", "accuracy", "category", "color", "icon", "address", "description", "tags", "user_id", "created_at", "updated_at"}
+
+type CSVOptions struct {
+	// Delimiter separates the columns, detected from the header when 0.
+	Delimiter rune
+	// Mapping maps fields to the header of their column, it overrides the
+	// headers recognised by default.
+	Mapping map[string]string
+}
+
+// ParseCSVDelimiter reads the delimiter parameter: a character or its name,
+// as a semicolon cannot be written unescaped in a URL, or empty to detect it.
+func ParseCSVDelimiter(value string) (rune, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case ",", "comma":
+		return ',', nil
+	case ";", "semicolon":
+		return ';', nil
+	case "|", "pipe":
+		return '|', nil
+	case "\t", `\t`, "tab":
+		return '\t', nil
+	}
+	return 0, errors.New("delimiter must be comma, semicolon, pipe or tab")
+}
+
+// CSVImport is a parsed CSV file: its locations and the raw header and rows
+// they were read from.
+type CSVImport struct {
+	Delimiter rune
+	Header    []string
+	Rows      [][]string
+	Locations []LocationImport
+}
+
+// ParseCSVLocations reads one location per row of a CSV file with a header.
+// Numbers may use a decimal comma and coordinates may be written in degrees,
+// minutes and seconds.
+func ParseCSVLocations(r io.Reader, options CSVOptions) (*CSVImport, error) {
+	input := bufio.NewReader(r)
+	if bom, _ := input.Peek(3); bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		input.Discard(3)
+	}
+	if options.Delimiter == 0 {
+		options.Delimiter = detectCSVDelimiter(input)
+	}
+
+	reader := csv.NewReader(input)
+	reader.Comma = options.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV must have a header row")
+	}
+	if err != nil {
+		return nil, errors.New("invalid CSV: " + err.Error())
+	}
+	columns, err := mapCSVColumns(header, options.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CSVImport{Delimiter: options.Delimiter, Header: header}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid CSV: " + err.Error())
+		}
+		if isBlankCSVRow(row) {
+			continue
+		}
+		if len(result.Rows) == MaxImportedFeatures {
+			return nil, fmt.Errorf("CSV must not contain more than %d rows", MaxImportedFeatures)
+		}
+		line, _ := reader.FieldPos(0)
+		location := csvLocation(row, columns)
+		location.Line = line
+		result.Rows = append(result.Rows, row)
+		result.Locations = append(result.Locations, location)
+	}
+	return result, nil
+}
+
+// detectCSVDelimiter picks the most frequent of , ; tab and | in the header.
+func detectCSVDelimiter(input *bufio.Reader) rune {
+	head, _ := input.Peek(4096)
+	if end := bytes.IndexByte(head, '\n'); end >= 0 {
+		head = head[:end]
+	}
+	delimiter, count := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		if n := bytes.Count(head, []byte(string(candidate))); n > count {
+			delimiter, count = candidate, n
+		}
+	}
+	return delimiter
+}
+
+// mapCSVColumns returns the index of the column of each mapped field.
+func mapCSVColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := indexes[key]; !ok {
+			indexes[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for field, aliases := range csvColumnAliases {
+		for _, alias := range aliases {
+			if i, ok := indexes[alias]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	for field, name := range mapping {
+		if _, ok := csvColumnAliases[field]; !ok {
+			return nil, fmt.Errorf("mapping: unknown field %s", field)
+		}
+		i, ok := indexes[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("mapping: column %s not found", name)
+		}
+		columns[field] = i
+	}
+
+	_, hasLatitude := columns["latitude"]
+	_, hasLongitude := columns["longitude"]
+	_, hasCoordinates := columns["coordinates"]
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("CSV must have a name column")
+	} else if !hasCoordinates && (!hasLatitude || !hasLongitude) {
+		return nil, errors.New("CSV must have latitude and longitude columns, or a coordinates column")
+	}
+	if hasLatitude && hasLongitude {
+		delete(columns, "coordinates")
+	}
+	return columns, nil
+}
+
+func isBlankCSVRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// csvLocation reads a row as a location.
+func csvLocation(row []string, columns map[string]int) LocationImport {
+	var location LocationImport
+	cell := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	number := func(field string) *float64 {
+		text := cell(field)
+		if text == "" {
+			return nil
+		}
+		value, err := coords.ParseNumber(text)
+		if err != nil {
+			location.Errors = append(location.Errors, field+" must be a number")
+		}
+		return &value
+	}
+
+	request := &location.Request
+	request.Name = cell("name")
+	request.Description = cell("description")
+	request.Address = cell("address")
+	request.Category = strings.ToLower(cell("category"))
+	request.Color = cell("color")
+	request.Icon = cell("icon")
+	request.Altitude = number("altitude")
+	request.Accuracy = number("accuracy")
+	for _, tag := range strings.FieldsFunc(cell("tags"), func(r rune) bool { return r == ',' || r == ';' || r == '|' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			location.Tags = append(location.Tags, tag)
+		}
+	}
+
+	var err error
+	if _, ok := columns["coordinates"]; ok {
+		if request.Latitude, request.Longitude, err = coords.Parse(cell("coordinates")); err != nil {
+			location.Errors = append(location.Errors, err.Error())
+		}
+	} else {
+		if request.Latitude, err = coords.ParseLatitude(cell("latitude")); err != nil {
+			location.Errors = append(location.Errors, err.Error())
+		}
+		if request.Longitude, err = coords.ParseLongitude(cell("longitude")); err != nil {
+			location.Errors = append(location.Errors, err.Error())
+		}
+	}
+
+	if len(location.Errors) == 0 {
+		location.Validate()
+	}
+	return location
+}
+
+// CSVLocationWriter writes locations as the CSVExportColumns rows of a CSV
+// file.
+type CSVLocationWriter struct {
+	writer       *csv.Writer
+	decimalComma bool
+}
+
+// NewCSVLocationWriter returns a writer separating the columns with
+// delimiter, and the decimals with a comma when decimalComma is set.
+func NewCSVLocationWriter(w io.Writer, delimiter rune, decimalComma bool) *CSVLocationWriter {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+	return &CSVLocationWriter{writer: writer, decimalComma: decimalComma}
+}
+
+func (writer *CSVLocationWriter) WriteHeader() error {
+	return writer.writer.Write(CSVExportColumns)
+}
+
+// Write writes a location, with empty coordinates, altitude, accuracy and
+// address when they are hidden from the caller.
+func (writer *CSVLocationWriter) Write(location LocationResponse) error {
+	latitude, longitude := writer.number(&location.Latitude), writer.number(&location.Longitude)
+	if location.CoordinatesHidden {
+		latitude, longitude = "", ""
+	}
+	return writer.writer.Write([]string{
+		strconv.FormatUint(uint64(location.ID), 10),
+		location.Name,
+		latitude,
+		longitude,
+		writer.number(location.Altitude),
+		writer.number(location.Accuracy),
+		location.Category,
+		location.Color,
+		location.Icon,
+		location.Address,
+		location.Description,
+		strings.Join(location.Tags, ", "),
+		strconv.FormatUint(uint64(location.UserID), 10),
+		location.CreatedAt.UTC().Format(time.RFC3339),
+		location.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// Flush writes the buffered rows.
+func (writer *CSVLocationWriter) Flush() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+func (writer *CSVLocationWriter) number(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	text := strconv.FormatFloat(*value, 'f', -1, 64)
+	if writer.decimalComma {
+		text = strings.Replace(text, ".", ",", 1)
+	}
+	return text
+}
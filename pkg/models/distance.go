@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type DistanceMatrixLocation struct {
+	ID        uint    `json:"id"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type TravelMatrixResponse struct {
+	Mode string `json:"mode"`
+	// route when computed by the router, estimate from the great-circle
+	// distance otherwise
+	Method string `json:"method"`
+	// Length of the routes in meters, only when routed
+	Distances [][]*float64 `json:"distances,omitempty"`
+	// Travel times in seconds, null where there is no route
+	Durations [][]*float64 `json:"durations"`
+}
+
+type DistanceMatrixResponse struct {
+	Sources      []DistanceMatrixLocation `json:"sources"`
+	Destinations []DistanceMatrixLocation `json:"destinations"`
+	// Great-circle distances in meters, a row per source
+	Distances [][]float64            `json:"distances"`
+	Travel    []TravelMatrixResponse `json:"travel"`
+}
+
+// ParseIDList reads comma separated IDs, nil when the text is empty.
+func ParseIDList(text string) ([]uint, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	var ids []uint
+	for _, part := range strings.Split(text, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err != nil || id < 1 {
+			return nil, errors.New("IDs must be comma separated positive integers")
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"io"
+	"locate-this/database/dbmodel"
+)
+
+// LocationEncoder writes exported locations one by one, so that large exports
+// are streamed instead of built in memory.
+type LocationEncoder interface {
+	// Begin writes the start of the document.
+	Begin() error
+	// Encode writes a location, visible tells whether its coordinates can be
+	// shown to the caller.
+	Encode(location *dbmodel.LocationEntry, visible bool) error
+	// End writes the end of the document.
+	End() error
+}
+
+// ExportFormats maps the supported export formats to their media type.
+var ExportFormats = map[string]string{
+	"geojson": "application/geo+json",
+	"gpx":     "application/gpx+xml",
+	"kml":     "application/vnd.google-earth.kml+xml",
+}
+
+// NewLocationEncoder returns the encoder of an export format writing to w.
+func NewLocationEncoder(format string, w io.Writer) (LocationEncoder, error) {
+	switch format {
+	case "geojson":
+		return &geoJSONEncoder{w: w}, nil
+	case "gpx":
+		return newGPXEncoder(w), nil
+	case "kml":
+		return newKMLEncoder(w), nil
+	}
+	return nil, errors.New("format must be geojson, gpx or kml")
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxFolderName = 100
+	// MaxFolderLocations is the number of locations added or moved at once at
+	// most.
+	MaxFolderLocations = 500
+)
+
+type FolderRequest struct {
+	Name string `json:"name" example:"Climbing spots"`
+	// Folder to create it in, at the root when missing, only read when the folder is created
+	ParentID *uint `json:"parent_id"`
+}
+
+func (req *FolderRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errors.New("name must not be null")
+	} else if utf8.RuneCountInString(req.Name) > maxFolderName {
+		return errors.New("name must not exceed 100 characters")
+	} else if req.ParentID != nil && *req.ParentID < 1 {
+		return errors.New("parent_id must be >= 1")
+	}
+	return nil
+}
+
+type FolderMoveRequest struct {
+	// New parent folder, the root when missing
+	ParentID *uint `json:"parent_id"`
+	// Position among the folders of the parent counted from 0, last when missing
+	Position *int `json:"position"`
+}
+
+func (req *FolderMoveRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if req.ParentID != nil && *req.ParentID < 1 {
+		return errors.New("parent_id must be >= 1")
+	} else if req.Position != nil && *req.Position < 0 {
+		return errors.New("position must be >= 0")
+	}
+	return nil
+}
+
+type FolderLocationsRequest struct {
+	LocationIDs []uint `json:"location_ids"`
+	// Folder the locations are moved from, only read when they are moved
+	FromFolderID uint `json:"from_folder_id"`
+	// Position of the first location counted from 0, after the last location when missing
+	Position *int `json:"position"`
+}
+
+func (req *FolderLocationsRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if len(req.LocationIDs) == 0 {
+		return errors.New("location_ids must not be empty")
+	} else if len(req.LocationIDs) > MaxFolderLocations {
+		return errors.New("location_ids must have at most 500 locations")
+	} else if req.Position != nil && *req.Position < 0 {
+		return errors.New("position must be >= 0")
+	}
+	for _, id := range req.LocationIDs {
+		if id == 0 {
+			return errors.New("location_ids must be positive")
+		}
+	}
+	return nil
+}
+
+type FolderOrderRequest struct {
+	// Every location of the folder, in their new order
+	LocationIDs []uint `json:"location_ids"`
+}
+
+func (req *FolderOrderRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if len(req.LocationIDs) == 0 {
+		return errors.New("location_ids must not be empty")
+	}
+	return nil
+}
+
+type FolderShareRequest struct {
+	GroupID              uint `json:"group_id"`
+	IsVisibleCoordinates bool `json:"is_visible_coordinates"`
+}
+
+func (req *FolderShareRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if req.GroupID < 1 {
+		return errors.New("group_id must be >= 1")
+	}
+	return nil
+}
+
+type FolderGroupResponse struct {
+	GroupID              uint `json:"group_id"`
+	IsVisibleCoordinates bool `json:"is_visible_coordinates"`
+}
+
+// FolderResponse is a folder with its subfolders. Its locations are only
+// listed when the folder itself is requested.
+type FolderResponse struct {
+	ID       uint   `json:"folder_id"`
+	ParentID *uint  `json:"parent_id"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+	// Number of locations in the folder, without those of its subfolders
+	LocationCount int `json:"location_count"`
+	// Groups the locations of the folder and of its subfolders are shared in
+	Groups    []FolderGroupResponse `json:"groups"`
+	Folders   []FolderResponse      `json:"folders"`
+	Locations []LocationResponse    `json:"locations,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// NewFolderTree nests the folders of a user under parentID, or returns the
+// root folders when it is nil.
+func NewFolderTree(folders []dbmodel.FolderEntry, counts map[uint]int, groups []dbmodel.FolderGroupEntry, parentID *uint) []FolderResponse {
+	children := make(map[uint][]*dbmodel.FolderEntry)
+	var roots []*dbmodel.FolderEntry
+	for i := range folders {
+		if folders[i].ParentID == nil {
+			roots = append(roots, &folders[i])
+		} else {
+			children[*folders[i].ParentID] = append(children[*folders[i].ParentID], &folders[i])
+		}
+	}
+	groupsByFolder := make(map[uint][]FolderGroupResponse)
+	for _, group := range groups {
+		groupsByFolder[group.FolderID] = append(groupsByFolder[group.FolderID], FolderGroupResponse{
+			GroupID:              group.GroupID,
+			IsVisibleCoordinates: group.IsVisibleCoordinates,
+		})
+	}
+
+	var build func(entries []*dbmodel.FolderEntry) []FolderResponse
+	build = func(entries []*dbmodel.FolderEntry) []FolderResponse {
+		responses := make([]FolderResponse, 0, len(entries))
+		for i, entry := range entries {
+			folderGroups := groupsByFolder[entry.ID]
+			if folderGroups == nil {
+				folderGroups = make([]FolderGroupResponse, 0)
+			}
+			responses = append(responses, FolderResponse{
+				ID:            entry.ID,
+				ParentID:      entry.ParentID,
+				Name:          entry.Name,
+				Position:      i,
+				LocationCount: counts[entry.ID],
+				Groups:        folderGroups,
+				Folders:       build(children[entry.ID]),
+				CreatedAt:     entry.CreatedAt,
+				UpdatedAt:     entry.UpdatedAt,
+			})
+		}
+		return responses
+	}
+	if parentID == nil {
+		return build(roots)
+	}
+	return build(children[*parentID])
+}
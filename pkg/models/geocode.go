@@ -0,0 +1,24 @@
+package models
+
+import "locate-this/pkg/geocoder"
+
+type GeocodeResultResponse struct {
+	Name        string  `json:"name"`
+	Address     string  `json:"address"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	CountryCode string  `json:"country_code,omitempty"`
+	// Kind of place, city for the offline dataset
+	Type string `json:"type,omitempty" example:"city"`
+}
+
+func NewGeocodeResultResponse(result geocoder.Result) GeocodeResultResponse {
+	return GeocodeResultResponse{
+		Name:        result.Name,
+		Address:     result.Address,
+		Latitude:    result.Latitude,
+		Longitude:   result.Longitude,
+		CountryCode: result.CountryCode,
+		Type:        result.Kind,
+	}
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultGeofenceHysteresis = 20
+	defaultGeofenceDebounce   = 30
+	maxGeofenceVertices       = 1000
+)
+
+type GeofenceRequest struct {
+	GroupID uint   `json:"group_id"`
+	Name    string `json:"name"`
+	// circle or polygon
+	Shape string `json:"shape" example:"circle"`
+	// Center of a circle following a location
+	LocationID *uint `json:"location_id"`
+	// Center of a circle that is not attached to a location
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	// Radius of a circle in meters
+	Radius float64 `json:"radius"`
+	// Vertices of a polygon as [latitude, longitude] pairs
+	Polygon [][2]float64 `json:"polygon"`
+	// Margin in meters around the boundary where a member keeps their previous state (20 by default)
+	Hysteresis *float64 `json:"hysteresis"`
+	// Seconds a member must stay on the other side before entering or exiting (30 by default)
+	DebounceSeconds *int `json:"debounce_seconds"`
+	// URL called with every enter and exit event
+	WebhookURL string `json:"webhook_url"`
+}
+
+func (a *GeofenceRequest) Bind(r *http.Request) error {
+	if a.GroupID < 1 {
+		return errors.New("group_id must be >= 1")
+	} else if a.Name == "" {
+		return errors.New("name must not be null")
+	}
+
+	switch a.Shape {
+	case dbmodel.GeofenceShapeCircle:
+		if (a.LocationID == nil) == (a.Latitude == nil || a.Longitude == nil) {
+			return errors.New("a circle needs either location_id or latitude and longitude")
+		} else if a.Latitude != nil && (*a.Latitude < -90 || *a.Latitude > 90) {
+			return errors.New("latitude must be between -90 and 90")
+		} else if a.Longitude != nil && (*a.Longitude < -180 || *a.Longitude > 180) {
+			return errors.New("longitude must be between -180 and 180")
+		} else if a.Radius <= 0 || a.Radius > 100000 {
+			return errors.New("radius must be between 0 and 100000 meters")
+		}
+		a.Polygon = nil
+	case dbmodel.GeofenceShapePolygon:
+		if len(a.Polygon) < 3 || len(a.Polygon) > maxGeofenceVertices {
+			return errors.New("polygon must have between 3 and 1000 vertices")
+		}
+		for _, vertex := range a.Polygon {
+			if vertex[0] < -90 || vertex[0] > 90 || vertex[1] < -180 || vertex[1] > 180 {
+				return errors.New("polygon vertices must be valid [latitude, longitude] pairs")
+			}
+		}
+		a.LocationID, a.Latitude, a.Longitude, a.Radius = nil, nil, nil, 0
+	default:
+		return errors.New("shape must be circle or polygon")
+	}
+
+	if a.Hysteresis == nil {
+		hysteresis := float64(defaultGeofenceHysteresis)
+		a.Hysteresis = &hysteresis
+	} else if *a.Hysteresis < 0 || *a.Hysteresis > 1000 {
+		return errors.New("hysteresis must be between 0 and 1000 meters")
+	}
+	if a.DebounceSeconds == nil {
+		debounce := defaultGeofenceDebounce
+		a.DebounceSeconds = &debounce
+	} else if *a.DebounceSeconds < 0 || *a.DebounceSeconds > 3600 {
+		return errors.New("debounce_seconds must be between 0 and 3600")
+	}
+
+	if a.WebhookURL != "" {
+		parsed, err := url.Parse(a.WebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.New("webhook_url must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+type GeofenceResponse struct {
+	ID              uint         `json:"geofence_id"`
+	GroupID         uint         `json:"group_id"`
+	UserID          uint         `json:"user_id"`
+	Name            string       `json:"name"`
+	Shape           string       `json:"shape"`
+	LocationID      *uint        `json:"location_id,omitempty"`
+	Latitude        *float64     `json:"latitude,omitempty"`
+	Longitude       *float64     `json:"longitude,omitempty"`
+	Radius          float64      `json:"radius,omitempty"`
+	Polygon         [][2]float64 `json:"polygon,omitempty"`
+	Hysteresis      float64      `json:"hysteresis"`
+	DebounceSeconds int          `json:"debounce_seconds"`
+	WebhookURL      string       `json:"webhook_url,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+func NewGeofenceResponse(entry *dbmodel.GeofenceEntry) GeofenceResponse {
+	return GeofenceResponse{
+		ID:              entry.ID,
+		GroupID:         entry.GroupID,
+		UserID:          entry.UserID,
+		Name:            entry.Name,
+		Shape:           entry.Shape,
+		LocationID:      entry.LocationID,
+		Latitude:        entry.Latitude,
+		Longitude:       entry.Longitude,
+		Radius:          entry.Radius,
+		Polygon:         entry.Polygon,
+		Hysteresis:      entry.Hysteresis,
+		DebounceSeconds: entry.DebounceSeconds,
+		WebhookURL:      entry.WebhookURL,
+		CreatedAt:       entry.CreatedAt,
+	}
+}
+
+// HideWebhook leaves out the webhook of the geofence, which only its creator
+// and the group admin may see.
+func (a *GeofenceResponse) HideWebhook() {
+	a.WebhookURL = ""
+}
+
+type GeofenceStateResponse struct {
+	UserID    uint      `json:"user_id"`
+	Inside    bool      `json:"inside"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// GeofenceEventPayload is the body sent to the webhook of a geofence.
+type GeofenceEventPayload struct {
+	Event        string    `json:"event"`
+	GeofenceID   uint      `json:"geofence_id"`
+	GeofenceName string    `json:"geofence_name"`
+	GroupID      uint      `json:"group_id"`
+	UserID       uint      `json:"user_id"`
+	Username     string    `json:"username"`
+	Latitude     float64   `json:"latitude"`
+	Longitude    float64   `json:"longitude"`
+	Time         time.Time `json:"time"`
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"locate-this/database/dbmodel"
+	"time"
+)
+
+type GeoJSONGeometry struct {
+	Type string `json:"type" example:"Point"`
+	// [longitude, latitude] or [longitude, latitude, altitude]
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type LocationFeatureProperties struct {
+	ID          uint      `json:"location_id"`
+	Name        string    `json:"name"`
+	UserID      uint      `json:"user_id"`
+	Description string    `json:"description,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Color       string    `json:"color,omitempty"`
+	Icon        string    `json:"icon,omitempty"`
+	Accuracy    *float64  `json:"accuracy,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type LocationFeature struct {
+	Type string `json:"type" example:"Feature"`
+	ID   uint   `json:"id"`
+	// null when the coordinates of the location are hidden from the caller
+	Geometry   *GeoJSONGeometry          `json:"geometry"`
+	Properties LocationFeatureProperties `json:"properties"`
+}
+
+type LocationFeatureCollection struct {
+	Type     string            `json:"type" example:"FeatureCollection"`
+	Features []LocationFeature `json:"features"`
+}
+
+// newLocationFeature converts a location to GeoJSON, leaving out its geometry
+// and its address when its coordinates are not visible.
+func newLocationFeature(location *dbmodel.LocationEntry, visible bool) LocationFeature {
+	feature := LocationFeature{
+		Type: "Feature",
+		ID:   location.ID,
+		Properties: LocationFeatureProperties{
+			ID:          location.ID,
+			Name:        location.Name,
+			UserID:      location.UserID,
+			Description: location.Description,
+			Category:    location.Category,
+			Color:       location.Color,
+			Icon:        location.Icon,
+			Tags:        tagNames(location.Tags),
+			CreatedAt:   location.CreatedAt,
+			UpdatedAt:   location.UpdatedAt,
+		},
+	}
+	if visible {
+		coordinates := []float64{location.Longitude, location.Latitude}
+		if location.Altitude != nil {
+			coordinates = append(coordinates, *location.Altitude)
+		}
+		feature.Geometry = &GeoJSONGeometry{Type: "Point", Coordinates: coordinates}
+		feature.Properties.Accuracy = location.Accuracy
+		feature.Properties.Address = location.Address
+	}
+	return feature
+}
+
+// geoJSONEncoder writes a FeatureCollection feature by feature.
+type geoJSONEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (encoder *geoJSONEncoder) Begin() error {
+	_, err := io.WriteString(encoder.w, `{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+func (encoder *geoJSONEncoder) Encode(location *dbmodel.LocationEntry, visible bool) error {
+	data, err := json.Marshal(newLocationFeature(location, visible))
+	if err != nil {
+		return err
+	}
+	if encoder.count > 0 {
+		data = append([]byte{','}, data...)
+	}
+	encoder.count++
+	_, err = encoder.w.Write(data)
+	return err
+}
+
+func (encoder *geoJSONEncoder) End() error {
+	_, err := io.WriteString(encoder.w, "]}\n")
+	return err
+}
+
+func tagNames(tags []*dbmodel.TagEntry) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+type geoJSONInput struct {
+	Type       string                     `json:"type"`
+	Features   []json.RawMessage          `json:"features"`
+	Geometry   *geoJSONInputGeometry      `json:"geometry"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+// geoJSONInputGeometry defers the decoding of the coordinates, whose shape
+// depends on the geometry type.
+type geoJSONInputGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ParseGeoJSONLocations reads the Point features of a FeatureCollection, or a
+// single Feature, as locations. The properties use the names of the export,
+// and the simplestyle names marker-color and marker-symbol are understood.
+func ParseGeoJSONLocations(data []byte) ([]LocationImport, error) {
+	var input geoJSONInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, errors.New("invalid GeoJSON: " + err.Error())
+	}
+
+	var features []json.RawMessage
+	switch input.Type {
+	case "FeatureCollection":
+		features = input.Features
+	case "Feature":
+		features = []json.RawMessage{data}
+	default:
+		return nil, errors.New("GeoJSON must be a FeatureCollection or a Feature")
+	}
+	if len(features) > MaxImportedFeatures {
+		return nil, fmt.Errorf("GeoJSON must not contain more than %d features", MaxImportedFeatures)
+	}
+
+	locations := make([]LocationImport, 0, len(features))
+	for _, raw := range features {
+		locations = append(locations, parseLocationFeature(raw))
+	}
+	return locations, nil
+}
+
+func parseLocationFeature(raw json.RawMessage) LocationImport {
+	var location LocationImport
+	var feature geoJSONInput
+	if err := json.Unmarshal(raw, &feature); err != nil || feature.Type != "Feature" {
+		location.Errors = append(location.Errors, "item must be a GeoJSON Feature")
+		return location
+	}
+
+	properties := feature.Properties
+	request := &location.Request
+	readProperty(&location, properties, &request.Name, "name", "title")
+	readProperty(&location, properties, &request.Description, "description", "desc")
+	readProperty(&location, properties, &request.Address, "address")
+	readProperty(&location, properties, &request.Category, "category")
+	readProperty(&location, properties, &request.Color, "color", "marker-color")
+	readProperty(&location, properties, &request.Icon, "icon", "marker-symbol")
+	readProperty(&location, properties, &request.Accuracy, "accuracy")
+	readProperty(&location, properties, &request.Altitude, "altitude", "ele")
+	readProperty(&location, properties, &location.Tags, "tags")
+
+	var coordinates []float64
+	if feature.Geometry == nil || feature.Geometry.Type != "Point" {
+		location.Errors = append(location.Errors, "geometry must be a Point")
+	} else if err := json.Unmarshal(feature.Geometry.Coordinates, &coordinates); err != nil || len(coordinates) < 2 || len(coordinates) > 3 {
+		location.Errors = append(location.Errors, "coordinates must be [longitude, latitude] or [longitude, latitude, altitude]")
+	} else {
+		request.Longitude = coordinates[0]
+		request.Latitude = coordinates[1]
+		if len(coordinates) == 3 {
+			request.Altitude = &coordinates[2]
+		}
+	}
+
+	if len(location.Errors) == 0 {
+		location.Validate()
+	}
+	return location
+}
+
+// readProperty decodes the first of the names present in the properties,
+// recording an error when it has the wrong type.
+func readProperty(location *LocationImport, properties map[string]json.RawMessage, target any, names ...string) {
+	for _, name := range names {
+		raw, ok := properties[name]
+		if !ok || string(raw) == "null" {
+			continue
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			location.Errors = append(location.Errors, "property "+name+" has an invalid type")
+		}
+		return
+	}
+}
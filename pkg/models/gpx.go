@@ -0,0 +1,154 @@
+package models
+
+import (
+	"encoding/xml"
+	"io"
+	"locate-this/database/dbmodel"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gpxWaypoint is a GPX 1.1 wpt element. The numbers are kept as text so that
+// a malformed waypoint is reported instead of failing the whole file.
+type gpxWaypoint struct {
+	XMLName     xml.Name `xml:"wpt"`
+	Latitude    string   `xml:"lat,attr"`
+	Longitude   string   `xml:"lon,attr"`
+	Elevation   string   `xml:"ele,omitempty"`
+	Time        string   `xml:"time,omitempty"`
+	Name        string   `xml:"name,omitempty"`
+	Comment     string   `xml:"cmt,omitempty"`
+	Description string   `xml:"desc,omitempty"`
+	Symbol      string   `xml:"sym,omitempty"`
+	Type        string   `xml:"type,omitempty"`
+}
+
+// location reads a waypoint as a location: sym is the icon and type the
+// category when it is one of LocationCategories.
+func (waypoint *gpxWaypoint) location() LocationImport {
+	var location LocationImport
+	request := &location.Request
+	request.Name = strings.TrimSpace(waypoint.Name)
+	request.Description = strings.TrimSpace(waypoint.Description)
+	if request.Description == "" {
+		request.Description = strings.TrimSpace(waypoint.Comment)
+	}
+	request.Icon = strings.TrimSpace(waypoint.Symbol)
+	if category := strings.ToLower(strings.TrimSpace(waypoint.Type)); isLocationCategory(category) {
+		request.Category = category
+	}
+
+	var err error
+	if request.Latitude, err = parseXMLNumber(waypoint.Latitude); err != nil {
+		location.Errors = append(location.Errors, "lat must be a number")
+	}
+	if request.Longitude, err = parseXMLNumber(waypoint.Longitude); err != nil {
+		location.Errors = append(location.Errors, "lon must be a number")
+	}
+	if waypoint.Elevation != "" {
+		altitude, err := parseXMLNumber(waypoint.Elevation)
+		if err != nil {
+			location.Errors = append(location.Errors, "ele must be a number")
+		}
+		request.Altitude = &altitude
+	}
+
+	if len(location.Errors) == 0 {
+		location.Validate()
+	}
+	return location
+}
+
+func parseXMLNumber(text string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(text), 64)
+}
+
+// gpxEncoder writes the locations as the waypoints of a GPX 1.1 document.
+// Locations whose coordinates are hidden are left out.
+type gpxEncoder struct {
+	w       io.Writer
+	encoder *xml.Encoder
+}
+
+func newGPXEncoder(w io.Writer) *gpxEncoder {
+	return &gpxEncoder{w: w, encoder: xml.NewEncoder(w)}
+}
+
+func (encoder *gpxEncoder) Begin() error {
+	_, err := io.WriteString(encoder.w, xml.Header+
+		`<gpx version="1.1" creator="LocateThis" xmlns="http://www.topografix.com/GPX/1/1">`+"\n")
+	return err
+}
+
+func (encoder *gpxEncoder) Encode(location *dbmodel.LocationEntry, visible bool) error {
+	if !visible {
+		return nil
+	}
+	waypoint := gpxWaypoint{
+		Latitude:    strconv.FormatFloat(location.Latitude, 'f', -1, 64),
+		Longitude:   strconv.FormatFloat(location.Longitude, 'f', -1, 64),
+		Time:        location.CreatedAt.UTC().Format(time.RFC3339),
+		Name:        location.Name,
+		Description: location.Description,
+		Symbol:      location.Icon,
+		Type:        location.Category,
+	}
+	if location.Altitude != nil {
+		waypoint.Elevation = strconv.FormatFloat(*location.Altitude, 'f', -1, 64)
+	}
+	if err := encoder.encoder.Encode(waypoint); err != nil {
+		return err
+	}
+	_, err := io.WriteString(encoder.w, "\n")
+	return err
+}
+
+func (encoder *gpxEncoder) End() error {
+	_, err := io.WriteString(encoder.w, "</gpx>\n")
+	return err
+}
+
+// gpxRoute is a GPX 1.1 rte element, the ordered points of a trip.
+type gpxRoute struct {
+	XMLName     xml.Name        `xml:"rte"`
+	Name        string          `xml:"name,omitempty"`
+	Description string          `xml:"desc,omitempty"`
+	Points      []gpxRoutePoint `xml:"rtept"`
+}
+
+type gpxRoutePoint struct {
+	Latitude    string `xml:"lat,attr"`
+	Longitude   string `xml:"lon,attr"`
+	Name        string `xml:"name,omitempty"`
+	Description string `xml:"desc,omitempty"`
+}
+
+// WriteTripGPX writes a trip as the route of a GPX 1.1 document. The stops
+// whose coordinates are hidden are left out.
+func WriteTripGPX(w io.Writer, trip *dbmodel.TripEntry, stops []dbmodel.TripStop) error {
+	route := gpxRoute{Name: trip.Name, Description: trip.Description}
+	for _, stop := range stops {
+		if !stop.CoordinatesVisible {
+			continue
+		}
+		route.Points = append(route.Points, gpxRoutePoint{
+			Latitude:    strconv.FormatFloat(stop.Latitude, 'f', -1, 64),
+			Longitude:   strconv.FormatFloat(stop.Longitude, 'f', -1, 64),
+			Name:        stop.Name,
+			Description: stop.Note,
+		})
+	}
+
+	encoder := newGPXEncoder(w)
+	if err := encoder.Begin(); err != nil {
+		return err
+	}
+	if err := encoder.encoder.Encode(route); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return err
+	}
+	return encoder.End()
+}
@@ -19,6 +19,7 @@ func (a *GroupRequest) Bind(r *http.Request) error {
 type GroupResponse struct {
 	ID        uint               `json:"group_id"`
 	Name      string             `json:"name"`
+	AdminID   uint               `json:"admin_id"`
 	Users     []UserResponse     `json:"users"`
 	Locations []LocationResponse `json:"locations"`
 }
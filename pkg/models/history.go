@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/geo"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultHistoryPoints = 1000
+	maxHistoryPoints     = 10000
+	// MaxHistoryRetentionDays bounds the retention a user can choose.
+	MaxHistoryRetentionDays = 3650
+)
+
+type HistoryQuery struct {
+	From      *time.Time
+	To        *time.Time
+	Tolerance float64
+	MaxPoints int
+}
+
+// ParseHistoryQuery reads the period and downsampling parameters of a history
+// endpoint.
+func ParseHistoryQuery(r *http.Request) (HistoryQuery, error) {
+	query := r.URL.Query()
+	history := HistoryQuery{MaxPoints: defaultHistoryPoints}
+
+	if from := query.Get("from"); from != "" {
+		value, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return history, errors.New("from must be an RFC 3339 date")
+		}
+		history.From = &value
+	}
+	if to := query.Get("to"); to != "" {
+		value, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return history, errors.New("to must be an RFC 3339 date")
+		}
+		history.To = &value
+	}
+	if history.From != nil && history.To != nil && history.To.Before(*history.From) {
+		return history, errors.New("to must be after from")
+	}
+
+	if tolerance := query.Get("tolerance"); tolerance != "" {
+		value, err := strconv.ParseFloat(tolerance, 64)
+		if err != nil || value < 0 {
+			return history, errors.New("tolerance must be a positive number of meters")
+		}
+		history.Tolerance = value
+	}
+	if maxPoints := query.Get("max_points"); maxPoints != "" {
+		value, err := strconv.Atoi(maxPoints)
+		if err != nil || value < 2 || value > maxHistoryPoints {
+			return history, errors.New("max_points must be between 2 and 10000")
+		}
+		history.MaxPoints = value
+	}
+	return history, nil
+}
+
+type HistoryPointResponse struct {
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Accuracy   *float64  `json:"accuracy,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type HistoryResponse struct {
+	// Distance travelled over the whole period, in meters
+	Distance float64 `json:"distance"`
+	// Number of positions recorded over the period, before downsampling
+	PointCount int                    `json:"point_count"`
+	Points     []HistoryPointResponse `json:"points"`
+}
+
+// NewHistoryResponse measures the track and downsamples it with the
+// Douglas–Peucker algorithm, raising the tolerance until it fits MaxPoints.
+func NewHistoryResponse(entries []dbmodel.LocationHistoryEntry, query HistoryQuery) HistoryResponse {
+	points := make([]geo.Point, len(entries))
+	for i, entry := range entries {
+		points[i] = geo.Point{Latitude: entry.Latitude, Longitude: entry.Longitude}
+	}
+
+	tolerance := query.Tolerance
+	kept := geo.Simplify(points, tolerance)
+	for len(kept) > query.MaxPoints {
+		tolerance = max(tolerance*2, 1)
+		kept = geo.Simplify(points, tolerance)
+	}
+
+	response := HistoryResponse{
+		Distance:   geo.PathLength(points),
+		PointCount: len(entries),
+		Points:     make([]HistoryPointResponse, 0, len(kept)),
+	}
+	for _, i := range kept {
+		response.Points = append(response.Points, HistoryPointResponse{
+			Latitude:   entries[i].Latitude,
+			Longitude:  entries[i].Longitude,
+			Accuracy:   entries[i].Accuracy,
+			RecordedAt: entries[i].RecordedAt,
+		})
+	}
+	return response
+}
+
+type HistoryRetentionRequest struct {
+	// Days the history is kept, 0 to stop recording it and null for the server default
+	Days *int `json:"days"`
+}
+
+func (a *HistoryRetentionRequest) Bind(r *http.Request) error {
+	if a.Days != nil && (*a.Days < 0 || *a.Days > MaxHistoryRetentionDays) {
+		return errors.New("days must be between 0 and 3650")
+	}
+	return nil
+}
+
+type HistoryRetentionResponse struct {
+	Days      int  `json:"days"`
+	IsDefault bool `json:"is_default"`
+}
@@ -0,0 +1,66 @@
+package models
+
+// MaxImportedFeatures bounds the number of locations of an imported file.
+const MaxImportedFeatures = 5000
+
+// DefaultDedupeRadius is the distance in meters under which an imported
+// location is a duplicate of an existing one with the same name.
+const DefaultDedupeRadius = 25.0
+
+const (
+	ImportStatusValid     = "valid"
+	ImportStatusInvalid   = "invalid"
+	ImportStatusDuplicate = "duplicate"
+	ImportStatusImported  = "imported"
+	ImportStatusFailed    = "failed"
+)
+
+// LocationImport is a location read from an imported file, with the errors
+// found while reading and validating it.
+type LocationImport struct {
+	Request LocationRequest
+	Tags    []string
+	Errors  []string
+	// Line of the file the location starts at, when the format has lines
+	Line int
+}
+
+// Validate runs the validation of the location and of its tags.
+func (a *LocationImport) Validate() {
+	if err := a.Request.Bind(nil); err != nil {
+		a.Errors = append(a.Errors, err.Error())
+	}
+	if len(a.Tags) > 0 {
+		tags := &TagRequest{Tags: a.Tags}
+		if err := tags.Bind(nil); err != nil {
+			a.Errors = append(a.Errors, err.Error())
+		}
+		a.Tags = tags.Tags
+	}
+}
+
+type ImportItemReport struct {
+	// Position of the item in the file, starting at 0
+	Index int `json:"index"`
+	// Line of the file the item starts at, for CSV files
+	Line   int    `json:"line,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status" example:"imported"`
+	// Created location, or existing location for a duplicate
+	LocationID uint `json:"location_id,omitempty"`
+	// Position of the earlier item of the file it duplicates
+	DuplicateOf *int     `json:"duplicate_of,omitempty"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun bool `json:"dry_run"`
+	// skip or atomic
+	Mode       string             `json:"mode" example:"skip"`
+	Total      int                `json:"total"`
+	Valid      int                `json:"valid"`
+	Invalid    int                `json:"invalid"`
+	Duplicates int                `json:"duplicates"`
+	Imported   int                `json:"imported"`
+	Items      []ImportItemReport `json:"items"`
+}
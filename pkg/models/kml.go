@@ -0,0 +1,217 @@
+package models
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"locate-this/database/dbmodel"
+	"strconv"
+	"strings"
+)
+
+// kmlPlacemark is a KML 2.2 Placemark element. The fields of a location that
+// KML does not define are stored in its ExtendedData.
+type kmlPlacemark struct {
+	XMLName      xml.Name         `xml:"Placemark"`
+	ID           string           `xml:"id,attr,omitempty"`
+	Name         string           `xml:"name,omitempty"`
+	Address      string           `xml:"address,omitempty"`
+	Description  string           `xml:"description,omitempty"`
+	ExtendedData *kmlExtendedData `xml:"ExtendedData,omitempty"`
+	Point        *kmlPoint        `xml:"Point,omitempty"`
+}
+
+type kmlExtendedData struct {
+	Data []kmlData `xml:"Data"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	// longitude,latitude[,altitude]
+	Coordinates string `xml:"coordinates"`
+}
+
+// location reads a placemark as a location.
+func (placemark *kmlPlacemark) location() LocationImport {
+	var location LocationImport
+	request := &location.Request
+	request.Name = strings.TrimSpace(placemark.Name)
+	request.Description = strings.TrimSpace(placemark.Description)
+	request.Address = strings.TrimSpace(placemark.Address)
+	if placemark.ExtendedData != nil {
+		for _, data := range placemark.ExtendedData.Data {
+			value := strings.TrimSpace(data.Value)
+			switch data.Name {
+			case "category":
+				request.Category = value
+			case "color":
+				request.Color = value
+			case "icon":
+				request.Icon = value
+			case "accuracy":
+				accuracy, err := parseXMLNumber(value)
+				if err != nil {
+					location.Errors = append(location.Errors, "accuracy must be a number")
+				}
+				request.Accuracy = &accuracy
+			case "tags":
+				for _, tag := range strings.Split(value, ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						location.Tags = append(location.Tags, tag)
+					}
+				}
+			}
+		}
+	}
+
+	if placemark.Point == nil {
+		location.Errors = append(location.Errors, "geometry must be a Point")
+	} else if coordinates, err := parseKMLCoordinates(placemark.Point.Coordinates); err != nil {
+		location.Errors = append(location.Errors, err.Error())
+	} else {
+		request.Longitude = coordinates[0]
+		request.Latitude = coordinates[1]
+		if len(coordinates) == 3 {
+			request.Altitude = &coordinates[2]
+		}
+	}
+
+	if len(location.Errors) == 0 {
+		location.Validate()
+	}
+	return location
+}
+
+func parseKMLCoordinates(text string) ([]float64, error) {
+	invalid := errors.New("coordinates must be longitude,latitude or longitude,latitude,altitude")
+	fields := strings.Split(strings.TrimSpace(text), ",")
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, invalid
+	}
+	coordinates := make([]float64, 0, len(fields))
+	for _, field := range fields {
+		value, err := parseXMLNumber(field)
+		if err != nil {
+			return nil, invalid
+		}
+		coordinates = append(coordinates, value)
+	}
+	return coordinates, nil
+}
+
+// kmlEncoder writes the locations as the placemarks of a KML 2.2 document.
+// Locations whose coordinates are hidden are left out.
+type kmlEncoder struct {
+	w       io.Writer
+	encoder *xml.Encoder
+}
+
+func newKMLEncoder(w io.Writer) *kmlEncoder {
+	return &kmlEncoder{w: w, encoder: xml.NewEncoder(w)}
+}
+
+func (encoder *kmlEncoder) Begin() error {
+	_, err := io.WriteString(encoder.w, xml.Header+
+		`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>LocateThis</name>`+"\n")
+	return err
+}
+
+func (encoder *kmlEncoder) Encode(location *dbmodel.LocationEntry, visible bool) error {
+	if !visible {
+		return nil
+	}
+	coordinates := strconv.FormatFloat(location.Longitude, 'f', -1, 64) + "," + strconv.FormatFloat(location.Latitude, 'f', -1, 64)
+	if location.Altitude != nil {
+		coordinates += "," + strconv.FormatFloat(*location.Altitude, 'f', -1, 64)
+	}
+	placemark := kmlPlacemark{
+		ID:          "location-" + strconv.FormatUint(uint64(location.ID), 10),
+		Name:        location.Name,
+		Address:     location.Address,
+		Description: location.Description,
+		Point:       &kmlPoint{Coordinates: coordinates},
+	}
+	var data []kmlData
+	for _, field := range []kmlData{
+		{Name: "category", Value: location.Category},
+		{Name: "color", Value: location.Color},
+		{Name: "icon", Value: location.Icon},
+		{Name: "tags", Value: strings.Join(tagNames(location.Tags), ",")},
+	} {
+		if field.Value != "" {
+			data = append(data, field)
+		}
+	}
+	if location.Accuracy != nil {
+		data = append(data, kmlData{Name: "accuracy", Value: strconv.FormatFloat(*location.Accuracy, 'f', -1, 64)})
+	}
+	if len(data) > 0 {
+		placemark.ExtendedData = &kmlExtendedData{Data: data}
+	}
+	if err := encoder.encoder.Encode(placemark); err != nil {
+		return err
+	}
+	_, err := io.WriteString(encoder.w, "\n")
+	return err
+}
+
+func (encoder *kmlEncoder) End() error {
+	_, err := io.WriteString(encoder.w, "</Document></kml>\n")
+	return err
+}
+
+// ParseXMLLocations reads the waypoints of a GPX document or the Point
+// placemarks of a KML document, at any depth of its folders, as locations.
+// The document is decoded while it is read.
+func ParseXMLLocations(r io.Reader) ([]LocationImport, error) {
+	decoder := xml.NewDecoder(r)
+	root := ""
+	var locations []LocationImport
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid XML: " + err.Error())
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root == "" {
+			root = start.Name.Local
+			if root != "gpx" && root != "kml" {
+				return nil, errors.New("XML must be a GPX or KML document")
+			}
+			continue
+		}
+
+		switch {
+		case root == "gpx" && start.Name.Local == "wpt":
+			var waypoint gpxWaypoint
+			if err := decoder.DecodeElement(&waypoint, &start); err != nil {
+				return nil, errors.New("invalid GPX: " + err.Error())
+			}
+			locations = append(locations, waypoint.location())
+		case root == "kml" && start.Name.Local == "Placemark":
+			var placemark kmlPlacemark
+			if err := decoder.DecodeElement(&placemark, &start); err != nil {
+				return nil, errors.New("invalid KML: " + err.Error())
+			}
+			locations = append(locations, placemark.location())
+		}
+		if len(locations) > MaxImportedFeatures {
+			return nil, fmt.Errorf("file must not contain more than %d locations", MaxImportedFeatures)
+		}
+	}
+	if root == "" {
+		return nil, errors.New("XML must be a GPX or KML document")
+	}
+	return locations, nil
+}
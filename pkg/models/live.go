@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"net/http"
+	"time"
+)
+
+// LiveShareMaxDuration is the longest time-boxed live share, in minutes.
+const LiveShareMaxDuration = 7 * 24 * 60
+
+type LiveShareRequest struct {
+	GroupID uint `json:"group_id"`
+	// Duration of the share in minutes, the share lasts until it is stopped when omitted
+	DurationMinutes int `json:"duration_minutes" example:"60"`
+}
+
+func (a *LiveShareRequest) Bind(r *http.Request) error {
+	if a.GroupID < 1 {
+		return errors.New("group_id must be >= 1")
+	} else if a.DurationMinutes < 0 || a.DurationMinutes > LiveShareMaxDuration {
+		return errors.New("duration_minutes must be between 0 and 10080")
+	}
+	return nil
+}
+
+type LivePositionRequest struct {
+	GroupID   uint     `json:"group_id"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Accuracy  *float64 `json:"accuracy"`
+	Heading   *float64 `json:"heading"`
+	Speed     *float64 `json:"speed"`
+}
+
+func (a *LivePositionRequest) Bind(r *http.Request) error {
+	if a.GroupID < 1 {
+		return errors.New("group_id must be >= 1")
+	} else if a.Latitude < -90 || a.Latitude > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	} else if a.Longitude < -180 || a.Longitude > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	} else if a.Accuracy != nil && *a.Accuracy < 0 {
+		return errors.New("accuracy must be positive")
+	} else if a.Heading != nil && (*a.Heading < 0 || *a.Heading >= 360) {
+		return errors.New("heading must be between 0 and 360 degrees")
+	} else if a.Speed != nil && *a.Speed < 0 {
+		return errors.New("speed must be positive")
+	}
+	return nil
+}
+
+type LiveShareResponse struct {
+	ID         uint       `json:"live_share_id"`
+	UserID     uint       `json:"user_id"`
+	Username   string     `json:"username"`
+	GroupID    uint       `json:"group_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Latitude   *float64   `json:"latitude,omitempty"`
+	Longitude  *float64   `json:"longitude,omitempty"`
+	Accuracy   *float64   `json:"accuracy,omitempty"`
+	Heading    *float64   `json:"heading,omitempty"`
+	Speed      *float64   `json:"speed,omitempty"`
+	RecordedAt *time.Time `json:"recorded_at,omitempty"`
+}
+
+func NewLiveShareResponse(entry *dbmodel.LiveShareEntry) LiveShareResponse {
+	return LiveShareResponse{
+		ID:         entry.ID,
+		UserID:     entry.UserID,
+		Username:   entry.User.Username,
+		GroupID:    entry.GroupID,
+		CreatedAt:  entry.CreatedAt,
+		ExpiresAt:  entry.ExpiresAt,
+		Latitude:   entry.Latitude,
+		Longitude:  entry.Longitude,
+		Accuracy:   entry.Accuracy,
+		Heading:    entry.Heading,
+		Speed:      entry.Speed,
+		RecordedAt: entry.RecordedAt,
+	}
+}
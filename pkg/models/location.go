@@ -2,27 +2,162 @@ package models
 
 import (
 	"errors"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/coords"
 	"net/http"
+	"regexp"
+	"time"
+	"unicode/utf8"
 )
 
+// LocationCategories is the taxonomy accepted for LocationRequest.Category,
+// it can be replaced with the LOCATION_CATEGORIES environment variable.
+var LocationCategories = []string{"home", "work", "restaurant", "shop", "meeting point", "parking", "other"}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
 type LocationRequest struct {
-	Name      string  `json:"name"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	UserID    uint    `json:"user_id"`
+	Name        string   `json:"name"`
+	Latitude    float64  `json:"latitude"`
+	Longitude   float64  `json:"longitude"`
+	UserID      uint     `json:"user_id"`
+	Description string   `json:"description"`
+	Address     string   `json:"address"`
+	Category    string   `json:"category"`
+	Color       string   `json:"color" example:"#ff8800"`
+	Icon        string   `json:"icon"`
+	Altitude    *float64 `json:"altitude"`
+	Accuracy    *float64 `json:"accuracy"`
+	// Coordinates replace latitude and longitude when set, in decimal
+	// degrees, degrees minutes seconds, UTM, MGRS, geohash or Plus Code
+	Coordinates string `json:"coordinates" example:"31U DQ 48251 11932"`
 }
 
 func (a *LocationRequest) Bind(r *http.Request) error {
+	if a.Coordinates != "" {
+		latitude, longitude, err := coords.Parse(a.Coordinates)
+		if err != nil {
+			return err
+		}
+		a.Latitude, a.Longitude = latitude, longitude
+	}
 	if a.Name == "" {
 		return errors.New("name must not be null")
+	} else if a.Latitude < -90 || a.Latitude > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	} else if a.Longitude < -180 || a.Longitude > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	} else if utf8.RuneCountInString(a.Description) > 2000 {
+		return errors.New("description must not exceed 2000 characters")
+	} else if utf8.RuneCountInString(a.Address) > 500 {
+		return errors.New("address must not exceed 500 characters")
+	} else if a.Category != "" && !isLocationCategory(a.Category) {
+		return errors.New("category is not supported")
+	} else if a.Color != "" && !colorPattern.MatchString(a.Color) {
+		return errors.New("color must be an hexadecimal color like #ff8800")
+	} else if utf8.RuneCountInString(a.Icon) > 64 {
+		return errors.New("icon must not exceed 64 characters")
+	} else if a.Altitude != nil && (*a.Altitude < -11000 || *a.Altitude > 9000) {
+		return errors.New("altitude must be between -11000 and 9000 meters")
+	} else if a.Accuracy != nil && *a.Accuracy < 0 {
+		return errors.New("accuracy must be positive")
 	}
 	return nil
 }
 
+// NeedsGeocoding reports whether the location was given by its address only,
+// the coordinates being left out (0, 0).
+func (a *LocationRequest) NeedsGeocoding() bool {
+	return a.Latitude == 0 && a.Longitude == 0 && a.Address != ""
+}
+
+func isLocationCategory(category string) bool {
+	for _, c := range LocationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
 type LocationResponse struct {
-	ID        uint    `json:"location_id"`
-	Name      string  `json:"name"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	UserID    uint    `json:"user_id"`
+	ID          uint      `json:"location_id"`
+	Name        string    `json:"name"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	UserID      uint      `json:"user_id"`
+	Description string    `json:"description,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Color       string    `json:"color,omitempty"`
+	Icon        string    `json:"icon,omitempty"`
+	Altitude    *float64  `json:"altitude,omitempty"`
+	Accuracy    *float64  `json:"accuracy,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Coordinates in the formats asked with the formats parameter
+	Formats map[string]string `json:"formats,omitempty" example:"utm:31U 448251 5411932"`
+	// The coordinates, altitude, accuracy and address are left out
+	CoordinatesHidden bool `json:"coordinates_hidden,omitempty"`
+}
+
+func NewLocationResponse(entry *dbmodel.LocationEntry) LocationResponse {
+	return LocationResponse{
+		ID:          entry.ID,
+		Name:        entry.Name,
+		Latitude:    entry.Latitude,
+		Longitude:   entry.Longitude,
+		UserID:      entry.UserID,
+		Description: entry.Description,
+		Address:     entry.Address,
+		Category:    entry.Category,
+		Color:       entry.Color,
+		Icon:        entry.Icon,
+		Altitude:    entry.Altitude,
+		Accuracy:    entry.Accuracy,
+		CreatedAt:   entry.CreatedAt,
+		UpdatedAt:   entry.UpdatedAt,
+	}
+}
+
+// ParseCoordinateFormats reads the formats parameter, the comma separated
+// formats to add to the coordinates of the returned locations.
+func ParseCoordinateFormats(r *http.Request) ([]coords.Format, error) {
+	return coords.ParseFormats(r.URL.Query().Get("formats"))
+}
+
+// HideCoordinates leaves out the coordinates of a location hidden from the
+// caller, and its altitude, accuracy and address which would reveal them.
+func (a *LocationResponse) HideCoordinates() {
+	a.Latitude, a.Longitude = 0, 0
+	a.Altitude, a.Accuracy = nil, nil
+	a.Address = ""
+	a.CoordinatesHidden = true
+}
+
+// AddCoordinateFormats writes the coordinates of the locations in each of the
+// formats, leaving out those a position cannot be written in.
+func AddCoordinateFormats(locations []LocationResponse, formats []coords.Format) {
+	if len(formats) == 0 {
+		return
+	}
+	for i := range locations {
+		if locations[i].CoordinatesHidden {
+			continue
+		}
+		locations[i].Formats = make(map[string]string, len(formats))
+		for _, format := range formats {
+			if text, ok := coords.Encode(format, locations[i].Latitude, locations[i].Longitude); ok {
+				locations[i].Formats[string(format)] = text
+			}
+		}
+	}
+}
+
+// NearbyLocationResponse is a location with its distance in meters to the
+// searched point.
+type NearbyLocationResponse struct {
+	LocationResponse
+	Distance float64 `json:"distance" example:"120.5"`
 }
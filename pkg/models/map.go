@@ -0,0 +1,71 @@
+package models
+
+import (
+	"errors"
+	"locate-this/pkg/geo"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type MapClusterResponse struct {
+	// Centroid of the clustered locations
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Count     int     `json:"count"`
+	// IDs of the first clustered locations
+	SampleIDs []uint `json:"sample_ids"`
+}
+
+type MapResponse struct {
+	Zoom int `json:"zoom"`
+	// Number of locations in the box
+	Total     int                  `json:"total"`
+	Clusters  []MapClusterResponse `json:"clusters"`
+	Locations []LocationResponse   `json:"locations"`
+	// Set when there were too many locations to return them all
+	Truncated bool `json:"truncated"`
+}
+
+// ParseBoundingBox reads a box written minLng,minLat,maxLng,maxLat, as in
+// GeoJSON. A box crossing the antimeridian is written either with minLng
+// greater than maxLng (170,-20,-170,0) or with longitudes beyond 180
+// (170,-20,190,0), as map libraries give it.
+func ParseBoundingBox(text string) (geo.Bounds, error) {
+	parts := strings.Split(text, ",")
+	if len(parts) != 4 {
+		return geo.Bounds{}, errors.New("bbox must be minLng,minLat,maxLng,maxLat")
+	}
+	var values [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return geo.Bounds{}, errors.New("bbox must be minLng,minLat,maxLng,maxLat")
+		}
+		values[i] = value
+	}
+	west, south, east, north := values[0], values[1], values[2], values[3]
+	if south < -90 || north > 90 {
+		return geo.Bounds{}, errors.New("bbox latitudes must be between -90 and 90")
+	}
+	if south > north {
+		return geo.Bounds{}, errors.New("bbox minLat must not be greater than maxLat")
+	}
+	if west < east && east-west >= 360 {
+		return geo.Bounds{South: south, West: -180, North: north, East: 180}, nil
+	}
+	return geo.Bounds{South: south, West: wrapLongitude(west), North: north, East: wrapLongitude(east)}, nil
+}
+
+// wrapLongitude brings a longitude back between -180 and 180, leaving those
+// already between them, 180 included, as they are.
+func wrapLongitude(longitude float64) float64 {
+	if longitude >= -180 && longitude <= 180 {
+		return longitude
+	}
+	longitude = math.Mod(longitude+180, 360)
+	if longitude < 0 {
+		longitude += 360
+	}
+	return longitude - 180
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+)
+
+// MaxMeetingLocations is the number of locations a meeting point is
+// computed from at most.
+const MaxMeetingLocations = 100
+
+type MeetingPointRequest struct {
+	// Locations shared in the group whose coordinates are visible to the caller
+	LocationIDs []uint `json:"location_ids"`
+}
+
+func (req *MeetingPointRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if len(req.LocationIDs) < 2 {
+		return errors.New("location_ids must have at least 2 locations")
+	} else if len(req.LocationIDs) > MaxMeetingLocations {
+		return errors.New("location_ids must have at most 100 locations")
+	}
+	seen := make(map[uint]bool, len(req.LocationIDs))
+	for _, id := range req.LocationIDs {
+		if id == 0 {
+			return errors.New("location_ids must be positive")
+		}
+		if seen[id] {
+			return errors.New("location_ids must not repeat a location")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+type PointResponse struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// MeetingLocationResponse is a selected location.
+type MeetingLocationResponse struct {
+	ID        uint    `json:"id"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Distance in meters to the median
+	Distance float64 `json:"distance"`
+}
+
+type MeetingPointResponse struct {
+	// Center of mass of the locations on the globe
+	Midpoint PointResponse `json:"midpoint"`
+	// Point minimizing the total distance to the locations
+	Median PointResponse `json:"median"`
+	// Sum of the distances in meters from the locations to the median
+	TotalDistance float64 `json:"total_distance"`
+	// Location shared in the group closest to the median, whose coordinates
+	// are visible, null when there is none
+	Closest   *NearbyLocationResponse   `json:"closest"`
+	Locations []MeetingLocationResponse `json:"locations"`
+}
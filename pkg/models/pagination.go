@@ -0,0 +1,142 @@
+package models
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type PageResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Total      *int64      `json:"total,omitempty"`
+}
+
+func NewPageResponse(data interface{}, page dbmodel.PageInfo) *PageResponse {
+	return &PageResponse{Data: data, NextCursor: page.NextCursor, Total: page.Total}
+}
+
+// ParseQueryOptions reads the pagination, sort and filter parameters of a list
+// endpoint. sortFields lists the values accepted by the sort parameter.
+func ParseQueryOptions(r *http.Request, sortFields []string) (dbmodel.QueryOptions, error) {
+	query := r.URL.Query()
+	options := dbmodel.QueryOptions{
+		After:        query.Get("after"),
+		NameContains: query.Get("name"),
+		Category:     query.Get("category"),
+		Tags:         normalizeTags(query["tag"]),
+		// Location details
+		DescriptionContains: query.Get("description"),
+		AddressContains:     query.Get("address"),
+		Color:               query.Get("color"),
+		Icon:                query.Get("icon"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			return options, errors.New("limit must be a positive integer")
+		}
+		options.Limit = value
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		if len(sort) > 1 && sort[0] == '-' {
+			options.Descending = true
+			sort = sort[1:]
+		}
+		allowed := false
+		for _, field := range sortFields {
+			if field == sort {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return options, errors.New("unsupported sort field")
+		}
+		options.Sort = sort
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		options.Descending = true
+	default:
+		return options, errors.New("order must be asc or desc")
+	}
+
+	switch query.Get("tag_mode") {
+	case "", "any":
+	case "all":
+		options.MatchAllTags = true
+	default:
+		return options, errors.New("tag_mode must be any or all")
+	}
+
+	if before := query.Get("created_before"); before != "" {
+		value, err := time.Parse(time.RFC3339, before)
+		if err != nil {
+			return options, errors.New("created_before must be an RFC 3339 date")
+		}
+		options.CreatedBefore = &value
+	}
+	if after := query.Get("created_after"); after != "" {
+		value, err := time.Parse(time.RFC3339, after)
+		if err != nil {
+			return options, errors.New("created_after must be an RFC 3339 date")
+		}
+		options.CreatedAfter = &value
+	}
+
+	if owner := query.Get("owner"); owner != "" {
+		value, err := strconv.Atoi(owner)
+		if err != nil || value < 1 {
+			return options, errors.New("owner must be >= 1")
+		}
+		options.OwnerID = uint(value)
+	}
+
+	bounds := []struct {
+		name   string
+		target **float64
+	}{
+		{"min_altitude", &options.MinAltitude},
+		{"max_altitude", &options.MaxAltitude},
+		{"max_accuracy", &options.MaxAccuracy},
+	}
+	for _, bound := range bounds {
+		if value := query.Get(bound.name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return options, errors.New(bound.name + " must be a number")
+			}
+			*bound.target = &parsed
+		}
+	}
+
+	if options.After != "" {
+		if err := dbmodel.CheckCursor(options); err != nil {
+			return options, err
+		}
+	}
+
+	return options, nil
+}
+
+// normalizeTags normalizes the tag filters like the stored tags and removes
+// the duplicates, which would never all match.
+func normalizeTags(values []string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		tag := NormalizeTag(value)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -0,0 +1,8 @@
+package models
+
+type SearchResultResponse struct {
+	Kind  string  `json:"kind"`
+	ID    uint    `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/geo"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ShareRuleRequest struct {
+	GroupID uint `json:"group_id"`
+	// Tag the locations must have
+	Tag string `json:"tag" example:"climbing"`
+	// Category the locations must be of
+	Category string `json:"category" example:"restaurant"`
+	// Area the locations must be inside, minLng,minLat,maxLng,maxLat
+	BBox                 string `json:"bbox" example:"2.2,48.8,2.5,48.9"`
+	IsVisibleCoordinates bool   `json:"is_visible_coordinates"`
+	// bounds is the parsed bbox
+	bounds *geo.Bounds
+}
+
+func (req *ShareRuleRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if req.GroupID < 1 {
+		return errors.New("group_id must be >= 1")
+	}
+	req.Tag = NormalizeTag(req.Tag)
+	if req.Category != "" && !isLocationCategory(req.Category) {
+		return errors.New("category is not supported")
+	}
+	if req.BBox != "" {
+		bounds, err := ParseBoundingBox(req.BBox)
+		if err != nil {
+			return err
+		}
+		req.bounds = &bounds
+	}
+	if req.Tag == "" && req.Category == "" && req.bounds == nil {
+		return errors.New("a rule needs a tag, a category or a bbox")
+	}
+	return nil
+}
+
+// NewShareRuleEntry returns the rule of a request, owned by userID.
+func NewShareRuleEntry(req *ShareRuleRequest, userID uint) *dbmodel.ShareRuleEntry {
+	entry := &dbmodel.ShareRuleEntry{
+		UserID:               userID,
+		GroupID:              req.GroupID,
+		Tag:                  req.Tag,
+		Category:             req.Category,
+		IsVisibleCoordinates: req.IsVisibleCoordinates,
+	}
+	if req.bounds != nil {
+		south, west, north, east := req.bounds.South, req.bounds.West, req.bounds.North, req.bounds.East
+		entry.South, entry.West, entry.North, entry.East = &south, &west, &north, &east
+	}
+	return entry
+}
+
+type ShareRuleResponse struct {
+	ID       uint   `json:"rule_id"`
+	GroupID  uint   `json:"group_id"`
+	Tag      string `json:"tag,omitempty"`
+	Category string `json:"category,omitempty"`
+	// Area as minLng,minLat,maxLng,maxLat
+	BBox                 string `json:"bbox,omitempty"`
+	IsVisibleCoordinates bool   `json:"is_visible_coordinates"`
+	// Number of locations the rule shares
+	LocationCount int       `json:"location_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func NewShareRuleResponse(entry *dbmodel.ShareRuleEntry, locationCount int) ShareRuleResponse {
+	response := ShareRuleResponse{
+		ID:                   entry.ID,
+		GroupID:              entry.GroupID,
+		Tag:                  entry.Tag,
+		Category:             entry.Category,
+		IsVisibleCoordinates: entry.IsVisibleCoordinates,
+		LocationCount:        locationCount,
+		CreatedAt:            entry.CreatedAt,
+		UpdatedAt:            entry.UpdatedAt,
+	}
+	if bounds, ok := entry.Bounds(); ok {
+		response.BBox = formatCoordinate(bounds.West) + "," + formatCoordinate(bounds.South) + "," +
+			formatCoordinate(bounds.East) + "," + formatCoordinate(bounds.North)
+	}
+	return response
+}
+
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// ShareRulePreviewResponse tells what a rule would share before it is saved.
+type ShareRulePreviewResponse struct {
+	// Locations matching the rule
+	Locations []LocationResponse `json:"locations"`
+	// Locations the rule would share in the group
+	ToShare []uint `json:"to_share"`
+	// Locations already shared in the group
+	AlreadyShared []uint `json:"already_shared"`
+}
+
+func NewShareRulePreviewResponse(locations []dbmodel.LocationEntry, shared map[uint]bool) ShareRulePreviewResponse {
+	response := ShareRulePreviewResponse{
+		Locations:     make([]LocationResponse, 0, len(locations)),
+		ToShare:       make([]uint, 0),
+		AlreadyShared: make([]uint, 0),
+	}
+	for i := range locations {
+		response.Locations = append(response.Locations, NewLocationResponse(&locations[i]))
+		if shared[locations[i].ID] {
+			response.AlreadyShared = append(response.AlreadyShared, locations[i].ID)
+		} else {
+			response.ToShare = append(response.ToShare, locations[i].ID)
+		}
+	}
+	return response
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+type TagRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func (req *TagRequest) Bind(r *http.Request) error {
+	if req == nil || len(req.Tags) == 0 {
+		return errors.New("tags must not be empty")
+	}
+	for i, tag := range req.Tags {
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			return errors.New("tags must not be blank")
+		} else if utf8.RuneCountInString(tag) > 64 {
+			return errors.New("tags must not exceed 64 characters")
+		}
+		req.Tags[i] = tag
+	}
+	return nil
+}
+
+// NormalizeTag trims and lower cases a tag so "Parking " and "parking" are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+type TagResponse struct {
+	ID   uint   `json:"tag_id"`
+	Name string `json:"name"`
+}
+
+func LocationIDs(locations []LocationResponse) []uint {
+	ids := make([]uint, len(locations))
+	for i, location := range locations {
+		ids[i] = location.ID
+	}
+	return ids
+}
+
+// AttachTags fills the tags of each location from tags keyed by location ID.
+func AttachTags(locations []LocationResponse, tags map[uint][]string) {
+	for i := range locations {
+		locations[i].Tags = tags[locations[i].ID]
+	}
+}
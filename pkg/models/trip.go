@@ -0,0 +1,159 @@
+package models
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/geo"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MaxTripStops is the number of stops a trip has at most.
+const MaxTripStops = 200
+
+type TripRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Locations visited in order, only read when the trip is created
+	LocationIDs []uint `json:"location_ids"`
+}
+
+func (req *TripRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errors.New("name must not be null")
+	} else if len(req.LocationIDs) > MaxTripStops {
+		return errors.New("location_ids must have at most 200 locations")
+	}
+	for _, id := range req.LocationIDs {
+		if id == 0 {
+			return errors.New("location_ids must be positive")
+		}
+	}
+	return nil
+}
+
+type TripStopRequest struct {
+	LocationID uint `json:"location_id"`
+	// Position of the stop counted from 0, after the last stop when missing
+	Position *int   `json:"position"`
+	Note     string `json:"note"`
+}
+
+func (req *TripStopRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if req.LocationID < 1 {
+		return errors.New("location_id must be >= 1")
+	} else if req.Position != nil && *req.Position < 0 {
+		return errors.New("position must be >= 0")
+	}
+	return nil
+}
+
+type TripOrderRequest struct {
+	// Every stop of the trip, in the order they are visited
+	StopIDs []uint `json:"stop_ids"`
+}
+
+func (req *TripOrderRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if len(req.StopIDs) == 0 {
+		return errors.New("stop_ids must not be empty")
+	}
+	return nil
+}
+
+type TripShareRequest struct {
+	GroupID uint `json:"group_id"`
+}
+
+func (req *TripShareRequest) Bind(r *http.Request) error {
+	if req == nil {
+		return errors.New("empty request")
+	} else if req.GroupID < 1 {
+		return errors.New("group_id must be >= 1")
+	}
+	return nil
+}
+
+// TripStopResponse is a stop of a trip, without the name of its location when
+// the caller cannot see it and without its coordinates when they are hidden.
+type TripStopResponse struct {
+	ID         uint     `json:"stop_id"`
+	Position   int      `json:"position"`
+	LocationID uint     `json:"location_id"`
+	Name       string   `json:"name,omitempty"`
+	Note       string   `json:"note"`
+	Latitude   *float64 `json:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty"`
+	// Distance in meters from the previous stop with coordinates
+	Distance *float64 `json:"distance,omitempty"`
+}
+
+type TripResponse struct {
+	ID          uint   `json:"trip_id"`
+	UserID      uint   `json:"user_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Groups the trip is shared in, only given to its creator
+	GroupIDs []uint             `json:"group_ids,omitempty"`
+	Stops    []TripStopResponse `json:"stops"`
+	// Length in meters of the path through the stops with coordinates
+	Length float64 `json:"length"`
+	// Number of stops whose coordinates are hidden from the caller, left out
+	// of the length
+	HiddenStops int       `json:"hidden_stops"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type TripOptimizeResponse struct {
+	TripResponse
+	// Length in meters before the stops were reordered
+	PreviousLength float64 `json:"previous_length"`
+}
+
+func NewTripResponse(trip *dbmodel.TripEntry, stops []dbmodel.TripStop, groupIDs []uint) TripResponse {
+	response := TripResponse{
+		ID:          trip.ID,
+		UserID:      trip.UserID,
+		Name:        trip.Name,
+		Description: trip.Description,
+		GroupIDs:    groupIDs,
+		Stops:       make([]TripStopResponse, 0, len(stops)),
+		CreatedAt:   trip.CreatedAt,
+		UpdatedAt:   trip.UpdatedAt,
+	}
+	var previous *geo.Point
+	for i, stop := range stops {
+		stopResponse := TripStopResponse{
+			ID:         stop.ID,
+			Position:   i,
+			LocationID: stop.LocationID,
+			Name:       stop.Name,
+			Note:       stop.Note,
+		}
+		if stop.CoordinatesVisible {
+			latitude, longitude := stop.Latitude, stop.Longitude
+			stopResponse.Latitude, stopResponse.Longitude = &latitude, &longitude
+			point := geo.Point{Latitude: latitude, Longitude: longitude}
+			if previous != nil {
+				distance := math.Round(geo.Distance(*previous, point))
+				stopResponse.Distance = &distance
+				response.Length += distance
+			}
+			previous = &point
+		} else {
+			response.HiddenStops++
+		}
+		response.Stops = append(response.Stops, stopResponse)
+	}
+	return response
+}
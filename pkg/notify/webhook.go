@@ -0,0 +1,125 @@
+// Package notify delivers notifications outside of the API.
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+const (
+	webhookAttempts = 3
+	webhookTimeout  = 10 * time.Second
+)
+
+// ErrNonPublicAddress is returned for a webhook whose host resolves to an
+// address of the server or of its network.
+var ErrNonPublicAddress = errors.New("webhook address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, which cloud providers
+// also use for their internal services.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+type Notifier interface {
+	// Notify sends the payload to the URL in the background.
+	Notify(url string, payload any)
+}
+
+// WebhookNotifier posts JSON payloads, retrying with an exponential backoff.
+// When a secret is set, the body is signed with HMAC-SHA256 in the
+// X-LocateThis-Signature header so that receivers can authenticate it.
+// Webhooks are set by the users, so the connections to loopback, private
+// and link-local addresses are refused, once the host is resolved and on
+// every redirect.
+type WebhookNotifier struct {
+	client *http.Client
+	secret string
+}
+
+func NewWebhookNotifier(secret string) *WebhookNotifier {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: dialPublicOnly}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: webhookTimeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &WebhookNotifier{client: &http.Client{Timeout: webhookTimeout, Transport: transport}, secret: secret}
+}
+
+// dialPublicOnly refuses the connections to addresses that are not public,
+// checked on the resolved address so that a host name cannot point to them.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	addressPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublic(addressPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, addressPort.Addr())
+	}
+	return nil
+}
+
+func isPublic(address netip.Addr) bool {
+	address = address.Unmap()
+	return address.IsGlobalUnicast() && !address.IsPrivate() && !sharedAddressSpace.Contains(address)
+}
+
+func (notifier *WebhookNotifier) Notify(url string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("Failed to encode webhook payload:", err)
+		return
+	}
+	go notifier.deliver(url, body)
+}
+
+func (notifier *WebhookNotifier) deliver(url string, body []byte) {
+	backoff := time.Second
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		err := notifier.post(url, body)
+		if err == nil {
+			return
+		}
+		if attempt == webhookAttempts || errors.Is(err, ErrNonPublicAddress) {
+			log.Println("Failed to deliver webhook to", url+":", err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (notifier *WebhookNotifier) post(url string, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "LocateThis-Webhook")
+	if notifier.secret != "" {
+		mac := hmac.New(sha256.New, []byte(notifier.secret))
+		mac.Write(body)
+		request.Header.Set("X-LocateThis-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	response, err := notifier.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		address string
+		public  bool
+	}{
+		{"93.184.215.14", true},
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"127.10.0.1", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"172.31.255.255", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"100.100.100.200", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:8.8.8.8", true},
+	}
+	for _, test := range tests {
+		if got := isPublic(netip.MustParseAddr(test.address)); got != test.public {
+			t.Errorf("isPublic(%s) = %v, want %v", test.address, got, test.public)
+		}
+	}
+}
+
+func TestWebhookRefusesNonPublicAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	port := server.URL[strings.LastIndex(server.URL, ":")+1:]
+
+	notifier := NewWebhookNotifier("")
+	for _, url := range []string{
+		server.URL,
+		"http://localhost:" + port + "/hook",
+		"http://[::1]:" + port,
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/hook",
+		"https://192.168.1.1/hook",
+	} {
+		if err := notifier.post(url, []byte("{}")); !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("post(%s) = %v, want ErrNonPublicAddress", url, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("%d requests reached the local server", requests)
+	}
+}
+
+func TestWebhookSignsTheBody(t *testing.T) {
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get("X-LocateThis-Signature")
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		}
+	}))
+	defer server.Close()
+
+	// the test server is local, the client of the server reaches it
+	notifier := &WebhookNotifier{client: server.Client(), secret: "secret"}
+	if err := notifier.post(server.URL, []byte(`{"event":"geofence.entered"}`)); err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`{"event":"geofence.entered"}`))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want || string(body) != `{"event":"geofence.entered"}` {
+		t.Errorf("received %s signed %q, want %q", body, signature, want)
+	}
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	if err := notifier.post(server.URL, []byte("{}")); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("post to a failing receiver = %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"locate-this/pkg/geo"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const osrmTimeout = 10 * time.Second
+
+// osrmProfiles are the profiles of the OSRM API for each mode of travel.
+var osrmProfiles = map[Mode]string{Walk: "foot", Bike: "bike", Car: "car"}
+
+// OSRMRouter queries the table service of servers implementing the OSRM HTTP
+// API, such as self-hosted osrm-routed. A server routes with the single
+// profile it was built for, so each mode may have its own server.
+type OSRMRouter struct {
+	client *http.Client
+	// base URL of the server of each mode
+	baseURLs map[Mode]string
+}
+
+func NewOSRMRouter(baseURLs map[Mode]string) *OSRMRouter {
+	router := &OSRMRouter{client: &http.Client{Timeout: osrmTimeout}, baseURLs: make(map[Mode]string)}
+	for mode, baseURL := range baseURLs {
+		router.baseURLs[mode] = strings.TrimRight(baseURL, "/")
+	}
+	return router
+}
+
+type osrmTable struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Distances [][]*float64 `json:"distances"`
+	Durations [][]*float64 `json:"durations"`
+}
+
+func (router *OSRMRouter) Table(ctx context.Context, mode Mode, sources, destinations []geo.Point) (*Table, error) {
+	baseURL, ok := router.baseURLs[mode]
+	if !ok {
+		return nil, ErrModeNotRouted
+	}
+	// the sources then the destinations, referenced by their index
+	coordinates := make([]string, 0, len(sources)+len(destinations))
+	sourceIndexes := make([]string, 0, len(sources))
+	destinationIndexes := make([]string, 0, len(destinations))
+	for _, point := range sources {
+		sourceIndexes = append(sourceIndexes, strconv.Itoa(len(coordinates)))
+		coordinates = append(coordinates, osrmCoordinate(point))
+	}
+	for _, point := range destinations {
+		destinationIndexes = append(destinationIndexes, strconv.Itoa(len(coordinates)))
+		coordinates = append(coordinates, osrmCoordinate(point))
+	}
+	parameters := url.Values{
+		"sources":      {strings.Join(sourceIndexes, ";")},
+		"destinations": {strings.Join(destinationIndexes, ";")},
+		"annotations":  {"duration,distance"},
+	}
+	path := "/table/v1/" + osrmProfiles[mode] + "/" + strings.Join(coordinates, ";")
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+path+"?"+parameters.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := router.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	// errors come with a code and a message, whatever the status
+	var table osrmTable
+	if err := json.NewDecoder(response.Body).Decode(&table); err != nil {
+		return nil, fmt.Errorf("osrm: table responded %s", response.Status)
+	}
+	if table.Code != "Ok" {
+		return nil, fmt.Errorf("osrm: table responded %s: %s", table.Code, table.Message)
+	}
+	if !matrixSize(table.Durations, len(sources), len(destinations)) {
+		return nil, errors.New("osrm: the table does not have the size of the request")
+	}
+	if !matrixSize(table.Distances, len(sources), len(destinations)) {
+		table.Distances = nil
+	}
+	return &Table{Distances: table.Distances, Durations: table.Durations}, nil
+}
+
+func osrmCoordinate(point geo.Point) string {
+	return strconv.FormatFloat(point.Longitude, 'f', 6, 64) + "," + strconv.FormatFloat(point.Latitude, 'f', 6, 64)
+}
+
+func matrixSize(matrix [][]*float64, rows, columns int) bool {
+	if len(matrix) != rows {
+		return false
+	}
+	for _, row := range matrix {
+		if len(row) != columns {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"locate-this/pkg/geo"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// osrmStub answers the table service of the OSRM API with a canned response,
+// recording the requests it receives.
+type osrmStub struct {
+	server   *httptest.Server
+	paths    []string
+	queries  []url.Values
+	status   int
+	response string
+}
+
+func newOSRMStub(t *testing.T, status int, response string) *osrmStub {
+	stub := &osrmStub{status: status, response: response}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.paths = append(stub.paths, r.URL.Path)
+		stub.queries = append(stub.queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(stub.status)
+		w.Write([]byte(stub.response))
+	}))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+var (
+	bellecour = geo.Point{Latitude: 45.757814, Longitude: 4.832011}
+	fourviere = geo.Point{Latitude: 45.762207, Longitude: 4.822104}
+	partDieu  = geo.Point{Latitude: 45.760585, Longitude: 4.859435}
+)
+
+// tableValues returns the values of a table, -1 where there is no route.
+func tableValues(matrix [][]*float64) [][]float64 {
+	values := make([][]float64, len(matrix))
+	for i, row := range matrix {
+		values[i] = make([]float64, len(row))
+		for j, value := range row {
+			values[i][j] = -1
+			if value != nil {
+				values[i][j] = *value
+			}
+		}
+	}
+	return values
+}
+
+func equalValues(a, b [][]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestOSRMTable(t *testing.T) {
+	stub := newOSRMStub(t, http.StatusOK, `{
+		"code": "Ok",
+		"durations": [[812.4, null], [0, 1304.2]],
+		"distances": [[1123.5, null], [0, 2987.1]],
+		"sources": [{"location": [4.832011, 45.757814]}, {"location": [4.822104, 45.762207]}],
+		"destinations": [{"location": [4.822104, 45.762207]}, {"location": [4.859435, 45.760585]}]
+	}`)
+	router := NewOSRMRouter(map[Mode]string{Walk: stub.server.URL + "/"})
+
+	table, err := router.Table(context.Background(), Walk, []geo.Point{bellecour, fourviere}, []geo.Point{fourviere, partDieu})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if durations := tableValues(table.Durations); !equalValues(durations, [][]float64{{812.4, -1}, {0, 1304.2}}) {
+		t.Errorf("durations = %v", durations)
+	}
+	if distances := tableValues(table.Distances); !equalValues(distances, [][]float64{{1123.5, -1}, {0, 2987.1}}) {
+		t.Errorf("distances = %v", distances)
+	}
+
+	// the sources then the destinations, as longitude,latitude
+	wantPath := "/table/v1/foot/4.832011,45.757814;4.822104,45.762207;4.822104,45.762207;4.859435,45.760585"
+	if stub.paths[0] != wantPath {
+		t.Errorf("path = %s, want %s", stub.paths[0], wantPath)
+	}
+	query := stub.queries[0]
+	if query.Get("sources") != "0;1" || query.Get("destinations") != "2;3" || query.Get("annotations") != "duration,distance" {
+		t.Errorf("query = %v", query)
+	}
+}
+
+func TestOSRMTableServerPerMode(t *testing.T) {
+	response := `{"code": "Ok", "durations": [[60]], "distances": [[100]]}`
+	bike, car := newOSRMStub(t, http.StatusOK, response), newOSRMStub(t, http.StatusOK, response)
+	router := NewOSRMRouter(map[Mode]string{Bike: bike.server.URL, Car: car.server.URL})
+	ctx := context.Background()
+
+	if _, err := router.Table(ctx, Bike, []geo.Point{bellecour}, []geo.Point{partDieu}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := router.Table(ctx, Car, []geo.Point{bellecour}, []geo.Point{partDieu}); err != nil {
+		t.Fatal(err)
+	}
+	if len(bike.paths) != 1 || !strings.HasPrefix(bike.paths[0], "/table/v1/bike/") {
+		t.Errorf("bike server requests = %v", bike.paths)
+	}
+	if len(car.paths) != 1 || !strings.HasPrefix(car.paths[0], "/table/v1/car/") {
+		t.Errorf("car server requests = %v", car.paths)
+	}
+
+	if _, err := router.Table(ctx, Walk, []geo.Point{bellecour}, []geo.Point{partDieu}); !errors.Is(err, ErrModeNotRouted) {
+		t.Errorf("Table for walking: err = %v, want ErrModeNotRouted", err)
+	}
+}
+
+func TestOSRMTableErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		message  string
+	}{
+		{"invalid query", http.StatusBadRequest, `{"code": "InvalidQuery", "message": "Query string malformed close to position 28"}`, "InvalidQuery: Query string malformed"},
+		{"too big", http.StatusBadRequest, `{"code": "TooBig", "message": "Too many table coordinates"}`, "TooBig"},
+		{"error code with 200", http.StatusOK, `{"code": "NoTable", "message": "No table found"}`, "NoTable"},
+		{"not JSON", http.StatusBadGateway, `<html>Bad Gateway</html>`, "responded 502 Bad Gateway"},
+		{"missing durations", http.StatusOK, `{"code": "Ok", "distances": [[100]]}`, "size of the request"},
+		{"missing row", http.StatusOK, `{"code": "Ok", "durations": []}`, "size of the request"},
+		{"short row", http.StatusOK, `{"code": "Ok", "durations": [[]]}`, "size of the request"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newOSRMStub(t, test.status, test.response)
+			table, err := NewOSRMRouter(map[Mode]string{Car: stub.server.URL}).Table(context.Background(), Car, []geo.Point{bellecour}, []geo.Point{partDieu})
+			if err == nil || !strings.Contains(err.Error(), test.message) {
+				t.Errorf("Table = %+v, %v, want an error containing %q", table, err, test.message)
+			}
+		})
+	}
+
+	t.Run("distances of another size", func(t *testing.T) {
+		stub := newOSRMStub(t, http.StatusOK, `{"code": "Ok", "durations": [[60]], "distances": [[100, 200]]}`)
+		table, err := NewOSRMRouter(map[Mode]string{Car: stub.server.URL}).Table(context.Background(), Car, []geo.Point{bellecour}, []geo.Point{partDieu})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if table.Distances != nil || *table.Durations[0][0] != 60 {
+			t.Errorf("Table = %+v, want the durations only", table)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		if _, err := NewOSRMRouter(map[Mode]string{Car: server.URL}).Table(context.Background(), Car, []geo.Point{bellecour}, []geo.Point{partDieu}); err == nil {
+			t.Error("Table on a closed server did not fail")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+		router := NewOSRMRouter(map[Mode]string{Car: server.URL})
+		router.client.Timeout = 50 * time.Millisecond
+		if _, err := router.Table(context.Background(), Car, []geo.Point{bellecour}, []geo.Point{partDieu}); err == nil {
+			t.Error("Table on a stalled server did not fail")
+		}
+	})
+}
@@ -0,0 +1,126 @@
+// Package routing estimates the distances and travel times between
+// locations, from the great-circle distance or with a routing service.
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"locate-this/pkg/geo"
+	"math"
+	"os"
+	"strings"
+)
+
+type Mode string
+
+const (
+	Walk Mode = "walk"
+	Bike Mode = "bike"
+	Car  Mode = "car"
+)
+
+var Modes = []Mode{Walk, Bike, Car}
+
+// Speeds are the average speeds in km/h of the travel time estimates.
+var Speeds = map[Mode]float64{Walk: 5, Bike: 15, Car: 50}
+
+// DetourFactor is the ratio between the length of a route and the
+// great-circle distance, a usual average on road networks.
+const DetourFactor = 1.3
+
+// MaxTableSize is the number of sources or destinations a router accepts at
+// most, the default limit of OSRM.
+const MaxTableSize = 100
+
+// Table holds the distances in meters along the routes and the travel times
+// in seconds from each source to each destination, nil where there is no
+// route.
+type Table struct {
+	Distances [][]*float64
+	Durations [][]*float64
+}
+
+// ErrModeNotRouted is returned by a router for a mode it has no routes for.
+var ErrModeNotRouted = errors.New("mode not routed")
+
+type Router interface {
+	// Table returns the routes from every source to every destination with a
+	// mode of travel.
+	Table(ctx context.Context, mode Mode, sources, destinations []geo.Point) (*Table, error)
+}
+
+// New builds the router selected by the ROUTER environment variable, none
+// (nil) by default or "osrm" for OSRM compatible servers: ROUTER_URL for
+// every mode, or ROUTER_URL_WALK, ROUTER_URL_BIKE and ROUTER_URL_CAR for a
+// server per mode. The modes without a server are estimated.
+func New() (Router, error) {
+	switch os.Getenv("ROUTER") {
+	case "":
+		return nil, nil
+	case "osrm":
+		baseURLs := make(map[Mode]string)
+		for _, mode := range Modes {
+			if url := os.Getenv("ROUTER_URL_" + strings.ToUpper(string(mode))); url != "" {
+				baseURLs[mode] = url
+			} else if url := os.Getenv("ROUTER_URL"); url != "" {
+				baseURLs[mode] = url
+			}
+		}
+		if len(baseURLs) == 0 {
+			return nil, errors.New("ROUTER_URL is required by the osrm router")
+		}
+		return NewOSRMRouter(baseURLs), nil
+	}
+	return nil, errors.New("unknown ROUTER")
+}
+
+// ParseModes reads comma separated modes of travel, all of them when the
+// text is empty.
+func ParseModes(text string) ([]Mode, error) {
+	if strings.TrimSpace(text) == "" {
+		return Modes, nil
+	}
+	var modes []Mode
+	seen := make(map[Mode]bool)
+	for _, name := range strings.Split(text, ",") {
+		mode := Mode(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := Speeds[mode]; !ok {
+			return nil, fmt.Errorf("unknown mode %q, expected walk, bike or car", name)
+		}
+		if !seen[mode] {
+			seen[mode] = true
+			modes = append(modes, mode)
+		}
+	}
+	return modes, nil
+}
+
+// GreatCircleDistances returns the distances in meters from each source to
+// each destination, to the meter.
+func GreatCircleDistances(sources, destinations []geo.Point) [][]float64 {
+	distances := make([][]float64, len(sources))
+	for i, source := range sources {
+		distances[i] = make([]float64, len(destinations))
+		for j, destination := range destinations {
+			distances[i][j] = math.Round(geo.Distance(source, destination))
+		}
+	}
+	return distances
+}
+
+// EstimateDurations returns rough travel times in seconds for great-circle
+// distances, lengthened by DetourFactor and travelled at the speed of the
+// mode, to the second.
+func EstimateDurations(distances [][]float64, mode Mode) [][]*float64 {
+	metersPerSecond := Speeds[mode] / 3.6
+	durations := make([][]*float64, len(distances))
+	for i, row := range distances {
+		durations[i] = make([]*float64, len(row))
+		for j, distance := range row {
+			duration := math.Round(distance * DetourFactor / metersPerSecond)
+			durations[i][j] = &duration
+		}
+	}
+	return durations
+}
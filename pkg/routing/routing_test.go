@@ -0,0 +1,64 @@
+package routing
+
+import (
+	"locate-this/pkg/geo"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		modes   []Mode
+		invalid bool
+	}{
+		{"no router", map[string]string{}, nil, false},
+		{"one server", map[string]string{"ROUTER": "osrm", "ROUTER_URL": "http://osrm:5000/"}, []Mode{Walk, Bike, Car}, false},
+		{"a server per mode", map[string]string{"ROUTER": "osrm", "ROUTER_URL_CAR": "http://car:5000", "ROUTER_URL_WALK": "http://foot:5000"}, []Mode{Walk, Car}, false},
+		{"no server", map[string]string{"ROUTER": "osrm"}, nil, true},
+		{"unknown router", map[string]string{"ROUTER": "graphhopper", "ROUTER_URL": "http://gh:8989"}, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"ROUTER", "ROUTER_URL", "ROUTER_URL_WALK", "ROUTER_URL_BIKE", "ROUTER_URL_CAR"} {
+				t.Setenv(name, test.env[name])
+			}
+			router, err := New()
+			if (err != nil) != test.invalid {
+				t.Fatalf("New: err = %v", err)
+			}
+			if test.modes == nil {
+				if router != nil {
+					t.Errorf("New = %v, want no router", router)
+				}
+				return
+			}
+			osrm := router.(*OSRMRouter)
+			if len(osrm.baseURLs) != len(test.modes) {
+				t.Errorf("servers = %v, want %v", osrm.baseURLs, test.modes)
+			}
+			for _, mode := range test.modes {
+				if url := osrm.baseURLs[mode]; url == "" || strings.HasSuffix(url, "/") {
+					t.Errorf("server of %s = %q", mode, url)
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateDurations(t *testing.T) {
+	distances := GreatCircleDistances([]geo.Point{bellecour, partDieu}, []geo.Point{fourviere, bellecour})
+	if distances[0][0] != math.Round(geo.Distance(bellecour, fourviere)) || distances[0][1] != 0 || distances[1][1] != math.Round(geo.Distance(partDieu, bellecour)) {
+		t.Errorf("distances = %v", distances)
+	}
+	// 1 km lengthened by 30% at 5 km/h is 936 seconds
+	durations := EstimateDurations([][]float64{{1000, 0}}, Walk)
+	if *durations[0][0] != 936 || *durations[0][1] != 0 {
+		t.Errorf("walking durations = %v", tableValues(durations))
+	}
+	if durations := EstimateDurations([][]float64{{1000}}, Car); *durations[0][0] != 94 {
+		t.Errorf("driving duration = %v, want 94", *durations[0][0])
+	}
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"locate-this/config"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+const defaultSearchLimit = 20
+
+type SearchConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *SearchConfig {
+	return &SearchConfig{configuration}
+}
+
+// @Summary		Search
+// @Description	Full-text search over the locations, groups and group members visible to the caller, best matches first
+// @Tags			search
+// @Accept			json
+// @Produce		json
+// @Param			q		query		string	true	"Search terms, matched by prefix and without accents"
+// @Param			limit	query		int		false	"Maximum number of results, 200 at most"
+// @Success		200	{array}	models.SearchResultResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/search [get]
+func (config *SearchConfig) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		render.JSON(w, r, map[string]string{"error": "q must not be empty"})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			render.JSON(w, r, map[string]string{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(parsed, dbmodel.MaxPageLimit)
+	}
+
+	user, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	results, err := config.SearchRepository.Search(user.ID, query, limit)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to search"})
+		return
+	}
+
+	searchResponse := make([]models.SearchResultResponse, 0)
+	for _, result := range results {
+		searchResponse = append(searchResponse, models.SearchResultResponse{
+			Kind:  result.Kind,
+			ID:    result.RefID,
+			Name:  result.Name,
+			Score: result.Rank,
+		})
+	}
+
+	render.JSON(w, r, searchResponse)
+}
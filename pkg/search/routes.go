@@ -0,0 +1,19 @@
+package search
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Search:
+- GET /search?q=
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	SearchConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Get("/", SearchConfig.SearchHandler)
+	return router
+}
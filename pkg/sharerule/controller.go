@@ -0,0 +1,260 @@
+package sharerule
+
+import (
+	"fmt"
+	"locate-this/config"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type ShareRuleConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *ShareRuleConfig {
+	return &ShareRuleConfig{configuration}
+}
+
+// @Summary		Create a share rule
+// @Description	Share in a group of the caller all their locations with a tag, of a category or inside a bbox, the conditions given together. The locations matching the rule are shared at once, and those created or changed later as soon as they match; a location that no longer matches is taken out of the group.
+// @Tags			share-rules
+// @Accept			json
+// @Produce		json
+// @Param			request	body		models.ShareRuleRequest	true	"Group and conditions"
+// @Success		200		{object}	models.ShareRuleResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/share-rules [post]
+func (config *ShareRuleConfig) PostShareRuleHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.ShareRuleRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	caller, ok := config.memberOfGroup(w, r, req.GroupID)
+	if !ok {
+		return
+	}
+
+	res, changes, err := config.ShareRuleRepository.Create(models.NewShareRuleEntry(req, caller.ID))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to create share rule"})
+		return
+	}
+	config.SharePublisher.Publish(changes)
+
+	config.renderShareRule(w, r, res)
+}
+
+// @Summary		Get the share rules
+// @Description	Retrieve the share rules of the caller with the number of locations each of them shares
+// @Tags			share-rules
+// @Accept			json
+// @Produce		json
+// @Success		200	{array}		models.ShareRuleResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/share-rules [get]
+func (config *ShareRuleConfig) GetShareRulesHandler(w http.ResponseWriter, r *http.Request) {
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	rules, err := config.ShareRuleRepository.FindRulesForUser(caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve share rules"})
+		return
+	}
+	counts, err := config.ShareRuleRepository.CountShares(caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve share rules"})
+		return
+	}
+
+	rulesResponse := make([]models.ShareRuleResponse, 0, len(rules))
+	for i := range rules {
+		rulesResponse = append(rulesResponse, models.NewShareRuleResponse(&rules[i], counts[rules[i].ID]))
+	}
+
+	render.JSON(w, r, rulesResponse)
+}
+
+// @Summary		Get a share rule
+// @Description	Retrieve a share rule of the caller
+// @Tags			share-rules
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Share rule ID"
+// @Success		200	{object}	models.ShareRuleResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/share-rules/{id} [get]
+func (config *ShareRuleConfig) GetShareRuleByIDHandler(w http.ResponseWriter, r *http.Request) {
+	rule, _, ok := config.ownShareRule(w, r)
+	if !ok {
+		return
+	}
+
+	config.renderShareRule(w, r, rule)
+}
+
+// @Summary		Update a share rule
+// @Description	Change the group or the conditions of a share rule of the caller. The locations it now matches are shared, and those it no longer matches are taken out of the group.
+// @Tags			share-rules
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int						true	"Share rule ID"
+// @Param			request	body		models.ShareRuleRequest	true	"Group and conditions"
+// @Success		200		{object}	models.ShareRuleResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/share-rules/{id} [put]
+func (config *ShareRuleConfig) PutShareRuleHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.ShareRuleRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	rule, caller, ok := config.ownShareRule(w, r)
+	if !ok {
+		return
+	}
+	member, err := config.GroupEntryRepository.IsMember(req.GroupID, caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
+	}
+
+	res, changes, err := config.ShareRuleRepository.Update(models.NewShareRuleEntry(req, caller.ID), rule.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to update share rule"})
+		return
+	}
+	config.SharePublisher.Publish(changes)
+
+	config.renderShareRule(w, r, res)
+}
+
+// @Summary		Delete a share rule
+// @Description	Delete a share rule of the caller and take the locations it shared out of the group, unless another of their rules for the group matches them
+// @Tags			share-rules
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Share rule ID"
+// @Success		200	{object}	map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/share-rules/{id} [delete]
+func (config *ShareRuleConfig) DeleteShareRuleHandler(w http.ResponseWriter, r *http.Request) {
+	rule, _, ok := config.ownShareRule(w, r)
+	if !ok {
+		return
+	}
+
+	changes, err := config.ShareRuleRepository.Delete(rule.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to delete share rule"})
+		return
+	}
+	config.SharePublisher.Publish(changes)
+
+	render.JSON(w, r, map[string]string{"message": "Share rule deleted successfully"})
+}
+
+// @Summary		Preview a share rule
+// @Description	Tell which locations of the caller a share rule would match, and which of them it would share in the group, without saving it
+// @Tags			share-rules
+// @Accept			json
+// @Produce		json
+// @Param			request	body		models.ShareRuleRequest	true	"Group and conditions"
+// @Success		200		{object}	models.ShareRulePreviewResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/share-rules/preview [post]
+func (config *ShareRuleConfig) PostShareRulePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.ShareRuleRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	caller, ok := config.memberOfGroup(w, r, req.GroupID)
+	if !ok {
+		return
+	}
+
+	locations, shared, err := config.ShareRuleRepository.Preview(models.NewShareRuleEntry(req, caller.ID))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to preview share rule"})
+		return
+	}
+	previewResponse := models.NewShareRulePreviewResponse(locations, shared)
+	tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(previewResponse.Locations))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve tags"})
+		return
+	}
+	models.AttachTags(previewResponse.Locations, tags)
+
+	render.JSON(w, r, previewResponse)
+}
+
+// memberOfGroup returns the caller when they belong to the group of a rule,
+// rendering the error otherwise.
+func (config *ShareRuleConfig) memberOfGroup(w http.ResponseWriter, r *http.Request, groupID uint) (*dbmodel.UserEntry, bool) {
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, false
+	}
+	member, err := config.GroupEntryRepository.IsMember(groupID, caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return nil, false
+	}
+	return caller, true
+}
+
+// ownShareRule returns the share rule of the id parameter when the caller
+// owns it, rendering the error otherwise.
+func (config *ShareRuleConfig) ownShareRule(w http.ResponseWriter, r *http.Request) (*dbmodel.ShareRuleEntry, *dbmodel.UserEntry, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return nil, nil, false
+	}
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, nil, false
+	}
+	rule, err := config.ShareRuleRepository.FindById(uint(id))
+	if err != nil || rule.UserID != caller.ID {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve share rule"})
+		return nil, nil, false
+	}
+	return rule, caller, true
+}
+
+func (config *ShareRuleConfig) renderShareRule(w http.ResponseWriter, r *http.Request, rule *dbmodel.ShareRuleEntry) {
+	counts, err := config.ShareRuleRepository.CountShares(rule.UserID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve share rule"})
+		return
+	}
+
+	render.JSON(w, r, models.NewShareRuleResponse(rule, counts[rule.ID]))
+}
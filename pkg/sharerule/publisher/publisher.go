@@ -0,0 +1,52 @@
+// Package publisher tells the groups about the locations the share rules
+// shared in them, updated or took out of them.
+package publisher
+
+import (
+	"errors"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/events"
+	"locate-this/pkg/tiles"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+type Publisher struct {
+	locations dbmodel.LocationRepository
+	eventHub  *events.Hub
+	tileCache *tiles.Cache
+}
+
+func NewPublisher(locations dbmodel.LocationRepository, eventHub *events.Hub, tileCache *tiles.Cache) *Publisher {
+	return &Publisher{locations: locations, eventHub: eventHub, tileCache: tileCache}
+}
+
+// Publish sends the events of the changes the repositories made to the shares
+// of the rules, once committed, and invalidates the tiles of the locations
+// whose viewers changed.
+func (publisher *Publisher) Publish(changes dbmodel.ShareChanges) {
+	invalidated := make(map[uint]bool)
+	publish := func(eventType string, shares []dbmodel.LocationShare) {
+		for _, share := range shares {
+			publisher.eventHub.Publish(events.Event{Type: eventType, GroupID: share.GroupID, LocationID: share.LocationID})
+			if invalidated[share.LocationID] {
+				continue
+			}
+			invalidated[share.LocationID] = true
+			location, err := publisher.locations.FindById(share.LocationID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// a deleted location, whose tiles its deletion invalidates
+				continue
+			}
+			if err != nil {
+				log.Println("Failed to retrieve location", share.LocationID, err)
+				continue
+			}
+			publisher.tileCache.InvalidatePoint(location.Latitude, location.Longitude)
+		}
+	}
+	publish(events.LocationShared, changes.Shared)
+	publish(events.LocationUpdated, changes.Updated)
+	publish(events.LocationUnshared, changes.Unshared)
+}
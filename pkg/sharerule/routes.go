@@ -0,0 +1,29 @@
+package sharerule
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Share rules:
+- POST /share-rules
+- GET /share-rules
+- POST /share-rules/preview
+- GET /share-rules/{id}
+- PUT /share-rules/{id}
+- DELETE /share-rules/{id}
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	ShareRuleConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Post("/", ShareRuleConfig.PostShareRuleHandler)
+	router.Get("/", ShareRuleConfig.GetShareRulesHandler)
+	router.Post("/preview", ShareRuleConfig.PostShareRulePreviewHandler)
+	router.Get("/{id}", ShareRuleConfig.GetShareRuleByIDHandler)
+	router.Put("/{id}", ShareRuleConfig.PutShareRuleHandler)
+	router.Delete("/{id}", ShareRuleConfig.DeleteShareRuleHandler)
+	return router
+}
@@ -0,0 +1,54 @@
+package staticmap
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// drawBasemap draws the tiles of the directory covering the image, the world
+// repeated east and west. Missing tiles are left to the background. It
+// reports whether a tile was drawn.
+func drawBasemap(img *image.RGBA, v view, dir string) bool {
+	count := 1 << v.zoom
+	left := v.centerX - float64(v.width)/2
+	top := v.centerY - float64(v.height)/2
+	firstColumn, lastColumn := int(math.Floor(left/tileSize)), int(math.Floor((left+float64(v.width)-1)/tileSize))
+	firstRow, lastRow := int(math.Max(0, math.Floor(top/tileSize))), int(math.Min(float64(count-1), math.Floor((top+float64(v.height)-1)/tileSize)))
+
+	drawn := false
+	for row := firstRow; row <= lastRow; row++ {
+		for column := firstColumn; column <= lastColumn; column++ {
+			tile, ok := loadTile(dir, v.zoom, ((column%count)+count)%count, row)
+			if !ok {
+				continue
+			}
+			origin := image.Point{int(math.Round(float64(column*tileSize) - left)), int(math.Round(float64(row*tileSize) - top))}
+			draw.Draw(img, image.Rectangle{origin, origin.Add(image.Point{tileSize, tileSize})}, tile, tile.Bounds().Min, draw.Src)
+			drawn = true
+		}
+	}
+	return drawn
+}
+
+// loadTile reads a tile stored as {z}/{x}/{y}.png or .jpg.
+func loadTile(dir string, z, x, y int) (image.Image, bool) {
+	for _, extension := range []string{".png", ".jpg"} {
+		file, err := os.Open(filepath.Join(dir, strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y)+extension))
+		if err != nil {
+			continue
+		}
+		tile, _, err := image.Decode(file)
+		file.Close()
+		if err == nil && tile.Bounds().Dx() == tileSize && tile.Bounds().Dy() == tileSize {
+			return tile, true
+		}
+	}
+	return nil, false
+}
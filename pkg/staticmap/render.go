@@ -0,0 +1,251 @@
+package staticmap
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"locate-this/pkg/tiles"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tileSize is the size in pixels of a tile of the map at each zoom level.
+	tileSize = 256
+	// maxZoom is the deepest zoom level an image is fitted to, a few streets.
+	maxZoom = 17
+	// singleMarkerZoom is the zoom level of an image showing a single marker.
+	singleMarkerZoom = 15
+	// padding keeps the markers and their labels away from the edges.
+	padding     = 40
+	markerSize  = 7
+	labelScale  = 2
+	maxLabelLen = 24
+)
+
+var (
+	backgroundColor = color.RGBA{0xE8, 0xEC, 0xEF, 0xFF}
+	defaultColor    = color.RGBA{0xD3, 0x2F, 0x2F, 0xFF}
+	outlineColor    = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	labelColor      = color.RGBA{0x21, 0x21, 0x21, 0xFF}
+)
+
+type Marker struct {
+	Latitude  float64
+	Longitude float64
+	Label     string
+	Color     color.RGBA
+}
+
+type Options struct {
+	Width  int
+	Height int
+	// TilesDir is a directory of basemap tiles stored as {z}/{x}/{y}.png,
+	// the map is drawn on a plain background without it
+	TilesDir string
+	// Attribution is written in a corner when a basemap is drawn
+	Attribution string
+}
+
+// view is the part of the Web Mercator map shown in the image.
+type view struct {
+	zoom int
+	// center of the image, in pixels of the world map at the zoom level
+	centerX float64
+	centerY float64
+	width   int
+	height  int
+}
+
+// Render draws the markers on a Web Mercator map fitted to show them all,
+// labelled with their names where the labels do not overlap.
+func Render(markers []Marker, options Options) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, options.Width, options.Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{backgroundColor}, image.Point{}, draw.Src)
+
+	v := fit(markers, options.Width, options.Height)
+	basemap := options.TilesDir != "" && drawBasemap(img, v, options.TilesDir)
+
+	positions := make([]image.Point, len(markers))
+	for i, marker := range markers {
+		positions[i] = v.project(marker.Latitude, marker.Longitude)
+	}
+	// from north to south, so that the southern markers are drawn over
+	order := make([]int, len(markers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return positions[order[i]].Y < positions[order[j]].Y })
+	for _, i := range order {
+		drawMarker(img, positions[i], markers[i].Color)
+	}
+
+	// the labels go around the markers, never over them
+	taken := make([]image.Rectangle, 0, 2*len(markers))
+	for _, position := range positions {
+		taken = append(taken, image.Rectangle{position, position}.Inset(-markerSize-2))
+	}
+	for i, marker := range markers {
+		label := asciiText(strings.TrimSpace(marker.Label))
+		if len(label) > maxLabelLen {
+			label = strings.TrimSpace(label[:maxLabelLen-2]) + ".."
+		}
+		if label == "" {
+			continue
+		}
+		// on the right of the marker, or on its left near the right edge
+		width := textWidth(label, labelScale)
+		for _, offset := range []int{markerSize + 4, -markerSize - 4 - width} {
+			origin := positions[i].Add(image.Point{offset, -glyphHeight * labelScale / 2})
+			box := image.Rect(origin.X, origin.Y, origin.X+width, origin.Y+glyphHeight*labelScale).Inset(-2)
+			if box.In(img.Rect) && !overlaps(box, taken) {
+				taken = append(taken, box)
+				drawHaloText(img, label, origin, labelScale)
+				break
+			}
+		}
+	}
+
+	if basemap && options.Attribution != "" {
+		drawAttribution(img, asciiText(options.Attribution))
+	}
+	return img
+}
+
+// fit chooses the deepest zoom level showing every marker with the padding
+// around them, centered on them. The markers are taken on the shortest span
+// of longitudes, across the antimeridian when it is.
+func fit(markers []Marker, width, height int) view {
+	v := view{width: width, height: height}
+	if len(markers) == 0 {
+		v.centerX, v.centerY = tileSize/2, tileSize/2
+		return v
+	}
+
+	xs := make([]float64, len(markers))
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for i, marker := range markers {
+		x, y := tiles.Mercator(marker.Latitude, marker.Longitude)
+		xs[i] = x
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	minX, maxX := shortestSpan(xs)
+
+	v.zoom = singleMarkerZoom
+	if maxX > minX || maxY > minY {
+		v.zoom = 0
+		for zoom := maxZoom; zoom > 0; zoom-- {
+			scale := tileSize * math.Exp2(float64(zoom))
+			if (maxX-minX)*scale <= float64(width-2*padding) && (maxY-minY)*scale <= float64(height-2*padding) {
+				v.zoom = zoom
+				break
+			}
+		}
+	}
+	scale := tileSize * math.Exp2(float64(v.zoom))
+	v.centerX, v.centerY = (minX+maxX)/2*scale, (minY+maxY)/2*scale
+	return v
+}
+
+// shortestSpan returns the smallest interval of x coordinates of the Web
+// Mercator square holding every x, leaving out its largest gap. The upper
+// bound is beyond 1 when the interval crosses the antimeridian.
+func shortestSpan(xs []float64) (float64, float64) {
+	sort.Float64s(xs)
+	// the gap across the antimeridian, between the last and the first
+	gap, minX, maxX := xs[0]+1-xs[len(xs)-1], xs[0], xs[len(xs)-1]
+	for i := 1; i < len(xs); i++ {
+		if xs[i]-xs[i-1] > gap {
+			gap, minX, maxX = xs[i]-xs[i-1], xs[i], xs[i-1]+1
+		}
+	}
+	return minX, maxX
+}
+
+// project returns the pixel of the image of a position, the nearest copy of
+// the world when the map wraps around.
+func (v view) project(latitude, longitude float64) image.Point {
+	worldSize := tileSize * math.Exp2(float64(v.zoom))
+	x, y := tiles.Mercator(latitude, longitude)
+	x, y = x*worldSize-v.centerX, y*worldSize-v.centerY
+	x -= math.Round(x/worldSize) * worldSize
+	return image.Point{int(math.Round(x + float64(v.width)/2)), int(math.Round(y + float64(v.height)/2))}
+}
+
+// drawMarker draws a disc with an outline, smoothed on its edges.
+func drawMarker(img *image.RGBA, center image.Point, fill color.RGBA) {
+	if fill.A == 0 {
+		fill = defaultColor
+	}
+	outer := float64(markerSize + 2)
+	for y := center.Y - markerSize - 3; y <= center.Y+markerSize+3; y++ {
+		for x := center.X - markerSize - 3; x <= center.X+markerSize+3; x++ {
+			if !(image.Point{x, y}).In(img.Rect) {
+				continue
+			}
+			distance := math.Hypot(float64(x-center.X), float64(y-center.Y))
+			if coverage := clamp(outer + 0.5 - distance); coverage > 0 {
+				blend(img, x, y, outlineColor, coverage)
+			}
+			if coverage := clamp(markerSize + 0.5 - distance); coverage > 0 {
+				blend(img, x, y, fill, coverage)
+			}
+		}
+	}
+}
+
+// drawHaloText draws a text surrounded by a white halo, readable on any
+// background.
+func drawHaloText(img *image.RGBA, text string, origin image.Point, scale int) {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx != 0 || dy != 0 {
+				drawText(img, text, origin.Add(image.Point{dx, dy}), scale, outlineColor)
+			}
+		}
+	}
+	drawText(img, text, origin, scale, labelColor)
+}
+
+func drawAttribution(img *image.RGBA, text string) {
+	width := textWidth(text, 1)
+	box := image.Rect(img.Rect.Max.X-width-6, img.Rect.Max.Y-glyphHeight-6, img.Rect.Max.X, img.Rect.Max.Y)
+	draw.Draw(img, box, &image.Uniform{color.RGBA{0xFF, 0xFF, 0xFF, 0xC0}}, image.Point{}, draw.Over)
+	drawText(img, text, box.Min.Add(image.Point{3, 3}), 1, labelColor)
+}
+
+func overlaps(box image.Rectangle, boxes []image.Rectangle) bool {
+	for _, other := range boxes {
+		if box.Overlaps(other) {
+			return true
+		}
+	}
+	return false
+}
+
+func blend(img *image.RGBA, x, y int, c color.RGBA, coverage float64) {
+	under := img.RGBAAt(x, y)
+	mix := func(top, bottom uint8) uint8 {
+		return uint8(math.Round(float64(top)*coverage + float64(bottom)*(1-coverage)))
+	}
+	img.SetRGBA(x, y, color.RGBA{mix(c.R, under.R), mix(c.G, under.G), mix(c.B, under.B), 0xFF})
+}
+
+func clamp(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
+
+// ParseColor reads a color written #rrggbb, as the colors of the locations.
+func ParseColor(text string) (color.RGBA, bool) {
+	text = strings.TrimPrefix(strings.TrimSpace(text), "#")
+	if len(text) != 6 {
+		return color.RGBA{}, false
+	}
+	value, err := strconv.ParseUint(text, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xFF}, true
+}
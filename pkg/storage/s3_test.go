@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "eu-west-3"
+	testBucket    = "attachments"
+)
+
+// s3Stub is a minimal S3 server keeping the objects in memory. It checks the
+// Signature Version 4 of every request the way S3 does, from the request it
+// received, and answers 403 when it does not match.
+type s3Stub struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	paths   []string
+}
+
+func newS3Stub(t *testing.T) (*s3Stub, *httptest.Server) {
+	stub := &s3Stub{objects: make(map[string][]byte), types: make(map[string]string)}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func (stub *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := verifySignature(r, testSecretKey); err != nil {
+		http.Error(w, "SignatureDoesNotMatch: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	prefix := "/" + testBucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	stub.paths = append(stub.paths, r.URL.EscapedPath())
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if int64(len(body)) != r.ContentLength {
+			http.Error(w, "IncompleteBody", http.StatusBadRequest)
+			return
+		}
+		stub.objects[key] = body
+		stub.types[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := stub.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", stub.types[key])
+		w.Write(body)
+	case http.MethodDelete:
+		if _, ok := stub.objects[key]; !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		delete(stub.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verifySignature recomputes the signature of r following the AWS
+// documentation, independently of S3Store.sign.
+func verifySignature(r *http.Request, secretKey string) error {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 ") {
+		return errors.New("missing AWS4-HMAC-SHA256 authorization")
+	}
+	fields := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, "AWS4-HMAC-SHA256 "), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		fields[name] = value
+	}
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[0] != testAccessKey || credential[2] != testRegion || credential[3] != "s3" || credential[4] != "aws4_request" {
+		return errors.New("bad credential scope " + fields["Credential"])
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if !strings.HasPrefix(amzDate, credential[1]) {
+		return errors.New("X-Amz-Date does not match the credential date")
+	}
+
+	signed := strings.Split(fields["SignedHeaders"], ";")
+	if !sort.StringsAreSorted(signed) {
+		return errors.New("signed headers are not sorted")
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range signed {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	canonicalRequest := r.Method + "\n" + r.URL.EscapedPath() + "\n" + r.URL.RawQuery + "\n" +
+		canonicalHeaders.String() + "\n" + fields["SignedHeaders"] + "\n" + payloadHash
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	scope := strings.Join(credential[1:], "/")
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), credential[1])
+	key = hmacSHA256(key, credential[2])
+	key = hmacSHA256(key, credential[3])
+	key = hmacSHA256(key, credential[4])
+	if expected := hex.EncodeToString(hmacSHA256(key, stringToSign)); expected != fields["Signature"] {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func newTestS3Store(t *testing.T, endpoint, secretKey string) *S3Store {
+	store, err := NewS3Store(S3Options{Endpoint: endpoint + "/", Region: testRegion, Bucket: testBucket, AccessKey: testAccessKey, SecretKey: secretKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestS3StorePutGetDelete(t *testing.T) {
+	stub, server := newS3Stub(t)
+	store := newTestS3Store(t, server.URL, testSecretKey)
+	ctx := context.Background()
+
+	keys := []string{
+		"attachments/12/0f3a",
+		"attachments/12/photo de l'été.jpg",
+	}
+	for _, key := range keys {
+		data := []byte("content of " + key)
+		if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+		if got := stub.types[key]; got != "image/jpeg" {
+			t.Errorf("Put(%q) stored content type %q, want image/jpeg", key, got)
+		}
+
+		body, err := store.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		got, _ := io.ReadAll(body)
+		body.Close()
+		if !bytes.Equal(got, data) {
+			t.Errorf("Get(%q) = %q, want %q", key, got, data)
+		}
+
+		if err := store.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete(%q): %v", key, err)
+		}
+		if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) after Delete: err = %v, want ErrNotFound", key, err)
+		}
+	}
+	if len(stub.objects) != 0 {
+		t.Errorf("%d objects left in the bucket", len(stub.objects))
+	}
+	if want := "/attachments/attachments/12/photo%20de%20l%27%C3%A9t%C3%A9.jpg"; stub.paths[4] != want {
+		t.Errorf("escaped path = %q, want %q", stub.paths[4], want)
+	}
+}
+
+func TestS3StoreMissingObject(t *testing.T) {
+	_, server := newS3Stub(t)
+	store := newTestS3Store(t, server.URL, testSecretKey)
+
+	if _, err := store.Get(context.Background(), "attachments/1/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get: err = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(context.Background(), "attachments/1/missing"); err != nil {
+		t.Errorf("Delete of a missing object: err = %v, want nil", err)
+	}
+}
+
+func TestS3StoreRejectedSignature(t *testing.T) {
+	_, server := newS3Stub(t)
+	store := newTestS3Store(t, server.URL, "not-the-secret")
+
+	err := store.Put(context.Background(), "attachments/1/a", strings.NewReader("a"), 1, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put with a wrong secret: err = %v, want a 403 error", err)
+	}
+}
+
+func TestS3StoreServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "SlowDown", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	store := newTestS3Store(t, server.URL, testSecretKey)
+
+	_, err := store.Get(context.Background(), "attachments/1/a")
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "SlowDown") {
+		t.Errorf("Get: err = %v, want the server error", err)
+	}
+}
+
+func TestS3StoreSign(t *testing.T) {
+	store := newTestS3Store(t, "https://s3.example.com", testSecretKey)
+	req, _ := http.NewRequest(http.MethodGet, store.objectURL("attachments/1/a b"), nil)
+	store.sign(req, time.Date(2013, 5, 24, 0, 0, 0, 0, time.UTC))
+
+	if got := req.Header.Get("X-Amz-Date"); got != "20130524T000000Z" {
+		t.Errorf("X-Amz-Date = %q", got)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20130524/eu-west-3/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if got := req.Header.Get("Authorization"); !strings.HasPrefix(got, want) {
+		t.Errorf("Authorization = %q, want prefix %q", got, want)
+	}
+	if err := verifySignature(req, testSecretKey); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	if err := verifySignature(req, "other"); err == nil {
+		t.Error("signature verifies with another secret")
+	}
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores binary objects such as attachments under a key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New builds the store selected by the STORAGE_DRIVER environment variable,
+// "local" (default) or "s3".
+func New() (BlobStore, error) {
+	switch os.Getenv("STORAGE_DRIVER") {
+	case "", "local":
+		root := os.Getenv("STORAGE_PATH")
+		if root == "" {
+			root = "uploads"
+		}
+		return NewLocalStore(root)
+	case "s3":
+		return NewS3Store(S3Options{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+	default:
+		return nil, errors.New("unknown STORAGE_DRIVER")
+	}
+}
+
+// NewKey returns a random key under prefix.
+func NewKey(prefix string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return path.Join(prefix, hex.EncodeToString(random)), nil
+}
@@ -0,0 +1,63 @@
+package tag
+
+import (
+	"locate-this/config"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/models"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+const defaultAutocompleteLimit = 10
+
+type TagConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *TagConfig {
+	return &TagConfig{configuration}
+}
+
+// @Summary		Autocomplete tags
+// @Description	Retrieve the caller's tags starting with a prefix
+// @Tags			tags
+// @Accept			json
+// @Produce		json
+// @Param			prefix	query		string	false	"Tag prefix"
+// @Param			limit	query		int		false	"Maximum number of tags"
+// @Success		200	{array}	models.TagResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/tags [get]
+func (config *TagConfig) GetTagsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultAutocompleteLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			render.JSON(w, r, map[string]string{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = parsed
+	}
+
+	user, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	tags, err := config.TagRepository.FindByPrefix(user.ID, models.NormalizeTag(r.URL.Query().Get("prefix")), limit)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve tags"})
+		return
+	}
+
+	tagsResponse := make([]models.TagResponse, 0)
+	for _, tag := range tags {
+		tagsResponse = append(tagsResponse, models.TagResponse{ID: tag.ID, Name: tag.Name})
+	}
+
+	render.JSON(w, r, tagsResponse)
+}
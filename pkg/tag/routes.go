@@ -0,0 +1,19 @@
+package tag
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Tags:
+- GET /tags?prefix=
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	TagConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Get("/", TagConfig.GetTagsHandler)
+	return router
+}
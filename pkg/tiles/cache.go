@@ -0,0 +1,154 @@
+package tiles
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// Entry is an encoded tile with its ETag.
+type Entry struct {
+	Data []byte
+	ETag string
+}
+
+func NewEntry(data []byte) *Entry {
+	sum := sha256.Sum256(data)
+	return &Entry{Data: data, ETag: `"` + hex.EncodeToString(sum[:16]) + `"`}
+}
+
+// Cache keeps the tiles rendered for each user, evicting the least recently
+// used ones beyond its size. A tile depends on the locations it covers and on
+// those the user may see, so the tiles are dropped when a location in them
+// changes and all the tiles of a user when their groups change.
+type Cache struct {
+	size  int
+	mutex sync.Mutex
+	// generation is increased by every invalidation, a tile rendered before
+	// one may be outdated and is not kept
+	generation uint64
+	// entries by user and tile, most recently used first
+	order   *list.List
+	entries map[cacheKey]*list.Element
+	byTile  map[Tile]map[uint]*list.Element
+	byUser  map[uint]map[Tile]*list.Element
+}
+
+type cacheKey struct {
+	userID uint
+	tile   Tile
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	entry *Entry
+}
+
+// NewCache returns a cache of size tiles, 0 to disable it.
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[cacheKey]*list.Element),
+		byTile:  make(map[Tile]map[uint]*list.Element),
+		byUser:  make(map[uint]map[Tile]*list.Element),
+	}
+}
+
+func (cache *Cache) Get(userID uint, tile Tile) (*Entry, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	element, ok := cache.entries[cacheKey{userID, tile}]
+	if !ok {
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	return element.Value.(*cacheEntry).entry, true
+}
+
+// Generation returns the current generation, to be read before rendering a
+// tile and given back to Put.
+func (cache *Cache) Generation() uint64 {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.generation
+}
+
+// Put keeps a tile rendered during a generation, unless the cache was
+// invalidated since.
+func (cache *Cache) Put(userID uint, tile Tile, entry *Entry, generation uint64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.size <= 0 || generation != cache.generation {
+		return
+	}
+	key := cacheKey{userID, tile}
+	if element, ok := cache.entries[key]; ok {
+		element.Value.(*cacheEntry).entry = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	element := cache.order.PushFront(&cacheEntry{key: key, entry: entry})
+	cache.entries[key] = element
+	if cache.byTile[tile] == nil {
+		cache.byTile[tile] = make(map[uint]*list.Element)
+	}
+	cache.byTile[tile][userID] = element
+	if cache.byUser[userID] == nil {
+		cache.byUser[userID] = make(map[Tile]*list.Element)
+	}
+	cache.byUser[userID][tile] = element
+	for cache.order.Len() > cache.size {
+		cache.remove(cache.order.Back())
+	}
+}
+
+// InvalidatePoint drops the tiles of every user containing a point, at every
+// zoom level.
+func (cache *Cache) InvalidatePoint(latitude, longitude float64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.generation++
+	for zoom := 0; zoom <= MaxZoom; zoom++ {
+		for _, element := range cache.byTile[TileAt(latitude, longitude, zoom)] {
+			cache.remove(element)
+		}
+	}
+}
+
+// InvalidateUser drops the tiles of a user.
+func (cache *Cache) InvalidateUser(userID uint) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.generation++
+	for _, element := range cache.byUser[userID] {
+		cache.remove(element)
+	}
+}
+
+// InvalidateAll drops every tile, when the change is too broad to be
+// followed.
+func (cache *Cache) InvalidateAll() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.generation++
+	cache.order.Init()
+	cache.entries = make(map[cacheKey]*list.Element)
+	cache.byTile = make(map[Tile]map[uint]*list.Element)
+	cache.byUser = make(map[uint]map[Tile]*list.Element)
+}
+
+func (cache *Cache) remove(element *list.Element) {
+	key := element.Value.(*cacheEntry).key
+	cache.order.Remove(element)
+	delete(cache.entries, key)
+	delete(cache.byTile[key.tile], key.userID)
+	if len(cache.byTile[key.tile]) == 0 {
+		delete(cache.byTile, key.tile)
+	}
+	delete(cache.byUser[key.userID], key.tile)
+	if len(cache.byUser[key.userID]) == 0 {
+		delete(cache.byUser, key.userID)
+	}
+}
@@ -0,0 +1,204 @@
+package tiles
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// Extent is the number of units along a side of the tiles, in which the
+// positions of the features are given.
+const Extent = 4096
+
+// Field numbers and types of the Mapbox Vector Tile protobuf schema, version 2.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+
+	tileLayers = 3
+
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+	layerVersion  = 15
+
+	featureID       = 1
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+	valueDouble = 3
+	valueUint   = 5
+	valueSint   = 6
+	valueBool   = 7
+
+	geometryPoint = 1
+	commandMoveTo = 1
+)
+
+// Layer is a layer of point features of a vector tile. The keys and values of
+// the properties are shared by its features, each one written once.
+type Layer struct {
+	name     string
+	features [][]byte
+	keys     []string
+	keyIndex map[string]uint32
+	values   [][]byte
+	// index of the encoded values
+	valueIndex map[string]uint32
+}
+
+func NewLayer(name string) *Layer {
+	return &Layer{name: name, keyIndex: make(map[string]uint32), valueIndex: make(map[string]uint32)}
+}
+
+func (layer *Layer) Len() int {
+	return len(layer.features)
+}
+
+// AddPoint adds a point at a position of the tile, in Extent units. An id of
+// 0 is left out. The properties are strings, integers, floats or booleans,
+// the others are ignored.
+func (layer *Layer) AddPoint(id uint64, x, y int, properties map[string]any) {
+	var feature []byte
+	if id != 0 {
+		feature = appendTag(feature, featureID, wireVarint)
+		feature = binary.AppendUvarint(feature, id)
+	}
+
+	// sorted, so that the same features always make the same tile
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var tags []byte
+	for _, key := range keys {
+		value, ok := encodeValue(properties[key])
+		if !ok {
+			continue
+		}
+		tags = binary.AppendUvarint(tags, uint64(layer.key(key)))
+		tags = binary.AppendUvarint(tags, uint64(layer.value(value)))
+	}
+	if len(tags) > 0 {
+		feature = appendBytes(feature, featureTags, tags)
+	}
+
+	feature = appendTag(feature, featureType, wireVarint)
+	feature = binary.AppendUvarint(feature, geometryPoint)
+	var geometry []byte
+	geometry = binary.AppendUvarint(geometry, commandMoveTo|1<<3)
+	geometry = binary.AppendUvarint(geometry, zigzag(int64(x)))
+	geometry = binary.AppendUvarint(geometry, zigzag(int64(y)))
+	feature = appendBytes(feature, featureGeometry, geometry)
+
+	layer.features = append(layer.features, feature)
+}
+
+func (layer *Layer) key(key string) uint32 {
+	index, ok := layer.keyIndex[key]
+	if !ok {
+		index = uint32(len(layer.keys))
+		layer.keys = append(layer.keys, key)
+		layer.keyIndex[key] = index
+	}
+	return index
+}
+
+func (layer *Layer) value(value []byte) uint32 {
+	index, ok := layer.valueIndex[string(value)]
+	if !ok {
+		index = uint32(len(layer.values))
+		layer.values = append(layer.values, value)
+		layer.valueIndex[string(value)] = index
+	}
+	return index
+}
+
+func (layer *Layer) encode() []byte {
+	var data []byte
+	data = appendTag(data, layerVersion, wireVarint)
+	data = binary.AppendUvarint(data, 2)
+	data = appendBytes(data, layerName, []byte(layer.name))
+	for _, feature := range layer.features {
+		data = appendBytes(data, layerFeatures, feature)
+	}
+	for _, key := range layer.keys {
+		data = appendBytes(data, layerKeys, []byte(key))
+	}
+	for _, value := range layer.values {
+		data = appendBytes(data, layerValues, value)
+	}
+	data = appendTag(data, layerExtent, wireVarint)
+	return binary.AppendUvarint(data, Extent)
+}
+
+// Encode returns the protobuf encoding of a tile made of the layers, the
+// empty ones being left out.
+func Encode(layers ...*Layer) []byte {
+	data := []byte{}
+	for _, layer := range layers {
+		if layer.Len() > 0 {
+			data = appendBytes(data, tileLayers, layer.encode())
+		}
+	}
+	return data
+}
+
+// encodeValue returns the Value message of a property.
+func encodeValue(value any) ([]byte, bool) {
+	var data []byte
+	switch value := value.(type) {
+	case string:
+		return appendBytes(data, valueString, []byte(value)), true
+	case bool:
+		data = appendTag(data, valueBool, wireVarint)
+		if value {
+			return binary.AppendUvarint(data, 1), true
+		}
+		return binary.AppendUvarint(data, 0), true
+	case int:
+		return encodeInteger(int64(value)), true
+	case int64:
+		return encodeInteger(value), true
+	case uint:
+		data = appendTag(data, valueUint, wireVarint)
+		return binary.AppendUvarint(data, uint64(value)), true
+	case uint64:
+		data = appendTag(data, valueUint, wireVarint)
+		return binary.AppendUvarint(data, value), true
+	case float64:
+		data = appendTag(data, valueDouble, wireFixed64)
+		return binary.LittleEndian.AppendUint64(data, math.Float64bits(value)), true
+	}
+	return nil, false
+}
+
+func encodeInteger(value int64) []byte {
+	var data []byte
+	if value >= 0 {
+		data = appendTag(data, valueUint, wireVarint)
+		return binary.AppendUvarint(data, uint64(value))
+	}
+	data = appendTag(data, valueSint, wireVarint)
+	return binary.AppendUvarint(data, zigzag(value))
+}
+
+func appendTag(data []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(data, uint64(field<<3|wireType))
+}
+
+func appendBytes(data []byte, field int, value []byte) []byte {
+	data = appendTag(data, field, wireBytes)
+	data = binary.AppendUvarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+func zigzag(value int64) uint64 {
+	return uint64(value<<1) ^ uint64(value>>63)
+}
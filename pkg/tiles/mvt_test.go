@@ -0,0 +1,276 @@
+package tiles
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// field is a field of a protobuf message, its varint or fixed64 value or its
+// bytes.
+type field struct {
+	number int
+	wire   int
+	value  uint64
+	bytes  []byte
+}
+
+// decodeMessage reads the fields of a protobuf message.
+func decodeMessage(t *testing.T, data []byte) []field {
+	t.Helper()
+	var fields []field
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatalf("bad tag in %x", data)
+		}
+		data = data[n:]
+		f := field{number: int(tag >> 3), wire: int(tag & 7)}
+		switch f.wire {
+		case wireVarint:
+			f.value, n = binary.Uvarint(data)
+			if n <= 0 {
+				t.Fatalf("bad varint in %x", data)
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				t.Fatalf("short fixed64 in %x", data)
+			}
+			f.value, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				t.Fatalf("bad length in %x", data)
+			}
+			f.bytes, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			t.Fatalf("wire type %d in %x", f.wire, data)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+type decodedFeature struct {
+	id         uint64
+	geometry   []uint64
+	properties map[string]any
+}
+
+type decodedLayer struct {
+	version  uint64
+	name     string
+	extent   uint64
+	features []decodedFeature
+}
+
+// decodeTile reads the point layers of a tile and resolves the properties of
+// their features.
+func decodeTile(t *testing.T, data []byte) []decodedLayer {
+	t.Helper()
+	var layers []decodedLayer
+	for _, tileField := range decodeMessage(t, data) {
+		if tileField.number != tileLayers || tileField.wire != wireBytes {
+			t.Fatalf("tile field %+v", tileField)
+		}
+		var layer decodedLayer
+		var keys []string
+		var values []any
+		var features [][]field
+		for _, f := range decodeMessage(t, tileField.bytes) {
+			switch f.number {
+			case layerVersion:
+				layer.version = f.value
+			case layerName:
+				layer.name = string(f.bytes)
+			case layerExtent:
+				layer.extent = f.value
+			case layerKeys:
+				keys = append(keys, string(f.bytes))
+			case layerValues:
+				values = append(values, decodeValue(t, f.bytes))
+			case layerFeatures:
+				features = append(features, decodeMessage(t, f.bytes))
+			default:
+				t.Fatalf("layer field %+v", f)
+			}
+		}
+		for _, fields := range features {
+			feature := decodedFeature{properties: map[string]any{}}
+			for _, f := range fields {
+				switch f.number {
+				case featureID:
+					feature.id = f.value
+				case featureType:
+					if f.value != geometryPoint {
+						t.Fatalf("feature type %d", f.value)
+					}
+				case featureTags:
+					tags := unpackVarints(t, f.bytes)
+					if len(tags)%2 != 0 {
+						t.Fatalf("odd tags %v", tags)
+					}
+					for i := 0; i < len(tags); i += 2 {
+						if tags[i] >= uint64(len(keys)) || tags[i+1] >= uint64(len(values)) {
+							t.Fatalf("tags %v beyond %d keys and %d values", tags, len(keys), len(values))
+						}
+						feature.properties[keys[tags[i]]] = values[tags[i+1]]
+					}
+				case featureGeometry:
+					feature.geometry = unpackVarints(t, f.bytes)
+				}
+			}
+			layer.features = append(layer.features, feature)
+		}
+		layers = append(layers, layer)
+	}
+	return layers
+}
+
+func decodeValue(t *testing.T, data []byte) any {
+	fields := decodeMessage(t, data)
+	if len(fields) != 1 {
+		t.Fatalf("value %x has %d fields", data, len(fields))
+	}
+	switch f := fields[0]; f.number {
+	case valueString:
+		return string(f.bytes)
+	case valueDouble:
+		return math.Float64frombits(f.value)
+	case valueUint:
+		return f.value
+	case valueSint:
+		return int64(f.value>>1) ^ -int64(f.value&1)
+	case valueBool:
+		return f.value == 1
+	}
+	t.Fatalf("value field %+v", fields[0])
+	return nil
+}
+
+func unpackVarints(t *testing.T, data []byte) []uint64 {
+	var values []uint64
+	for len(data) > 0 {
+		value, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatalf("bad packed varint in %x", data)
+		}
+		values, data = append(values, value), data[n:]
+	}
+	return values
+}
+
+func TestZigzag(t *testing.T) {
+	tests := []struct {
+		value int64
+		want  uint64
+	}{
+		{0, 0},
+		{-1, 1},
+		{1, 2},
+		{-2, 3},
+		{25, 50},
+		{17, 34},
+		{4096, 8192},
+		{-4096, 8191},
+		{math.MaxInt64, math.MaxUint64 - 1},
+		{math.MinInt64, math.MaxUint64},
+	}
+	for _, test := range tests {
+		if got := zigzag(test.value); got != test.want {
+			t.Errorf("zigzag(%d) = %d, want %d", test.value, got, test.want)
+		}
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	tests := []struct {
+		value any
+		want  []byte
+		ok    bool
+	}{
+		{"Lyon", []byte{0x0a, 4, 'L', 'y', 'o', 'n'}, true},
+		{"", []byte{0x0a, 0}, true},
+		{true, []byte{0x38, 1}, true},
+		{false, []byte{0x38, 0}, true},
+		{0, []byte{0x28, 0}, true},
+		{300, []byte{0x28, 0xac, 0x02}, true},
+		{-1, []byte{0x30, 1}, true},
+		{int64(-300), []byte{0x30, 0xd7, 0x04}, true},
+		{uint(7), []byte{0x28, 7}, true},
+		{uint64(1) << 40, []byte{0x28, 0x80, 0x80, 0x80, 0x80, 0x80, 0x20}, true},
+		{1.5, []byte{0x19, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f}, true},
+		{float32(1.5), nil, false},
+		{nil, nil, false},
+		{[]string{"a"}, nil, false},
+	}
+	for _, test := range tests {
+		got, ok := encodeValue(test.value)
+		if ok != test.ok || !bytes.Equal(got, test.want) {
+			t.Errorf("encodeValue(%#v) = %x, %v, want %x, %v", test.value, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestEncodePoint(t *testing.T) {
+	// the point of the example of the specification, at (25, 17)
+	layer := NewLayer("points")
+	layer.AddPoint(0, 25, 17, nil)
+	want := []byte{
+		0x1a, 0x16, // layers
+		0x78, 0x02, // version 2
+		0x0a, 0x06, 'p', 'o', 'i', 'n', 't', 's', // name
+		0x12, 0x07, // features
+		0x18, 0x01, // type point
+		0x22, 0x03, 0x09, 0x32, 0x22, // geometry MoveTo(25, 17)
+		0x28, 0x80, 0x20, // extent 4096
+	}
+	if got := Encode(layer); !bytes.Equal(got, want) {
+		t.Errorf("Encode = %x, want %x", got, want)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	locations := NewLayer("locations")
+	locations.AddPoint(12, 0, 0, map[string]any{"name": "Lyon", "category": "city", "visible": true})
+	locations.AddPoint(13, Extent, Extent, map[string]any{"name": "Villeurbanne", "category": "city", "altitude": 170.5, "ignored": []int{1}})
+	locations.AddPoint(1<<40, -64, 4160, map[string]any{"rank": -3, "count": uint(2)})
+	clusters := NewLayer("clusters")
+	clusters.AddPoint(0, 2048, 2048, map[string]any{"count": 42})
+
+	layers := decodeTile(t, Encode(locations, NewLayer("empty"), clusters))
+	want := []decodedLayer{
+		{version: 2, name: "locations", extent: Extent, features: []decodedFeature{
+			{id: 12, geometry: []uint64{9, 0, 0}, properties: map[string]any{"name": "Lyon", "category": "city", "visible": true}},
+			{id: 13, geometry: []uint64{9, 8192, 8192}, properties: map[string]any{"name": "Villeurbanne", "category": "city", "altitude": 170.5}},
+			// points in the buffer around the tile
+			{id: 1 << 40, geometry: []uint64{9, 127, 8320}, properties: map[string]any{"rank": int64(-3), "count": uint64(2)}},
+		}},
+		{version: 2, name: "clusters", extent: Extent, features: []decodedFeature{
+			{geometry: []uint64{9, 4096, 4096}, properties: map[string]any{"count": uint64(42)}},
+		}},
+	}
+	if !reflect.DeepEqual(layers, want) {
+		t.Errorf("decoded tile = %+v, want %+v", layers, want)
+	}
+
+	// the keys and values shared by the features are written once
+	if len(locations.keys) != 6 || len(locations.values) != 7 {
+		t.Errorf("keys %q and %d values", locations.keys, len(locations.values))
+	}
+	if got := Encode(locations, clusters); !bytes.Equal(got, Encode(locations, clusters)) {
+		t.Error("the same layers make different tiles")
+	}
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	if got := Encode(); got == nil || len(got) != 0 {
+		t.Errorf("Encode() = %#v, want an empty tile", got)
+	}
+	if got := Encode(NewLayer("locations")); len(got) != 0 {
+		t.Errorf("Encode of an empty layer = %x", got)
+	}
+}
@@ -0,0 +1,84 @@
+package tiles
+
+import (
+	"locate-this/pkg/geo"
+	"math"
+)
+
+// MaxZoom is the deepest zoom level of the tiles.
+const MaxZoom = 22
+
+// maxMercatorLatitude is the latitude where the Web Mercator map ends.
+const maxMercatorLatitude = 85.0511287798
+
+// Tile is a tile of the Web Mercator map, numbered from the northwest corner
+// as in the XYZ scheme of the map libraries.
+type Tile struct {
+	Z int
+	X int
+	Y int
+}
+
+func (tile Tile) Valid() bool {
+	if tile.Z < 0 || tile.Z > MaxZoom {
+		return false
+	}
+	count := 1 << tile.Z
+	return tile.X >= 0 && tile.X < count && tile.Y >= 0 && tile.Y < count
+}
+
+// Bounds returns the box of the tile. The tiles of the first and last rows
+// reach the poles, the points beyond the end of the map being drawn on its
+// edge.
+func (tile Tile) Bounds() geo.Bounds {
+	count := math.Exp2(float64(tile.Z))
+	bounds := geo.Bounds{
+		South: tileLatitude(float64(tile.Y+1) / count),
+		West:  float64(tile.X)/count*360 - 180,
+		North: tileLatitude(float64(tile.Y) / count),
+		East:  float64(tile.X+1)/count*360 - 180,
+	}
+	if tile.Y == 0 {
+		bounds.North = 90
+	}
+	if tile.Y == int(count)-1 {
+		bounds.South = -90
+	}
+	return bounds
+}
+
+// Project returns the position of a point in the tile, in units of a tile
+// of extent units a side.
+func (tile Tile) Project(latitude, longitude float64, extent int) (int, int) {
+	count := math.Exp2(float64(tile.Z))
+	x, y := Mercator(latitude, longitude)
+	return int(math.Round((x*count - float64(tile.X)) * float64(extent))), int(math.Round((y*count - float64(tile.Y)) * float64(extent)))
+}
+
+// TileAt returns the tile containing a point at a zoom level.
+func TileAt(latitude, longitude float64, zoom int) Tile {
+	count := math.Exp2(float64(zoom))
+	x, y := Mercator(latitude, longitude)
+	return Tile{Z: zoom, X: tileIndex(x, count), Y: tileIndex(y, count)}
+}
+
+// Mercator projects a point on the Web Mercator square, x and y between 0
+// and 1 from the northwest corner.
+func Mercator(latitude, longitude float64) (float64, float64) {
+	latitude = math.Max(-maxMercatorLatitude, math.Min(maxMercatorLatitude, latitude))
+	x := (longitude + 180) / 360
+	y := (1 - math.Asinh(math.Tan(latitude*math.Pi/180))/math.Pi) / 2
+	return x, y
+}
+
+// tileIndex returns the index of the tile containing a coordinate of the
+// Web Mercator square, the last tile containing its edge.
+func tileIndex(value, count float64) int {
+	return int(math.Max(0, math.Min(math.Floor(value*count), count-1)))
+}
+
+// tileLatitude returns the latitude of a y coordinate of the Web Mercator
+// square.
+func tileLatitude(y float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y))) * 180 / math.Pi
+}
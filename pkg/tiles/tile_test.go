@@ -0,0 +1,79 @@
+package tiles
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTileAt(t *testing.T) {
+	tests := []struct {
+		latitude, longitude float64
+		zoom                int
+		want                Tile
+	}{
+		{45.76, 4.83, 0, Tile{0, 0, 0}},
+		{45.76, 4.83, 10, Tile{10, 525, 365}},
+		{48.8584, 2.2945, 15, Tile{15, 16592, 11272}},
+		{-33.8568, 151.2153, 12, Tile{12, 3768, 2457}},
+		// the edges of the map are in its last tiles
+		{90, 180, 3, Tile{3, 7, 0}},
+		{-90, -180, 3, Tile{3, 0, 7}},
+		{0, 0, 1, Tile{1, 1, 1}},
+	}
+	for _, test := range tests {
+		if got := TileAt(test.latitude, test.longitude, test.zoom); got != test.want || !got.Valid() {
+			t.Errorf("TileAt(%v, %v, %d) = %v, want %v", test.latitude, test.longitude, test.zoom, got, test.want)
+		}
+	}
+}
+
+func TestTileBoundsAndProject(t *testing.T) {
+	tests := []Tile{{0, 0, 0}, {1, 1, 0}, {10, 525, 365}, {3, 7, 7}, {22, 2097152, 1048576}}
+	for _, tile := range tests {
+		bounds := tile.Bounds()
+		if bounds.West >= bounds.East || bounds.South >= bounds.North {
+			t.Errorf("%v.Bounds() = %+v", tile, bounds)
+			continue
+		}
+		north := math.Min(bounds.North, maxMercatorLatitude)
+		south := math.Max(bounds.South, -maxMercatorLatitude)
+		corners := []struct {
+			latitude, longitude float64
+			x, y                int
+		}{
+			{north, bounds.West, 0, 0},
+			{south, bounds.East, Extent, Extent},
+			{bounds.North, bounds.West, 0, 0},
+		}
+		for _, corner := range corners {
+			if x, y := tile.Project(corner.latitude, corner.longitude, Extent); x != corner.x || y != corner.y {
+				t.Errorf("%v.Project(%v, %v) = %d, %d, want %d, %d", tile, corner.latitude, corner.longitude, x, y, corner.x, corner.y)
+			}
+		}
+		// the tile at the center of the bounds is the tile itself
+		center := TileAt((north+south)/2, (bounds.West+bounds.East)/2, tile.Z)
+		if center != tile {
+			t.Errorf("TileAt(center of %v) = %v", tile, center)
+		}
+	}
+}
+
+func TestTileValid(t *testing.T) {
+	tests := []struct {
+		tile  Tile
+		valid bool
+	}{
+		{Tile{0, 0, 0}, true},
+		{Tile{2, 3, 3}, true},
+		{Tile{2, 4, 0}, false},
+		{Tile{2, 0, -1}, false},
+		{Tile{-1, 0, 0}, false},
+		{Tile{MaxZoom, 1<<MaxZoom - 1, 0}, true},
+		{Tile{MaxZoom + 1, 0, 0}, false},
+	}
+	for _, test := range tests {
+		if got := test.tile.Valid(); got != test.valid {
+			t.Errorf("%v.Valid() = %v, want %v", test.tile, got, test.valid)
+		}
+	}
+}
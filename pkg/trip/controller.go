@@ -0,0 +1,548 @@
+package trip
+
+import (
+	"errors"
+	"fmt"
+	"locate-this/config"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/geo"
+	"locate-this/pkg/models"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type TripConfig struct {
+	*config.Config
+}
+
+func New(configuration *config.Config) *TripConfig {
+	return &TripConfig{configuration}
+}
+
+// @Summary		Create a trip
+// @Description	Create an ordered itinerary of locations whose coordinates the caller can see
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			request	body		models.TripRequest	true	"Trip data"
+// @Success		200		{object}	models.TripResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips [post]
+func (config *TripConfig) PostTripHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.TripRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	stops := make([]dbmodel.TripStopEntry, 0, len(req.LocationIDs))
+	for _, locationID := range req.LocationIDs {
+		if !config.visibleLocation(w, r, locationID, caller) {
+			return
+		}
+		stops = append(stops, dbmodel.TripStopEntry{LocationID: locationID})
+	}
+
+	tripEntry := &dbmodel.TripEntry{UserID: caller.ID, Name: req.Name, Description: req.Description}
+	res, err := config.TripRepository.Create(tripEntry, stops)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to create trip"})
+		return
+	}
+
+	config.renderTrip(w, r, res, caller)
+}
+
+// @Summary		Get the trips
+// @Description	Retrieve the trips of the caller and the ones shared in their groups, only those shared in a group when group_id is given
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			group_id	query		int	false	"Group ID"
+// @Success		200			{array}		models.TripResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips [get]
+func (config *TripConfig) GetTripsHandler(w http.ResponseWriter, r *http.Request) {
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+
+	var groupID *uint
+	if value := r.URL.Query().Get("group_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			render.JSON(w, r, map[string]string{"error": "group_id must be >= 1"})
+			return
+		}
+		member, err := config.GroupEntryRepository.IsMember(uint(id), caller.ID)
+		if err != nil || !member {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+			return
+		}
+		groupID = new(uint)
+		*groupID = uint(id)
+	}
+
+	trips, err := config.TripRepository.FindTripsForUser(caller.ID, groupID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve trips"})
+		return
+	}
+
+	tripsResponse := make([]models.TripResponse, 0, len(trips))
+	for i := range trips {
+		tripResponse, err := config.tripResponse(&trips[i], caller)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve trips"})
+			return
+		}
+		tripsResponse = append(tripsResponse, tripResponse)
+	}
+
+	render.JSON(w, r, tripsResponse)
+}
+
+// @Summary		Get a trip
+// @Description	Retrieve a trip of the caller or shared in one of their groups, with its stops in order and its length. The stops whose coordinates are hidden from the caller come without them and are left out of the length.
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Trip ID"
+// @Success		200	{object}	models.TripResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id} [get]
+func (config *TripConfig) GetTripByIDHandler(w http.ResponseWriter, r *http.Request) {
+	trip, caller, ok := config.visibleTrip(w, r)
+	if !ok {
+		return
+	}
+
+	config.renderTrip(w, r, trip, caller)
+}
+
+// @Summary		Export a trip as GPX
+// @Description	Download a trip as a GPX route through its stops, leaving out those whose coordinates are hidden from the caller
+// @Tags			trips
+// @Produce		application/gpx+xml
+// @Param			id	path		int	true	"Trip ID"
+// @Success		200	{file}		file
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id}/trip.gpx [get]
+func (config *TripConfig) GetTripGPXHandler(w http.ResponseWriter, r *http.Request) {
+	trip, caller, ok := config.visibleTrip(w, r)
+	if !ok {
+		return
+	}
+
+	stops, err := config.TripRepository.FindStops(trip.ID, caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve trip stops"})
+		return
+	}
+
+	w.Header().Set("Content-Type", models.ExportFormats["gpx"])
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "trip-" + strconv.Itoa(int(trip.ID)) + ".gpx"}))
+	if err := models.WriteTripGPX(w, trip, stops); err != nil {
+		log.Println("Failed to export trip:", err)
+	}
+}
+
+// @Summary		Update a trip
+// @Description	Rename a trip or change its description, allowed to its creator. The stops are changed with their own endpoints.
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int					true	"Trip ID"
+// @Param			request	body		models.TripRequest	true	"Trip data"
+// @Success		200		{object}	models.TripResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id} [put]
+func (config *TripConfig) PutTripHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.TripRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	trip, caller, ok := config.ownTrip(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := config.TripRepository.Update(&dbmodel.TripEntry{Name: req.Name, Description: req.Description}, trip.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to update trip"})
+		return
+	}
+
+	config.renderTrip(w, r, res, caller)
+}
+
+// @Summary		Delete a trip
+// @Description	Delete a trip, allowed to its creator. The locations are kept.
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"Trip ID"
+// @Success		200	{object}	map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id} [delete]
+func (config *TripConfig) DeleteTripHandler(w http.ResponseWriter, r *http.Request) {
+	trip, _, ok := config.ownTrip(w, r)
+	if !ok {
+		return
+	}
+
+	if err := config.TripRepository.Delete(trip.ID); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to delete trip"})
+		return
+	}
+
+	render.JSON(w, r, map[string]string{"message": "Trip deleted successfully"})
+}
+
+// @Summary		Add a stop to a trip
+// @Description	Insert a location whose coordinates the caller can see at a position of the trip, counted from 0, or after the last stop. Allowed to the creator of the trip.
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int						true	"Trip ID"
+// @Param			request	body		models.TripStopRequest	true	"Stop data"
+// @Success		200		{object}	models.TripResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id}/stops [post]
+func (config *TripConfig) PostTripStopHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.TripStopRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	trip, caller, ok := config.ownTrip(w, r)
+	if !ok {
+		return
+	}
+	if !config.visibleLocation(w, r, req.LocationID, caller) {
+		return
+	}
+	stops, err := config.TripRepository.FindStops(trip.ID, caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve trip stops"})
+		return
+	}
+	if len(stops) >= models.MaxTripStops {
+		render.JSON(w, r, map[string]string{"error": "A trip has at most 200 stops"})
+		return
+	}
+
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
+	stop := &dbmodel.TripStopEntry{LocationID: req.LocationID, Note: req.Note}
+	if err := config.TripRepository.InsertStop(trip.ID, stop, position); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to add trip stop"})
+		return
+	}
+
+	config.renderTrip(w, r, trip, caller)
+}
+
+// @Summary		Remove a stop from a trip
+// @Description	Remove a stop from a trip, allowed to its creator. The following stops move one position back.
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int	true	"Trip ID"
+// @Param			stopID	path		int	true	"Stop ID"
+// @Success		200		{object}	models.TripResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id}/stops/{stopID} [delete]
+func (config *TripConfig) DeleteTripStopHandler(w http.ResponseWriter, r *http.Request) {
+	stopID, err := strconv.Atoi(chi.URLParam(r, "stopID"))
+	if err != nil {
+		fmt.Println("Error during stopID convertion")
+	}
+	if stopID < 1 {
+		render.JSON(w, r, map[string]string{"error": "stopID must be >= 1"})
+		return
+	}
+
+	trip, caller, ok := config.ownTrip(w, r)
+	if !ok {
+		return
+	}
+
+	if err := config.TripRepository.RemoveStop(trip.ID, uint(stopID)); err != nil {
+		if errors.Is(err, dbmodel.ErrTripStopNotFound) {
+			render.JSON(w, r, map[string]string{"error": "Failed to retrieve trip stop"})
+			return
+		}
+		render.JSON(w, r, map[string]string{"error": "Failed to remove trip stop"})
+		return
+	}
+
+	config.renderTrip(w, r, trip, caller)
+}
+
+// @Summary		Reorder the stops of a trip
+// @Description	Visit the stops of a trip in a new order, given with every stop ID once. Allowed to the creator of the trip.
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int						true	"Trip ID"
+// @Param			request	body		models.TripOrderRequest	true	"Stop order"
+// @Success		200		{object}	models.TripResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id}/stops/order [put]
+func (config *TripConfig) PutTripStopOrderHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.TripOrderRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	trip, caller, ok := config.ownTrip(w, r)
+	if !ok {
+		return
+	}
+
+	if err := config.TripRepository.ReorderStops(trip.ID, req.StopIDs); err != nil {
+		if errors.Is(err, dbmodel.ErrTripStopNotFound) {
+			render.JSON(w, r, map[string]string{"error": "stop_ids must hold every stop of the trip once"})
+			return
+		}
+		render.JSON(w, r, map[string]string{"error": "Failed to reorder trip stops"})
+		return
+	}
+
+	config.renderTrip(w, r, trip, caller)
+}
+
+// @Summary		Optimize the order of the stops of a trip
+// @Description	Reorder the stops of a trip by going from the first one to the closest stop not visited yet, each time, which shortens the trip without guaranteeing the shortest order. The last stop stays last with keep_last. Allowed to the creator of the trip when every stop has visible coordinates.
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			id			path		int		true	"Trip ID"
+// @Param			keep_last	query		bool	false	"Keep the last stop last"
+// @Success		200			{object}	models.TripOptimizeResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id}/optimize [post]
+func (config *TripConfig) PostTripOptimizeHandler(w http.ResponseWriter, r *http.Request) {
+	keepLast := false
+	if value := r.URL.Query().Get("keep_last"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			render.JSON(w, r, map[string]string{"error": "keep_last must be true or false"})
+			return
+		}
+		keepLast = parsed
+	}
+
+	trip, caller, ok := config.ownTrip(w, r)
+	if !ok {
+		return
+	}
+	stops, err := config.TripRepository.FindStops(trip.ID, caller.ID)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve trip stops"})
+		return
+	}
+
+	points := make([]geo.Point, 0, len(stops))
+	for _, stop := range stops {
+		if !stop.CoordinatesVisible {
+			render.JSON(w, r, map[string]string{"error": "A trip with stops whose coordinates are hidden cannot be optimized"})
+			return
+		}
+		points = append(points, geo.Point{Latitude: stop.Latitude, Longitude: stop.Longitude})
+	}
+	previousLength := models.NewTripResponse(trip, stops, nil).Length
+
+	stopIDs := make([]uint, 0, len(stops))
+	for _, index := range geo.NearestNeighbourOrder(points, keepLast) {
+		stopIDs = append(stopIDs, stops[index].ID)
+	}
+	if err := config.TripRepository.ReorderStops(trip.ID, stopIDs); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to reorder trip stops"})
+		return
+	}
+
+	tripResponse, err := config.tripResponse(trip, caller)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve trip stops"})
+		return
+	}
+	render.JSON(w, r, models.TripOptimizeResponse{TripResponse: tripResponse, PreviousLength: previousLength})
+}
+
+// @Summary		Share a trip into a group
+// @Description	Let the members of a group of the caller see a trip of theirs. The stops keep the visibility of their locations in the groups of each member.
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int						true	"Trip ID"
+// @Param			request	body		models.TripShareRequest	true	"Group"
+// @Success		200		{object}	models.TripResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id}/groups [post]
+func (config *TripConfig) PostTripGroupHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.TripShareRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	trip, caller, ok := config.ownTrip(w, r)
+	if !ok {
+		return
+	}
+	member, err := config.GroupEntryRepository.IsMember(req.GroupID, caller.ID)
+	if err != nil || !member {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve group"})
+		return
+	}
+
+	if err := config.TripRepository.Share(trip.ID, req.GroupID); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to share trip"})
+		return
+	}
+
+	config.renderTrip(w, r, trip, caller)
+}
+
+// @Summary		Stop sharing a trip into a group
+// @Description	Remove a trip from a group, allowed to its creator
+// @Tags			trips
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int	true	"Trip ID"
+// @Param			groupID	path		int	true	"Group ID"
+// @Success		200		{object}	models.TripResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/trips/{id}/groups/{groupID} [delete]
+func (config *TripConfig) DeleteTripGroupHandler(w http.ResponseWriter, r *http.Request) {
+	groupID, err := strconv.Atoi(chi.URLParam(r, "groupID"))
+	if err != nil {
+		fmt.Println("Error during groupID convertion")
+	}
+	if groupID < 1 {
+		render.JSON(w, r, map[string]string{"error": "groupID must be >= 1"})
+		return
+	}
+
+	trip, caller, ok := config.ownTrip(w, r)
+	if !ok {
+		return
+	}
+
+	if err := config.TripRepository.Unshare(trip.ID, uint(groupID)); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to unshare trip"})
+		return
+	}
+
+	config.renderTrip(w, r, trip, caller)
+}
+
+// visibleTrip returns the trip of the id parameter when the caller created it
+// or belongs to a group it is shared in, rendering the error otherwise.
+func (config *TripConfig) visibleTrip(w http.ResponseWriter, r *http.Request) (*dbmodel.TripEntry, *dbmodel.UserEntry, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return nil, nil, false
+	}
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, nil, false
+	}
+	visible, err := config.TripRepository.IsVisibleTo(uint(id), caller.ID)
+	if err != nil || !visible {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve trip"})
+		return nil, nil, false
+	}
+	trip, err := config.TripRepository.FindById(uint(id))
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve trip"})
+		return nil, nil, false
+	}
+	return trip, caller, true
+}
+
+func (config *TripConfig) ownTrip(w http.ResponseWriter, r *http.Request) (*dbmodel.TripEntry, *dbmodel.UserEntry, bool) {
+	trip, caller, ok := config.visibleTrip(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	if trip.UserID != caller.ID {
+		render.JSON(w, r, map[string]string{"error": "Only the creator of the trip can do this"})
+		return nil, nil, false
+	}
+	return trip, caller, true
+}
+
+// visibleLocation checks that the caller can see the coordinates of a
+// location to visit, rendering the error otherwise.
+func (config *TripConfig) visibleLocation(w http.ResponseWriter, r *http.Request, locationID uint, caller *dbmodel.UserEntry) bool {
+	visible, err := config.LocationEntryRepository.AreCoordinatesVisibleTo(locationID, caller.ID)
+	if err != nil || !visible {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve location " + strconv.Itoa(int(locationID))})
+		return false
+	}
+	return true
+}
+
+// tripResponse returns the trip with its stops as seen by the caller, and the
+// groups it is shared in when the caller created it.
+func (config *TripConfig) tripResponse(trip *dbmodel.TripEntry, caller *dbmodel.UserEntry) (models.TripResponse, error) {
+	stops, err := config.TripRepository.FindStops(trip.ID, caller.ID)
+	if err != nil {
+		return models.TripResponse{}, err
+	}
+	var groupIDs []uint
+	if trip.UserID == caller.ID {
+		if groupIDs, err = config.TripRepository.FindGroupIDsForTrip(trip.ID); err != nil {
+			return models.TripResponse{}, err
+		}
+	}
+	return models.NewTripResponse(trip, stops, groupIDs), nil
+}
+
+func (config *TripConfig) renderTrip(w http.ResponseWriter, r *http.Request, trip *dbmodel.TripEntry, caller *dbmodel.UserEntry) {
+	tripResponse, err := config.tripResponse(trip, caller)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve trip stops"})
+		return
+	}
+	render.JSON(w, r, tripResponse)
+}
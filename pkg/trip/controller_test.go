@@ -0,0 +1,231 @@
+package trip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"locate-this/config"
+	"locate-this/database"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/models"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// tripTest is alice with five locations along a street, from west to east A,
+// E, C, B and D, and bob sharing his office with her.
+type tripTest struct {
+	db            *gorm.DB
+	router        chi.Router
+	configuration *config.Config
+	alice, bob    *dbmodel.UserEntry
+	locations     map[string]uint
+}
+
+func newTripTest(t *testing.T) *tripTest {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.Migrate(db)
+	configuration := &config.Config{
+		UserEntryRepository:          dbmodel.NewUserRepository(db),
+		GroupEntryRepository:         dbmodel.NewGroupRepository(db),
+		GroupUserEntryRepository:     dbmodel.NewGroupUserRepository(db),
+		GroupLocationEntryRepository: dbmodel.NewGroupLocationRepository(db),
+		LocationEntryRepository:      dbmodel.NewLocationRepository(db),
+		TripRepository:               dbmodel.NewTripRepository(db),
+	}
+	test := &tripTest{db: db, router: chi.NewRouter(), configuration: configuration, locations: make(map[string]uint)}
+	test.router.Mount("/trips", Routes(configuration))
+
+	users := make([]*dbmodel.UserEntry, 2)
+	for i, name := range []string{"alice", "bob"} {
+		users[i], err = configuration.UserEntryRepository.Create(&dbmodel.UserEntry{Email: name + "@example.com", Password: "-", Username: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	test.alice, test.bob = users[0], users[1]
+	group, err := configuration.GroupEntryRepository.Create(&dbmodel.GroupEntry{Name: "work", AdminID: test.bob.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := configuration.GroupUserEntryRepository.Create(&dbmodel.GroupUserEntry{UserEntryID: test.alice.ID, GroupEntryID: group.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 0.01° of longitude is about 780 meters in Lyon
+	for name, longitude := range map[string]float64{"A": 4.80, "B": 4.84, "C": 4.82, "D": 4.85, "E": 4.81} {
+		location, _, err := configuration.LocationEntryRepository.Create(&dbmodel.LocationEntry{UserID: test.alice.ID, Name: name, Latitude: 45.76, Longitude: longitude})
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.locations[name] = location.ID
+	}
+	office, _, err := configuration.LocationEntryRepository.Create(&dbmodel.LocationEntry{UserID: test.bob.ID, Name: "office", Latitude: 45.76, Longitude: 4.83})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.locations["office"] = office.ID
+	if _, err := configuration.GroupLocationEntryRepository.Create(&dbmodel.GroupLocationEntry{GroupEntryID: group.ID, LocationEntryID: office.ID, IsVisibleCoordinates: true}); err != nil {
+		t.Fatal(err)
+	}
+	return test
+}
+
+// trip creates a trip of alice visiting the locations in order and returns
+// its ID and the IDs of its stops.
+func (test *tripTest) trip(t *testing.T, names ...string) (uint, []uint) {
+	stops := make([]dbmodel.TripStopEntry, len(names))
+	for i, name := range names {
+		stops[i] = dbmodel.TripStopEntry{LocationID: test.locations[name]}
+	}
+	trip, err := test.configuration.TripRepository.Create(&dbmodel.TripEntry{UserID: test.alice.ID, Name: strings.Join(names, " ")}, stops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopIDs := make([]uint, len(stops))
+	for i, stop := range stops {
+		stopIDs[i] = stop.ID
+	}
+	return trip.ID, stopIDs
+}
+
+// request sends the request as the user and decodes the response.
+func (test *tripTest) request(t *testing.T, user *dbmodel.UserEntry, method, path, body string, response any) {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request = request.WithContext(context.WithValue(request.Context(), "id", user.Email))
+	w := httptest.NewRecorder()
+	test.router.ServeHTTP(w, request)
+	if err := json.Unmarshal(w.Body.Bytes(), response); err != nil {
+		t.Fatalf("%s %s = %s: %v", method, path, w.Body, err)
+	}
+}
+
+// stopNames returns the names of the stops of a trip as alice sees them.
+func (test *tripTest) stopNames(t *testing.T, tripID uint) []string {
+	stops, err := test.configuration.TripRepository.FindStops(tripID, test.alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(stops))
+	for i, stop := range stops {
+		names[i] = stop.Name
+		if stop.Position != i {
+			t.Errorf("stop %s at position %d, want %d", stop.Name, stop.Position, i)
+		}
+	}
+	return names
+}
+
+func TestPostTripOptimize(t *testing.T) {
+	test := newTripTest(t)
+	tests := []struct {
+		name  string
+		stops []string
+		query string
+		want  []string
+	}{
+		{"from the first stop", []string{"A", "B", "C", "D", "E"}, "", []string{"A", "E", "C", "B", "D"}},
+		{"from the middle", []string{"C", "A", "D", "E", "B"}, "", []string{"C", "E", "A", "B", "D"}},
+		{"last stop kept last", []string{"A", "D", "C", "B"}, "?keep_last=true", []string{"A", "C", "D", "B"}},
+		{"last stop anywhere", []string{"B", "D", "C", "A"}, "?keep_last=false", []string{"B", "D", "C", "A"}},
+		{"shared location", []string{"D", "A", "office", "B"}, "", []string{"D", "B", "office", "A"}},
+		{"same location twice", []string{"A", "C", "A", "E"}, "?keep_last=1", []string{"A", "A", "C", "E"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tripID, _ := test.trip(t, tt.stops...)
+			var response models.TripOptimizeResponse
+			test.request(t, test.alice, "POST", fmt.Sprintf("/trips/%d/optimize%s", tripID, tt.query), "", &response)
+			names := make([]string, len(response.Stops))
+			for i, stop := range response.Stops {
+				names[i] = stop.Name
+			}
+			if !slices.Equal(names, tt.want) || !slices.Equal(test.stopNames(t, tripID), tt.want) {
+				t.Errorf("stops = %v, want %v", names, tt.want)
+			}
+			if response.Length > response.PreviousLength || response.PreviousLength == 0 {
+				t.Errorf("length %v, previously %v", response.Length, response.PreviousLength)
+			}
+		})
+	}
+}
+
+func TestPostTripOptimizeRefused(t *testing.T) {
+	test := newTripTest(t)
+	tripID, _ := test.trip(t, "D", "office", "A", "B")
+	tests := []struct {
+		name  string
+		user  *dbmodel.UserEntry
+		query string
+		err   string
+	}{
+		{"bad keep_last", test.alice, "?keep_last=maybe", "keep_last must be true or false"},
+		{"not the creator", test.bob, "", "Failed to retrieve trip"},
+		{"hidden coordinates", test.alice, "", "stops whose coordinates are hidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "hidden coordinates" {
+				err := test.db.Model(&dbmodel.GroupLocationEntry{}).Where("location_entry_id = ?", test.locations["office"]).Update("is_visible_coordinates", false).Error
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			var response map[string]string
+			test.request(t, tt.user, "POST", fmt.Sprintf("/trips/%d/optimize%s", tripID, tt.query), "", &response)
+			if !strings.Contains(response["error"], tt.err) {
+				t.Errorf("response = %v, want the error %q", response, tt.err)
+			}
+			if names := test.stopNames(t, tripID); !slices.Equal(names, []string{"D", "office", "A", "B"}) && !slices.Equal(names, []string{"D", "", "A", "B"}) {
+				t.Errorf("stops = %v, want them left in their order", names)
+			}
+		})
+	}
+}
+
+func TestPutTripStopOrder(t *testing.T) {
+	test := newTripTest(t)
+	tripID, stopIDs := test.trip(t, "A", "B", "C")
+	_, otherStopIDs := test.trip(t, "D", "E")
+	tests := []struct {
+		name    string
+		stopIDs []uint
+		want    []string
+	}{
+		{"reversed", []uint{stopIDs[2], stopIDs[1], stopIDs[0]}, []string{"C", "B", "A"}},
+		{"missing a stop", []uint{stopIDs[0], stopIDs[1]}, nil},
+		{"a stop twice", []uint{stopIDs[0], stopIDs[1], stopIDs[1]}, nil},
+		{"a stop of another trip", []uint{stopIDs[0], stopIDs[1], otherStopIDs[0]}, nil},
+		{"an extra stop", []uint{stopIDs[0], stopIDs[1], stopIDs[2], otherStopIDs[0]}, nil},
+		{"back in order", []uint{stopIDs[0], stopIDs[1], stopIDs[2]}, []string{"A", "B", "C"}},
+	}
+	want := []string{"A", "B", "C"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(models.TripOrderRequest{StopIDs: tt.stopIDs})
+			var response map[string]any
+			test.request(t, test.alice, "PUT", fmt.Sprintf("/trips/%d/stops/order", tripID), string(body), &response)
+			if tt.want == nil {
+				if response["error"] != "stop_ids must hold every stop of the trip once" {
+					t.Errorf("response = %v, want the order refused", response)
+				}
+			} else {
+				want = tt.want
+			}
+			if names := test.stopNames(t, tripID); !slices.Equal(names, want) {
+				t.Errorf("stops = %v, want %v", names, want)
+			}
+		})
+	}
+}
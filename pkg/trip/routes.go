@@ -0,0 +1,43 @@
+package trip
+
+import (
+	"locate-this/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+Trips:
+- POST /trips
+- GET /trips?group_id=
+- GET /trips/{id}
+- PUT /trips/{id}
+- DELETE /trips/{id}
+- GET /trips/{id}/trip.gpx
+
+- POST /trips/{id}/stops
+- DELETE /trips/{id}/stops/{stopID}
+- PUT /trips/{id}/stops/order
+- POST /trips/{id}/optimize?keep_last=
+
+- POST /trips/{id}/groups
+- DELETE /trips/{id}/groups/{groupID}
+*/
+
+func Routes(configuration *config.Config) chi.Router {
+	TripConfig := New(configuration)
+	router := chi.NewRouter()
+	router.Post("/", TripConfig.PostTripHandler)
+	router.Get("/", TripConfig.GetTripsHandler)
+	router.Get("/{id}", TripConfig.GetTripByIDHandler)
+	router.Put("/{id}", TripConfig.PutTripHandler)
+	router.Delete("/{id}", TripConfig.DeleteTripHandler)
+	router.Get("/{id}/trip.gpx", TripConfig.GetTripGPXHandler)
+	router.Post("/{id}/stops", TripConfig.PostTripStopHandler)
+	router.Delete("/{id}/stops/{stopID}", TripConfig.DeleteTripStopHandler)
+	router.Put("/{id}/stops/order", TripConfig.PutTripStopOrderHandler)
+	router.Post("/{id}/optimize", TripConfig.PostTripOptimizeHandler)
+	router.Post("/{id}/groups", TripConfig.PostTripGroupHandler)
+	router.Delete("/{id}/groups/{groupID}", TripConfig.DeleteTripGroupHandler)
+	return router
+}
@@ -1,12 +1,16 @@
 package user
 
 import (
+	"errors"
 	"fmt"
 	"locate-this/config"
 	"locate-this/database/dbmodel"
+	"locate-this/pkg/authentication"
+	"locate-this/pkg/location"
 	"locate-this/pkg/models"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -21,16 +25,29 @@ func New(configuration *config.Config) *UserConfig {
 }
 
 // @Summary		Get all users
-// @Description	Retrieve a list of all users
+// @Description	Retrieve a page of all users
 // @Tags			users
 // @Accept			json
 // @Produce		json
-// @Success		200	{array}	models.UserResponse
+// @Param			limit			query		int		false	"Page size"
+// @Param			after			query		string	false	"Cursor returned by the previous page"
+// @Param			sort			query		string	false	"Sort field, prefix with - for descending order"
+// @Param			order			query		string	false	"asc or desc"
+// @Param			name			query		string	false	"Username contains"
+// @Param			created_before	query		string	false	"RFC 3339 date"
+// @Param			created_after	query		string	false	"RFC 3339 date"
+// @Success		200	{object}	models.PageResponse{data=[]models.UserResponse}
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
 // @Router			/users [get]
 func (config *UserConfig) GetAllUserHandler(w http.ResponseWriter, r *http.Request) {
-	entries, err := config.UserEntryRepository.FindAll()
+	options, err := models.ParseQueryOptions(r, dbmodel.UserSortFields)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	entries, page, err := config.UserEntryRepository.FindAll(options)
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to retrieve users"})
 		return
@@ -45,7 +62,7 @@ func (config *UserConfig) GetAllUserHandler(w http.ResponseWriter, r *http.Reque
 		})
 	}
 
-	render.JSON(w, r, usersResponse)
+	render.JSON(w, r, models.NewPageResponse(usersResponse, page))
 }
 
 // @Summary		Get user by ID
@@ -122,12 +139,33 @@ func (config *UserConfig) GetUserByUsernameHandler(w http.ResponseWriter, r *htt
 }
 
 // @Summary		Get locations for a user
-// @Description	Retrieve all locations created by a user
+// @Description	Retrieve the locations of a user visible to the caller: all of them for the user, otherwise the ones shared in a group of the caller. The coordinates, altitude, accuracy and address of the locations whose coordinates are hidden from the caller are left out, and coordinates_hidden is set; the address, altitude and accuracy filters do not match them.
 // @Tags			users
 // @Accept			json
 // @Produce		json
-// @Param			id	path		int	true	"User ID"
-// @Success		200	{array}	models.LocationResponse
+// @Param			id				path		int		true	"User ID"
+// @Param			limit			query		int		false	"Page size"
+// @Param			after			query		string	false	"Cursor returned by the previous page"
+// @Param			sort			query		string	false	"Sort field, prefix with - for descending order"
+// @Param			order			query		string	false	"asc or desc"
+// @Param			name			query		string	false	"Name contains"
+// @Param			created_before	query		string	false	"RFC 3339 date"
+// @Param			created_after	query		string	false	"RFC 3339 date"
+// @Param			category		query		string	false	"Category"
+// @Param			description		query		string	false	"Description contains"
+// @Param			address			query		string	false	"Address contains"
+// @Param			color			query		string	false	"Color"
+// @Param			icon			query		string	false	"Icon"
+// @Param			min_altitude	query		number	false	"Minimum altitude in meters"
+// @Param			max_altitude	query		number	false	"Maximum altitude in meters"
+// @Param			max_accuracy	query		number	false	"Maximum accuracy radius in meters"
+// @Param			tag				query		[]string	false	"Tags" collectionFormat(multi)
+// @Param			tag_mode		query		string	false	"any (default) or all of the tags"
+// @Param			format			query		string	false	"json (default) or csv to download every page"
+// @Param			delimiter		query		string	false	"CSV delimiter: comma (default), semicolon, pipe or tab"
+// @Param			decimal			query		string	false	"CSV decimal separator: point (default) or comma"
+// @Param			formats			query		string	false	"Comma separated coordinate formats to add: dms, utm, mgrs, geohash, pluscode"
+// @Success		200	{object}	models.PageResponse{data=[]models.LocationResponse}
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
 // @Router			/users/{id}/locations [get]
@@ -140,12 +178,46 @@ func (config *UserConfig) GetLocationsForUserHandler(w http.ResponseWriter, r *h
 		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
 		return
 	}
-	locations, err := config.UserEntryRepository.FindLocationsForUser(uint(id))
+	options, err := models.ParseQueryOptions(r, dbmodel.LocationSortFields)
 	if err != nil {
-		render.JSON(w, r, map[string]string{"error": "Failed to retrieve locations"})
+		render.JSON(w, r, map[string]string{"error": err.Error()})
 		return
 	}
-	render.JSON(w, r, locations)
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	options.TagViewerID = caller.ID
+
+	location.RenderLocationList(w, r, options, func(options dbmodel.QueryOptions) ([]models.LocationResponse, dbmodel.PageInfo, error) {
+		locations, page, err := config.UserEntryRepository.FindLocationsForUser(uint(id), caller.ID, options)
+		if err != nil {
+			return nil, page, errors.New("Failed to retrieve locations")
+		}
+
+		locationsResponse := make([]models.LocationResponse, 0)
+		for _, location := range locations {
+			locationsResponse = append(locationsResponse, models.NewLocationResponse(&location))
+		}
+		visible, err := config.LocationEntryRepository.FindVisibleLocationIDs(caller.ID, models.LocationIDs(locationsResponse))
+		if err != nil {
+			return nil, page, errors.New("Failed to retrieve locations")
+		}
+		for i := range locationsResponse {
+			if !visible[locationsResponse[i].ID] {
+				locationsResponse[i].HideCoordinates()
+			}
+		}
+
+		tags, err := config.TagRepository.FindVisibleTagsForLocations(caller.ID, models.LocationIDs(locationsResponse))
+		if err != nil {
+			return nil, page, errors.New("Failed to retrieve tags")
+		}
+		models.AttachTags(locationsResponse, tags)
+		return locationsResponse, page, nil
+	})
 }
 
 // @Summary		Get groups for a user
@@ -153,8 +225,16 @@ func (config *UserConfig) GetLocationsForUserHandler(w http.ResponseWriter, r *h
 // @Tags			users
 // @Accept			json
 // @Produce		json
-// @Param			id	path		int	true	"User ID"
-// @Success		200	{array}	models.GroupResponse
+// @Param			id				path		int		true	"User ID"
+// @Param			limit			query		int		false	"Page size"
+// @Param			after			query		string	false	"Cursor returned by the previous page"
+// @Param			sort			query		string	false	"Sort field, prefix with - for descending order"
+// @Param			order			query		string	false	"asc or desc"
+// @Param			name			query		string	false	"Name contains"
+// @Param			created_before	query		string	false	"RFC 3339 date"
+// @Param			created_after	query		string	false	"RFC 3339 date"
+// @Param			owner			query		int		false	"Admin user ID"
+// @Success		200	{object}	models.PageResponse{data=[]models.GroupResponse}
 // @Failure 400 {object} map[string]string
 // @Security BearerAuth
 // @Router			/users/{id}/groups [get]
@@ -167,12 +247,28 @@ func (config *UserConfig) GetGroupsForUserHandler(w http.ResponseWriter, r *http
 		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
 		return
 	}
-	groups, err := config.UserEntryRepository.FindGroupsForUser(uint(id))
+	options, err := models.ParseQueryOptions(r, dbmodel.GroupSortFields)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": err.Error()})
+		return
+	}
+
+	groups, page, err := config.UserEntryRepository.FindGroupsForUser(uint(id), options)
 	if err != nil {
 		render.JSON(w, r, map[string]string{"error": "Failed to retrieve groups"})
 		return
 	}
-	render.JSON(w, r, groups)
+
+	groupsResponse := make([]models.GroupResponse, 0)
+	for _, group := range groups {
+		groupsResponse = append(groupsResponse, models.GroupResponse{
+			ID:      group.ID,
+			Name:    group.Name,
+			AdminID: group.AdminID,
+		})
+	}
+
+	render.JSON(w, r, models.NewPageResponse(groupsResponse, page))
 }
 
 // @Summary		Update a user
@@ -237,5 +333,122 @@ func (config *UserConfig) DeleteUserHandler(w http.ResponseWriter, r *http.Reque
 		render.JSON(w, r, map[string]string{"error": "Failed to delete user"})
 		return
 	}
+	// the locations of the user disappear from the tiles of their groups
+	config.TileCache.InvalidateAll()
 	render.JSON(w, r, "Succefully deleted entry")
 }
+
+// @Summary		Export the locations of a user
+// @Description	Stream the locations of a user visible to the caller as a GeoJSON FeatureCollection, GPX waypoints or KML placemarks. Locations whose coordinates are hidden from the caller have a null geometry and no address in GeoJSON and are left out of GPX and KML.
+// @Tags			users
+// @Produce		json
+// @Produce		xml
+// @Param			id		path		int		true	"User ID"
+// @Param			format	path		string	true	"geojson, gpx or kml"
+// @Success		200		{object}	models.LocationFeatureCollection
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/users/{id}/locations.{format} [get]
+func (config *UserConfig) GetLocationsExportForUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return
+	}
+
+	caller, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return
+	}
+	location.ExportLocations(w, r, chi.URLParam(r, "format"), "user-"+strconv.Itoa(id)+"-locations", func(fn dbmodel.ExportBatchFunc) error {
+		return config.LocationEntryRepository.ExportLocationsForUser(uint(id), caller.ID, fn)
+	})
+}
+
+// @Summary		Get the history retention of a user
+// @Description	Retrieve how many days the position history of the caller is kept
+// @Tags			users
+// @Accept			json
+// @Produce		json
+// @Param			id	path		int	true	"User ID"
+// @Success		200	{object}	models.HistoryRetentionResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/users/{id}/history-retention [get]
+func (config *UserConfig) GetHistoryRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := config.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	render.JSON(w, r, models.HistoryRetentionResponse{
+		Days:      config.HistoryRetentionDays(user),
+		IsDefault: user.HistoryRetentionDays == nil,
+	})
+}
+
+// @Summary		Update the history retention of a user
+// @Description	Choose how many days the position history of the caller is kept. 0 stops recording it, null restores the server default. Older positions are deleted.
+// @Tags			users
+// @Accept			json
+// @Produce		json
+// @Param			id		path		int								true	"User ID"
+// @Param			request	body		models.HistoryRetentionRequest	true	"Retention"
+// @Success		200		{object}	models.HistoryRetentionResponse
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router			/users/{id}/history-retention [put]
+func (config *UserConfig) PutHistoryRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	req := &models.HistoryRetentionRequest{}
+	if err := render.Bind(r, req); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	user, ok := config.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := config.UserEntryRepository.UpdateHistoryRetention(user.ID, req.Days); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to update history retention"})
+		return
+	}
+	user.HistoryRetentionDays = req.Days
+
+	days := config.HistoryRetentionDays(user)
+	if err := config.HistoryRepository.DeleteBefore(user.ID, time.Now().AddDate(0, 0, -days)); err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to apply history retention"})
+		return
+	}
+
+	render.JSON(w, r, models.HistoryRetentionResponse{Days: days, IsDefault: req.Days == nil})
+}
+
+// currentUser checks that the id of the path is the caller's, rendering the
+// error otherwise.
+func (config *UserConfig) currentUser(w http.ResponseWriter, r *http.Request) (*dbmodel.UserEntry, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		fmt.Println("Error during id convertion")
+	}
+	if id < 1 {
+		render.JSON(w, r, map[string]string{"error": "id must be >= 1"})
+		return nil, false
+	}
+
+	user, err := authentication.GetCurrentUser(r, config.UserEntryRepository)
+	if err != nil {
+		render.JSON(w, r, map[string]string{"error": "Failed to retrieve current user"})
+		return nil, false
+	}
+	if user.ID != uint(id) {
+		render.JSON(w, r, map[string]string{"error": "Only the user can do this"})
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,149 @@
+package user
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"locate-this/config"
+	"locate-this/database"
+	"locate-this/database/dbmodel"
+	"locate-this/pkg/models"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func TestGetLocationsForUserShowsTheVisibleLocations(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.Migrate(db)
+	configuration := &config.Config{
+		UserEntryRepository:          dbmodel.NewUserRepository(db),
+		GroupEntryRepository:         dbmodel.NewGroupRepository(db),
+		GroupUserEntryRepository:     dbmodel.NewGroupUserRepository(db),
+		GroupLocationEntryRepository: dbmodel.NewGroupLocationRepository(db),
+		LocationEntryRepository:      dbmodel.NewLocationRepository(db),
+		TagRepository:                dbmodel.NewTagRepository(db),
+	}
+	router := chi.NewRouter()
+	router.Mount("/users", Routes(configuration))
+
+	users := make([]*dbmodel.UserEntry, 3)
+	for i, name := range []string{"alice", "bob", "carol"} {
+		users[i], err = configuration.UserEntryRepository.Create(&dbmodel.UserEntry{Email: name + "@example.com", Password: "-", Username: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	alice, bob, carol := users[0], users[1], users[2]
+	group, err := configuration.GroupEntryRepository.Create(&dbmodel.GroupEntry{Name: "friends", AdminID: alice.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := configuration.GroupUserEntryRepository.Create(&dbmodel.GroupUserEntry{UserEntryID: bob.ID, GroupEntryID: group.ID}); err != nil {
+		t.Fatal(err)
+	}
+	altitude := 170.0
+	// home is shared with its coordinates hidden, office with them and diary is not shared
+	locations := make(map[string]*dbmodel.LocationEntry)
+	for _, name := range []string{"home", "office", "diary"} {
+		locations[name], _, err = configuration.LocationEntryRepository.Create(&dbmodel.LocationEntry{
+			UserID: alice.ID, Name: name, Latitude: 45.76, Longitude: 4.83, Altitude: &altitude, Address: name + " street, Lyon",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, visible := range map[string]bool{"home": false, "office": true} {
+		share := &dbmodel.GroupLocationEntry{GroupEntryID: group.ID, LocationEntryID: locations[name].ID, IsVisibleCoordinates: visible}
+		if _, err := configuration.GroupLocationEntryRepository.Create(share); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(user *dbmodel.UserEntry, query string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", "/users/"+strconv.Itoa(int(alice.ID))+"/locations?"+query, nil)
+		request = request.WithContext(context.WithValue(request.Context(), "id", user.Email))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+		return w
+	}
+
+	tests := []struct {
+		user    *dbmodel.UserEntry
+		query   string
+		visible []string
+		hidden  []string
+	}{
+		{alice, "", []string{"diary", "home", "office"}, nil},
+		{bob, "", []string{"office"}, []string{"home"}},
+		{carol, "", nil, nil},
+		// the filters on the address do not match hidden addresses
+		{alice, "address=street", []string{"diary", "home", "office"}, nil},
+		{bob, "address=home", nil, nil},
+		{bob, "address=street", []string{"office"}, nil},
+		{bob, "min_altitude=100", []string{"office"}, nil},
+		{bob, "name=o", []string{"office"}, []string{"home"}},
+	}
+	for _, test := range tests {
+		var page struct {
+			Data []models.LocationResponse `json:"data"`
+		}
+		if w := get(test.user, test.query); json.Unmarshal(w.Body.Bytes(), &page) != nil {
+			t.Fatalf("%s, %q: response = %s", test.user.Username, test.query, w.Body)
+		}
+		var visible, hidden []string
+		for _, location := range page.Data {
+			if !location.CoordinatesHidden {
+				visible = append(visible, location.Name)
+				continue
+			}
+			hidden = append(hidden, location.Name)
+			if location.Latitude != 0 || location.Longitude != 0 || location.Altitude != nil || location.Address != "" {
+				t.Errorf("%s, %q: %s shows its hidden coordinates: %+v", test.user.Username, test.query, location.Name, location)
+			}
+		}
+		slices.Sort(visible)
+		if !slices.Equal(visible, test.visible) || !slices.Equal(hidden, test.hidden) {
+			t.Errorf("%s, %q: visible %v and hidden %v, want %v and %v", test.user.Username, test.query, visible, hidden, test.visible, test.hidden)
+		}
+	}
+
+	// the CSV export of every page is filtered the same way
+	w := get(bob, "format=csv")
+	rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("csv = %s: %v", w.Body, err)
+	}
+	if len(rows) != 3 || !slices.Equal(rows[0], models.CSVExportColumns) {
+		t.Fatalf("csv = %v", rows)
+	}
+	for _, row := range rows[1:] {
+		// latitude, longitude, altitude, accuracy and address
+		coordinates := []string{row[2], row[3], row[4], row[9]}
+		switch row[1] {
+		case "home":
+			if !slices.Equal(coordinates, []string{"", "", "", ""}) {
+				t.Errorf("csv row of home = %v, want its coordinates hidden", row)
+			}
+		case "office":
+			if !slices.Equal(coordinates, []string{"45.76", "4.83", "170", "office street, Lyon"}) {
+				t.Errorf("csv row of office = %v", row)
+			}
+		default:
+			t.Errorf("csv row of %s is not visible to bob", row[1])
+		}
+	}
+	if w := get(carol, "format=csv"); strings.Count(w.Body.String(), "\n") != 1 {
+		t.Errorf("csv for carol = %s, want the header only", w.Body)
+	}
+}
@@ -13,6 +13,11 @@ Users:
 - GET /users/{id}
 - PUT /users/{id}
 - DELETE /users/{id}
+
+- GET /users/{id}/locations.{format} (geojson, gpx or kml)
+
+- GET /users/{id}/history-retention
+- PUT /users/{id}/history-retention
 */
 
 func Routes(configuration *config.Config) chi.Router {
@@ -26,5 +31,8 @@ func Routes(configuration *config.Config) chi.Router {
 	router.Delete("/{id}", UserConfig.DeleteUserHandler)
 	router.Get("/{id}/locations", UserConfig.GetLocationsForUserHandler)
 	router.Get("/{id}/groups", UserConfig.GetGroupsForUserHandler)
+	router.Get("/{id}/locations.{format}", UserConfig.GetLocationsExportForUserHandler)
+	router.Get("/{id}/history-retention", UserConfig.GetHistoryRetentionHandler)
+	router.Put("/{id}/history-retention", UserConfig.PutHistoryRetentionHandler)
 	return router
 }